@@ -24,8 +24,10 @@ import (
 	"os"
 
 	"github.com/pkg/errors"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/logger"
+	"github.com/wentaojin/transferdb/metrics"
 
 	"github.com/wentaojin/transferdb/server"
 	"go.uber.org/zap"
@@ -41,6 +43,8 @@ func main() {
 	logger.NewZapLogger(cfg)
 	config.RecordAppVersion("transferdb", cfg)
 
+	// pprof 以及 prometheus metrics 共用端口
+	http.Handle("/metrics", metrics.Handler())
 	go func() {
 		if err := http.ListenAndServe(cfg.AppConfig.PprofPort, nil); err != nil {
 			zap.L().Fatal("listen and serve pprof failed", zap.Error(errors.Cause(err)))
@@ -56,6 +60,13 @@ func main() {
 	// 程序运行
 	ctx := context.Background()
 	if err := server.Run(ctx, cfg); err != nil {
+		// 结果报告门禁不通过，以门禁退出码退出，区别于程序运行失败
+		var gateErr *common.ReportGateError
+		if errors.As(err, &gateErr) {
+			zap.L().Error("server run report gate failed", zap.Error(err))
+			_ = zap.L().Sync()
+			os.Exit(gateErr.ExitCode())
+		}
 		zap.L().Fatal("server run failed", zap.Error(errors.Cause(err)))
 	}
 }
@@ -70,3 +70,14 @@ const (
 	AssessNameSchemaTableAvgRowLengthTopRelated = "SCHEMA_TABLE_AVG_ROW_LENGTH_TOP_RELATED"
 	AssessNameSchemaTableNumberTypeEqual0       = "SCHEMA_TABLE_NUMBER_TYPE_EQUAL0"
 )
+
+// Assess Diff Type，多次评估不兼容对象对比
+const (
+	AssessDiffTypeNew       = "NEW"
+	AssessDiffTypeResolved  = "RESOLVED"
+	AssessDiffTypeIncreased = "INCREASED"
+	AssessDiffTypeDecreased = "DECREASED"
+)
+
+// 评估编号时间格式
+const AssessIDTimeFormat = "20060102150405"
@@ -189,16 +189,16 @@ var BuildInOracleO2MDatatypeNameMap = map[string]string{
 	BuildInOracleDatatypeTimestampWithTimeZone7:      "DATETIME",
 	BuildInOracleDatatypeTimestampWithTimeZone8:      "DATETIME",
 	BuildInOracleDatatypeTimestampWithTimeZone9:      "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone0: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone1: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone2: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone3: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone4: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone5: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone6: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone7: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone8: "DATETIME",
-	BuildInOracleDatatypeTimestampWithLocalTimeZone9: "DATETIME",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone0: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone1: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone2: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone3: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone4: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone5: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone6: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone7: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone8: "TIMESTAMP",
+	BuildInOracleDatatypeTimestampWithLocalTimeZone9: "TIMESTAMP",
 	BuildInOracleDatatypeIntervalDay:                 "VARCHAR",
 }
 
@@ -0,0 +1,167 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 字段名映射规则
+// 1、源端字段、目标端字段均不为空，字段重命名
+// 2、目标端字段为空，源端字段不迁移（字段子集）
+// 3、源端字段为空，目标端新增字段，需指定目标端字段类型，可选默认值，数据迁移由目标端默认值填充
+type ColumnNameRule struct {
+	ColumnNameS   string
+	ColumnNameT   string
+	ColumnTypeT   string
+	DefaultValueT string
+}
+
+func ValidColumnNameRule(columnNameS, columnNameT, columnTypeT string) error {
+	switch {
+	case columnNameS == "" && columnNameT == "":
+		return fmt.Errorf("source column and target column cannot be both empty")
+	case columnNameS == "" && columnTypeT == "":
+		return fmt.Errorf("target column [%s] is target-only column, target datatype cannot be empty", columnNameT)
+	}
+	return nil
+}
+
+// 表级别字段名映射规则，nil 表示字段名原样迁移
+type TableColumnNameRule struct {
+	renames map[string]string
+	drops   map[string]struct{}
+	adds    []ColumnNameRule
+}
+
+func NewTableColumnNameRule() *TableColumnNameRule {
+	return &TableColumnNameRule{
+		renames: make(map[string]string),
+		drops:   make(map[string]struct{}),
+	}
+}
+
+// 规则追加，相同源端字段或目标端新增字段后者覆盖前者
+func (t *TableColumnNameRule) AddRule(r ColumnNameRule) {
+	if r.ColumnNameS == "" {
+		for i, a := range t.adds {
+			if strings.EqualFold(a.ColumnNameT, r.ColumnNameT) {
+				t.adds[i] = r
+				return
+			}
+		}
+		t.adds = append(t.adds, r)
+		return
+	}
+	columnNameS := StringUPPER(r.ColumnNameS)
+	if r.ColumnNameT == "" {
+		delete(t.renames, columnNameS)
+		t.drops[columnNameS] = struct{}{}
+		return
+	}
+	delete(t.drops, columnNameS)
+	t.renames[columnNameS] = r.ColumnNameT
+}
+
+// 源端字段对应目标端字段名，false 表示源端字段不迁移
+func (t *TableColumnNameRule) TargetColumnName(columnNameS string) (string, bool) {
+	if t == nil {
+		return columnNameS, true
+	}
+	if _, ok := t.drops[StringUPPER(columnNameS)]; ok {
+		return "", false
+	}
+	if val, ok := t.renames[StringUPPER(columnNameS)]; ok {
+		return val, true
+	}
+	return columnNameS, true
+}
+
+// 目标端字段对应源端字段名，false 表示目标端新增字段或者不存在映射
+func (t *TableColumnNameRule) SourceColumnName(columnNameT string) (string, bool) {
+	if t == nil {
+		return columnNameT, true
+	}
+	for s, v := range t.renames {
+		if strings.EqualFold(v, columnNameT) {
+			return s, true
+		}
+	}
+	for _, a := range t.adds {
+		if strings.EqualFold(a.ColumnNameT, columnNameT) {
+			return "", false
+		}
+	}
+	if _, ok := t.drops[StringUPPER(columnNameT)]; ok {
+		return "", false
+	}
+	return columnNameT, true
+}
+
+// 目标端新增字段
+func (t *TableColumnNameRule) AddColumns() []ColumnNameRule {
+	if t == nil {
+		return nil
+	}
+	return t.adds
+}
+
+// 字段列表映射，过滤不迁移字段并重命名，字段名带反引号保持反引号
+func (t *TableColumnNameRule) TargetColumnNames(columnNameS []string) []string {
+	if t == nil {
+		return columnNameS
+	}
+	var columnNameT []string
+	for _, c := range columnNameS {
+		quoted := strings.HasPrefix(c, "`") && strings.HasSuffix(c, "`") && len(c) >= 2
+		name := c
+		if quoted {
+			name = c[1 : len(c)-1]
+		}
+		target, ok := t.TargetColumnName(name)
+		if !ok {
+			continue
+		}
+		if quoted {
+			target = StringsBuilder("`", target, "`")
+		}
+		columnNameT = append(columnNameT, target)
+	}
+	return columnNameT
+}
+
+// 字段数据转换规则以目标端字段名重新映射，不迁移字段规则忽略
+func (t *TableColumnNameRule) TargetColumnTransform(transforms map[string]*ColumnTransform) map[string]*ColumnTransform {
+	if t == nil || len(transforms) == 0 {
+		return transforms
+	}
+	targetTransforms := make(map[string]*ColumnTransform, len(transforms))
+	for c, ct := range transforms {
+		if target, ok := t.TargetColumnName(c); ok {
+			targetTransforms[StringUPPER(target)] = ct
+		}
+	}
+	return targetTransforms
+}
+
+// 目标端新增字段定义，默认值原样输出，字符默认值需自行带单引号
+func (r ColumnNameRule) TargetColumnMeta() string {
+	if r.DefaultValueT == "" {
+		return StringsBuilder("`", r.ColumnNameT, "` ", r.ColumnTypeT)
+	}
+	return StringsBuilder("`", r.ColumnNameT, "` ", r.ColumnTypeT, " DEFAULT ", r.DefaultValueT)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testTableColumnNameRule() *TableColumnNameRule {
+	t := NewTableColumnNameRule()
+	t.AddRule(ColumnNameRule{ColumnNameS: "name", ColumnNameT: "USER_NAME"})
+	t.AddRule(ColumnNameRule{ColumnNameS: "PASSWD"})
+	t.AddRule(ColumnNameRule{ColumnNameT: "CREATED_AT", ColumnTypeT: "DATETIME", DefaultValueT: "CURRENT_TIMESTAMP"})
+	t.AddRule(ColumnNameRule{ColumnNameS: "OLD", ColumnNameT: "TMP"})
+	// 后者覆盖前者
+	t.AddRule(ColumnNameRule{ColumnNameS: "OLD", ColumnNameT: "NEW"})
+	t.AddRule(ColumnNameRule{ColumnNameS: "REMARK"})
+	t.AddRule(ColumnNameRule{ColumnNameS: "REMARK", ColumnNameT: "NOTE"})
+	return t
+}
+
+func TestValidColumnNameRule(t *testing.T) {
+	// 改名、删除、新增
+	if err := ValidColumnNameRule("A", "B", ""); err != nil {
+		t.Errorf("rename rule error = %v", err)
+	}
+	if err := ValidColumnNameRule("A", "", ""); err != nil {
+		t.Errorf("drop rule error = %v", err)
+	}
+	if err := ValidColumnNameRule("", "B", "INT"); err != nil {
+		t.Errorf("add rule error = %v", err)
+	}
+	// 新增字段必须指定类型，且源端目标端不能同时为空
+	if err := ValidColumnNameRule("", "B", ""); err == nil {
+		t.Error("add rule without datatype should fail")
+	}
+	if err := ValidColumnNameRule("", "", ""); err == nil {
+		t.Error("empty rule should fail")
+	}
+}
+
+func TestTableColumnNameRuleColumnName(t *testing.T) {
+	var nilRule *TableColumnNameRule
+	if got, ok := nilRule.TargetColumnName("NAME"); got != "NAME" || !ok {
+		t.Errorf("nil rule TargetColumnName() = (%v, %v), want (NAME, true)", got, ok)
+	}
+	if got, ok := nilRule.SourceColumnName("NAME"); got != "NAME" || !ok {
+		t.Errorf("nil rule SourceColumnName() = (%v, %v), want (NAME, true)", got, ok)
+	}
+
+	rule := testTableColumnNameRule()
+	// 源端 -> 目标端，不区分大小写，重复规则以后者为准
+	targets := map[string]string{
+		"NAME":   "USER_NAME",
+		"name":   "USER_NAME",
+		"OLD":    "NEW",
+		"REMARK": "NOTE",
+		"ID":     "ID",
+	}
+	for columnNameS, want := range targets {
+		if got, ok := rule.TargetColumnName(columnNameS); got != want || !ok {
+			t.Errorf("TargetColumnName(%s) = (%v, %v), want (%v, true)", columnNameS, got, ok, want)
+		}
+	}
+	if got, ok := rule.TargetColumnName("PASSWD"); ok {
+		t.Errorf("TargetColumnName(PASSWD) = %v, want dropped", got)
+	}
+
+	// 目标端 -> 源端，新增以及删除字段无对应源端字段
+	if got, ok := rule.SourceColumnName("user_name"); got != "NAME" || !ok {
+		t.Errorf("SourceColumnName(user_name) = (%v, %v), want (NAME, true)", got, ok)
+	}
+	if got, ok := rule.SourceColumnName("ID"); got != "ID" || !ok {
+		t.Errorf("SourceColumnName(ID) = (%v, %v), want (ID, true)", got, ok)
+	}
+	for _, columnNameT := range []string{"CREATED_AT", "PASSWD"} {
+		if got, ok := rule.SourceColumnName(columnNameT); ok {
+			t.Errorf("SourceColumnName(%s) = %v, want not found", columnNameT, got)
+		}
+	}
+}
+
+func TestTableColumnNameRuleTargetColumnNames(t *testing.T) {
+	rule := testTableColumnNameRule()
+	tests := []struct {
+		name        string
+		rule        *TableColumnNameRule
+		columnNameS []string
+		want        []string
+	}{
+		{name: "nil rule", rule: nil, columnNameS: []string{"ID", "NAME"}, want: []string{"ID", "NAME"}},
+		{name: "plain", rule: rule, columnNameS: []string{"ID", "NAME", "PASSWD", "OLD"}, want: []string{"ID", "USER_NAME", "NEW"}},
+		{name: "quoted", rule: rule, columnNameS: []string{"`ID`", "`NAME`", "`PASSWD`"}, want: []string{"`ID`", "`USER_NAME`"}},
+		{name: "all dropped", rule: rule, columnNameS: []string{"PASSWD"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.TargetColumnNames(tt.columnNameS); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TargetColumnNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableColumnNameRuleTargetColumnTransform(t *testing.T) {
+	rule := testTableColumnNameRule()
+	nameTransform := &ColumnTransform{ColumnName: "NAME", TransformType: TransformTypeNullify}
+	passwdTransform := &ColumnTransform{ColumnName: "PASSWD", TransformType: TransformTypeNullify}
+	idTransform := &ColumnTransform{ColumnName: "ID", TransformType: TransformTypeNullify}
+
+	got := rule.TargetColumnTransform(map[string]*ColumnTransform{
+		"NAME":   nameTransform,
+		"PASSWD": passwdTransform,
+		"ID":     idTransform,
+	})
+	want := map[string]*ColumnTransform{
+		"USER_NAME": nameTransform,
+		"ID":        idTransform,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TargetColumnTransform() = %v, want %v", got, want)
+	}
+}
+
+func TestColumnNameRuleTargetColumnMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		rule ColumnNameRule
+		want string
+	}{
+		{name: "without default", rule: ColumnNameRule{ColumnNameT: "C1", ColumnTypeT: "INT"}, want: "`C1` INT"},
+		{name: "with default", rule: ColumnNameRule{ColumnNameT: "C1", ColumnTypeT: "VARCHAR(10)", DefaultValueT: "'x'"}, want: "`C1` VARCHAR(10) DEFAULT 'x'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.TargetColumnMeta(); got != tt.want {
+				t.Errorf("TargetColumnMeta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableColumnNameRuleAddColumns(t *testing.T) {
+	var nilRule *TableColumnNameRule
+	if got := nilRule.AddColumns(); got != nil {
+		t.Errorf("AddColumns() = %v, want nil", got)
+	}
+	want := []ColumnNameRule{{ColumnNameT: "CREATED_AT", ColumnTypeT: "DATETIME", DefaultValueT: "CURRENT_TIMESTAMP"}}
+	if got := testTableColumnNameRule().AddColumns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AddColumns() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"encoding/hex"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 数据校验时间类型小数秒精度规则，用于目标端精度低于源端精度的字段
+// round 源端按目标端精度四舍五入后对比（默认，与 MySQL/TiDB 写入小数秒默认行为一致）
+// truncate 源端按目标端精度截断后对比（目标端开启 TIME_TRUNCATE_FRACTIONAL 或者迁移截断写入）
+// strict 以源端精度严格对比，目标端精度不足导致的小数秒丢失视为数据不一致
+const (
+	CompareTimePrecisionRound    = "ROUND"
+	CompareTimePrecisionTruncate = "TRUNCATE"
+	CompareTimePrecisionStrict   = "STRICT"
+)
+
+var CompareTimePrecisionRules = []string{
+	CompareTimePrecisionRound,
+	CompareTimePrecisionTruncate,
+	CompareTimePrecisionStrict,
+}
+
+// 一致性数据校验快照状态
+// requested compare 申请快照，ready 增量同步已于日志文件批次边界记录一致点（MySQL 目标端暂停增量应用）
+// released compare 表校验完成释放快照，expired 增量应用暂停超时自动恢复，表校验结果可能不一致
+const (
+	CompareSnapshotRequested = "REQUESTED"
+	CompareSnapshotReady     = "READY"
+	CompareSnapshotReleased  = "RELEASED"
+	CompareSnapshotExpired   = "EXPIRED"
+)
+
+// MySQL/TiDB 时间类型最大小数秒精度
+const MySQLMaxTimePrecision = 6
+
+// 数据校验时间类型对比小数秒精度
+// strict 取源端、目标端精度最大值，其他规则取最小值
+func CompareTimePrecision(sourceScale, targetScale int, rule string) int {
+	if strings.EqualFold(rule, CompareTimePrecisionStrict) {
+		if sourceScale > targetScale {
+			return sourceScale
+		}
+		return targetScale
+	}
+	if sourceScale < targetScale {
+		return sourceScale
+	}
+	return targetScale
+}
+
+// ORACLE 时间类型数据校验字段格式化
+// 1、带符号年份 SYYYY 输出，公元前日期以 - 开头，超出目标端范围的数据不会被误判一致
+// 2、DATE 类型不支持 FF 格式，按对比精度 CAST 为 TIMESTAMP
+// 3、round 规则源端精度高于对比精度时 CAST 四舍五入，TO_CHAR FF 格式本身截断
+func OracleCompareTimeColumn(columnExpr string, isDate bool, sourceScale, precision int, rule string) string {
+	if (isDate && precision > 0) || (strings.EqualFold(rule, CompareTimePrecisionRound) && sourceScale > precision) {
+		columnExpr = StringsBuilder("CAST(", columnExpr, " AS TIMESTAMP(", strconv.Itoa(precision), "))")
+	}
+	format := "SYYYY-MM-DD HH24:MI:SS"
+	if precision > 0 {
+		format = StringsBuilder(format, ".FF", strconv.Itoa(precision))
+	}
+	return StringsBuilder("LTRIM(TO_CHAR(", columnExpr, ",'", format, "'))")
+}
+
+// MySQL/TiDB 时间类型数据校验字段格式化，直接格式化字段值，避免 UNIX_TIMESTAMP 1970 年之前以及 2038 年之后数据失真
+// 对比精度超过目标端最大精度时补齐 0，由源端多余非 0 小数秒识别数据不一致
+func MySQLCompareTimeColumn(columnName string, precision int) string {
+	switch {
+	case precision <= 0:
+		return StringsBuilder("DATE_FORMAT(", columnName, ",'%Y-%m-%d %H:%i:%s')")
+	case precision <= MySQLMaxTimePrecision:
+		return StringsBuilder("SUBSTR(DATE_FORMAT(", columnName, ",'%Y-%m-%d %H:%i:%s.%f'),1,", strconv.Itoa(20+precision), ")")
+	default:
+		return StringsBuilder("CONCAT(DATE_FORMAT(", columnName, ",'%Y-%m-%d %H:%i:%s.%f'),'", strings.Repeat("0", precision-MySQLMaxTimePrecision), "')")
+	}
+}
+
+// 数据校验 chunk 不一致错误详情，用于结果报告区分数据不一致以及运行失败
+const CompareChunkMismatchError = "schema table data chunk isn't euqal"
+
+// 结果报告数据校验不一致类别
+const (
+	CompareCategoryChunkMismatch = "chunk_mismatch"
+	CompareCategoryChunkError    = "chunk_error"
+)
+
+// 数据校验 chunk 对比方式
+// rows 逐行 CRC32 对比，不一致输出修复 SQL（默认）
+// hash 无主键/唯一键表全表流式聚合行数以及 CRC32 对比，不缓存行数据，不一致仅输出差异不输出修复 SQL
+const (
+	CompareMethodRows = "ROWS"
+	CompareMethodHash = "HASH"
+)
+
+// 数据校验切分键字段，上下游以二进制排序规则比较，确保同一范围条件上下游数据集一致
+// ColumnS/ColumnT 为上下游范围比较表达式，CharsetT 非空表示目标端字段按该字符集编码转换后二进制比较
+type CompareKeyColumn struct {
+	ColumnName string
+	DataType   string
+	ColumnS    string
+	ColumnT    string
+	CharsetT   string
+}
+
+// 数据校验字段信息，用于数据不一致行按键配对输出字段级差异
+// ColumnName 为上下游查询字段别名（源端字段名），IsKey 表示配对键字段（主键/唯一键）
+type CompareColumn struct {
+	ColumnName string
+	DataTypeS  string
+	DataTypeT  string
+	IsKey      bool
+}
+
+// 抽样校验等距选取，候选项按顺序等分 n 段，每段选取中间位置，相同输入选取结果一致
+func CompareSampleIndex(total, n int) []int {
+	var index []int
+	if n >= total {
+		for i := 0; i < total; i++ {
+			index = append(index, i)
+		}
+		return index
+	}
+	for i := 0; i < n; i++ {
+		index = append(index, (2*i+1)*total/(2*n))
+	}
+	return index
+}
+
+// 抽样校验分层选取，strata 为按顺序排列候选项所属层（例如表路由分片），返回选取候选项下标（升序）
+// n 不小于层数时每层至少选取 1 个，其余按层大小比例分配（最大余数法），层内按顺序等距选取
+func CompareSampleStratified(strata []string, n int) []int {
+	if n >= len(strata) {
+		return CompareSampleIndex(len(strata), n)
+	}
+	var keys []string
+	members := make(map[string][]int)
+	for i, s := range strata {
+		if _, ok := members[s]; !ok {
+			keys = append(keys, s)
+		}
+		members[s] = append(members[s], i)
+	}
+
+	alloc := make([]int, len(keys))
+	weights := make([]int, len(keys))
+	rest, weightTotal := n, 0
+	for i, k := range keys {
+		weights[i] = len(members[k])
+		if n >= len(keys) {
+			alloc[i] = 1
+			weights[i]--
+		}
+		weightTotal += weights[i]
+	}
+	if n >= len(keys) {
+		rest = n - len(keys)
+	}
+	if rest > 0 && weightTotal > 0 {
+		remainders := make([]int, len(keys))
+		assigned := 0
+		for i := range keys {
+			alloc[i] += rest * weights[i] / weightTotal
+			assigned += rest * weights[i] / weightTotal
+			remainders[i] = rest * weights[i] % weightTotal
+		}
+		order := make([]int, len(keys))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return remainders[order[i]] > remainders[order[j]]
+		})
+		for _, i := range order[:rest-assigned] {
+			alloc[i]++
+		}
+	}
+
+	var index []int
+	for i, k := range keys {
+		for _, j := range CompareSampleIndex(len(members[k]), alloc[i]) {
+			index = append(index, members[k][j])
+		}
+	}
+	sort.Ints(index)
+	return index
+}
+
+// 抽样校验置信度，sampled 抽样数，mismatched 不一致数，population 抽样前待校验总数
+// 返回不一致比例估计值以及 95% 置信上限，无不一致以 1 - 0.05^(1/n) 计算上限，否则以正态近似并按有限总体修正
+func CompareSampleConfidence(sampled, mismatched, population int64) (float64, float64) {
+	if sampled <= 0 {
+		return 0, 1
+	}
+	estimate := float64(mismatched) / float64(sampled)
+	if sampled >= population {
+		return estimate, estimate
+	}
+	if mismatched == 0 {
+		return 0, 1 - math.Pow(0.05, 1/float64(sampled))
+	}
+	fpc := math.Sqrt(float64(population-sampled) / float64(population-1))
+	upper := estimate + 1.96*math.Sqrt(estimate*(1-estimate)/float64(sampled))*fpc
+	if upper > 1 {
+		upper = 1
+	}
+	return estimate, upper
+}
+
+// ORACLE 字段类型展示，NUMBER 未指定精度 (38,127) 按 NUMBER 展示
+func OracleCompareColumnType(dataType, charLength, dataLength, dataPrecision, dataScale string) string {
+	switch StringUPPER(dataType) {
+	case "NUMBER":
+		if dataPrecision == "38" && dataScale == "127" {
+			return dataType
+		}
+		if dataScale == "127" {
+			return StringsBuilder(dataType, "(", dataPrecision, ")")
+		}
+		return StringsBuilder(dataType, "(", dataPrecision, ",", dataScale, ")")
+	case "CHAR", "NCHAR", "VARCHAR2", "NVARCHAR2":
+		return StringsBuilder(dataType, "(", charLength, ")")
+	case "RAW":
+		return StringsBuilder(dataType, "(", dataLength, ")")
+	default:
+		return dataType
+	}
+}
+
+// 数据校验数据行字段值拆分，数据行以逗号拼接且字符值内标点符号已转义 (SpecialLettersUsingMySQL)，未转义逗号为字段分隔符
+func SplitCompareRowValues(row string) []string {
+	var (
+		values  []string
+		b       strings.Builder
+		escaped bool
+	)
+	for _, r := range row {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			values = append(values, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return append(values, b.String())
+}
+
+// MySQL/TiDB 数据校验切分键边界值字面量，value 为源端边界原始值
+// 1、字符类型以十六进制字面量表示，避免转义以及连接字符集影响，CHAR 类型去除尾部空格与目标端存储一致
+// 2、时间类型小数秒截取至目标端最大精度，切分键时间类型精度不超过目标端最大精度
+// 3、RAW 类型以十六进制字面量表示
+func MySQLCompareKeyLiteral(value string, key CompareKeyColumn) string {
+	dataType := StringUPPER(key.DataType)
+	switch {
+	case IsContainString([]string{"CHAR", "VARCHAR2", "NCHAR", "NVARCHAR2"}, dataType):
+		if dataType == "CHAR" || dataType == "NCHAR" {
+			value = strings.TrimRight(value, " ")
+		}
+		literal := StringsBuilder("_utf8mb4 X'", hex.EncodeToString([]byte(value)), "'")
+		if key.CharsetT != "" {
+			return StringsBuilder("CAST(CONVERT(", literal, " USING ", strings.ToLower(key.CharsetT), ") AS BINARY)")
+		}
+		return literal
+	case dataType == "DATE":
+		return StringsBuilder("'", value, "'")
+	case strings.HasPrefix(dataType, "TIMESTAMP"):
+		if len(value) > 20+MySQLMaxTimePrecision {
+			value = value[:20+MySQLMaxTimePrecision]
+		}
+		return StringsBuilder("'", value, "'")
+	case dataType == "RAW":
+		return StringsBuilder("X'", value, "'")
+	default:
+		return value
+	}
+}
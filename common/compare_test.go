@@ -0,0 +1,223 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCompareTimePrecision(t *testing.T) {
+	// [源端精度, 目标端精度] -> 对比精度
+	tests := map[string]struct {
+		sourceScale, targetScale int
+		rule                     string
+		want                     int
+	}{
+		"round source higher":    {9, 6, CompareTimePrecisionRound, 6},
+		"round target higher":    {3, 6, CompareTimePrecisionRound, 3},
+		"truncate source higher": {9, 0, CompareTimePrecisionTruncate, 0},
+		"strict source higher":   {9, 6, CompareTimePrecisionStrict, 9},
+		"strict target higher":   {0, 6, CompareTimePrecisionStrict, 6},
+		"strict lower case":      {9, 6, "strict", 9},
+		"equal":                  {6, 6, CompareTimePrecisionRound, 6},
+	}
+	for name, tt := range tests {
+		if got := CompareTimePrecision(tt.sourceScale, tt.targetScale, tt.rule); got != tt.want {
+			t.Errorf("%s: CompareTimePrecision(%d, %d, %s) = %v, want %v", name, tt.sourceScale, tt.targetScale, tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestOracleCompareTimeColumn(t *testing.T) {
+	tests := []struct {
+		name        string
+		columnExpr  string
+		isDate      bool
+		sourceScale int
+		precision   int
+		rule        string
+		want        string
+	}{
+		{
+			name:       "date without fraction",
+			columnExpr: `"C"`,
+			isDate:     true,
+			rule:       CompareTimePrecisionRound,
+			want:       `LTRIM(TO_CHAR("C",'SYYYY-MM-DD HH24:MI:SS'))`,
+		},
+		{
+			name:       "date with fraction",
+			columnExpr: `"C"`,
+			isDate:     true,
+			precision:  3,
+			rule:       CompareTimePrecisionStrict,
+			want:       `LTRIM(TO_CHAR(CAST("C" AS TIMESTAMP(3)),'SYYYY-MM-DD HH24:MI:SS.FF3'))`,
+		},
+		{
+			name:        "timestamp round",
+			columnExpr:  `"C"`,
+			sourceScale: 9,
+			precision:   6,
+			rule:        CompareTimePrecisionRound,
+			want:        `LTRIM(TO_CHAR(CAST("C" AS TIMESTAMP(6)),'SYYYY-MM-DD HH24:MI:SS.FF6'))`,
+		},
+		{
+			name:        "timestamp truncate",
+			columnExpr:  `"C"`,
+			sourceScale: 9,
+			precision:   6,
+			rule:        CompareTimePrecisionTruncate,
+			want:        `LTRIM(TO_CHAR("C",'SYYYY-MM-DD HH24:MI:SS.FF6'))`,
+		},
+		{
+			name:        "timestamp round same scale",
+			columnExpr:  `"C"`,
+			sourceScale: 6,
+			precision:   6,
+			rule:        CompareTimePrecisionRound,
+			want:        `LTRIM(TO_CHAR("C",'SYYYY-MM-DD HH24:MI:SS.FF6'))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OracleCompareTimeColumn(tt.columnExpr, tt.isDate, tt.sourceScale, tt.precision, tt.rule); got != tt.want {
+				t.Errorf("OracleCompareTimeColumn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLCompareTimeColumn(t *testing.T) {
+	tests := []struct {
+		name       string
+		columnName string
+		precision  int
+		want       string
+	}{
+		{name: "without fraction", columnName: "`C`", precision: 0, want: "DATE_FORMAT(`C`,'%Y-%m-%d %H:%i:%s')"},
+		{name: "fraction", columnName: "`C`", precision: 3, want: "SUBSTR(DATE_FORMAT(`C`,'%Y-%m-%d %H:%i:%s.%f'),1,23)"},
+		{name: "max fraction", columnName: "`C`", precision: 6, want: "SUBSTR(DATE_FORMAT(`C`,'%Y-%m-%d %H:%i:%s.%f'),1,26)"},
+		{name: "over max fraction", columnName: "`C`", precision: 9, want: "CONCAT(DATE_FORMAT(`C`,'%Y-%m-%d %H:%i:%s.%f'),'000')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MySQLCompareTimeColumn(tt.columnName, tt.precision); got != tt.want {
+				t.Errorf("MySQLCompareTimeColumn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLCompareKeyLiteral(t *testing.T) {
+	// 字符型按 utf8mb4 十六进制字面量对比，避免目标端排序规则影响边界
+	tests := []struct {
+		dataType string
+		charsetT string
+		value    string
+		want     string
+	}{
+		{"NUMBER", "", "100", "100"},
+		{"VARCHAR2", "", "a'b", "_utf8mb4 X'612762'"},
+		{"char", "", "ab  ", "_utf8mb4 X'6162'"},
+		{"NVARCHAR2", "", "ab ", "_utf8mb4 X'616220'"},
+		{"VARCHAR2", "GBK", "中", "CAST(CONVERT(_utf8mb4 X'e4b8ad' USING gbk) AS BINARY)"},
+		{"DATE", "", "2023-01-02 03:04:05", "'2023-01-02 03:04:05'"},
+		{"TIMESTAMP(3)", "", "2023-01-02 03:04:05.123", "'2023-01-02 03:04:05.123'"},
+		{"TIMESTAMP(9)", "", "2023-01-02 03:04:05.123456789", "'2023-01-02 03:04:05.123456'"},
+		{"RAW", "", "0A1B", "X'0A1B'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			got := MySQLCompareKeyLiteral(tt.value, CompareKeyColumn{DataType: tt.dataType, CharsetT: tt.charsetT})
+			if got != tt.want {
+				t.Errorf("MySQLCompareKeyLiteral(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSampleIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		n     int
+		want  []int
+	}{
+		{name: "empty", total: 0, n: 3, want: nil},
+		{name: "none", total: 10, n: 0, want: nil},
+		{name: "all", total: 5, n: 10, want: []int{0, 1, 2, 3, 4}},
+		{name: "two of ten", total: 10, n: 2, want: []int{2, 7}},
+		{name: "three of ten", total: 10, n: 3, want: []int{1, 5, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareSampleIndex(tt.total, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareSampleIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSampleStratified(t *testing.T) {
+	strata := []string{"A", "A", "A", "A", "A", "A", "B", "B"}
+	tests := []struct {
+		name   string
+		strata []string
+		n      int
+		want   []int
+	}{
+		{name: "all", strata: strata, n: 8, want: []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{name: "single stratum", strata: []string{"", "", "", ""}, n: 2, want: []int{1, 3}},
+		{name: "every stratum", strata: strata, n: 2, want: []int{3, 7}},
+		{name: "proportional", strata: strata, n: 4, want: []int{1, 3, 5, 7}},
+		{name: "fewer than strata", strata: strata, n: 1, want: []int{3}},
+		{name: "interleaved strata", strata: []string{"A", "B", "A", "B", "A", "B"}, n: 2, want: []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareSampleStratified(tt.strata, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareSampleStratified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSampleConfidence(t *testing.T) {
+	tests := []struct {
+		name         string
+		sampled      int64
+		mismatched   int64
+		population   int64
+		wantEstimate float64
+		wantUpper    float64
+	}{
+		{name: "not sampled", sampled: 0, mismatched: 0, population: 10, wantEstimate: 0, wantUpper: 1},
+		{name: "fully sampled", sampled: 10, mismatched: 2, population: 10, wantEstimate: 0.2, wantUpper: 0.2},
+		{name: "no mismatch", sampled: 10, mismatched: 0, population: 100, wantEstimate: 0, wantUpper: 0.2588655508930523},
+		{name: "mismatch", sampled: 100, mismatched: 10, population: 1000, wantEstimate: 0.1, wantUpper: 0.15581049015024695},
+		{name: "upper bound capped", sampled: 4, mismatched: 3, population: 10, wantEstimate: 0.75, wantUpper: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate, upper := CompareSampleConfidence(tt.sampled, tt.mismatched, tt.population)
+			if math.Abs(estimate-tt.wantEstimate) > 1e-9 || math.Abs(upper-tt.wantUpper) > 1e-9 {
+				t.Errorf("CompareSampleConfidence() = (%v, %v), want (%v, %v)", estimate, upper, tt.wantEstimate, tt.wantUpper)
+			}
+		})
+	}
+}
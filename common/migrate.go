@@ -15,6 +15,8 @@ limitations under the License.
 */
 package common
 
+import "strings"
+
 // 数据全量/实时同步 Oracle 版本要求
 // 要求 oracle 11g 及以上
 const RequireOracleDBVersion = "11"
@@ -32,7 +34,197 @@ const (
 	MigrateOperationDropTable     = "DROP TABLE"
 )
 
-// 用于控制当程序消费追平到当前 CURRENT 重做日志，
-// 当值 == 0 启用 filterOracleIncrRecord 大于或者等于逻辑
-// 当值 == 1 启用 filterOracleIncrRecord 大于逻辑，避免已被消费得日志一直被重复消费
-var MigrateCurrentResetFlag = 0
+// 增量同步数据应用错误处理策略
+// abort 遇错中断同步（默认）
+// skip 忽略错误事件并继续推进表 checkpoint
+// park 错误事件写入元数据表 [incr_park_detail] 并继续推进表 checkpoint，待下游修复后通过 park 模式重放或丢弃
+const (
+	MigrateIncrErrorPolicyAbort = "ABORT"
+	MigrateIncrErrorPolicySkip  = "SKIP"
+	MigrateIncrErrorPolicyPark  = "PARK"
+)
+
+var MigrateIncrErrorPolicies = []string{
+	MigrateIncrErrorPolicyAbort,
+	MigrateIncrErrorPolicySkip,
+	MigrateIncrErrorPolicyPark,
+}
+
+// 暂存事件重放要求增量同步任务已停止，增量同步元数据该时间窗口（秒）内有更新视为增量同步任务运行中
+const MigrateIncrParkReplayIdleSeconds = 60
+
+// 增量同步心跳表，源端定时更新心跳表经 logminer 捕获应用至目标端，用于计算端到端同步延迟
+const MigrateIncrHeartbeatTable = "TRANSFERDB_HEARTBEAT"
+
+// 全量/csv 表 chunk 切分方式
+// rowid 基于 DBMS_PARALLEL_EXECUTE ROWID 切分（默认），需源端 CREATE JOB 权限，索引组织表 (IOT) 自动以主键切分
+// key 基于主键/唯一键范围采样切分，源端只读，无可用主键/唯一键全表作为单个 chunk
+const (
+	MigrateChunkMethodRowID = "ROWID"
+	MigrateChunkMethodKey   = "KEY"
+)
+
+var MigrateChunkMethods = []string{
+	MigrateChunkMethodRowID,
+	MigrateChunkMethodKey,
+}
+
+// 表 chunk 切分条件最大长度，与元数据表 [full_sync_meta] chunk_detail_s 字段长度一致
+const MigrateChunkDetailMaxLength = 300
+
+// 主键/唯一键范围切分采样，每 chunk 采样行数
+const MigrateChunkKeySampleRows = 100
+
+// GenChunkKeyRange 按切分键边界生成 chunk 范围条件，首个 chunk <= 首边界，末个 chunk > 末边界
+// columns 为切分键字段比较表达式，bounds 为对应边界值 SQL 字面量，上下游以各自表达式以及字面量生成
+func GenChunkKeyRange(columns []string, bounds [][]string) []string {
+	var chunkRes []string
+	for i, b := range bounds {
+		if i == 0 {
+			chunkRes = append(chunkRes, chunkKeyCompare(columns, b, false))
+			continue
+		}
+		chunkRes = append(chunkRes, StringsBuilder(chunkKeyCompare(columns, bounds[i-1], true), ` AND `, chunkKeyCompare(columns, b, false)))
+	}
+	chunkRes = append(chunkRes, chunkKeyCompare(columns, bounds[len(bounds)-1], true))
+	return chunkRes
+}
+
+// ChunkKeyLeadingBounds 复合键切分条件过长时以首字段作为边界，首字段重复边界去重
+func ChunkKeyLeadingBounds(bounds [][]string) [][]string {
+	var leadingBounds [][]string
+	for _, b := range bounds {
+		if len(leadingBounds) > 0 && leadingBounds[len(leadingBounds)-1][0] == b[0] {
+			continue
+		}
+		leadingBounds = append(leadingBounds, b[:1])
+	}
+	return leadingBounds
+}
+
+// 复合键范围比较逐级展开，greater 为 true 表示键 > 边界，否则键 <= 边界
+// (K1,K2) > (V1,V2) 展开为 (K1 > V1 OR (K1 = V1 AND K2 > V2))
+func chunkKeyCompare(columns []string, bound []string, greater bool) string {
+	op, lastOp := `<`, `<=`
+	if greater {
+		op, lastOp = `>`, `>`
+	}
+	if len(columns) == 1 {
+		return StringsBuilder(columns[0], ` `, lastOp, ` `, bound[0])
+	}
+	var ors []string
+	for i := range columns {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, StringsBuilder(columns[j], ` = `, bound[j]))
+		}
+		cmp := op
+		if i == len(columns)-1 {
+			cmp = lastOp
+		}
+		ands = append(ands, StringsBuilder(columns[i], ` `, cmp, ` `, bound[i]))
+		ors = append(ors, strings.Join(ands, ` AND `))
+	}
+	return StringsBuilder(`(`, strings.Join(ors, ` OR `), `)`)
+}
+
+// 全量同步目标端索引约束延迟创建（full defer-index = true）
+// 迁移前保留目标端表主键，暂缓创建二级索引/唯一索引以及外键，表全部 chunk 成功后创建索引，任务全部表迁移完成后创建外键
+const (
+	MigrateDeferIndexTypeIndex      = "INDEX"
+	MigrateDeferIndexTypeForeignKey = "FOREIGN KEY"
+)
+
+// 表延迟创建状态，记录于元数据表 [wait_sync_meta]
+// DEFERRED 索引外键已暂缓，INDEXED 索引已创建外键待创建，BUILT 全部创建完成，FAILED 创建失败，重新运行 full 任务重试
+const (
+	MigrateDeferIndexStatusDeferred = "DEFERRED"
+	MigrateDeferIndexStatusIndexed  = "INDEXED"
+	MigrateDeferIndexStatusBuilt    = "BUILT"
+	MigrateDeferIndexStatusFailed   = "FAILED"
+)
+
+// 目标端延迟创建索引/外键
+type MigrateDeferIndex struct {
+	SchemaNameT     string `json:"schema_name_t"`
+	TableNameT      string `json:"table_name_t"`
+	IndexName       string `json:"index_name"`
+	IndexType       string `json:"index_type"`
+	IndexDefinition string `json:"index_definition"`
+}
+
+func (d MigrateDeferIndex) DropSQL() string {
+	return StringsBuilder(`ALTER TABLE `, d.SchemaNameT, `.`, d.TableNameT, ` DROP `, d.IndexType, " `", strings.ReplaceAll(d.IndexName, "`", "``"), "`")
+}
+
+func (d MigrateDeferIndex) AddSQL() string {
+	return StringsBuilder(`ALTER TABLE `, d.SchemaNameT, `.`, d.TableNameT, ` ADD `, d.IndexDefinition)
+}
+
+// ParseMigrateDeferIndex 解析目标端 SHOW CREATE TABLE 获取二级索引、唯一索引以及外键定义，主键以及 CHECK 约束保留
+// 无主键表保留唯一索引，全量 REPLACE INTO 断点续传重跑 chunk 依赖唯一索引去重，暂缓创建将导致重复数据且唯一索引创建失败
+func ParseMigrateDeferIndex(schemaName, tableName, createTableDDL string) []MigrateDeferIndex {
+	lines := strings.Split(createTableDDL, "\n")
+	hasPrimaryKey := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(line)), "PRIMARY KEY ") {
+			hasPrimaryKey = true
+			break
+		}
+	}
+
+	var deferIndexes []MigrateDeferIndex
+	for _, line := range lines {
+		definition := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		upperDefinition := strings.ToUpper(definition)
+
+		indexType := MigrateDeferIndexTypeIndex
+		switch {
+		case strings.HasPrefix(upperDefinition, "UNIQUE KEY "):
+			if !hasPrimaryKey {
+				continue
+			}
+		case strings.HasPrefix(upperDefinition, "KEY "),
+			strings.HasPrefix(upperDefinition, "FULLTEXT KEY "),
+			strings.HasPrefix(upperDefinition, "SPATIAL KEY "):
+		case strings.HasPrefix(upperDefinition, "CONSTRAINT ") && strings.Contains(upperDefinition, " FOREIGN KEY "):
+			indexType = MigrateDeferIndexTypeForeignKey
+		default:
+			continue
+		}
+		indexName, ok := parseQuoteIdentifier(definition)
+		if !ok {
+			continue
+		}
+		deferIndexes = append(deferIndexes, MigrateDeferIndex{
+			SchemaNameT:     schemaName,
+			TableNameT:      tableName,
+			IndexName:       indexName,
+			IndexType:       indexType,
+			IndexDefinition: definition,
+		})
+	}
+	return deferIndexes
+}
+
+// 获取定义中首个反引号标识符，连续两个反引号为转义反引号
+func parseQuoteIdentifier(definition string) (string, bool) {
+	start := strings.Index(definition, "`")
+	if start < 0 {
+		return "", false
+	}
+	var name strings.Builder
+	for i := start + 1; i < len(definition); i++ {
+		if definition[i] != '`' {
+			name.WriteByte(definition[i])
+			continue
+		}
+		if i+1 < len(definition) && definition[i+1] == '`' {
+			name.WriteByte('`')
+			i++
+			continue
+		}
+		return name.String(), true
+	}
+	return "", false
+}
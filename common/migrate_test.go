@@ -0,0 +1,173 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkKeyCompare(t *testing.T) {
+	single, composite := []string{"ID"}, []string{"K1", "K2"}
+
+	if got := chunkKeyCompare(single, []string{"10"}, false); got != "ID <= 10" {
+		t.Errorf("single upper bound = %v", got)
+	}
+	if got := chunkKeyCompare(single, []string{"10"}, true); got != "ID > 10" {
+		t.Errorf("single lower bound = %v", got)
+	}
+	// 联合键按字典序展开
+	if got := chunkKeyCompare(composite, []string{"1", "'a'"}, false); got != "(K1 < 1 OR K1 = 1 AND K2 <= 'a')" {
+		t.Errorf("composite upper bound = %v", got)
+	}
+	if got := chunkKeyCompare(composite, []string{"1", "'a'"}, true); got != "(K1 > 1 OR K1 = 1 AND K2 > 'a')" {
+		t.Errorf("composite lower bound = %v", got)
+	}
+	want := "(K1 > 1 OR K1 = 1 AND K2 > 2 OR K1 = 1 AND K2 = 2 AND K3 > 3)"
+	if got := chunkKeyCompare([]string{"K1", "K2", "K3"}, []string{"1", "2", "3"}, true); got != want {
+		t.Errorf("three columns lower bound = %v, want %v", got, want)
+	}
+}
+
+func TestGenChunkKeyRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		bounds  [][]string
+		want    []string
+	}{
+		{
+			name:    "single bound",
+			columns: []string{"ID"},
+			bounds:  [][]string{{"100"}},
+			want:    []string{"ID <= 100", "ID > 100"},
+		},
+		{
+			name:    "single column",
+			columns: []string{"ID"},
+			bounds:  [][]string{{"100"}, {"200"}, {"300"}},
+			want:    []string{"ID <= 100", "ID > 100 AND ID <= 200", "ID > 200 AND ID <= 300", "ID > 300"},
+		},
+		{
+			name:    "composite",
+			columns: []string{"K1", "K2"},
+			bounds:  [][]string{{"1", "5"}, {"2", "3"}},
+			want: []string{
+				"(K1 < 1 OR K1 = 1 AND K2 <= 5)",
+				"(K1 > 1 OR K1 = 1 AND K2 > 5) AND (K1 < 2 OR K1 = 2 AND K2 <= 3)",
+				"(K1 > 2 OR K1 = 2 AND K2 > 3)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GenChunkKeyRange(tt.columns, tt.bounds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenChunkKeyRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkKeyLeadingBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds [][]string
+		want   [][]string
+	}{
+		{name: "single column", bounds: [][]string{{"1"}, {"2"}}, want: [][]string{{"1"}, {"2"}}},
+		{name: "distinct leading", bounds: [][]string{{"1", "a"}, {"2", "b"}}, want: [][]string{{"1"}, {"2"}}},
+		{name: "duplicate leading", bounds: [][]string{{"1", "a"}, {"1", "b"}, {"2", "a"}, {"2", "c"}, {"3", "a"}}, want: [][]string{{"1"}, {"2"}, {"3"}}},
+		{name: "empty", bounds: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChunkKeyLeadingBounds(tt.bounds); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ChunkKeyLeadingBounds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMigrateDeferIndex(t *testing.T) {
+	withPrimaryKey := "CREATE TABLE `T1` (\n" +
+		"  `ID` bigint NOT NULL,\n" +
+		"  `NAME` varchar(30) DEFAULT NULL,\n" +
+		"  `P_ID` bigint DEFAULT NULL,\n" +
+		"  `DOC` text,\n" +
+		"  PRIMARY KEY (`ID`) /*T![clustered_index] CLUSTERED */,\n" +
+		"  UNIQUE KEY `UK_NAME` (`NAME`),\n" +
+		"  KEY `IDX_P``ID` (`P_ID`),\n" +
+		"  FULLTEXT KEY `FT_DOC` (`DOC`),\n" +
+		"  CONSTRAINT `FK_P` FOREIGN KEY (`P_ID`) REFERENCES `P` (`ID`),\n" +
+		"  CONSTRAINT `CK_ID` CHECK ((`ID` > 0))\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	withoutPrimaryKey := "CREATE TABLE `T2` (\n" +
+		"  `ID` bigint NOT NULL,\n" +
+		"  `NAME` varchar(30) DEFAULT NULL,\n" +
+		"  UNIQUE KEY `UK_ID` (`ID`),\n" +
+		"  KEY `IDX_NAME` (`NAME`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+	tests := []struct {
+		name string
+		ddl  string
+		want []MigrateDeferIndex
+	}{
+		{
+			name: "with primary key",
+			ddl:  withPrimaryKey,
+			want: []MigrateDeferIndex{
+				{SchemaNameT: "S", TableNameT: "T", IndexName: "UK_NAME", IndexType: MigrateDeferIndexTypeIndex, IndexDefinition: "UNIQUE KEY `UK_NAME` (`NAME`)"},
+				{SchemaNameT: "S", TableNameT: "T", IndexName: "IDX_P`ID", IndexType: MigrateDeferIndexTypeIndex, IndexDefinition: "KEY `IDX_P``ID` (`P_ID`)"},
+				{SchemaNameT: "S", TableNameT: "T", IndexName: "FT_DOC", IndexType: MigrateDeferIndexTypeIndex, IndexDefinition: "FULLTEXT KEY `FT_DOC` (`DOC`)"},
+				{SchemaNameT: "S", TableNameT: "T", IndexName: "FK_P", IndexType: MigrateDeferIndexTypeForeignKey, IndexDefinition: "CONSTRAINT `FK_P` FOREIGN KEY (`P_ID`) REFERENCES `P` (`ID`)"},
+			},
+		},
+		{
+			name: "without primary key keep unique key",
+			ddl:  withoutPrimaryKey,
+			want: []MigrateDeferIndex{
+				{SchemaNameT: "S", TableNameT: "T", IndexName: "IDX_NAME", IndexType: MigrateDeferIndexTypeIndex, IndexDefinition: "KEY `IDX_NAME` (`NAME`)"},
+			},
+		},
+		{
+			name: "only primary key",
+			ddl:  "CREATE TABLE `T3` (\n  `ID` bigint NOT NULL,\n  PRIMARY KEY (`ID`)\n)",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMigrateDeferIndex("S", "T", tt.ddl); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMigrateDeferIndex() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateDeferIndexSQL(t *testing.T) {
+	d := MigrateDeferIndex{SchemaNameT: "S", TableNameT: "T", IndexName: "IDX_P`ID", IndexType: MigrateDeferIndexTypeIndex, IndexDefinition: "KEY `IDX_P``ID` (`P_ID`)"}
+	if got, want := d.DropSQL(), "ALTER TABLE S.T DROP INDEX `IDX_P``ID`"; got != want {
+		t.Errorf("DropSQL() = %v, want %v", got, want)
+	}
+	if got, want := d.AddSQL(), "ALTER TABLE S.T ADD KEY `IDX_P``ID` (`P_ID`)"; got != want {
+		t.Errorf("AddSQL() = %v, want %v", got, want)
+	}
+	fk := MigrateDeferIndex{SchemaNameT: "S", TableNameT: "T", IndexName: "FK_P", IndexType: MigrateDeferIndexTypeForeignKey}
+	if got, want := fk.DropSQL(), "ALTER TABLE S.T DROP FOREIGN KEY `FK_P`"; got != want {
+		t.Errorf("DropSQL() = %v, want %v", got, want)
+	}
+}
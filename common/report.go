@@ -0,0 +1,301 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 机器可读结果报告格式，适用 check、compare、assess 模式，为空不输出
+const (
+	ReportFormatJSON  = "JSON"
+	ReportFormatJUnit = "JUNIT"
+)
+
+var ReportFormats = []string{
+	ReportFormatJSON,
+	ReportFormatJUnit,
+}
+
+// 结果报告状态，表级别以及任务级别
+const (
+	ReportStatusPass     = "PASS"
+	ReportStatusMismatch = "MISMATCH"
+	ReportStatusFailed   = "FAILED"
+)
+
+// CI 门禁退出码策略
+// none 不影响退出码
+// failed 存在运行失败表时非零退出
+// mismatch 存在不一致或者运行失败表时非零退出
+const (
+	ReportFailOnNone     = "NONE"
+	ReportFailOnFailed   = "FAILED"
+	ReportFailOnMismatch = "MISMATCH"
+)
+
+var ReportFailOnPolicies = []string{
+	ReportFailOnNone,
+	ReportFailOnFailed,
+	ReportFailOnMismatch,
+}
+
+// 门禁失败进程退出码，区别于程序运行错误退出码 1
+const ReportGateExitCode = 3
+
+type Report struct {
+	TaskName    string        `json:"task_name"`
+	TaskMode    string        `json:"task_mode"`
+	DBTypeS     string        `json:"db_type_s"`
+	DBTypeT     string        `json:"db_type_t"`
+	SchemaNameS string        `json:"schema_name_s"`
+	SchemaNameT string        `json:"schema_name_t"`
+	Status      string        `json:"status"`
+	StartTime   time.Time     `json:"start_time"`
+	EndTime     time.Time     `json:"end_time"`
+	Cost        string        `json:"cost"`
+	Summary     ReportSummary `json:"summary"`
+	Tables      []ReportTable `json:"tables"`
+	Files       []string      `json:"files,omitempty"`
+	Detail      interface{}   `json:"detail,omitempty"`
+	mutex       *sync.Mutex   `json:"-"`
+}
+
+type ReportSummary struct {
+	TableTotals   int            `json:"table_totals"`
+	TablePass     int            `json:"table_pass"`
+	TableMismatch int            `json:"table_mismatch"`
+	TableFailed   int            `json:"table_failed"`
+	Categories    map[string]int `json:"categories"`
+}
+
+type ReportTable struct {
+	SchemaNameS string         `json:"schema_name_s"`
+	TableNameS  string         `json:"table_name_s"`
+	SchemaNameT string         `json:"schema_name_t,omitempty"`
+	TableNameT  string         `json:"table_name_t,omitempty"`
+	Status      string         `json:"status"`
+	Categories  map[string]int `json:"categories,omitempty"`
+	FixFile     string         `json:"fix_file,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+func NewReport(taskName, taskMode, dbTypeS, dbTypeT, schemaNameS, schemaNameT string, startTime time.Time) *Report {
+	return &Report{
+		TaskName:    taskName,
+		TaskMode:    StringUPPER(taskMode),
+		DBTypeS:     dbTypeS,
+		DBTypeT:     dbTypeT,
+		SchemaNameS: schemaNameS,
+		SchemaNameT: schemaNameT,
+		StartTime:   startTime,
+		mutex:       &sync.Mutex{},
+	}
+}
+
+// AppendTable 并发安全，不一致类别为空且无错误视为 PASS
+func (r *Report) AppendTable(t ReportTable) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if t.Status == "" {
+		switch {
+		case t.Error != "":
+			t.Status = ReportStatusFailed
+		case len(t.Categories) > 0:
+			t.Status = ReportStatusMismatch
+		default:
+			t.Status = ReportStatusPass
+		}
+	}
+	r.Tables = append(r.Tables, t)
+}
+
+func (r *Report) AppendFile(files ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Files = append(r.Files, files...)
+}
+
+// Finish 汇总表级别结果，任务状态取最差表状态
+func (r *Report) Finish(endTime time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sort.SliceStable(r.Tables, func(i, j int) bool {
+		return r.Tables[i].TableNameS < r.Tables[j].TableNameS
+	})
+
+	summary := ReportSummary{Categories: make(map[string]int)}
+	for _, t := range r.Tables {
+		summary.TableTotals++
+		switch t.Status {
+		case ReportStatusPass:
+			summary.TablePass++
+		case ReportStatusMismatch:
+			summary.TableMismatch++
+		default:
+			summary.TableFailed++
+		}
+		for category, counts := range t.Categories {
+			summary.Categories[category] += counts
+		}
+	}
+	r.Summary = summary
+
+	switch {
+	case summary.TableFailed > 0:
+		r.Status = ReportStatusFailed
+	case summary.TableMismatch > 0:
+		r.Status = ReportStatusMismatch
+	default:
+		r.Status = ReportStatusPass
+	}
+	r.EndTime = endTime
+	r.Cost = endTime.Sub(r.StartTime).String()
+}
+
+// Write 输出结果报告文件 report_${mode}_${source_schema}.json/xml
+func (r *Report) Write(reportDir, format string) (string, error) {
+	if err := PathExist(reportDir); err != nil {
+		return "", err
+	}
+
+	var (
+		fileName string
+		content  []byte
+		err      error
+	)
+	switch StringUPPER(format) {
+	case ReportFormatJSON:
+		fileName = fmt.Sprintf("report_%s_%s.json", strings.ToLower(r.TaskMode), r.SchemaNameS)
+		content, err = json.MarshalIndent(r, "", "  ")
+	case ReportFormatJUnit:
+		fileName = fmt.Sprintf("report_%s_%s.xml", strings.ToLower(r.TaskMode), r.SchemaNameS)
+		content, err = xml.MarshalIndent(r.junit(), "", "  ")
+		content = append([]byte(xml.Header), content...)
+	default:
+		return "", fmt.Errorf("report format [%s] isn't support, support format [%v]", format, ReportFormats)
+	}
+	if err != nil {
+		return "", fmt.Errorf("report format [%s] marshal failed: %v", format, err)
+	}
+
+	reportFile := filepath.Join(reportDir, fileName)
+	if err = os.WriteFile(reportFile, content, 0666); err != nil {
+		return "", fmt.Errorf("report file [%s] write failed: %v", reportFile, err)
+	}
+	return reportFile, nil
+}
+
+// Gate 按门禁策略判断任务结果，不满足返回 ReportGateError
+func (r *Report) Gate(failOn string) error {
+	switch StringUPPER(failOn) {
+	case ReportFailOnFailed:
+		if r.Status == ReportStatusFailed {
+			return &ReportGateError{Report: r, FailOn: failOn}
+		}
+	case ReportFailOnMismatch:
+		if r.Status != ReportStatusPass {
+			return &ReportGateError{Report: r, FailOn: failOn}
+		}
+	}
+	return nil
+}
+
+type ReportGateError struct {
+	Report *Report
+	FailOn string
+}
+
+func (e *ReportGateError) Error() string {
+	return fmt.Sprintf("mode [%s] schema [%s] report status [%s] isn't pass by fail-on [%s], table mismatch [%d] failed [%d]",
+		e.Report.TaskMode, e.Report.SchemaNameS, e.Report.Status, e.FailOn, e.Report.Summary.TableMismatch, e.Report.Summary.TableFailed)
+}
+
+func (e *ReportGateError) ExitCode() int {
+	return ReportGateExitCode
+}
+
+// JUnit XML 格式，每张表对应一个 testcase，不一致记为 failure，运行失败记为 error
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+func (r *Report) junit() junitTestSuites {
+	suite := junitTestSuite{
+		Name:      fmt.Sprintf("%s.%s", strings.ToLower(r.TaskMode), r.SchemaNameS),
+		Tests:     r.Summary.TableTotals,
+		Failures:  r.Summary.TableMismatch,
+		Errors:    r.Summary.TableFailed,
+		Time:      fmt.Sprintf("%.3f", r.EndTime.Sub(r.StartTime).Seconds()),
+		Timestamp: r.StartTime.Format(time.RFC3339),
+	}
+	for _, t := range r.Tables {
+		tc := junitTestCase{
+			Name:      t.TableNameS,
+			ClassName: fmt.Sprintf("%s.%s", strings.ToLower(r.TaskMode), t.SchemaNameS),
+		}
+		var categories []string
+		for category, counts := range t.Categories {
+			categories = append(categories, fmt.Sprintf("%s=%d", category, counts))
+		}
+		sort.Strings(categories)
+		content := strings.Join(categories, "\n")
+		if t.FixFile != "" {
+			content = StringsBuilder(content, "\nfix file: ", t.FixFile)
+		}
+		switch t.Status {
+		case ReportStatusMismatch:
+			tc.Failure = &junitMessage{Message: strings.Join(categories, ","), Type: ReportStatusMismatch, Content: content}
+		case ReportStatusFailed:
+			tc.Error = &junitMessage{Message: t.Error, Type: ReportStatusFailed, Content: content}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	return junitTestSuites{Suites: []junitTestSuite{suite}}
+}
@@ -0,0 +1,315 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 表路由规则，reverse/full/all/compare 模式生效
+// merge 多张源端表合并至同一张目标端表，目标端新增区分字段，字段值为当前源端表固定值，区分字段追加至目标端主键、唯一约束以及唯一索引
+// hash 按路由字段 MOD(ABS(字段值), hash-buckets) 拆分至多张目标端分片表，路由字段需为整数类型
+// range 按路由字段数值区间 [range-begin, range-end) 拆分至多张目标端分片表，区间边界为空表示无边界
+// 分片表均位于当前任务目标端实例，不支持跨实例分片，hash 分片需覆盖全部分桶，range 分片区间需连续且首尾无边界，保证数据不遗漏
+const (
+	TableRouteTypeMerge = "MERGE"
+	TableRouteTypeHash  = "HASH"
+	TableRouteTypeRange = "RANGE"
+)
+
+var TableRouteTypes = []string{
+	TableRouteTypeMerge,
+	TableRouteTypeHash,
+	TableRouteTypeRange,
+}
+
+type TableShard struct {
+	SchemaNameT string
+	TableNameT  string
+	HashBucket  int
+	RangeBegin  string
+	RangeEnd    string
+}
+
+type TableRoute struct {
+	RouteType string
+	// merge
+	SchemaNameT           string
+	TableNameT            string
+	DiscriminatorColumn   string
+	DiscriminatorValue    string
+	DiscriminatorDatatype string
+	// hash/range
+	RouteColumn string
+	HashBuckets int
+	Shards      []TableShard
+}
+
+func (r *TableRoute) IsMerge() bool {
+	return r != nil && r.RouteType == TableRouteTypeMerge
+}
+
+func (r *TableRoute) IsSplit() bool {
+	return r != nil && (r.RouteType == TableRouteTypeHash || r.RouteType == TableRouteTypeRange)
+}
+
+func (r *TableRoute) Valid() error {
+	switch r.RouteType {
+	case TableRouteTypeMerge:
+		if r.TableNameT == "" {
+			return fmt.Errorf("route type [%s] target table cannot be empty", r.RouteType)
+		}
+		if r.DiscriminatorColumn == "" || r.DiscriminatorDatatype == "" {
+			return fmt.Errorf("route type [%s] discriminator column and discriminator datatype cannot be empty", r.RouteType)
+		}
+		return nil
+	case TableRouteTypeHash, TableRouteTypeRange:
+		if r.RouteColumn == "" {
+			return fmt.Errorf("route type [%s] route column cannot be empty", r.RouteType)
+		}
+		if len(r.Shards) == 0 {
+			return fmt.Errorf("route type [%s] shards cannot be empty", r.RouteType)
+		}
+		if r.RouteType == TableRouteTypeHash && r.HashBuckets <= 0 {
+			return fmt.Errorf("route type [%s] hash buckets [%d] need be greater than 0", r.RouteType, r.HashBuckets)
+		}
+		for _, s := range r.Shards {
+			if s.TableNameT == "" {
+				return fmt.Errorf("route type [%s] shard target table cannot be empty", r.RouteType)
+			}
+			if r.RouteType == TableRouteTypeHash {
+				if s.HashBucket < 0 || s.HashBucket >= r.HashBuckets {
+					return fmt.Errorf("route type [%s] shard [%s] hash bucket [%d] out of range [0, %d)", r.RouteType, s.TableNameT, s.HashBucket, r.HashBuckets)
+				}
+				continue
+			}
+			begin, end, err := s.rangeBoundary()
+			if err != nil {
+				return fmt.Errorf("route type [%s] shard [%s] %v", r.RouteType, s.TableNameT, err)
+			}
+			if begin != nil && end != nil && begin.Cmp(end) >= 0 {
+				return fmt.Errorf("route type [%s] shard [%s] range begin [%s] need be less than range end [%s]", r.RouteType, s.TableNameT, s.RangeBegin, s.RangeEnd)
+			}
+		}
+		return r.validShardCoverage()
+	default:
+		return fmt.Errorf("route type [%s] isn't support, support type [%v]", r.RouteType, TableRouteTypes)
+	}
+}
+
+// 分片覆盖校验，仅支持同一目标端实例分片，全部分片需配置于当前任务
+// hash 每个分桶有且仅有一个分片，range 分片按区间排序后首尾相接，首个分片起始以及最后分片结束无边界
+func (r *TableRoute) validShardCoverage() error {
+	targetTables := make(map[string]struct{})
+	for _, s := range r.Shards {
+		targetTable := StringsBuilder(s.SchemaNameT, ".", s.TableNameT)
+		if _, ok := targetTables[targetTable]; ok {
+			return fmt.Errorf("route type [%s] shard target table [%s] is duplicate", r.RouteType, targetTable)
+		}
+		targetTables[targetTable] = struct{}{}
+	}
+
+	if r.RouteType == TableRouteTypeHash {
+		buckets := make(map[int]string)
+		for _, s := range r.Shards {
+			if t, ok := buckets[s.HashBucket]; ok {
+				return fmt.Errorf("route type [%s] hash bucket [%d] is duplicate in shard [%s] and [%s]", r.RouteType, s.HashBucket, t, s.TableNameT)
+			}
+			buckets[s.HashBucket] = s.TableNameT
+		}
+		for b := 0; b < r.HashBuckets; b++ {
+			if _, ok := buckets[b]; !ok {
+				return fmt.Errorf("route type [%s] hash bucket [%d] hasn't shard, only support same target instance shards, all hash buckets need be configured", r.RouteType, b)
+			}
+		}
+		return nil
+	}
+
+	shards := make([]TableShard, len(r.Shards))
+	copy(shards, r.Shards)
+	sort.SliceStable(shards, func(i, j int) bool {
+		bi, _, _ := shards[i].rangeBoundary()
+		bj, _, _ := shards[j].rangeBoundary()
+		if bi == nil || bj == nil {
+			return bi == nil && bj != nil
+		}
+		return bi.Cmp(bj) < 0
+	})
+	if shards[0].RangeBegin != "" {
+		return fmt.Errorf("route type [%s] shard [%s] range begin [%s] need be empty, only support same target instance shards, all ranges need be configured", r.RouteType, shards[0].TableNameT, shards[0].RangeBegin)
+	}
+	if shards[len(shards)-1].RangeEnd != "" {
+		return fmt.Errorf("route type [%s] shard [%s] range end [%s] need be empty, only support same target instance shards, all ranges need be configured", r.RouteType, shards[len(shards)-1].TableNameT, shards[len(shards)-1].RangeEnd)
+	}
+	for i := 1; i < len(shards); i++ {
+		_, prevEnd, _ := shards[i-1].rangeBoundary()
+		begin, _, _ := shards[i].rangeBoundary()
+		if prevEnd == nil || begin == nil || prevEnd.Cmp(begin) != 0 {
+			return fmt.Errorf("route type [%s] shard [%s] range end [%s] and shard [%s] range begin [%s] need be equal, ranges can't be overlapped or discontinuous",
+				r.RouteType, shards[i-1].TableNameT, shards[i-1].RangeEnd, shards[i].TableNameT, shards[i].RangeBegin)
+		}
+	}
+	return nil
+}
+
+// 目标端表数据清理方式
+const (
+	TableCleanTruncate = "TRUNCATE"
+	TableCleanDelete   = "DELETE"
+)
+
+// 目标端表数据清理，用于全量非断点续传重新运行以及任务重置
+type TableClean struct {
+	SchemaNameT string
+	TableNameT  string
+	Action      string
+	WhereT      string
+}
+
+func (c TableClean) TargetTable() string {
+	return StringsBuilder(c.SchemaNameT, ".", c.TableNameT)
+}
+
+func (c TableClean) SQL() string {
+	if c.Action == TableCleanDelete {
+		return StringsBuilder("DELETE FROM `", c.SchemaNameT, "`.`", c.TableNameT, "` WHERE ", c.WhereT)
+	}
+	return StringsBuilder("TRUNCATE TABLE `", c.SchemaNameT, "`.`", c.TableNameT, "`")
+}
+
+// 源端表对应目标端表数据清理，schemaNameT/tableNameT 为未配置路由时目标端表
+// merge 按区分字段删除当前源端表数据，保留其他源端表合并数据，hash/range 截断全部分片表
+func (r *TableRoute) TargetTableClean(schemaNameT, tableNameT string) []TableClean {
+	switch {
+	case r.IsMerge():
+		return []TableClean{{SchemaNameT: r.SchemaNameT, TableNameT: r.TableNameT, Action: TableCleanDelete, WhereT: r.DiscriminatorWhereT()}}
+	case r.IsSplit():
+		var cleans []TableClean
+		for _, s := range r.Shards {
+			cleans = append(cleans, TableClean{SchemaNameT: s.SchemaNameT, TableNameT: s.TableNameT, Action: TableCleanTruncate})
+		}
+		return cleans
+	default:
+		return []TableClean{{SchemaNameT: schemaNameT, TableNameT: tableNameT, Action: TableCleanTruncate}}
+	}
+}
+
+// 目标端区分字段定义
+func (r *TableRoute) DiscriminatorColumnMeta() string {
+	return StringsBuilder("`", r.DiscriminatorColumn, "` ", r.DiscriminatorDatatype, " NOT NULL")
+}
+
+// 源端查询区分字段，以目标端字段名作为查询别名
+func (r *TableRoute) DiscriminatorSelectS() string {
+	return StringsBuilder(quoteRouteLiteral(r.DiscriminatorValue), ` AS "`, r.DiscriminatorColumn, `"`)
+}
+
+// 目标端区分字段值
+func (r *TableRoute) DiscriminatorValueT() string {
+	return quoteRouteLiteral(r.DiscriminatorValue)
+}
+
+// 目标端区分字段过滤条件
+func (r *TableRoute) DiscriminatorWhereT() string {
+	return StringsBuilder("`", r.DiscriminatorColumn, "` = ", quoteRouteLiteral(r.DiscriminatorValue))
+}
+
+// 源端分片过滤条件，与增量路由计算方式保持一致
+func (r *TableRoute) ShardWhereS(s TableShard) string {
+	column := StringsBuilder(`"`, r.RouteColumn, `"`)
+	if r.RouteType == TableRouteTypeHash {
+		return StringsBuilder(`MOD(ABS(`, column, `), `, strconv.Itoa(r.HashBuckets), `) = `, strconv.Itoa(s.HashBucket))
+	}
+	var conds []string
+	if s.RangeBegin != "" {
+		conds = append(conds, StringsBuilder(column, ` >= `, s.RangeBegin))
+	}
+	if s.RangeEnd != "" {
+		conds = append(conds, StringsBuilder(column, ` < `, s.RangeEnd))
+	}
+	if len(conds) == 0 {
+		return `1 = 1`
+	}
+	return strings.Join(conds, ` AND `)
+}
+
+// 路由字段值所属分片，false 表示当前任务未配置对应分片，NULL 值不属于任何分片
+func (r *TableRoute) ShardByValue(value string) (TableShard, bool, error) {
+	if idx := strings.Index(value, "'"); idx >= 0 {
+		value = strings.Trim(value[idx:], "'")
+	}
+	if strings.EqualFold(value, "NULL") || value == "" {
+		return TableShard{}, false, nil
+	}
+	switch r.RouteType {
+	case TableRouteTypeHash:
+		key, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return TableShard{}, false, fmt.Errorf("route column [%s] value [%s] isn't integer", r.RouteColumn, value)
+		}
+		bucket := new(big.Int).Mod(new(big.Int).Abs(key), big.NewInt(int64(r.HashBuckets))).Int64()
+		for _, s := range r.Shards {
+			if int64(s.HashBucket) == bucket {
+				return s, true, nil
+			}
+		}
+		return TableShard{}, false, nil
+	case TableRouteTypeRange:
+		key, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return TableShard{}, false, fmt.Errorf("route column [%s] value [%s] isn't number", r.RouteColumn, value)
+		}
+		for _, s := range r.Shards {
+			begin, end, err := s.rangeBoundary()
+			if err != nil {
+				return TableShard{}, false, err
+			}
+			if (begin == nil || key.Cmp(begin) >= 0) && (end == nil || key.Cmp(end) < 0) {
+				return s, true, nil
+			}
+		}
+		return TableShard{}, false, nil
+	default:
+		return TableShard{}, false, fmt.Errorf("route type [%s] isn't split route", r.RouteType)
+	}
+}
+
+func (s TableShard) rangeBoundary() (*big.Rat, *big.Rat, error) {
+	var begin, end *big.Rat
+	if s.RangeBegin != "" {
+		v, ok := new(big.Rat).SetString(s.RangeBegin)
+		if !ok {
+			return nil, nil, fmt.Errorf("range begin [%s] isn't number", s.RangeBegin)
+		}
+		begin = v
+	}
+	if s.RangeEnd != "" {
+		v, ok := new(big.Rat).SetString(s.RangeEnd)
+		if !ok {
+			return nil, nil, fmt.Errorf("range end [%s] isn't number", s.RangeEnd)
+		}
+		end = v
+	}
+	return begin, end, nil
+}
+
+func quoteRouteLiteral(value string) string {
+	return StringsBuilder(`'`, strings.ReplaceAll(value, `'`, `''`), `'`)
+}
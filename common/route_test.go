@@ -0,0 +1,256 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testHashRoute() *TableRoute {
+	return &TableRoute{
+		RouteType:   TableRouteTypeHash,
+		RouteColumn: "ID",
+		HashBuckets: 3,
+		Shards: []TableShard{
+			{SchemaNameT: "S", TableNameT: "T_0", HashBucket: 0},
+			{SchemaNameT: "S", TableNameT: "T_1", HashBucket: 1},
+			{SchemaNameT: "S", TableNameT: "T_2", HashBucket: 2},
+		},
+	}
+}
+
+func testRangeRoute() *TableRoute {
+	return &TableRoute{
+		RouteType:   TableRouteTypeRange,
+		RouteColumn: "ID",
+		Shards: []TableShard{
+			{SchemaNameT: "S", TableNameT: "T_2", RangeBegin: "1000"},
+			{SchemaNameT: "S", TableNameT: "T_0", RangeEnd: "100"},
+			{SchemaNameT: "S", TableNameT: "T_1", RangeBegin: "100", RangeEnd: "1000"},
+		},
+	}
+}
+
+func TestTableRouteValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   func() *TableRoute
+		wantErr bool
+	}{
+		{name: "hash", route: testHashRoute},
+		{name: "range", route: testRangeRoute},
+		{
+			name: "merge",
+			route: func() *TableRoute {
+				return &TableRoute{RouteType: TableRouteTypeMerge, SchemaNameT: "S", TableNameT: "T", DiscriminatorColumn: "SRC", DiscriminatorDatatype: "VARCHAR(30)", DiscriminatorValue: "T1"}
+			},
+		},
+		{
+			name: "merge without discriminator",
+			route: func() *TableRoute {
+				return &TableRoute{RouteType: TableRouteTypeMerge, SchemaNameT: "S", TableNameT: "T"}
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			route:   func() *TableRoute { return &TableRoute{RouteType: "LIST"} },
+			wantErr: true,
+		},
+		{
+			name: "hash bucket out of range",
+			route: func() *TableRoute {
+				r := testHashRoute()
+				r.Shards[2].HashBucket = 3
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "hash bucket missing",
+			route: func() *TableRoute {
+				r := testHashRoute()
+				r.Shards = r.Shards[:2]
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "hash bucket duplicate",
+			route: func() *TableRoute {
+				r := testHashRoute()
+				r.Shards[2].HashBucket = 1
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "shard target table duplicate",
+			route: func() *TableRoute {
+				r := testHashRoute()
+				r.Shards[2].TableNameT = "T_1"
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "range begin bounded",
+			route: func() *TableRoute {
+				r := testRangeRoute()
+				r.Shards[1].RangeBegin = "0"
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "range end bounded",
+			route: func() *TableRoute {
+				r := testRangeRoute()
+				r.Shards[0].RangeEnd = "10000"
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "range gap",
+			route: func() *TableRoute {
+				r := testRangeRoute()
+				r.Shards[2].RangeEnd = "900"
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "range overlap",
+			route: func() *TableRoute {
+				r := testRangeRoute()
+				r.Shards[2].RangeBegin = "50"
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "range begin not less than end",
+			route: func() *TableRoute {
+				r := testRangeRoute()
+				r.Shards[2].RangeBegin = "1000"
+				return r
+			},
+			wantErr: true,
+		},
+		{
+			name: "range not number",
+			route: func() *TableRoute {
+				r := testRangeRoute()
+				r.Shards[2].RangeEnd = "abc"
+				return r
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.route().Valid(); (err != nil) != tt.wantErr {
+				t.Errorf("Valid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTableRouteShardByValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     *TableRoute
+		value     string
+		wantTable string
+		wantOK    bool
+		wantErr   bool
+	}{
+		{name: "hash bucket 0", route: testHashRoute(), value: "3", wantTable: "T_0", wantOK: true},
+		{name: "hash bucket 1", route: testHashRoute(), value: "'4'", wantTable: "T_1", wantOK: true},
+		{name: "hash negative", route: testHashRoute(), value: "-5", wantTable: "T_2", wantOK: true},
+		{name: "hash large number", route: testHashRoute(), value: "123456789012345678901234567890", wantTable: "T_0", wantOK: true},
+		{name: "hash logminer literal", route: testHashRoute(), value: `"ID" = '7'`, wantTable: "T_1", wantOK: true},
+		{name: "hash null", route: testHashRoute(), value: "NULL", wantOK: false},
+		{name: "hash not integer", route: testHashRoute(), value: "1.5", wantErr: true},
+		{name: "range lower unbounded", route: testRangeRoute(), value: "-1", wantTable: "T_0", wantOK: true},
+		{name: "range begin inclusive", route: testRangeRoute(), value: "100", wantTable: "T_1", wantOK: true},
+		{name: "range end exclusive", route: testRangeRoute(), value: "999.99", wantTable: "T_1", wantOK: true},
+		{name: "range upper unbounded", route: testRangeRoute(), value: "1000", wantTable: "T_2", wantOK: true},
+		{name: "range not number", route: testRangeRoute(), value: "abc", wantErr: true},
+		{name: "merge", route: &TableRoute{RouteType: TableRouteTypeMerge}, value: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := tt.route.ShardByValue(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ShardByValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if ok != tt.wantOK || got.TableNameT != tt.wantTable {
+				t.Errorf("ShardByValue() = (%v, %v), want (%v, %v)", got.TableNameT, ok, tt.wantTable, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTableRouteShardWhereS(t *testing.T) {
+	hashRoute, rangeRoute := testHashRoute(), testRangeRoute()
+	tests := []struct {
+		name  string
+		route *TableRoute
+		shard TableShard
+		want  string
+	}{
+		{name: "hash", route: hashRoute, shard: hashRoute.Shards[1], want: `MOD(ABS("ID"), 3) = 1`},
+		{name: "range lower unbounded", route: rangeRoute, shard: rangeRoute.Shards[1], want: `"ID" < 100`},
+		{name: "range bounded", route: rangeRoute, shard: rangeRoute.Shards[2], want: `"ID" >= 100 AND "ID" < 1000`},
+		{name: "range upper unbounded", route: rangeRoute, shard: rangeRoute.Shards[0], want: `"ID" >= 1000`},
+		{name: "range unbounded", route: rangeRoute, shard: TableShard{}, want: `1 = 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.ShardWhereS(tt.shard); got != tt.want {
+				t.Errorf("ShardWhereS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableRouteTargetTableClean(t *testing.T) {
+	mergeRoute := &TableRoute{RouteType: TableRouteTypeMerge, SchemaNameT: "S", TableNameT: "T_ALL", DiscriminatorColumn: "SRC", DiscriminatorValue: "T'1"}
+	tests := []struct {
+		name  string
+		route *TableRoute
+		want  []string
+	}{
+		{name: "without route", route: nil, want: []string{"TRUNCATE TABLE `M`.`T1`"}},
+		{name: "merge", route: mergeRoute, want: []string{"DELETE FROM `S`.`T_ALL` WHERE `SRC` = 'T''1'"}},
+		{name: "hash", route: testHashRoute(), want: []string{"TRUNCATE TABLE `S`.`T_0`", "TRUNCATE TABLE `S`.`T_1`", "TRUNCATE TABLE `S`.`T_2`"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range tt.route.TargetTableClean("M", "T1") {
+				got = append(got, c.SQL())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TargetTableClean() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -471,6 +471,29 @@ func SpecialLettersUsingMySQL(bs []byte) string {
 	return b.String()
 }
 
+// SpecialLettersUsingMySQL 转义逆处理，用于字段数据转换前还原原始字段值
+func UnSpecialLettersUsingMySQL(s string) string {
+	var (
+		b       strings.Builder
+		escaped bool
+	)
+	for _, r := range s {
+		if !escaped && r == '\\' {
+			escaped = true
+			continue
+		}
+		if escaped && !(unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+			b.WriteRune('\\')
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	if escaped {
+		b.WriteRune('\\')
+	}
+	return b.String()
+}
+
 func BytesToString(b []byte) string {
 	return *(*string)(unsafe.Pointer(&b))
 }
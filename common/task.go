@@ -38,14 +38,58 @@ const (
 	TaskModeCSV     = "CSV"
 	TaskModeFull    = "FULL"
 	TaskModeAll     = "ALL"
+	TaskModePark    = "PARK"
+	TaskModeServer  = "SERVER"
+	TaskModeTask    = "TASK"
+	TaskModeRule    = "RULE"
+	TaskModeMeta    = "META"
+)
+
+// 任务操作，用于 park、task、rule 等运维模式
+const (
+	TaskActionList    = "LIST"
+	TaskActionReplay  = "REPLAY"
+	TaskActionDiscard = "DISCARD"
+	TaskActionPause   = "PAUSE"
+	TaskActionResume  = "RESUME"
+	TaskActionCancel  = "CANCEL"
+	TaskActionStatus  = "STATUS"
+	TaskActionRetry   = "RETRY"
+	TaskActionReset   = "RESET"
+	TaskActionExport  = "EXPORT"
+	TaskActionDiff    = "DIFF"
+	TaskActionImport  = "IMPORT"
+	TaskActionRemove  = "REMOVE"
+)
+
+// 默认任务名，未配置任务名以及历史版本元数据记录统一归属该任务
+const DefaultTaskName = "default"
+
+// 表过滤规则版本，1 历史规则格式（'.' 为任意单个字符），2 支持 schema.table、取反、正则表达式以及对象属性（默认）
+const (
+	TableFilterVersionLegacy  = 1
+	TableFilterVersionDefault = 2
+)
+
+// 多 schema 任务共享线程预算配置项
+const (
+	ThreadBudgetReverse   = "reverse.reverse-threads"
+	ThreadBudgetCheck     = "check.check-threads"
+	ThreadBudgetDiff      = "compare.diff-threads"
+	ThreadBudgetFullTable = "full.table-threads"
+	ThreadBudgetFullTask  = "full.task-threads"
+	ThreadBudgetCSVTable  = "csv.table-threads"
+	ThreadBudgetCSVTask   = "csv.task-threads"
 )
 
 // 任务状态
 const (
-	TaskStatusWaiting = "WAITING"
-	TaskStatusRunning = "RUNNING"
-	TaskStatusSuccess = "SUCCESS"
-	TaskStatusFailed  = "FAILED"
+	TaskStatusWaiting  = "WAITING"
+	TaskStatusRunning  = "RUNNING"
+	TaskStatusSuccess  = "SUCCESS"
+	TaskStatusFailed   = "FAILED"
+	TaskStatusPaused   = "PAUSED"
+	TaskStatusCanceled = "CANCELED"
 )
 
 // 任务初始值
@@ -63,6 +107,12 @@ const (
 	DatabaseTypeMySQL  = "MYSQL"
 )
 
+// 元数据库类型，sqlite 为内嵌元数据库，无需部署 MySQL
+const (
+	MetaDBTypeMySQL  = "MYSQL"
+	MetaDBTypeSQLite = "SQLITE"
+)
+
 // 任务类型
 const (
 	TaskTypeOracle2MySQL = "ORACLE2MYSQL"
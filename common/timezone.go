@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 时区，TIMESTAMP WITH (LOCAL) TIME ZONE 数据统一转换为目标端时区
+const (
+	TimeZoneUTC = "+00:00"
+	// Logminer 会话 TIMESTAMP WITH TIME ZONE 输出格式，增量同步据此解析
+	OracleNLSTimestampTZFormat = "YYYY-MM-DD HH24:MI:SS.FF TZH:TZM"
+)
+
+var (
+	timeZoneOffsetRegexp   = regexp.MustCompile(`^[+-](0[0-9]|1[0-4]):[0-5][0-9]$`)
+	oracleTimestampTZRegex = regexp.MustCompile(`(?i)TO_TIMESTAMP_TZ\('([^']+)'\)`)
+)
+
+// 时区仅支持 UTC 或者 [+-]HH:MM 偏移量，保证上下游时区语义一致
+func AdjustTimeZoneOffset(timeZone string) (string, error) {
+	timeZone = strings.TrimSpace(timeZone)
+	if timeZone == "" {
+		return timeZone, nil
+	}
+	if strings.EqualFold(timeZone, "UTC") {
+		return TimeZoneUTC, nil
+	}
+	if !timeZoneOffsetRegexp.MatchString(timeZone) {
+		return timeZone, fmt.Errorf("time zone [%s] isn't support, only support [UTC] or offset [+-HH:MM], for example [+08:00]", timeZone)
+	}
+	return timeZone, nil
+}
+
+// [+-]HH:MM 偏移量转换为固定时区
+func TimeZoneLocation(timeZone string) (*time.Location, error) {
+	if !timeZoneOffsetRegexp.MatchString(timeZone) {
+		return nil, fmt.Errorf("time zone [%s] isn't offset [+-HH:MM]", timeZone)
+	}
+	hour, _ := strconv.Atoi(timeZone[1:3])
+	minute, _ := strconv.Atoi(timeZone[4:6])
+	offset := hour*3600 + minute*60
+	if timeZone[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(timeZone, offset), nil
+}
+
+// ORACLE 带时区时间类型字段转换到目标端时区，其他类型字段原样返回
+func OracleTimeZoneColumn(columnName, dataType, timeZone string) string {
+	if timeZone == "" || !strings.Contains(strings.ToUpper(dataType), "TIME ZONE") {
+		return columnName
+	}
+	return StringsBuilder(columnName, ` AT TIME ZONE '`, timeZone, `'`)
+}
+
+// Logminer SQL 中 TO_TIMESTAMP_TZ 函数转换为目标端时区时间字符串
+// 比如：TO_TIMESTAMP_TZ('2023-01-01 08:00:00.000000 +08:00') -> '2023-01-01 00:00:00.000000'（目标端时区 +00:00）
+func ReplaceOracleTimestampTZ(s string, timeZone string) (string, error) {
+	if timeZone == "" || !strings.Contains(strings.ToUpper(s), "TO_TIMESTAMP_TZ") {
+		return s, nil
+	}
+	loc, err := TimeZoneLocation(timeZone)
+	if err != nil {
+		return s, err
+	}
+	var replaceErr error
+	res := oracleTimestampTZRegex.ReplaceAllStringFunc(s, func(m string) string {
+		lit := oracleTimestampTZRegex.FindStringSubmatch(m)[1]
+		t, err := time.Parse("2006-01-02 15:04:05.999999999 -07:00", lit)
+		if err != nil {
+			replaceErr = fmt.Errorf("parse oracle timestamp with time zone [%s] failed, please check oracle session nls_timestamp_tz_format [%s]: %v", lit, OracleNLSTimestampTZFormat, err)
+			return m
+		}
+		return StringsBuilder(`'`, t.In(loc).Format("2006-01-02 15:04:05.999999"), `'`)
+	})
+	if replaceErr != nil {
+		return s, replaceErr
+	}
+	return res, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import "testing"
+
+func TestAdjustTimeZoneOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		want     string
+		wantErr  bool
+	}{
+		{name: "empty", timeZone: "", want: ""},
+		{name: "utc", timeZone: "utc", want: TimeZoneUTC},
+		{name: "utc space", timeZone: " UTC ", want: TimeZoneUTC},
+		{name: "positive offset", timeZone: "+08:00", want: "+08:00"},
+		{name: "negative offset", timeZone: "-05:30", want: "-05:30"},
+		{name: "max offset", timeZone: "+14:00", want: "+14:00"},
+		{name: "out of range hour", timeZone: "+15:00", wantErr: true},
+		{name: "out of range minute", timeZone: "+08:60", wantErr: true},
+		{name: "missing sign", timeZone: "08:00", wantErr: true},
+		{name: "region name", timeZone: "Asia/Shanghai", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AdjustTimeZoneOffset(tt.timeZone)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AdjustTimeZoneOffset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("AdjustTimeZoneOffset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceOracleTimestampTZ(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		timeZone string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "empty time zone",
+			s:        `insert into "T"("C") values (TO_TIMESTAMP_TZ('2023-01-01 08:00:00.000000 +08:00'))`,
+			timeZone: "",
+			want:     `insert into "T"("C") values (TO_TIMESTAMP_TZ('2023-01-01 08:00:00.000000 +08:00'))`,
+		},
+		{
+			name:     "without timestamp tz",
+			s:        `insert into "T"("C") values (TO_TIMESTAMP('2023-01-01 08:00:00.000000'))`,
+			timeZone: "+00:00",
+			want:     `insert into "T"("C") values (TO_TIMESTAMP('2023-01-01 08:00:00.000000'))`,
+		},
+		{
+			name:     "convert to utc",
+			s:        `insert into "T"("C") values (TO_TIMESTAMP_TZ('2023-01-01 08:00:00.000000 +08:00'))`,
+			timeZone: "+00:00",
+			want:     `insert into "T"("C") values ('2023-01-01 00:00:00')`,
+		},
+		{
+			name:     "keep fraction",
+			s:        `update "T" set "C" = TO_TIMESTAMP_TZ('2023-01-01 00:30:00.123456 -01:00') where "ID" = '1'`,
+			timeZone: "+08:00",
+			want:     `update "T" set "C" = '2023-01-01 09:30:00.123456' where "ID" = '1'`,
+		},
+		{
+			name:     "multiple and lower case",
+			s:        `values (to_timestamp_tz('2023-01-01 08:00:00 +08:00'),TO_TIMESTAMP_TZ('2023-01-01 09:00:00 +09:00'))`,
+			timeZone: "+00:00",
+			want:     `values ('2023-01-01 00:00:00','2023-01-01 00:00:00')`,
+		},
+		{
+			name:     "invalid literal",
+			s:        `values (TO_TIMESTAMP_TZ('01-JAN-23 08.00.00.000000 AM +08:00'))`,
+			timeZone: "+00:00",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid time zone",
+			s:        `values (TO_TIMESTAMP_TZ('2023-01-01 08:00:00 +08:00'))`,
+			timeZone: "UTC",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReplaceOracleTimestampTZ(tt.s, tt.timeZone)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReplaceOracleTimestampTZ() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ReplaceOracleTimestampTZ() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// 字段数据转换（脱敏）规则，full/csv/all 模式数据统一转换，NULL 值保持 NULL 不转换
+// hash 以 transform-param 为盐值 SHA256 十六进制输出
+// mask 部分遮盖，transform-param 格式 [保留前缀字符数,保留后缀字符数,遮盖字符]，比如 3,4,*，默认 1,1,*
+// constant 固定值，transform-param 为常量值
+// nullify 置为 NULL
+// random 保留格式随机化，数字/大写字母/小写字母按类别随机替换，其他字符保持不变，transform-param 为随机密钥，相同输入相同输出
+// expr 简单表达式，transform-param 为 text/template 模板，{{.}} 代表字段值，支持函数 upper/lower/trim/substr/replace/concat/hash
+const (
+	TransformTypeHash     = "HASH"
+	TransformTypeMask     = "MASK"
+	TransformTypeConstant = "CONSTANT"
+	TransformTypeNullify  = "NULLIFY"
+	TransformTypeRandom   = "RANDOM"
+	TransformTypeExpr     = "EXPR"
+)
+
+var TransformTypes = []string{
+	TransformTypeHash,
+	TransformTypeMask,
+	TransformTypeConstant,
+	TransformTypeNullify,
+	TransformTypeRandom,
+	TransformTypeExpr,
+}
+
+type ColumnTransform struct {
+	ColumnName     string
+	TransformType  string
+	TransformParam string
+
+	maskPrefix int
+	maskSuffix int
+	maskChar   string
+	exprTmpl   *template.Template
+}
+
+func NewColumnTransform(columnName, transformType, transformParam string) (*ColumnTransform, error) {
+	c := &ColumnTransform{
+		ColumnName:     StringUPPER(columnName),
+		TransformType:  StringUPPER(transformType),
+		TransformParam: transformParam,
+	}
+	switch c.TransformType {
+	case TransformTypeHash, TransformTypeConstant, TransformTypeNullify, TransformTypeRandom:
+	case TransformTypeMask:
+		c.maskPrefix, c.maskSuffix, c.maskChar = 1, 1, "*"
+		if transformParam != "" {
+			params := strings.Split(transformParam, ",")
+			if len(params) < 2 || len(params) > 3 {
+				return nil, fmt.Errorf("column [%s] transform type [%s] param [%s] isn't support, format [prefix,suffix,char]", columnName, transformType, transformParam)
+			}
+			prefix, err := strconv.Atoi(strings.TrimSpace(params[0]))
+			if err != nil || prefix < 0 {
+				return nil, fmt.Errorf("column [%s] transform type [%s] param [%s] prefix isn't non-negative integer", columnName, transformType, transformParam)
+			}
+			suffix, err := strconv.Atoi(strings.TrimSpace(params[1]))
+			if err != nil || suffix < 0 {
+				return nil, fmt.Errorf("column [%s] transform type [%s] param [%s] suffix isn't non-negative integer", columnName, transformType, transformParam)
+			}
+			c.maskPrefix, c.maskSuffix = prefix, suffix
+			if len(params) == 3 && params[2] != "" {
+				c.maskChar = params[2]
+			}
+		}
+	case TransformTypeExpr:
+		tmpl, err := template.New(c.ColumnName).Funcs(template.FuncMap{
+			"upper":   strings.ToUpper,
+			"lower":   strings.ToLower,
+			"trim":    strings.TrimSpace,
+			"replace": strings.ReplaceAll,
+			"concat":  func(s ...string) string { return strings.Join(s, "") },
+			"substr":  substrRunes,
+			"hash":    func(s string) string { return hashString("", s) },
+		}).Option("missingkey=error").Parse(transformParam)
+		if err != nil {
+			return nil, fmt.Errorf("column [%s] transform type [%s] param [%s] parse failed: %v", columnName, transformType, transformParam, err)
+		}
+		c.exprTmpl = tmpl
+	default:
+		return nil, fmt.Errorf("column [%s] transform type [%s] isn't support, support type [%v]", columnName, transformType, TransformTypes)
+	}
+	return c, nil
+}
+
+// 字段值转换，返回转换后字段值以及是否 NULL
+func (c *ColumnTransform) Transform(value string, isNull bool) (string, bool, error) {
+	if isNull {
+		return value, true, nil
+	}
+	switch c.TransformType {
+	case TransformTypeHash:
+		return hashString(c.TransformParam, value), false, nil
+	case TransformTypeMask:
+		runes := []rune(value)
+		if c.maskPrefix+c.maskSuffix >= len(runes) {
+			return strings.Repeat(c.maskChar, len(runes)), false, nil
+		}
+		return StringsBuilder(string(runes[:c.maskPrefix]),
+			strings.Repeat(c.maskChar, len(runes)-c.maskPrefix-c.maskSuffix),
+			string(runes[len(runes)-c.maskSuffix:])), false, nil
+	case TransformTypeConstant:
+		return c.TransformParam, false, nil
+	case TransformTypeNullify:
+		return "", true, nil
+	case TransformTypeRandom:
+		return randomPreserveFormat(c.TransformParam, value), false, nil
+	case TransformTypeExpr:
+		var sb strings.Builder
+		if err := c.exprTmpl.Execute(&sb, value); err != nil {
+			return value, isNull, fmt.Errorf("column [%s] transform expr [%s] execute failed: %v", c.ColumnName, c.TransformParam, err)
+		}
+		return sb.String(), false, nil
+	default:
+		return value, isNull, fmt.Errorf("column [%s] transform type [%s] isn't support", c.ColumnName, c.TransformType)
+	}
+}
+
+func hashString(salt, value string) string {
+	h := sha256.Sum256([]byte(StringsBuilder(salt, value)))
+	return hex.EncodeToString(h[:])
+}
+
+func substrRunes(s string, start, length int) string {
+	runes := []rune(s)
+	if start < 0 || start >= len(runes) || length <= 0 {
+		return ""
+	}
+	if start+length > len(runes) {
+		length = len(runes) - start
+	}
+	return string(runes[start : start+length])
+}
+
+// 基于 HMAC-SHA256 确定性随机，保证全量、增量以及重复运行结果一致
+func randomPreserveFormat(key, value string) string {
+	var (
+		sb      strings.Builder
+		stream  []byte
+		counter uint64
+	)
+	mac := hmac.New(sha256.New, []byte(key))
+	next := func() byte {
+		if len(stream) == 0 {
+			mac.Reset()
+			mac.Write([]byte(value))
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], counter)
+			mac.Write(c[:])
+			stream = mac.Sum(nil)
+			counter++
+		}
+		b := stream[0]
+		stream = stream[1:]
+		return b
+	}
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			sb.WriteRune('0' + rune(next()%10))
+		case r >= 'A' && r <= 'Z':
+			sb.WriteRune('A' + rune(next()%26))
+		case r >= 'a' && r <= 'z':
+			sb.WriteRune('a' + rune(next()%26))
+		case unicode.IsLetter(r):
+			// 非 ASCII 字母统一替换为小写字母，保持字符数不变
+			sb.WriteRune('a' + rune(next()%26))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// 全量数据字段值转换，字段值 NULL 以字符 NULL 表示且字符数据已 SpecialLettersUsingMySQL 转义
+func TransformMySQLEscapedValue(ct *ColumnTransform, value string) (string, error) {
+	res, isNull, err := ct.Transform(UnSpecialLettersUsingMySQL(value), value == `NULL`)
+	if err != nil {
+		return value, err
+	}
+	if isNull {
+		return `NULL`, nil
+	}
+	return SpecialLettersUsingMySQL([]byte(res)), nil
+}
+
+// CSV 字段值转换，字段值 NULL 以 null-value 表示，字符数据可能带有定界符以及转义
+func TransformCSVValue(ct *ColumnTransform, value, nullValue, delimiter string, escapeBackslash bool) (string, error) {
+	if nullValue == "" {
+		nullValue = `NULL`
+	}
+	var (
+		raw       = value
+		delimited bool
+	)
+	if delimiter != "" && len(raw) >= 2*len(delimiter) && strings.HasPrefix(raw, delimiter) && strings.HasSuffix(raw, delimiter) {
+		raw = raw[len(delimiter) : len(raw)-len(delimiter)]
+		delimited = true
+	}
+	if escapeBackslash {
+		raw = UnSpecialLettersUsingMySQL(raw)
+	}
+	res, isNull, err := ct.Transform(raw, value == nullValue)
+	if err != nil {
+		return value, err
+	}
+	if isNull {
+		return nullValue, nil
+	}
+	if escapeBackslash {
+		res = SpecialLettersUsingMySQL([]byte(res))
+	}
+	if delimited {
+		res = StringsBuilder(delimiter, res, delimiter)
+	}
+	return res, nil
+}
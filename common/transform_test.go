@@ -0,0 +1,202 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+import (
+	"fmt"
+	"testing"
+)
+
+func mustColumnTransform(t *testing.T, transformType, transformParam string) *ColumnTransform {
+	t.Helper()
+	ct, err := NewColumnTransform("c1", transformType, transformParam)
+	if err != nil {
+		t.Fatalf("NewColumnTransform(%s, %q) error = %v", transformType, transformParam, err)
+	}
+	return ct
+}
+
+func TestNewColumnTransform(t *testing.T) {
+	valid := [][2]string{
+		{"hash", "salt"},
+		{"MASK", ""},
+		{"MASK", "3,4,#"},
+		{"CONSTANT", "N/A"},
+		{"NULLIFY", ""},
+		{"RANDOM", "key"},
+		{"EXPR", `{{upper .}}`},
+	}
+	for _, v := range valid {
+		if _, err := NewColumnTransform("c1", v[0], v[1]); err != nil {
+			t.Errorf("NewColumnTransform(%s, %q) error = %v", v[0], v[1], err)
+		}
+	}
+
+	invalid := [][2]string{
+		{"MASK", "3"},
+		{"MASK", "-1,2"},
+		{"MASK", "1,x"},
+		{"MASK", "1,2,*,*"},
+		{"EXPR", `{{upper .`},
+		{"ENCRYPT", ""},
+	}
+	for _, v := range invalid {
+		if _, err := NewColumnTransform("c1", v[0], v[1]); err == nil {
+			t.Errorf("NewColumnTransform(%s, %q) should fail", v[0], v[1])
+		}
+	}
+}
+
+func TestColumnTransformTransform(t *testing.T) {
+	tests := []struct {
+		name           string
+		transformType  string
+		transformParam string
+		value          string
+		isNull         bool
+		want           string
+		wantNull       bool
+	}{
+		{name: "null keep", transformType: TransformTypeConstant, transformParam: "x", value: "", isNull: true, want: "", wantNull: true},
+		{name: "hash", transformType: TransformTypeHash, value: "abc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{name: "hash salt", transformType: TransformTypeHash, transformParam: "a", value: "bc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{name: "mask default", transformType: TransformTypeMask, value: "13800138000", want: "1*********0"},
+		{name: "mask param", transformType: TransformTypeMask, transformParam: "3,4,#", value: "13800138000", want: "138####8000"},
+		{name: "mask short value", transformType: TransformTypeMask, transformParam: "3,4", value: "abc", want: "***"},
+		{name: "mask multibyte", transformType: TransformTypeMask, value: "张三丰", want: "张*丰"},
+		{name: "constant", transformType: TransformTypeConstant, transformParam: "N/A", value: "abc", want: "N/A"},
+		{name: "nullify", transformType: TransformTypeNullify, value: "abc", want: "", wantNull: true},
+		{name: "expr upper", transformType: TransformTypeExpr, transformParam: `{{upper .}}`, value: "abc", want: "ABC"},
+		{name: "expr substr concat", transformType: TransformTypeExpr, transformParam: `{{concat (substr . 0 3) "-x"}}`, value: "abcdef", want: "abc-x"},
+		{name: "expr substr out of range", transformType: TransformTypeExpr, transformParam: `{{substr . 10 3}}`, value: "abc", want: ""},
+		{name: "expr replace trim", transformType: TransformTypeExpr, transformParam: `{{replace (trim .) "-" ""}}`, value: " 2023-01-01 ", want: "20230101"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := mustColumnTransform(t, tt.transformType, tt.transformParam)
+			got, gotNull, err := ct.Transform(tt.value, tt.isNull)
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			if got != tt.want || gotNull != tt.wantNull {
+				t.Errorf("Transform() = (%v, %v), want (%v, %v)", got, gotNull, tt.want, tt.wantNull)
+			}
+		})
+	}
+}
+
+// 随机替换需保持确定性、长度以及字符类别
+func TestColumnTransformRandom(t *testing.T) {
+	ct := mustColumnTransform(t, TransformTypeRandom, "k1")
+	for _, value := range []string{
+		"13800138000",
+		"Ab-12 cD",
+		"张三-01",
+		"abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	} {
+		got, _, err := ct.Transform(value, false)
+		if err != nil {
+			t.Fatalf("Transform(%q) error = %v", value, err)
+		}
+		if again, _, _ := ct.Transform(value, false); got != again {
+			t.Errorf("Transform(%q) isn't deterministic, got %v and %v", value, got, again)
+		}
+		gotRunes, valueRunes := []rune(got), []rune(value)
+		if len(gotRunes) != len(valueRunes) {
+			t.Errorf("Transform(%q) = %v, rune length %d, want %d", value, got, len(gotRunes), len(valueRunes))
+			continue
+		}
+		for i, r := range valueRunes {
+			g := gotRunes[i]
+			var ok bool
+			switch {
+			case r >= '0' && r <= '9':
+				ok = g >= '0' && g <= '9'
+			case r >= 'A' && r <= 'Z':
+				ok = g >= 'A' && g <= 'Z'
+			case r >= 'a' && r <= 'z', r > 127:
+				ok = g >= 'a' && g <= 'z'
+			default:
+				ok = g == r
+			}
+			if !ok {
+				t.Errorf("Transform(%q) = %v, position %d %q doesn't keep class of %q", value, got, i, g, r)
+			}
+		}
+	}
+
+	other := mustColumnTransform(t, TransformTypeRandom, "k2")
+	a, _, _ := ct.Transform("13800138000", false)
+	b, _, _ := other.Transform("13800138000", false)
+	if a == b {
+		t.Errorf("Transform() with different keys should differ, both got %v", a)
+	}
+}
+
+func TestTransformMySQLEscapedValue(t *testing.T) {
+	mask := mustColumnTransform(t, TransformTypeMask, "")
+
+	if got, _ := TransformMySQLEscapedValue(mask, `NULL`); got != `NULL` {
+		t.Errorf("null value = %v, want NULL", got)
+	}
+	// 先反转义再掩码，结果重新转义
+	if got, _ := TransformMySQLEscapedValue(mask, `a\'bc`); got != `a\*\*c` {
+		t.Errorf("escaped quote value = %v, want %v", got, `a\*\*c`)
+	}
+	if got, _ := TransformMySQLEscapedValue(mask, `abc`); got != `a\*c` {
+		t.Errorf("escaped result = %v, want %v", got, `a\*c`)
+	}
+	if got, _ := TransformMySQLEscapedValue(mustColumnTransform(t, TransformTypeNullify, ""), `abc`); got != `NULL` {
+		t.Errorf("nullify value = %v, want NULL", got)
+	}
+}
+
+func ExampleTransformCSVValue() {
+	mask, _ := NewColumnTransform("c1", TransformTypeMask, "")
+	constant, _ := NewColumnTransform("c1", TransformTypeConstant, `it's`)
+	nullify, _ := NewColumnTransform("c1", TransformTypeNullify, "")
+
+	for _, v := range []struct {
+		ct              *ColumnTransform
+		value           string
+		nullValue       string
+		delimiter       string
+		escapeBackslash bool
+	}{
+		{ct: mask, value: `NULL`},
+		{ct: mask, value: `\N`, nullValue: `\N`},
+		{ct: nullify, value: `"abc"`, nullValue: `\N`, delimiter: `"`},
+		{ct: mask, value: `"abcd"`, delimiter: `"`},
+		{ct: mask, value: `abcd`, delimiter: `"`},
+		{ct: constant, value: `"abc"`, delimiter: `"`, escapeBackslash: true},
+		{ct: constant, value: `"abc"`, delimiter: `"`},
+	} {
+		got, err := TransformCSVValue(v.ct, v.value, v.nullValue, v.delimiter, v.escapeBackslash)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(got)
+	}
+	// Output:
+	// NULL
+	// \N
+	// \N
+	// "a**d"
+	// a**d
+	// "it\'s"
+	// "it's"
+}
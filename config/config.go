@@ -30,26 +30,45 @@ type Config struct {
 	AppConfig     AppConfig     `toml:"app" json:"app"`
 	ReverseConfig ReverseConfig `toml:"reverse" json:"reverse"`
 	CheckConfig   CheckConfig   `toml:"check" json:"check"`
+	AssessConfig  AssessConfig  `toml:"assess" json:"assess"`
 	FullConfig    FullConfig    `toml:"full" json:"full"`
 	CSVConfig     CSVConfig     `toml:"csv" json:"csv"`
 	AllConfig     AllConfig     `toml:"all" json:"all"`
 	SchemaConfig  SchemaConfig  `toml:"schema-config" json:"schema-config"`
-	OracleConfig  OracleConfig  `toml:"oracle" json:"oracle"`
-	MySQLConfig   MySQLConfig   `toml:"mysql" json:"mysql"`
-	MetaConfig    MetaConfig    `toml:"meta" json:"meta"`
-	LogConfig     LogConfig     `toml:"log" json:"log"`
-	DiffConfig    DiffConfig    `toml:"compare" json:"compare"`
-	ConfigFile    string        `json:"config-file"`
-	PrintVersion  bool
-	TaskMode      string `json:"task-mode"`
-	DBTypeS       string `json:"db-type-s"`
-	DBTypeT       string `json:"db-type-t"`
+	// 多 schema 任务，配置后以其为准，忽略 schema-config
+	SchemaRouteConfig []SchemaConfig `toml:"schema-route-config" json:"schema-route-config"`
+	OracleConfig      OracleConfig   `toml:"oracle" json:"oracle"`
+	MySQLConfig       MySQLConfig    `toml:"mysql" json:"mysql"`
+	MetaConfig        MetaConfig     `toml:"meta" json:"meta"`
+	LogConfig         LogConfig      `toml:"log" json:"log"`
+	DiffConfig        DiffConfig     `toml:"compare" json:"compare"`
+	ReportConfig      ReportConfig   `toml:"report" json:"report"`
+	ConfigFile        string         `json:"config-file"`
+	PrintVersion      bool
+	TaskMode          string `json:"task-mode"`
+	DBTypeS           string `json:"db-type-s"`
+	DBTypeT           string `json:"db-type-t"`
+	Action            string `json:"action"`
+	TableName         string `json:"table-name"`
+	ActionMode        string `json:"action-mode"`
+	RuleFile          string `json:"rule-file"`
+	MetaFile          string `json:"meta-file"`
+	TaskName          string `json:"task-name"`
+	Confirm           bool   `json:"confirm"`
+	AssessID          string `json:"assess-id"`
+	BaseAssessID      string `json:"base-assess-id"`
+	// 多 schema 任务共享线程预算，运行时设置
+	ThreadBudget *ThreadBudget `toml:"-" json:"-"`
 }
 
 type AppConfig struct {
-	InsertBatchSize  int    `toml:"insert-batch-size" json:"insert-batch-size"`
-	SlowlogThreshold int    `toml:"slowlog-threshold" json:"slowlog-threshold"`
-	PprofPort        string `toml:"pprof-port" json:"pprof-port"`
+	InsertBatchSize    int    `toml:"insert-batch-size" json:"insert-batch-size"`
+	SlowlogThreshold   int    `toml:"slowlog-threshold" json:"slowlog-threshold"`
+	PprofPort          string `toml:"pprof-port" json:"pprof-port"`
+	ServerAddr         string `toml:"server-addr" json:"server-addr"`
+	TaskName           string `toml:"task-name" json:"task-name"`
+	SchemaThreads      int    `toml:"schema-threads" json:"schema-threads"`
+	TableFilterVersion int    `toml:"table-filter-version" json:"table-filter-version"`
 }
 
 type DiffConfig struct {
@@ -59,6 +78,23 @@ type DiffConfig struct {
 	EnableCheckpoint  bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
 	IgnoreStructCheck bool   `toml:"ignore-struct-check" json:"ignore-struct-check"`
 	FixSqlDir         string `toml:"fix-sql-dir" json:"fix-sql-dir"`
+	TimePrecisionRule string `toml:"time-precision-rule" json:"time-precision-rule"`
+	// 一致性校验，需与运行中 all 模式任务使用相同 task-name
+	Consistent        bool `toml:"consistent" json:"consistent"`
+	ConsistentTimeout int  `toml:"consistent-timeout" json:"consistent-timeout"`
+	// 一致性校验每次申请快照校验 chunk 数，MySQL 目标端增量应用按批次暂停
+	ConsistentBatch int `toml:"consistent-batch" json:"consistent-batch"`
+	// 抽样校验每次运行 chunk 比例，0 或者 100 全量校验
+	SamplePercent int `toml:"sample-percent" json:"sample-percent"`
+}
+
+// check、compare、assess 模式机器可读结果报告，用于 CI 流水线
+type ReportConfig struct {
+	// 报告格式 json / junit，为空不输出
+	Format    string `toml:"format" json:"format"`
+	ReportDir string `toml:"report-dir" json:"report-dir"`
+	// 门禁退出码策略 none / failed / mismatch
+	FailOn string `toml:"fail-on" json:"fail-on"`
 }
 
 type ReverseConfig struct {
@@ -72,6 +108,23 @@ type ReverseConfig struct {
 type CheckConfig struct {
 	CheckThreads int    `toml:"check-threads" json:"check-threads"`
 	CheckSQLDir  string `toml:"check-sql-dir" json:"check-sql-dir"`
+	// 直接在目标端执行修复脚本，需配合命令行 -confirm 确认，否则只输出修复脚本 (dry-run)
+	FixApply bool `toml:"fix-apply" json:"fix-apply"`
+	// 执行修复脚本时是否包含不安全语句 (字段类型收窄、删除字段等)
+	FixUnsafe bool `toml:"fix-unsafe" json:"fix-unsafe"`
+}
+
+// 迁移评估不兼容对象改造工作量默认权重（单个对象人天），[buildin_object_compatible] effort_weight 非 0 以元数据表为准
+type AssessConfig struct {
+	ConvertibleEffort   float64 `toml:"convertible-effort" json:"convertible-effort"`
+	InConvertibleEffort float64 `toml:"inconvertible-effort" json:"inconvertible-effort"`
+	// 采样压测，估算全量迁移耗时以及目标端存储
+	Benchmark        bool    `toml:"benchmark" json:"benchmark"`
+	BenchmarkTables  int     `toml:"benchmark-tables" json:"benchmark-tables"`
+	BenchmarkRows    int     `toml:"benchmark-rows" json:"benchmark-rows"`
+	BenchmarkSchema  string  `toml:"benchmark-schema" json:"benchmark-schema"`
+	ReplicaFactor    int     `toml:"replica-factor" json:"replica-factor"`
+	CompressionRatio float64 `toml:"compression-ratio" json:"compression-ratio"`
 }
 
 type CSVConfig struct {
@@ -91,6 +144,7 @@ type CSVConfig struct {
 	ConsistentRead   bool   `toml:"consistent-read" json:"consistent-read"`
 	SQLHint          string `toml:"sql-hint" json:"sql-hint"`
 	CallTimeout      int    `toml:"call-timeout" json:"call-timeout"`
+	ChunkMethod      string `toml:"chunk-method" json:"chunk-method"`
 }
 
 type FullConfig struct {
@@ -103,14 +157,21 @@ type FullConfig struct {
 	ConsistentRead   bool   `toml:"consistent-read" json:"consistent-read"`
 	SQLHint          string `toml:"sql-hint" json:"sql-hint"`
 	CallTimeout      int    `toml:"call-timeout" json:"call-timeout"`
+	ChunkMethod      string `toml:"chunk-method" json:"chunk-method"`
+	DeferIndex       bool   `toml:"defer-index" json:"defer-index"`
+	TiDBFastReorg    bool   `toml:"tidb-fast-reorg" json:"tidb-fast-reorg"`
 }
 
 type AllConfig struct {
-	LogminerQueryTimeout int `toml:"logminer-query-timeout" json:"logminer-query-timeout"`
-	FilterThreads        int `toml:"filter-threads" json:"filter-threads"`
-	ApplyThreads         int `toml:"apply-threads" json:"apply-threads"`
-	WorkerQueue          int `toml:"worker-queue" json:"worker-queue"`
-	WorkerThreads        int `toml:"worker-threads" json:"worker-threads"`
+	LogminerQueryTimeout int    `toml:"logminer-query-timeout" json:"logminer-query-timeout"`
+	FilterThreads        int    `toml:"filter-threads" json:"filter-threads"`
+	ApplyThreads         int    `toml:"apply-threads" json:"apply-threads"`
+	WorkerQueue          int    `toml:"worker-queue" json:"worker-queue"`
+	WorkerThreads        int    `toml:"worker-threads" json:"worker-threads"`
+	ErrorPolicy          string `toml:"error-policy" json:"error-policy"`
+	EnableHeartbeat      bool   `toml:"enable-heartbeat" json:"enable-heartbeat"`
+	HeartbeatTable       string `toml:"heartbeat-table" json:"heartbeat-table"`
+	HeartbeatInterval    int    `toml:"heartbeat-interval" json:"heartbeat-interval"`
 }
 
 type SchemaConfig struct {
@@ -121,14 +182,22 @@ type SchemaConfig struct {
 	GlobalTableOption        string                     `toml:"global-table-option" json:"global-table-option"`
 	CompareConfig            []CompareConfig            `toml:"compare-config" json:"compare-config"`
 	MigrateConfig            []MigrateConfig            `toml:"migrate-config" json:"migrate-config"`
+	IncrConfig               []IncrConfig               `toml:"incr-config" json:"incr-config"`
+	TransformConfig          []TransformConfig          `toml:"transform-config" json:"transform-config"`
+	ColumnNameConfig         []ColumnNameConfig         `toml:"column-name-config" json:"column-name-config"`
+	RouteConfig              []RouteConfig              `toml:"route-config" json:"route-config"`
 	StructNonClusteredConfig []StructNonClusteredConfig `toml:"struct-nonclustered-config" json:"struct-nonclustered-config"`
 	StructClusteredConfig    StructClusteredConfig      `toml:"struct-clustered-config" json:"struct-clustered-config"`
 }
 
 type CompareConfig struct {
-	SourceTable string `toml:"source-table" json:"source-table"`
-	IndexFields string `toml:"index-fields" json:"index-fields"`
-	Range       string `toml:"range" json:"range"`
+	SourceTable       string `toml:"source-table" json:"source-table"`
+	IndexFields       string `toml:"index-fields" json:"index-fields"`
+	Range             string `toml:"range" json:"range"`
+	TimePrecisionRule string `toml:"time-precision-rule" json:"time-precision-rule"`
+	// 一致性校验，需与运行中 all 模式任务使用相同 task-name
+	Consistent        bool `toml:"consistent" json:"consistent"`
+	ConsistentTimeout int  `toml:"consistent-timeout" json:"consistent-timeout"`
 }
 
 type MigrateConfig struct {
@@ -138,6 +207,78 @@ type MigrateConfig struct {
 	SQLHint     string `toml:"sql-hint" json:"sql-hint"`
 }
 
+type TransformConfig struct {
+	SourceTable    string `toml:"source-table" json:"source-table"`
+	ColumnName     string `toml:"column-name" json:"column-name"`
+	TransformType  string `toml:"transform-type" json:"transform-type"`
+	TransformParam string `toml:"transform-param" json:"transform-param"`
+}
+
+type ColumnNameConfig struct {
+	SourceTable    string `toml:"source-table" json:"source-table"`
+	SourceColumn   string `toml:"source-column" json:"source-column"`
+	TargetColumn   string `toml:"target-column" json:"target-column"`
+	TargetDatatype string `toml:"target-datatype" json:"target-datatype"`
+	TargetDefault  string `toml:"target-default" json:"target-default"`
+}
+
+type RouteConfig struct {
+	SourceTable           string             `toml:"source-table" json:"source-table"`
+	RouteType             string             `toml:"route-type" json:"route-type"`
+	TargetSchema          string             `toml:"target-schema" json:"target-schema"`
+	TargetTable           string             `toml:"target-table" json:"target-table"`
+	DiscriminatorColumn   string             `toml:"discriminator-column" json:"discriminator-column"`
+	DiscriminatorValue    string             `toml:"discriminator-value" json:"discriminator-value"`
+	DiscriminatorDatatype string             `toml:"discriminator-datatype" json:"discriminator-datatype"`
+	RouteColumn           string             `toml:"route-column" json:"route-column"`
+	HashBuckets           int                `toml:"hash-buckets" json:"hash-buckets"`
+	Shards                []RouteShardConfig `toml:"shards" json:"shards"`
+}
+
+type RouteShardConfig struct {
+	TargetSchema string `toml:"target-schema" json:"target-schema"`
+	TargetTable  string `toml:"target-table" json:"target-table"`
+	HashBucket   int    `toml:"hash-bucket" json:"hash-bucket"`
+	RangeBegin   string `toml:"range-begin" json:"range-begin"`
+	RangeEnd     string `toml:"range-end" json:"range-end"`
+}
+
+// 表路由规则，目标端 schema 为空默认 schema-config target-schema
+func (r RouteConfig) TableRoute(targetSchema string) *common.TableRoute {
+	route := &common.TableRoute{
+		RouteType:             common.StringUPPER(r.RouteType),
+		SchemaNameT:           common.StringUPPER(r.TargetSchema),
+		TableNameT:            common.StringUPPER(r.TargetTable),
+		DiscriminatorColumn:   r.DiscriminatorColumn,
+		DiscriminatorValue:    r.DiscriminatorValue,
+		DiscriminatorDatatype: r.DiscriminatorDatatype,
+		RouteColumn:           common.StringUPPER(r.RouteColumn),
+		HashBuckets:           r.HashBuckets,
+	}
+	if route.SchemaNameT == "" {
+		route.SchemaNameT = common.StringUPPER(targetSchema)
+	}
+	for _, s := range r.Shards {
+		shard := common.TableShard{
+			SchemaNameT: common.StringUPPER(s.TargetSchema),
+			TableNameT:  common.StringUPPER(s.TargetTable),
+			HashBucket:  s.HashBucket,
+			RangeBegin:  s.RangeBegin,
+			RangeEnd:    s.RangeEnd,
+		}
+		if shard.SchemaNameT == "" {
+			shard.SchemaNameT = common.StringUPPER(targetSchema)
+		}
+		route.Shards = append(route.Shards, shard)
+	}
+	return route
+}
+
+type IncrConfig struct {
+	SourceTable string `toml:"source-table" json:"source-table"`
+	ErrorPolicy string `toml:"error-policy" json:"error-policy"`
+}
+
 type StructNonClusteredConfig struct {
 	SourceTable             []string `toml:"source-table" json:"source-table"`
 	NonClusteredTableOption string   `toml:"nonclustered-table-option" json:"nonclustered-table-option"`
@@ -168,9 +309,12 @@ type MySQLConfig struct {
 	Charset       string `toml:"charset" json:"charset"`
 	ConnectParams string `toml:"connect-params" json:"connect-params"`
 	Overwrite     bool   `toml:"overwrite" json:"overwrite"`
+	TimeZone      string `toml:"time-zone" json:"time-zone"`
 }
 
 type MetaConfig struct {
+	DBType     string `toml:"db-type" json:"db-type"`
+	DBFile     string `toml:"db-file" json:"db-file"`
 	Username   string `toml:"username" json:"username"`
 	Password   string `toml:"password" json:"password"`
 	Host       string `toml:"host" json:"host"`
@@ -197,9 +341,18 @@ func NewConfig() *Config {
 	}
 	fs.BoolVar(&cfg.PrintVersion, "V", false, "print version information and exit")
 	fs.StringVar(&cfg.ConfigFile, "config", "./config.toml", "path to the configuration file")
-	fs.StringVar(&cfg.TaskMode, "mode", "", "specify the program running mode: [prepare assess reverse full csv all check compare]")
+	fs.StringVar(&cfg.TaskMode, "mode", "", "specify the program running mode: [prepare assess reverse full csv all check compare park server task rule meta]")
 	fs.StringVar(&cfg.DBTypeS, "source", "oracle", "specify the source db type")
 	fs.StringVar(&cfg.DBTypeT, "target", "mysql", "specify the target db type")
+	fs.StringVar(&cfg.Action, "action", "", "specify the operation action of the maintenance mode, mode park: [list replay discard], mode task: [status retry reset list remove], mode rule: [export diff import], mode meta: [export import], mode assess: [list diff]")
+	fs.StringVar(&cfg.TableName, "table", "", "specify the source table name of the maintenance mode, default all tables")
+	fs.StringVar(&cfg.ActionMode, "task-mode", "full", "specify the task mode of meta records operated by the maintenance mode task: [full csv all compare]")
+	fs.StringVar(&cfg.RuleFile, "rule-file", "./rule.yaml", "specify the rules file of the maintenance mode rule, file format is decided by extension: [.yaml .yml .toml]")
+	fs.StringVar(&cfg.TaskName, "task-name", "", "specify the task name that isolates meta records of different tasks, override the config app task-name")
+	fs.BoolVar(&cfg.Confirm, "confirm", false, "confirm to apply the fix sql of the mode check on the target db when check config fix-apply is enabled, or to truncate target tables of the mode task action reset, or to replay park records of the mode park action replay, otherwise only dry run")
+	fs.StringVar(&cfg.MetaFile, "meta-file", "./transferdb_meta.db", "specify the embedded sqlite meta file of the maintenance mode meta, export meta tables to it or import meta tables from it")
+	fs.StringVar(&cfg.AssessID, "assess-id", "", "specify the assess id of the mode assess action diff, default the latest assess")
+	fs.StringVar(&cfg.BaseAssessID, "base-assess-id", "", "specify the base assess id of the mode assess action diff, default the previous assess of the assess-id")
 	return cfg
 }
 
@@ -234,6 +387,22 @@ func (c *Config) Parse(args []string) error {
 	return nil
 }
 
+// 服务模式任务配置，content 为 toml 格式配置文件内容
+func NewTaskConfig(content, mode, source, target string) (*Config, error) {
+	c := &Config{
+		TaskMode: mode,
+		DBTypeS:  source,
+		DBTypeT:  target,
+	}
+	if _, err := toml.Decode(content, c); err != nil {
+		return nil, fmt.Errorf("failed decode toml task config: %v", err)
+	}
+	if err := c.AdjustConfig(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 // 加载配置文件并解析
 func (c *Config) configFromFile(file string) error {
 	if _, err := toml.DecodeFile(file, c); err != nil {
@@ -246,20 +415,222 @@ func (c *Config) AdjustConfig() error {
 	c.DBTypeS = common.StringUPPER(c.DBTypeS)
 	c.DBTypeT = common.StringUPPER(c.DBTypeT)
 	c.TaskMode = common.StringUPPER(c.TaskMode)
+	c.Action = common.StringUPPER(c.Action)
+	c.TableName = common.StringUPPER(c.TableName)
+	c.ActionMode = common.StringUPPER(c.ActionMode)
 	c.OracleConfig.PDBName = common.StringUPPER(c.OracleConfig.PDBName)
 
-	c.SchemaConfig.SourceSchema = common.StringUPPER(c.SchemaConfig.SourceSchema)
-	c.SchemaConfig.TargetSchema = common.StringUPPER(c.SchemaConfig.TargetSchema)
+	timeZone, err := common.AdjustTimeZoneOffset(c.MySQLConfig.TimeZone)
+	if err != nil {
+		return err
+	}
+	c.MySQLConfig.TimeZone = timeZone
 
 	if c.FullConfig.CallTimeout == 0 {
 		c.FullConfig.CallTimeout = 36000
 	}
+	// 任务名隔离元数据，命令行参数优先
+	if c.TaskName != "" {
+		c.AppConfig.TaskName = c.TaskName
+	}
+	if c.AppConfig.TaskName == "" {
+		c.AppConfig.TaskName = common.DefaultTaskName
+	}
+	if len(c.AppConfig.TaskName) > 64 {
+		return fmt.Errorf("app config task-name [%s] length cannot exceed 64", c.AppConfig.TaskName)
+	}
+	if c.AppConfig.ServerAddr == "" {
+		c.AppConfig.ServerAddr = ":9797"
+	}
+	if c.AppConfig.SchemaThreads <= 0 {
+		c.AppConfig.SchemaThreads = 4
+	}
+	switch c.AppConfig.TableFilterVersion {
+	case 0:
+		c.AppConfig.TableFilterVersion = common.TableFilterVersionDefault
+	case common.TableFilterVersionLegacy, common.TableFilterVersionDefault:
+	default:
+		return fmt.Errorf("app config table-filter-version [%d] isn't support, support version [%d %d]",
+			c.AppConfig.TableFilterVersion, common.TableFilterVersionLegacy, common.TableFilterVersionDefault)
+	}
+	if c.AssessConfig.ConvertibleEffort <= 0 {
+		c.AssessConfig.ConvertibleEffort = 0.1
+	}
+	if c.AssessConfig.InConvertibleEffort <= 0 {
+		c.AssessConfig.InConvertibleEffort = 1
+	}
+	if c.AssessConfig.BenchmarkTables <= 0 {
+		c.AssessConfig.BenchmarkTables = 3
+	}
+	if c.AssessConfig.BenchmarkRows <= 0 {
+		c.AssessConfig.BenchmarkRows = 100000
+	}
+	if c.AssessConfig.ReplicaFactor <= 0 {
+		if c.DBTypeT == common.DatabaseTypeTiDB {
+			c.AssessConfig.ReplicaFactor = 3
+		} else {
+			c.AssessConfig.ReplicaFactor = 1
+		}
+	}
+	if c.AssessConfig.CompressionRatio <= 0 {
+		c.AssessConfig.CompressionRatio = 1
+	}
 	if c.CSVConfig.CallTimeout == 0 {
 		c.CSVConfig.CallTimeout = 36000
 	}
+	if c.FullConfig.ChunkMethod == "" {
+		c.FullConfig.ChunkMethod = common.MigrateChunkMethodRowID
+	}
+	c.FullConfig.ChunkMethod = common.StringUPPER(c.FullConfig.ChunkMethod)
+	if !common.IsContainString(common.MigrateChunkMethods, c.FullConfig.ChunkMethod) {
+		return fmt.Errorf("full config chunk-method [%s] isn't support, support method [%v]", c.FullConfig.ChunkMethod, common.MigrateChunkMethods)
+	}
+	if c.CSVConfig.ChunkMethod == "" {
+		c.CSVConfig.ChunkMethod = common.MigrateChunkMethodRowID
+	}
+	c.CSVConfig.ChunkMethod = common.StringUPPER(c.CSVConfig.ChunkMethod)
+	if !common.IsContainString(common.MigrateChunkMethods, c.CSVConfig.ChunkMethod) {
+		return fmt.Errorf("csv config chunk-method [%s] isn't support, support method [%v]", c.CSVConfig.ChunkMethod, common.MigrateChunkMethods)
+	}
+
+	if c.MetaConfig.DBType == "" {
+		c.MetaConfig.DBType = common.MetaDBTypeMySQL
+	}
+	c.MetaConfig.DBType = common.StringUPPER(c.MetaConfig.DBType)
+	if !common.IsContainString([]string{common.MetaDBTypeMySQL, common.MetaDBTypeSQLite}, c.MetaConfig.DBType) {
+		return fmt.Errorf("meta config db-type [%s] isn't support, support db-type [mysql sqlite]", c.MetaConfig.DBType)
+	}
+	if c.MetaConfig.DBType == common.MetaDBTypeSQLite && c.MetaConfig.DBFile == "" {
+		c.MetaConfig.DBFile = "./transferdb.db"
+	}
+
+	if c.AllConfig.ErrorPolicy == "" {
+		c.AllConfig.ErrorPolicy = common.MigrateIncrErrorPolicyAbort
+	}
+	c.AllConfig.ErrorPolicy = common.StringUPPER(c.AllConfig.ErrorPolicy)
+	if !common.IsContainString(common.MigrateIncrErrorPolicies, c.AllConfig.ErrorPolicy) {
+		return fmt.Errorf("all config error-policy [%s] isn't support, support policy [%v]", c.AllConfig.ErrorPolicy, common.MigrateIncrErrorPolicies)
+	}
+	if c.AllConfig.HeartbeatTable == "" {
+		c.AllConfig.HeartbeatTable = common.MigrateIncrHeartbeatTable
+	}
+	c.AllConfig.HeartbeatTable = common.StringUPPER(c.AllConfig.HeartbeatTable)
+	if c.AllConfig.HeartbeatInterval <= 0 {
+		c.AllConfig.HeartbeatInterval = 10
+	}
+	if c.DiffConfig.TimePrecisionRule == "" {
+		c.DiffConfig.TimePrecisionRule = common.CompareTimePrecisionRound
+	}
+	c.DiffConfig.TimePrecisionRule = common.StringUPPER(c.DiffConfig.TimePrecisionRule)
+	if !common.IsContainString(common.CompareTimePrecisionRules, c.DiffConfig.TimePrecisionRule) {
+		return fmt.Errorf("compare config time-precision-rule [%s] isn't support, support rule [%v]", c.DiffConfig.TimePrecisionRule, common.CompareTimePrecisionRules)
+	}
+	if c.DiffConfig.ConsistentTimeout <= 0 {
+		c.DiffConfig.ConsistentTimeout = 1800
+	}
+	if c.DiffConfig.ConsistentBatch <= 0 {
+		c.DiffConfig.ConsistentBatch = 64
+	}
+	if c.DiffConfig.SamplePercent < 0 || c.DiffConfig.SamplePercent > 100 {
+		return fmt.Errorf("compare config sample-percent [%d] isn't support, range [0, 100]", c.DiffConfig.SamplePercent)
+	}
+	c.ReportConfig.Format = common.StringUPPER(c.ReportConfig.Format)
+	if c.ReportConfig.Format != "" && !common.IsContainString(common.ReportFormats, c.ReportConfig.Format) {
+		return fmt.Errorf("report config format [%s] isn't support, support format [%v]", c.ReportConfig.Format, common.ReportFormats)
+	}
+	if c.ReportConfig.ReportDir == "" {
+		c.ReportConfig.ReportDir = "./"
+	}
+	if c.ReportConfig.FailOn == "" {
+		c.ReportConfig.FailOn = common.ReportFailOnNone
+	}
+	c.ReportConfig.FailOn = common.StringUPPER(c.ReportConfig.FailOn)
+	if !common.IsContainString(common.ReportFailOnPolicies, c.ReportConfig.FailOn) {
+		return fmt.Errorf("report config fail-on [%s] isn't support, support policy [%v]", c.ReportConfig.FailOn, common.ReportFailOnPolicies)
+	}
+	if err = c.SchemaConfig.adjustConfig(); err != nil {
+		return err
+	}
+	sourceSchemas := make(map[string]struct{})
+	for i := range c.SchemaRouteConfig {
+		if err = c.SchemaRouteConfig[i].adjustConfig(); err != nil {
+			return err
+		}
+		sourceSchema := c.SchemaRouteConfig[i].SourceSchema
+		if sourceSchema == "" {
+			return fmt.Errorf("schema route config source-schema cannot be empty")
+		}
+		if _, ok := sourceSchemas[sourceSchema]; ok {
+			return fmt.Errorf("schema route config source-schema [%s] is duplicate", sourceSchema)
+		}
+		sourceSchemas[sourceSchema] = struct{}{}
+	}
+	return nil
+}
+
+func (c *SchemaConfig) adjustConfig() error {
+	c.SourceSchema = common.StringUPPER(c.SourceSchema)
+	c.TargetSchema = common.StringUPPER(c.TargetSchema)
+
+	for i, t := range c.CompareConfig {
+		if t.TimePrecisionRule == "" {
+			continue
+		}
+		c.CompareConfig[i].TimePrecisionRule = common.StringUPPER(t.TimePrecisionRule)
+		if !common.IsContainString(common.CompareTimePrecisionRules, c.CompareConfig[i].TimePrecisionRule) {
+			return fmt.Errorf("schema config compare-config table [%s] time-precision-rule [%s] isn't support, support rule [%v]", t.SourceTable, t.TimePrecisionRule, common.CompareTimePrecisionRules)
+		}
+	}
+	for i, t := range c.TransformConfig {
+		c.TransformConfig[i].TransformType = common.StringUPPER(t.TransformType)
+		if _, err := common.NewColumnTransform(t.ColumnName, t.TransformType, t.TransformParam); err != nil {
+			return fmt.Errorf("schema config transform-config table [%s] %v", t.SourceTable, err)
+		}
+	}
+	for _, t := range c.ColumnNameConfig {
+		if err := common.ValidColumnNameRule(t.SourceColumn, t.TargetColumn, t.TargetDatatype); err != nil {
+			return fmt.Errorf("schema config column-name-config table [%s] %v", t.SourceTable, err)
+		}
+	}
+	for _, t := range c.RouteConfig {
+		if err := t.TableRoute(c.TargetSchema).Valid(); err != nil {
+			return fmt.Errorf("schema config route-config table [%s] %v", t.SourceTable, err)
+		}
+	}
+	for i, t := range c.IncrConfig {
+		// 未配置则继承 [all] error-policy
+		if t.ErrorPolicy == "" {
+			continue
+		}
+		c.IncrConfig[i].ErrorPolicy = common.StringUPPER(t.ErrorPolicy)
+		if !common.IsContainString(common.MigrateIncrErrorPolicies, c.IncrConfig[i].ErrorPolicy) {
+			return fmt.Errorf("schema config incr-config table [%s] error-policy [%s] isn't support, support policy [%v]", t.SourceTable, t.ErrorPolicy, common.MigrateIncrErrorPolicies)
+		}
+	}
 	return nil
 }
 
+// 待处理 schema 列表，配置 schema-route-config 以其为准，否则为 schema-config
+func (c *Config) SchemaConfigs() []SchemaConfig {
+	if len(c.SchemaRouteConfig) > 0 {
+		return c.SchemaRouteConfig
+	}
+	return []SchemaConfig{c.SchemaConfig}
+}
+
+// 表过滤规则是否按历史规则格式解析
+func (c *Config) TableFilterLegacy() bool {
+	return c.AppConfig.TableFilterVersion == common.TableFilterVersionLegacy
+}
+
+// 指定 schema 任务配置，其余配置共享
+func (c *Config) WithSchemaConfig(schemaCfg SchemaConfig) *Config {
+	newCfg := *c
+	newCfg.SchemaConfig = schemaCfg
+	newCfg.SchemaRouteConfig = nil
+	return &newCfg
+}
+
 func (c *Config) String() string {
 	cfg, err := json.Marshal(c)
 	if err != nil {
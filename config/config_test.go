@@ -0,0 +1,47 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"testing"
+
+	"github.com/wentaojin/transferdb/common"
+)
+
+func TestSchemaConfigIncrErrorPolicy(t *testing.T) {
+	c := &SchemaConfig{
+		SourceSchema: "marvin",
+		IncrConfig: []IncrConfig{
+			{SourceTable: "t1", ErrorPolicy: ""},
+			{SourceTable: "t2", ErrorPolicy: "skip"},
+		},
+	}
+	if err := c.adjustConfig(); err != nil {
+		t.Fatalf("adjustConfig() error = %v", err)
+	}
+	// 空值保持为空，由 [all] error-policy 兜底
+	if c.IncrConfig[0].ErrorPolicy != "" {
+		t.Errorf("empty error-policy = %q, want inherit", c.IncrConfig[0].ErrorPolicy)
+	}
+	if c.IncrConfig[1].ErrorPolicy != common.MigrateIncrErrorPolicySkip {
+		t.Errorf("error-policy = %q, want %q", c.IncrConfig[1].ErrorPolicy, common.MigrateIncrErrorPolicySkip)
+	}
+
+	c.IncrConfig = []IncrConfig{{SourceTable: "t3", ErrorPolicy: "retry-forever"}}
+	if err := c.adjustConfig(); err == nil {
+		t.Error("adjustConfig() with unknown error-policy should fail")
+	}
+}
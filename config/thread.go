@@ -0,0 +1,60 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"context"
+	"sync"
+)
+
+// 多 schema 任务线程预算，同一线程配置项各 schema 共享，并发 schema 总线程数不超过该线程配置
+type ThreadBudget struct {
+	mu      sync.Mutex
+	threads map[string]chan struct{}
+}
+
+func NewThreadBudget() *ThreadBudget {
+	return &ThreadBudget{threads: make(map[string]chan struct{})}
+}
+
+// 获取线程配置项 name 一个线程，threads 为线程配置大小，返回线程释放函数
+func (b *ThreadBudget) Acquire(ctx context.Context, name string, threads int) (func(), error) {
+	b.mu.Lock()
+	ch, ok := b.threads[name]
+	if !ok {
+		if threads <= 0 {
+			threads = 1
+		}
+		ch = make(chan struct{}, threads)
+		b.threads[name] = ch
+	}
+	b.mu.Unlock()
+
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// 获取多 schema 共享线程，单 schema 任务未设置线程预算，由各自 errgroup 线程配置限制
+func (c *Config) AcquireThread(ctx context.Context, name string, threads int) (func(), error) {
+	if c.ThreadBudget == nil {
+		return func() {}, nil
+	}
+	return c.ThreadBudget.Acquire(ctx, name, threads)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 迁移评估兼容性结果记录表，每次评估以评估编号区分，用于多次评估结果对比
+type AssessResultMeta struct {
+	ID            uint    `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskName      string  `gorm:"type:varchar(64);not null;default:'default';index:idx_task_assess_obj,unique;comment:'任务名'" json:"task_name"`
+	DBTypeS       string  `gorm:"type:varchar(30);index:idx_task_assess_obj,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT       string  `gorm:"type:varchar(30);index:idx_task_assess_obj,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	AssessID      string  `gorm:"type:varchar(30);not null;index:idx_task_assess_obj,unique;comment:'评估编号'" json:"assess_id"`
+	SchemaNameS   string  `gorm:"type:varchar(100);not null;index:idx_task_assess_obj,unique;comment:'源端 schema'" json:"schema_name_s"`
+	AssessName    string  `gorm:"type:varchar(100);not null;index:idx_task_assess_obj,unique;comment:'评估类别'" json:"assess_name"`
+	ObjectNameS   string  `gorm:"type:varchar(300);not null;index:idx_task_assess_obj,unique,length:200;comment:'源数据库对象名'" json:"object_name_s"`
+	ObjectCounts  int     `gorm:"not null;default:0;comment:'对象数'" json:"object_counts"`
+	IsCompatible  string  `gorm:"type:char(1);comment:'对象是否可兼容'" json:"is_compatible"`
+	IsConvertible string  `gorm:"type:char(1);comment:'对象是否可改造'" json:"is_convertible"`
+	EffortWeight  float64 `gorm:"type:decimal(10,2);not null;default:0;comment:'单个对象改造工作量(人天)'" json:"effort_weight"`
+	EffortDays    float64 `gorm:"type:decimal(16,2);not null;default:0;comment:'对象改造工作量(人天)'" json:"effort_days"`
+	*BaseModel
+}
+
+func NewAssessResultMetaModel(m *Meta) *AssessResultMeta {
+	return &AssessResultMeta{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *AssessResultMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [AssessResultMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *AssessResultMeta) BatchCreateAssessResultMeta(ctx context.Context, createS []AssessResultMeta, batchSize int) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if len(createS) == 0 {
+		return nil
+	}
+	if err = rw.DB(ctx).CreateInBatches(createS, batchSize).Error; err != nil {
+		return fmt.Errorf("batch create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *AssessResultMeta) DetailAssessResultMeta(ctx context.Context, detailS *AssessResultMeta) ([]AssessResultMeta, error) {
+	var dsMetas []AssessResultMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return dsMetas, err
+	}
+	if err = rw.DB(ctx).Where(detailS).Order("schema_name_s, assess_name, object_name_s").Find(&dsMetas).Error; err != nil {
+		return dsMetas, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return dsMetas, nil
+}
+
+// 评估编号列表，schema 为空不限定 schema，按评估编号倒序（最近评估在前）
+func (rw *AssessResultMeta) ListAssessID(ctx context.Context, detailS *AssessResultMeta) ([]string, error) {
+	var assessIDs []string
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return assessIDs, err
+	}
+	if err = rw.DB(ctx).Model(&AssessResultMeta{}).Where(&AssessResultMeta{
+		DBTypeS:     common.StringUPPER(detailS.DBTypeS),
+		DBTypeT:     common.StringUPPER(detailS.DBTypeT),
+		SchemaNameS: common.StringUPPER(detailS.SchemaNameS),
+	}).Distinct("assess_id").Order("assess_id DESC").Pluck("assess_id", &assessIDs).Error; err != nil {
+		return assessIDs, fmt.Errorf("list table [%s] assess id failed: %v", table, err)
+	}
+	return assessIDs, nil
+}
@@ -16,6 +16,8 @@ limitations under the License.
 package meta
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"gorm.io/gorm"
 	"time"
 )
@@ -49,3 +51,53 @@ func getCurrentTime() time.Time {
 		time.Now().Nanosecond(),
 		time.Local)
 }
+
+// 表 chunk 任务状态统计，StartedAt/FinishedAt 为 chunk 最早创建以及最近更新时间，用于估算任务剩余时间
+type TableStatusCounts struct {
+	TableNameS string        `json:"table_name_s"`
+	TaskStatus string        `json:"task_status"`
+	Counts     int64         `json:"counts"`
+	StartedAt  AggregateTime `json:"started_at"`
+	FinishedAt AggregateTime `json:"finished_at"`
+}
+
+// 聚合时间，SQLite 元数据库 MIN/MAX 等聚合结果丢失字段类型以字符串返回，统一解析为 time.Time
+type AggregateTime struct {
+	time.Time
+}
+
+var aggregateTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+func (t *AggregateTime) Scan(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	case time.Time:
+		t.Time = v
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("aggregate time unsupported scan type [%T]", value)
+	}
+	for _, format := range aggregateTimeFormats {
+		if ts, err := time.ParseInLocation(format, s, time.Local); err == nil {
+			t.Time = ts
+			return nil
+		}
+	}
+	return fmt.Errorf("aggregate time [%s] parse failed", s)
+}
+
+func (t AggregateTime) Value() (driver.Value, error) {
+	return t.Time, nil
+}
@@ -506,14 +506,12 @@ func (rw *BuildinDatatypeRule) InitO2MBuildinDatatypeRule(ctx context.Context) e
 		DatatypeNameT: common.BuildInOracleO2MDatatypeNameMap[common.BuildInOracleDatatypeIntervalDay],
 	})
 
-	return rw.DB(ctx).Clauses(clause.OnConflict{
-		Columns: []clause.Column{
-			{Name: "db_type_s"},
-			{Name: "db_type_t"},
-			{Name: "datatype_name_s"},
-		},
+	if err := rw.DB(ctx).Clauses(clause.OnConflict{
 		DoNothing: true,
-	}).Create(buildinDataTypeR).Error
+	}).Create(buildinDataTypeR).Error; err != nil {
+		return err
+	}
+	return rw.updateLocalTimeZoneDatatypeRule(ctx, common.DatabaseTypeMySQL)
 }
 
 func (rw *BuildinDatatypeRule) InitO2TBuildinDatatypeRule(ctx context.Context) error {
@@ -960,14 +958,12 @@ func (rw *BuildinDatatypeRule) InitO2TBuildinDatatypeRule(ctx context.Context) e
 		DatatypeNameT: common.BuildInOracleO2MDatatypeNameMap[common.BuildInOracleDatatypeIntervalDay],
 	})
 
-	return rw.DB(ctx).Clauses(clause.OnConflict{
-		Columns: []clause.Column{
-			{Name: "db_type_s"},
-			{Name: "db_type_t"},
-			{Name: "datatype_name_s"},
-		},
+	if err := rw.DB(ctx).Clauses(clause.OnConflict{
 		DoNothing: true,
-	}).Create(buildinDataTypeR).Error
+	}).Create(buildinDataTypeR).Error; err != nil {
+		return err
+	}
+	return rw.updateLocalTimeZoneDatatypeRule(ctx, common.DatabaseTypeTiDB)
 }
 
 func (rw *BuildinDatatypeRule) InitM2OBuildinDatatypeRule(ctx context.Context) error {
@@ -1347,3 +1343,31 @@ func (rw *BuildinDatatypeRule) InitT2OBuildinDatatypeRule(ctx context.Context) e
 	})
 	return rw.DB(ctx).Clauses(clause.Insert{Modifier: "IGNORE"}).Create(buildinDataTypeR).Error
 }
+
+// 历史版本 TIMESTAMP WITH LOCAL TIME ZONE 内置规则转换为 DATETIME，已存在元数据库内置规则不会覆盖，升级为 TIMESTAMP
+// 仅更新仍为历史默认值 DATETIME 的规则
+func (rw *BuildinDatatypeRule) updateLocalTimeZoneDatatypeRule(ctx context.Context, dbTypeT string) error {
+	tableName, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	datatypeNameS := []string{
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone0,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone1,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone2,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone3,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone4,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone5,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone6,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone7,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone8,
+		common.BuildInOracleDatatypeTimestampWithLocalTimeZone9,
+	}
+	if err = rw.DB(ctx).Model(&BuildinDatatypeRule{}).
+		Where("db_type_s = ? AND db_type_t = ? AND datatype_name_s IN (?) AND datatype_name_t = ?",
+			common.DatabaseTypeOracle, dbTypeT, datatypeNameS, "DATETIME").
+		Update("datatype_name_t", common.BuildInOracleO2MDatatypeNameMap[common.BuildInOracleDatatypeTimestampWithLocalTimeZone0]).Error; err != nil {
+		return fmt.Errorf("update table [%s] timestamp with local time zone record failed: %v", tableName, err)
+	}
+	return nil
+}
@@ -126,10 +126,6 @@ func (rw *BuildinGlobalDefaultval) InitO2MTBuildinGlobalDefaultValue(ctx context
 	})
 
 	return rw.DB(ctx).Clauses(clause.OnConflict{
-		Columns: []clause.Column{
-			{Name: "source_default_value"},
-			{Name: "reverse_mode"},
-		},
 		DoNothing: true,
 	}).Create(buildinColumDefaultvals).Error
 }
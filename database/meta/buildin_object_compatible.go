@@ -30,6 +30,8 @@ type BuildinObjectCompatible struct {
 	ObjectNameS   string `gorm:"type:varchar(300);index:idx_dbtype_st_obj,unique;comment:'源数据库对象名'" json:"object_name_s"`
 	IsCompatible  string `gorm:"type:char(1);comment:'对象是否可兼容'" json:"is_compatible"`
 	IsConvertible string `gorm:"type:char(1);comment:'对象是否可改造'" json:"is_convertible"`
+	// 不兼容对象改造工作量评估权重，0 以 [assess] 配置默认权重为准
+	EffortWeight float64 `gorm:"type:decimal(10,2);not null;default:0;comment:'单个对象改造工作量(人天)'" json:"effort_weight"`
 	*BaseModel
 }
 
@@ -24,6 +24,7 @@ import (
 
 type ChunkErrorDetail struct {
 	ID           uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskName     string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_name;comment:'任务名'" json:"task_name"`
 	DBTypeS      string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT      string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
@@ -68,6 +69,18 @@ func (rw *ChunkErrorDetail) DeleteChunkErrorDetailBySchemaTaskMode(ctx context.C
 	return nil
 }
 
+func (rw *ChunkErrorDetail) DetailChunkErrorDetail(ctx context.Context, detailS *ChunkErrorDetail) ([]ChunkErrorDetail, error) {
+	var dataS []ChunkErrorDetail
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return dataS, err
+	}
+	if err = rw.DB(ctx).Where(detailS).Find(&dataS).Error; err != nil {
+		return dataS, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return dataS, nil
+}
+
 func (rw *ChunkErrorDetail) CreateChunkErrorDetail(ctx context.Context, createS *ChunkErrorDetail) error {
 	table, err := rw.ParseSchemaTable()
 	if err != nil {
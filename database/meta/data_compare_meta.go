@@ -23,20 +23,27 @@ import (
 )
 
 // 数据校验元数据表
+// 唯一索引 where 条件字段使用前缀索引，避免 utf8mb4 字符集下索引长度超出 3072 字节限制
 type DataCompareMeta struct {
 	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
-	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_obj,unique;comment:'源数据库类型'" json:"db_type_s"`
-	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_obj,unique;comment:'目标数据库类型'" json:"db_type_t"`
-	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_obj,unique;comment:'源端 schema'" json:"schema_name_s"`
-	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_obj,unique;comment:'源端表名'" json:"table_name_s"`
+	TaskName      string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_dbtype_st_obj,unique;comment:'任务名'" json:"task_name"`
+	DBTypeS       string `gorm:"type:varchar(30);index:idx_task_dbtype_st_obj,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT       string `gorm:"type:varchar(30);index:idx_task_dbtype_st_obj,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_obj,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_obj,unique;comment:'源端表名'" json:"table_name_s"`
 	ColumnDetailS string `gorm:"type:text;comment:'源端查询字段信息'" json:"column_detail_s"`
 	SchemaNameT   string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
 	TableNameT    string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
 	ColumnDetailT string `gorm:"type:text;comment:'目标端查询字段信息'" json:"column_detail_t"`
 	WhereColumn   string `gorm:"comment:'查询类型字段列'" json:"where_column"`
-	WhereRange    string `gorm:"type:varchar(300);not null;index:idx_dbtype_st_obj,unique;comment:'查询 where 条件'" json:"where_range"`
-	TaskMode      string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_obj,unique;comment:'任务模式'" json:"task_mode"`
+	WhereRange    string `gorm:"type:varchar(300);not null;index:idx_task_dbtype_st_obj,unique,length:200;comment:'查询 where 条件'" json:"where_range"`
+	WhereRangeT   string `gorm:"type:text;comment:'目标端查询 where 条件，为空与 where_range 一致'" json:"where_range_t"`
+	CompareMethod string `gorm:"type:varchar(30);not null;default:'ROWS';comment:'对比方式,only rows,hash'" json:"compare_method"`
+	RouteWhereS   string `gorm:"type:varchar(300);not null;default:'';index:idx_task_dbtype_st_obj,unique,length:200;comment:'源端表路由分片 where 条件'" json:"route_where_s"`
+	RouteWhereT   string `gorm:"type:varchar(300);not null;default:'';comment:'目标端表路由合并 where 条件'" json:"route_where_t"`
+	TaskMode      string `gorm:"type:varchar(30);not null;index:idx_task_dbtype_st_obj,unique;comment:'任务模式'" json:"task_mode"`
 	TaskStatus    string `gorm:"type:varchar(30);not null;comment:'数据对比状态,only waiting,success,failed'" json:"task_status"`
+	SampleRound   int    `gorm:"not null;default:0;comment:'抽样校验轮次,0 表示未抽样'" json:"sample_round"`
 	IsPartition   string `gorm:"comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
 	InfoDetail    string `gorm:"type:text;not null;comment:'信息详情'" json:"info_detail"`
 	ErrorDetail   string `gorm:"type:text;not null;comment:'错误详情'" json:"error_detail"`
@@ -94,12 +101,17 @@ func (rw *DataCompareMeta) BatchCreateDataCompareMeta(ctx context.Context, creat
 	return nil
 }
 
-func (rw *DataCompareMeta) TruncateDataCompareMeta(ctx context.Context) error {
+func (rw *DataCompareMeta) TruncateDataCompareMeta(ctx context.Context, deleteS *DataCompareMeta) error {
 	table, err := rw.ParseSchemaTable()
 	if err != nil {
 		return err
 	}
-	err = rw.DB(ctx).Raw(fmt.Sprintf("TRUNCATE TABLE %s", table)).Error
+	// 元数据表多任务、多 schema 共用，只清理当前任务 schema 记录
+	err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		deleteS.TaskMode).Delete(&DataCompareMeta{}).Error
 	if err != nil {
 		return fmt.Errorf("truncate table [%s] record failed: %v", table, err)
 	}
@@ -112,13 +124,14 @@ func (rw *DataCompareMeta) UpdateDataCompareMeta(ctx context.Context, deleteS *D
 		return err
 	}
 	if err = rw.DB(ctx).Model(DataCompareMeta{}).
-		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND where_range = ?",
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND where_range = ? AND route_where_s = ?",
 			common.StringUPPER(deleteS.DBTypeS),
 			common.StringUPPER(deleteS.DBTypeT),
 			common.StringUPPER(deleteS.SchemaNameS),
 			common.StringUPPER(deleteS.TableNameS),
 			common.StringUPPER(deleteS.TaskMode),
-			deleteS.WhereRange).
+			deleteS.WhereRange,
+			deleteS.RouteWhereS).
 		Updates(updates).Error; err != nil {
 		return fmt.Errorf("update table [%s] record failed: %v", table, err)
 	}
@@ -164,6 +177,26 @@ func (rw *DataCompareMeta) CountsDataCompareMetaByTaskTable(ctx context.Context,
 	return countsErr, nil
 }
 
+func (rw *DataCompareMeta) CountsDataCompareMetaGroupByTableStatus(ctx context.Context, detailS *DataCompareMeta) ([]TableStatusCounts, error) {
+	var statusCounts []TableStatusCounts
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return statusCounts, err
+	}
+	if err = rw.DB(ctx).Model(&DataCompareMeta{}).
+		Select("table_name_s, task_status, COUNT(1) AS counts, MIN(created_at) AS started_at, MAX(updated_at) AS finished_at").
+		Where(`db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?`,
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			common.StringUPPER(detailS.TaskMode)).
+		Group("table_name_s, task_status").
+		Scan(&statusCounts).Error; err != nil {
+		return statusCounts, fmt.Errorf("group table [%s] counts failed: %v", table, err)
+	}
+	return statusCounts, nil
+}
+
 func (rw *DataCompareMeta) DistinctDataCompareMetaTableNameSByTaskStatus(ctx context.Context, detailS *DataCompareMeta) ([]string, error) {
 	var tableNames []string
 	table, err := rw.ParseSchemaTable()
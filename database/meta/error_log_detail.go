@@ -24,6 +24,7 @@ import (
 
 type ErrorLogDetail struct {
 	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskName    string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_name;comment:'任务名'" json:"task_name"`
 	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
 	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
 	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
@@ -84,6 +85,28 @@ func (rw *ErrorLogDetail) DetailErrorLog(ctx context.Context, detailS *ErrorLogD
 	return tableErrDetails, nil
 }
 
+// 按自增编号获取最近错误记录，用于增量拉取
+func (rw *ErrorLogDetail) DetailErrorLogAfterID(ctx context.Context, detailS *ErrorLogDetail, afterID uint, limit int) ([]ErrorLogDetail, error) {
+	var tableErrDetails []ErrorLogDetail
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return tableErrDetails, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND UPPER(schema_name_s) = ? AND task_mode = ? AND id > ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.TaskMode,
+		afterID).Order("id DESC").Limit(limit).Find(&tableErrDetails).Error; err != nil {
+		return tableErrDetails, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	// 按自增编号升序返回
+	for i, j := 0, len(tableErrDetails)-1; i < j; i, j = i+1, j-1 {
+		tableErrDetails[i], tableErrDetails[j] = tableErrDetails[j], tableErrDetails[i]
+	}
+	return tableErrDetails, nil
+}
+
 func (rw *ErrorLogDetail) CountsErrorLogBySchema(ctx context.Context, detailS *ErrorLogDetail) (int64, error) {
 	var totals int64
 	table, err := rw.ParseSchemaTable()
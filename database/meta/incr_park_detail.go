@@ -0,0 +1,151 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 增量同步应用失败事件暂存表（error-policy = park）
+type IncrParkDetail struct {
+	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskName      string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_name;comment:'任务名'" json:"task_name"`
+	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_map;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT   string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT    string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	TaskMode      string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_map;comment:'任务模式'" json:"task_mode"`
+	ScnS          uint64 `gorm:"comment:'源端事件 SCN'" json:"scn_s"`
+	Operation     string `gorm:"type:varchar(30);comment:'源端事件操作类型'" json:"operation"`
+	OperationType string `gorm:"type:varchar(30);comment:'目标端转换操作类型'" json:"operation_type"`
+	SQLRedoS      string `gorm:"type:longtext;not null;comment:'源端 redo SQL'" json:"sql_redo_s"`
+	SQLUndoS      string `gorm:"type:longtext;comment:'源端 undo SQL'" json:"sql_undo_s"`
+	SQLRedoT      string `gorm:"type:longtext;comment:'目标端待执行 SQL（JSON 数组）'" json:"sql_redo_t"`
+	Replayable    string `gorm:"type:varchar(10);not null;default:'YES';comment:'是否可重放，转换失败事件无目标端 SQL 为 NO'" json:"replayable"`
+	ErrorDetail   string `gorm:"type:longtext;not null;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewIncrParkDetailModel(m *Meta) *IncrParkDetail {
+	return &IncrParkDetail{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *IncrParkDetail) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [IncrParkDetail] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *IncrParkDetail) CreateIncrParkDetail(ctx context.Context, createS *IncrParkDetail) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+// 按 SCN 顺序获取暂存事件，TableNameS 为空表示 schema 下所有表
+func (rw *IncrParkDetail) DetailIncrParkDetail(ctx context.Context, detailS *IncrParkDetail) ([]IncrParkDetail, error) {
+	var parkDetails []IncrParkDetail
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return parkDetails, err
+	}
+	tx := rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.TaskMode)
+	if detailS.TableNameS != "" {
+		tx = tx.Where("table_name_s = ?", common.StringUPPER(detailS.TableNameS))
+	}
+	if err = tx.Order("scn_s ASC").Order("id ASC").Find(&parkDetails).Error; err != nil {
+		return parkDetails, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return parkDetails, nil
+}
+
+func (rw *IncrParkDetail) UpdateIncrParkDetail(ctx context.Context, id uint, updates map[string]interface{}) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Model(&IncrParkDetail{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("update table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *IncrParkDetail) DeleteIncrParkDetail(ctx context.Context, id uint) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Where("id = ?", id).Delete(&IncrParkDetail{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *IncrParkDetail) DeleteIncrParkDetailBySchemaTable(ctx context.Context, deleteS *IncrParkDetail) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	tx := rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		deleteS.TaskMode)
+	if deleteS.TableNameS != "" {
+		tx = tx.Where("table_name_s = ?", common.StringUPPER(deleteS.TableNameS))
+	}
+	if err = tx.Delete(&IncrParkDetail{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
+
+// 目标端待执行 SQL
+func (rw *IncrParkDetail) GetSQLRedoT() ([]string, error) {
+	var sqls []string
+	if rw.SQLRedoT == "" {
+		return sqls, nil
+	}
+	if err := json.Unmarshal([]byte(rw.SQLRedoT), &sqls); err != nil {
+		return sqls, fmt.Errorf("json unmarshal incr park detail [%d] sql_redo_t failed: %v", rw.ID, err)
+	}
+	return sqls, nil
+}
+
+func (rw *IncrParkDetail) String() string {
+	jsonStr, _ := json.Marshal(rw)
+	return string(jsonStr)
+}
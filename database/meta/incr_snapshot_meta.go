@@ -0,0 +1,142 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 一致性数据校验快照协调表（compare consistent = true）
+// compare 按表申请快照，all 模式增量同步于日志文件批次边界响应，记录源端一致 SCN 以及目标端快照
+type IncrSnapshotMeta struct {
+	ID             uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	TaskName       string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_dbtype_st_map,unique;comment:'任务名'" json:"task_name"`
+	DBTypeS        string `gorm:"type:varchar(30);index:idx_task_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT        string `gorm:"type:varchar(30);index:idx_task_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS    string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS     string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
+	SnapshotStatus string `gorm:"type:varchar(30);not null;comment:'快照状态,requested/ready/released/expired'" json:"snapshot_status"`
+	PauseApply     string `gorm:"type:varchar(10);not null;default:'N';comment:'快照期间是否暂停增量应用,Y/N'" json:"pause_apply"`
+	PauseTimeout   int    `gorm:"comment:'增量应用暂停最长时间,单位秒'" json:"pause_timeout"`
+	ScnS           uint64 `gorm:"comment:'源端一致 SCN'" json:"scn_s"`
+	SnapshotT      string `gorm:"type:varchar(64);comment:'目标端快照 TSO，为空表示当前读'" json:"snapshot_t"`
+	*BaseModel
+}
+
+func NewIncrSnapshotMetaModel(m *Meta) *IncrSnapshotMeta {
+	return &IncrSnapshotMeta{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *IncrSnapshotMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [IncrSnapshotMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+// 申请快照，覆盖表历史快照记录
+func (rw *IncrSnapshotMeta) CreateIncrSnapshotMeta(ctx context.Context, createS *IncrSnapshotMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DeleteIncrSnapshotMeta(ctx, createS); err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *IncrSnapshotMeta) GetIncrSnapshotMeta(ctx context.Context, detailS *IncrSnapshotMeta) (IncrSnapshotMeta, error) {
+	var snapshotMeta IncrSnapshotMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return snapshotMeta, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		common.StringUPPER(detailS.TableNameS)).Find(&snapshotMeta).Error; err != nil {
+		return snapshotMeta, fmt.Errorf("get table [%s] record failed: %v", table, err)
+	}
+	return snapshotMeta, nil
+}
+
+// 获取 schema 下指定状态快照记录
+func (rw *IncrSnapshotMeta) DetailIncrSnapshotMetaByStatus(ctx context.Context, detailS *IncrSnapshotMeta) ([]IncrSnapshotMeta, error) {
+	var snapshotMetas []IncrSnapshotMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return snapshotMetas, err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND snapshot_status = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		detailS.SnapshotStatus).Find(&snapshotMetas).Error; err != nil {
+		return snapshotMetas, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return snapshotMetas, nil
+}
+
+// 按当前状态更新快照记录，返回是否更新成功，用于 compare 与增量同步状态流转
+func (rw *IncrSnapshotMeta) UpdateIncrSnapshotMetaByStatus(ctx context.Context, detailS *IncrSnapshotMeta, status string, updates map[string]interface{}) (bool, error) {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return false, err
+	}
+	tx := rw.DB(ctx).Model(&IncrSnapshotMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND snapshot_status = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		common.StringUPPER(detailS.TableNameS),
+		status).Updates(updates)
+	if tx.Error != nil {
+		return false, fmt.Errorf("update table [%s] record failed: %v", table, tx.Error)
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+func (rw *IncrSnapshotMeta) DeleteIncrSnapshotMeta(ctx context.Context, deleteS *IncrSnapshotMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		common.StringUPPER(deleteS.TableNameS)).Delete(&IncrSnapshotMeta{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *IncrSnapshotMeta) String() string {
+	jsonStr, _ := json.Marshal(rw)
+	return string(jsonStr)
+}
@@ -19,19 +19,32 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/logger"
 	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+	"sync"
 )
 
+// gorm 默认日志记录器全局共享，服务模式多任务并发创建元数据库连接只设置一次，各连接使用自身日志记录器
+var setDefaultLogger sync.Once
+
 type Meta struct {
 	GormDB *gorm.DB
+	// 任务名，非空时任务元数据表读写自动限定于该任务，为空则不做任务隔离（用于任务列表、元数据导出导入）
+	taskName string
 }
 
-func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MetaConfig, slowThreshold int) (*Meta, error) {
+func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MetaConfig, taskName string, slowThreshold int) (*Meta, error) {
+	if strings.EqualFold(mysqlCfg.DBType, common.MetaDBTypeSQLite) {
+		return NewSQLiteMetaDBEngine(mysqlCfg.DBFile, taskName, slowThreshold)
+	}
+
 	// 创建元数据库
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=utf8mb4&parseTime=True&loc=Local",
 		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port)
@@ -56,7 +69,7 @@ func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MetaConfig, slowThresh
 	dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.MetaSchema)
 	l := logger.NewGormLogger(zap.L(), slowThreshold)
-	l.SetAsDefault()
+	setDefaultLogger.Do(l.SetAsDefault)
 	gormDB, err := gorm.Open(mysql.New(mysql.Config{
 		DriverName: "mysql",
 		DSN:        dsn,
@@ -74,7 +87,36 @@ func NewMetaDBEngine(ctx context.Context, mysqlCfg config.MetaConfig, slowThresh
 		return nil, fmt.Errorf("error on open meta database connection: %v", err)
 	}
 
-	return &Meta{GormDB: gormDB}, nil
+	return newMeta(gormDB, taskName)
+}
+
+// 内嵌 SQLite 元数据库，数据库文件不存在自动创建
+// WAL 模式以及 busy timeout 支持多线程并发读写，写事务开始即加锁，避免读锁升级写锁冲突
+func NewSQLiteMetaDBEngine(dbFile, taskName string, slowThreshold int) (*Meta, error) {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=60000&_journal_mode=WAL&_txlock=immediate&_loc=auto", dbFile)
+	l := logger.NewGormLogger(zap.L(), slowThreshold)
+	setDefaultLogger.Do(l.SetAsDefault)
+	gormDB, err := gorm.Open(newSQLiteDialector(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		PrepareStmt:                              true,
+		Logger:                                   l,
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true, // 使用单数表名
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on open sqlite meta database [%s] connection, sqlite meta database requires binary built with CGO_ENABLED=1: %v", dbFile, err)
+	}
+	return newMeta(gormDB, taskName)
+}
+
+func newMeta(gormDB *gorm.DB, taskName string) (*Meta, error) {
+	if taskName != "" {
+		if err := registerTaskScope(gormDB, taskName); err != nil {
+			return nil, err
+		}
+	}
+	return &Meta{GormDB: gormDB, taskName: taskName}, nil
 }
 
 func WrapGormDB(gormDB *gorm.DB) *Meta {
@@ -98,7 +140,38 @@ func (m *Meta) DB(ctx context.Context) *gorm.DB {
 }
 
 func (m *Meta) MigrateTables() (err error) {
-	return m.migrateStream(
+	if err = m.migrateStream(metaModels()...); err != nil {
+		return err
+	}
+	return m.dropLegacyIndexes()
+}
+
+// 历史版本唯一索引不包含任务名 task_name，升级后删除，避免不同任务相同表元数据冲突
+func (m *Meta) dropLegacyIndexes() error {
+	legacyIndexes := []struct {
+		model interface{}
+		name  string
+	}{
+		{new(WaitSyncMeta), "idx_dbtype_st_map"},
+		{new(FullSyncMeta), "idx_dbtype_st_map"},
+		{new(IncrSyncMeta), "idx_dbtype_st_map"},
+		{new(DataCompareMeta), "idx_dbtype_st_obj"},
+	}
+	migrator := m.GormDB.Migrator()
+	for _, idx := range legacyIndexes {
+		if !migrator.HasIndex(idx.model, idx.name) {
+			continue
+		}
+		if err := migrator.DropIndex(idx.model, idx.name); err != nil {
+			return fmt.Errorf("error on drop legacy index [%s]: %v", idx.name, err)
+		}
+	}
+	return nil
+}
+
+// 元数据表模型，新增元数据表需同步添加
+func metaModels() []interface{} {
+	return []interface{}{
 		new(ColumnDatatypeRule),
 		new(TableDatatypeRule),
 		new(SchemaDatatypeRule),
@@ -113,7 +186,13 @@ func (m *Meta) MigrateTables() (err error) {
 		new(BuildinDatatypeRule),
 		new(TableNameRule),
 		new(ChunkErrorDetail),
-	)
+		new(IncrParkDetail),
+		new(ColumnTransformRule),
+		new(ColumnNameRule),
+		new(TableRouteRule),
+		new(AssessResultMeta),
+		new(IncrSnapshotMeta),
+	}
 }
 
 func (m *Meta) InitDefaultValue(ctx context.Context) error {
@@ -152,6 +231,43 @@ func (m *Meta) InitDefaultValue(ctx context.Context) error {
 	return nil
 }
 
+// 元数据表全量复制至目标元数据库，用于内嵌 SQLite 与 MySQL 元数据库之间导出导入
+// 目标元数据库表不存在自动创建，表数据以源端为准（先清理后写入），保留原记录 ID 以及创建、更新时间
+func (m *Meta) CopyTables(ctx context.Context, target *Meta, batchSize int) error {
+	if err := target.MigrateTables(); err != nil {
+		return err
+	}
+	for _, model := range metaModels() {
+		stmt := &gorm.Statement{DB: m.GormDB}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("parse meta model [%T] get table_name failed: %v", model, err)
+		}
+		table := stmt.Schema.Table
+
+		txn := target.GormDB.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Begin()
+		if err := txn.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model).Error; err != nil {
+			txn.Rollback()
+			return fmt.Errorf("delete target meta table [%s] record failed: %v", table, err)
+		}
+		var rows int64
+		records := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem())).Interface()
+		err := m.DB(ctx).Model(model).FindInBatches(records, batchSize, func(tx *gorm.DB, batch int) error {
+			rows += tx.RowsAffected
+			// SQLite 单条 SQL 绑定变量数有限制，按字段数拆分批量写入
+			return txn.CreateInBatches(records, max(1, 900/len(stmt.Schema.DBNames))).Error
+		}).Error
+		if err != nil {
+			txn.Rollback()
+			return fmt.Errorf("copy meta table [%s] record failed: %v", table, err)
+		}
+		if err = txn.Commit().Error; err != nil {
+			return fmt.Errorf("commit meta table [%s] copy transaction failed: %v", table, err)
+		}
+		zap.L().Info("copy meta table finished", zap.String("table", table), zap.Int64("rows", rows))
+	}
+	return nil
+}
+
 func (m *Meta) migrateStream(models ...interface{}) (err error) {
 	for _, model := range models {
 		err = m.GormDB.AutoMigrate(model)
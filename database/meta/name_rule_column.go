@@ -0,0 +1,130 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"gorm.io/gorm"
+)
+
+// 上下游数据表字段名映射规则 - 字段列级别
+// 目标端字段名为空表示源端字段不迁移，源端字段名为空表示目标端新增字段
+// 配置文件 [[schema-config.column-name-config]] 优先级高于元数据表
+type ColumnNameRule struct {
+	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_column_name,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_column_name,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_column_name,unique;comment:'源端库 schema'" json:"schema_name_s"`
+	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_column_name,unique;comment:'源端表名'" json:"table_name_s"`
+	ColumnNameS   string `gorm:"type:varchar(200);index:idx_dbtype_st_column_name,unique;comment:'源端表字段列名'" json:"column_name_s"`
+	ColumnNameT   string `gorm:"type:varchar(200);index:idx_dbtype_st_column_name,unique;comment:'目标端表字段列名'" json:"column_name_t"`
+	ColumnTypeT   string `gorm:"type:varchar(300);comment:'目标端新增字段类型'" json:"column_type_t"`
+	DefaultValueT string `gorm:"type:varchar(300);comment:'目标端新增字段默认值'" json:"default_value_t"`
+	*BaseModel
+}
+
+func NewColumnNameRuleModel(m *Meta) *ColumnNameRule {
+	return &ColumnNameRule{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *ColumnNameRule) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [ColumnNameRule] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *ColumnNameRule) CreateColumnNameRule(ctx context.Context, createS *ColumnNameRule) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err := rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *ColumnNameRule) DetailColumnNameRule(ctx context.Context, detailS *ColumnNameRule) ([]ColumnNameRule, error) {
+	var columnRules []ColumnNameRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Find(&columnRules).Error; err != nil {
+		return columnRules, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+
+	return columnRules, nil
+}
+
+// 获取源端 schema 字段名映射规则 -> map[表名]规则，配置文件优先级高于元数据表
+func (rw *ColumnNameRule) GetSchemaColumnNameRule(ctx context.Context, cfg *config.Config) (map[string]*common.TableColumnNameRule, error) {
+	columnRules, err := rw.DetailColumnNameRule(ctx, &ColumnNameRule{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columnNameRule := make(map[string]*common.TableColumnNameRule)
+	addRule := func(tableName string, r common.ColumnNameRule) error {
+		if err := common.ValidColumnNameRule(r.ColumnNameS, r.ColumnNameT, r.ColumnTypeT); err != nil {
+			return fmt.Errorf("schema [%s] table [%s] column name rule %v", cfg.SchemaConfig.SourceSchema, tableName, err)
+		}
+		if _, ok := columnNameRule[common.StringUPPER(tableName)]; !ok {
+			columnNameRule[common.StringUPPER(tableName)] = common.NewTableColumnNameRule()
+		}
+		columnNameRule[common.StringUPPER(tableName)].AddRule(r)
+		return nil
+	}
+
+	for _, r := range columnRules {
+		if err = addRule(r.TableNameS, common.ColumnNameRule{
+			ColumnNameS:   r.ColumnNameS,
+			ColumnNameT:   r.ColumnNameT,
+			ColumnTypeT:   r.ColumnTypeT,
+			DefaultValueT: r.DefaultValueT,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range cfg.SchemaConfig.ColumnNameConfig {
+		if err = addRule(c.SourceTable, common.ColumnNameRule{
+			ColumnNameS:   c.SourceColumn,
+			ColumnNameT:   c.TargetColumn,
+			ColumnTypeT:   c.TargetDatatype,
+			DefaultValueT: c.TargetDefault,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return columnNameRule, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"gorm.io/gorm"
+)
+
+// 上下游表路由规则 - 表级别
+// merge 一张源端表一条记录，hash/range 一个分片一条记录，同一源端表路由类型、路由字段以及 hash 分桶数需保持一致
+// 配置文件 [[schema-config.route-config]] 优先级高于元数据表，同一源端表以配置文件整体覆盖
+type TableRouteRule struct {
+	ID                    uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS               string `gorm:"type:varchar(30);index:idx_dbtype_st_route,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT               string `gorm:"type:varchar(30);index:idx_dbtype_st_route,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS           string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_route,unique;comment:'源端库 schema'" json:"schema_name_s"`
+	TableNameS            string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_route,unique;comment:'源端表名'" json:"table_name_s"`
+	RouteType             string `gorm:"type:varchar(30);not null;comment:'路由类型 merge/hash/range'" json:"route_type"`
+	SchemaNameT           string `gorm:"type:varchar(100);index:idx_dbtype_st_route,unique;comment:'目标端库 schema'" json:"schema_name_t"`
+	TableNameT            string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_route,unique;comment:'目标端表名'" json:"table_name_t"`
+	DiscriminatorColumn   string `gorm:"type:varchar(200);comment:'merge 目标端区分字段'" json:"discriminator_column"`
+	DiscriminatorValue    string `gorm:"type:varchar(300);comment:'merge 目标端区分字段值'" json:"discriminator_value"`
+	DiscriminatorDatatype string `gorm:"type:varchar(300);comment:'merge 目标端区分字段类型'" json:"discriminator_datatype"`
+	RouteColumn           string `gorm:"type:varchar(200);comment:'hash/range 源端路由字段'" json:"route_column"`
+	HashBuckets           int    `gorm:"comment:'hash 分桶数'" json:"hash_buckets"`
+	HashBucket            int    `gorm:"comment:'hash 分片分桶编号'" json:"hash_bucket"`
+	RangeBegin            string `gorm:"type:varchar(100);comment:'range 分片区间起始值（包含）'" json:"range_begin"`
+	RangeEnd              string `gorm:"type:varchar(100);comment:'range 分片区间结束值（不包含）'" json:"range_end"`
+	*BaseModel
+}
+
+func NewTableRouteRuleModel(m *Meta) *TableRouteRule {
+	return &TableRouteRule{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *TableRouteRule) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [TableRouteRule] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *TableRouteRule) CreateTableRouteRule(ctx context.Context, createS *TableRouteRule) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err := rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *TableRouteRule) DetailTableRouteRule(ctx context.Context, detailS *TableRouteRule) ([]TableRouteRule, error) {
+	var routeRules []TableRouteRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Find(&routeRules).Error; err != nil {
+		return routeRules, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+
+	return routeRules, nil
+}
+
+// 获取源端 schema 表路由规则 -> map[表名]规则，配置文件优先级高于元数据表
+func (rw *TableRouteRule) GetSchemaTableRoute(ctx context.Context, cfg *config.Config) (map[string]*common.TableRoute, error) {
+	routeRules, err := rw.DetailTableRouteRule(ctx, &TableRouteRule{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tableRoute := make(map[string]*common.TableRoute)
+	for _, r := range routeRules {
+		tableName := common.StringUPPER(r.TableNameS)
+		schemaNameT := common.StringUPPER(r.SchemaNameT)
+		if schemaNameT == "" {
+			schemaNameT = common.StringUPPER(cfg.SchemaConfig.TargetSchema)
+		}
+		route, ok := tableRoute[tableName]
+		if !ok {
+			route = &common.TableRoute{
+				RouteType:   common.StringUPPER(r.RouteType),
+				RouteColumn: common.StringUPPER(r.RouteColumn),
+				HashBuckets: r.HashBuckets,
+			}
+			tableRoute[tableName] = route
+		}
+		if route.RouteType != common.StringUPPER(r.RouteType) || route.RouteColumn != common.StringUPPER(r.RouteColumn) || route.HashBuckets != r.HashBuckets {
+			return nil, fmt.Errorf("schema [%s] table [%s] route rule route type, route column and hash buckets need be consistent", cfg.SchemaConfig.SourceSchema, r.TableNameS)
+		}
+		if route.RouteType == common.TableRouteTypeMerge {
+			if route.TableNameT != "" {
+				return nil, fmt.Errorf("schema [%s] table [%s] route type [%s] only support one target table", cfg.SchemaConfig.SourceSchema, r.TableNameS, route.RouteType)
+			}
+			route.SchemaNameT = schemaNameT
+			route.TableNameT = common.StringUPPER(r.TableNameT)
+			route.DiscriminatorColumn = r.DiscriminatorColumn
+			route.DiscriminatorValue = r.DiscriminatorValue
+			route.DiscriminatorDatatype = r.DiscriminatorDatatype
+			continue
+		}
+		route.Shards = append(route.Shards, common.TableShard{
+			SchemaNameT: schemaNameT,
+			TableNameT:  common.StringUPPER(r.TableNameT),
+			HashBucket:  r.HashBucket,
+			RangeBegin:  r.RangeBegin,
+			RangeEnd:    r.RangeEnd,
+		})
+	}
+	for _, c := range cfg.SchemaConfig.RouteConfig {
+		tableRoute[common.StringUPPER(c.SourceTable)] = c.TableRoute(cfg.SchemaConfig.TargetSchema)
+	}
+	for t, route := range tableRoute {
+		if err = route.Valid(); err != nil {
+			return nil, fmt.Errorf("schema [%s] table [%s] route rule %v", cfg.SchemaConfig.SourceSchema, t, err)
+		}
+	}
+	return tableRoute, nil
+}
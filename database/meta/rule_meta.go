@@ -0,0 +1,147 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+)
+
+// 规则元数据，用于规则文件 export/diff/import 与元数据表同步
+// 除全局默认值按源端、目标端数据库类型同步外，其余规则按源端 schema 同步
+type RuleMeta struct {
+	SchemaDatatypeRules []SchemaDatatypeRule
+	TableDatatypeRules  []TableDatatypeRule
+	ColumnDatatypeRules []ColumnDatatypeRule
+	TableNameRules      []TableNameRule
+	ColumnDefaultvals   []BuildinColumnDefaultval
+	GlobalDefaultvals   []BuildinGlobalDefaultval
+}
+
+// 规则元数据变更，Updates、Deletes 记录 ID 为元数据表已存在记录 ID
+type RuleMetaSync struct {
+	Creates RuleMeta
+	Updates RuleMeta
+	Deletes RuleMeta
+}
+
+func (rw *Transaction) DetailRuleMeta(ctx context.Context, dbTypeS, dbTypeT string, schemaNameS []string) (*RuleMeta, error) {
+	var (
+		rules   RuleMeta
+		schemas []string
+	)
+	for _, s := range schemaNameS {
+		schemas = append(schemas, common.StringUPPER(s))
+	}
+	detailS := rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) IN ?",
+		common.StringUPPER(dbTypeS),
+		common.StringUPPER(dbTypeT),
+		schemas).Order("id")
+
+	if err := detailS.Session(&gorm.Session{}).Find(&rules.SchemaDatatypeRules).Error; err != nil {
+		return nil, fmt.Errorf("detail table [schema_datatype_rule] record failed: %v", err)
+	}
+	if err := detailS.Session(&gorm.Session{}).Find(&rules.TableDatatypeRules).Error; err != nil {
+		return nil, fmt.Errorf("detail table [table_datatype_rule] record failed: %v", err)
+	}
+	if err := detailS.Session(&gorm.Session{}).Find(&rules.ColumnDatatypeRules).Error; err != nil {
+		return nil, fmt.Errorf("detail table [column_datatype_rule] record failed: %v", err)
+	}
+	if err := detailS.Session(&gorm.Session{}).Find(&rules.TableNameRules).Error; err != nil {
+		return nil, fmt.Errorf("detail table [table_name_rule] record failed: %v", err)
+	}
+	if err := detailS.Session(&gorm.Session{}).Find(&rules.ColumnDefaultvals).Error; err != nil {
+		return nil, fmt.Errorf("detail table [buildin_column_defaultval] record failed: %v", err)
+	}
+	if err := rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ?",
+		common.StringUPPER(dbTypeS),
+		common.StringUPPER(dbTypeT)).Order("id").Find(&rules.GlobalDefaultvals).Error; err != nil {
+		return nil, fmt.Errorf("detail table [buildin_global_defaultval] record failed: %v", err)
+	}
+	return &rules, nil
+}
+
+// 规则元数据变更同一事务执行，先删除后更新、新增，避免唯一索引冲突
+func (rw *Transaction) SyncRuleMeta(ctx context.Context, syncS *RuleMetaSync) error {
+	txn := rw.DB(ctx).Begin()
+	err := syncRuleMetaRecord(txn, "schema_datatype_rule",
+		syncS.Creates.SchemaDatatypeRules, syncS.Updates.SchemaDatatypeRules, syncS.Deletes.SchemaDatatypeRules,
+		"ColumnTypeT", "Comment")
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	err = syncRuleMetaRecord(txn, "table_datatype_rule",
+		syncS.Creates.TableDatatypeRules, syncS.Updates.TableDatatypeRules, syncS.Deletes.TableDatatypeRules,
+		"ColumnTypeT", "Comment")
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	err = syncRuleMetaRecord(txn, "column_datatype_rule",
+		syncS.Creates.ColumnDatatypeRules, syncS.Updates.ColumnDatatypeRules, syncS.Deletes.ColumnDatatypeRules,
+		"ColumnTypeS", "ColumnTypeT", "Comment")
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	err = syncRuleMetaRecord(txn, "table_name_rule",
+		syncS.Creates.TableNameRules, syncS.Updates.TableNameRules, syncS.Deletes.TableNameRules,
+		"SchemaNameT", "TableNameT", "Comment")
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	err = syncRuleMetaRecord(txn, "buildin_column_defaultval",
+		syncS.Creates.ColumnDefaultvals, syncS.Updates.ColumnDefaultvals, syncS.Deletes.ColumnDefaultvals,
+		"DefaultValueS", "DefaultValueT", "Comment")
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	err = syncRuleMetaRecord(txn, "buildin_global_defaultval",
+		syncS.Creates.GlobalDefaultvals, syncS.Updates.GlobalDefaultvals, syncS.Deletes.GlobalDefaultvals,
+		"DefaultValueT", "Comment")
+	if err != nil {
+		txn.Rollback()
+		return err
+	}
+	if err = txn.Commit().Error; err != nil {
+		return fmt.Errorf("commit rule meta sync transaction failed: %v", err)
+	}
+	return nil
+}
+
+func syncRuleMetaRecord[T any](txn *gorm.DB, table string, creates, updates, deletes []T, updateCols ...string) error {
+	for i := range deletes {
+		if err := txn.Delete(&deletes[i]).Error; err != nil {
+			return fmt.Errorf("delete table [%s] record by transaction failed: %v", table, err)
+		}
+	}
+	for i := range updates {
+		if err := txn.Model(&updates[i]).Select(updateCols).Updates(&updates[i]).Error; err != nil {
+			return fmt.Errorf("update table [%s] record by transaction failed: %v", table, err)
+		}
+	}
+	if len(creates) > 0 {
+		if err := txn.Create(&creates).Error; err != nil {
+			return fmt.Errorf("create table [%s] record by transaction failed: %v", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// 内嵌 SQLite 元数据库方言，元数据表模型按 MySQL 定义，SQLite 建表以及写入时转换不兼容部分
+// 1、字段类型 datetime(3) default current_timestamp(3) on update current_timestamp(3) -> datetime default current_timestamp
+// 2、SQLite 索引名数据库级别唯一，索引名统一追加表名前缀
+// 3、INSERT IGNORE -> INSERT OR IGNORE
+type sqliteDialector struct {
+	*sqlite.Dialector
+}
+
+func newSQLiteDialector(dsn string) gorm.Dialector {
+	return sqliteDialector{Dialector: sqlite.Open(dsn).(*sqlite.Dialector)}
+}
+
+func (d sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{Migrator: sqlite.Migrator{Migrator: migrator.Migrator{Config: migrator.Config{
+		DB:                          db,
+		Dialector:                   d,
+		CreateIndexAfterCreateTable: true,
+	}}}}
+}
+
+func (d sqliteDialector) DataTypeOf(field *schema.Field) string {
+	dataType := strings.ToLower(string(field.DataType))
+	if idx := strings.Index(dataType, " on update "); idx != -1 {
+		dataType = dataType[:idx]
+	}
+	if strings.HasPrefix(dataType, "datetime") {
+		// 字段类型需为 datetime，驱动才会解析为 time.Time
+		dataType = strings.Replace(dataType, "current_timestamp(3)", "current_timestamp", -1)
+		dataType = strings.Replace(dataType, "datetime(3)", "datetime", 1)
+		return dataType
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+func (d sqliteDialector) Initialize(db *gorm.DB) error {
+	if err := d.Dialector.Initialize(db); err != nil {
+		return err
+	}
+	insertBuilder := db.ClauseBuilders["INSERT"]
+	db.ClauseBuilders["INSERT"] = func(c clause.Clause, builder clause.Builder) {
+		if insert, ok := c.Expression.(clause.Insert); ok && strings.EqualFold(insert.Modifier, "IGNORE") {
+			insert.Modifier = "OR IGNORE"
+			c.Expression = insert
+		}
+		insertBuilder(c, builder)
+	}
+	return nil
+}
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+func (m sqliteMigrator) CreateIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		idx := stmt.Schema.LookIndex(name)
+		if idx == nil {
+			return fmt.Errorf("failed to create index with name %v", name)
+		}
+		createIndexSQL := "CREATE "
+		if idx.Class != "" {
+			createIndexSQL += idx.Class + " "
+		}
+		createIndexSQL += "INDEX ? ON ??"
+		return m.DB.Exec(createIndexSQL,
+			clause.Column{Name: sqliteIndexName(stmt.Table, idx.Name)},
+			clause.Table{Name: stmt.Table},
+			m.BuildIndexOptions(idx.Fields, stmt)).Error
+	})
+}
+
+func (m sqliteMigrator) HasIndex(value interface{}, name string) bool {
+	var count int
+	_ = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			name = idx.Name
+		}
+		return m.DB.Raw("SELECT count(*) FROM sqlite_master WHERE type = ? AND tbl_name = ? AND name = ?",
+			"index", stmt.Table, sqliteIndexName(stmt.Table, name)).Row().Scan(&count)
+	})
+	return count > 0
+}
+
+func (m sqliteMigrator) DropIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			name = idx.Name
+		}
+		return m.DB.Exec("DROP INDEX ?", clause.Column{Name: sqliteIndexName(stmt.Table, name)}).Error
+	})
+}
+
+func sqliteIndexName(table, name string) string {
+	return fmt.Sprintf("%s_%s", table, name)
+}
@@ -20,17 +20,19 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
+	"time"
 )
 
 // 增量同步元数据表
 type IncrSyncMeta struct {
 	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
-	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
-	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
-	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
-	TableNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
-	SchemaNameT string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'目标 schema'" json:"schema_name_t"`
-	TableNameT  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'目标表名'" json:"table_name_t"`
+	TaskName    string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_dbtype_st_map,unique;comment:'任务名'" json:"task_name"`
+	DBTypeS     string `gorm:"type:varchar(30);index:idx_task_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT     string `gorm:"type:varchar(30);index:idx_task_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS  string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'目标 schema'" json:"schema_name_t"`
+	TableNameT  string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'目标表名'" json:"table_name_t"`
 	GlobalScnS  uint64 `gorm:"comment:'源端全局 SCN'" json:"global_scn_s"`
 	TableScnS   uint64 `gorm:"comment:'源端表同步 SCN'" json:"table_scn_s"`
 	IsPartition string `gorm:"type:varchar(10);comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
@@ -109,6 +111,23 @@ func (rw *IncrSyncMeta) GetIncrSyncMetaMinTableScnSBySchema(ctx context.Context,
 	return sourceTableSCN, nil
 }
 
+// schema 增量同步元数据最近更新时间，用于判断增量同步任务是否运行中
+func (rw *IncrSyncMeta) GetIncrSyncMetaMaxUpdatedAtBySchema(ctx context.Context, detailS *IncrSyncMeta) (time.Time, error) {
+	var updatedAt AggregateTime
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return updatedAt.Time, err
+	}
+	if err = rw.DB(ctx).Model(&IncrSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+	).Select("MAX(updated_at)").Row().Scan(&updatedAt); err != nil {
+		return updatedAt.Time, fmt.Errorf("get table [%s] column [updated_at] max value failed: %v", table, err)
+	}
+	return updatedAt.Time, nil
+}
+
 func (rw *IncrSyncMeta) DetailIncrSyncMetaBySchema(ctx context.Context, detailS *IncrSyncMeta) ([]IncrSyncMeta, error) {
 	var incrMetas []IncrSyncMeta
 	table, err := rw.ParseSchemaTable()
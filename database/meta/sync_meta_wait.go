@@ -25,11 +25,12 @@ import (
 // 同步元数据表
 type WaitSyncMeta struct {
 	ID               uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
-	DBTypeS          string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
-	DBTypeT          string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
-	SchemaNameS      string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
-	TableNameS       string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
-	TaskMode         string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_map,unique;comment:'任务模式'" json:"task_mode"`
+	TaskName         string `gorm:"type:varchar(64);not null;default:'default';index:idx_task_dbtype_st_map,unique;comment:'任务名'" json:"task_name"`
+	DBTypeS          string `gorm:"type:varchar(30);index:idx_task_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT          string `gorm:"type:varchar(30);index:idx_task_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS      string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS       string `gorm:"type:varchar(100);not null;index:idx_task_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
+	TaskMode         string `gorm:"type:varchar(30);not null;index:idx_task_dbtype_st_map,unique;comment:'任务模式'" json:"task_mode"`
 	TaskStatus       string `gorm:"type:varchar(30);not null;comment:'任务状态'" json:"task_status"`
 	GlobalScnS       uint64 `gorm:"comment:'全量任务 full_sync_meta 全局 SCN'" json:"global_scn_s"`
 	ConsistentRead   string `gorm:"type:varchar(10);not null;comment:'一致性读'" json:"consistent_read"`
@@ -38,6 +39,9 @@ type WaitSyncMeta struct {
 	ChunkSuccessNums int64  `gorm:"comment:'全量任务 full_sync_meta 执行成功 chunk 数'" json:"chunk_success_nums"`
 	ChunkFailedNums  int64  `gorm:"comment:'全量任务 full_sync_meta 执行失败 chunk 数'" json:"chunk_failed_nums"`
 	IsPartition      string `gorm:"type:varchar(10);comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
+	TimeZone         string `gorm:"type:varchar(10);comment:'带时区时间类型数据转换时区'" json:"time_zone"`
+	DeferIndexDDL    string `gorm:"type:longtext;comment:'全量任务目标端延迟创建索引外键定义'" json:"defer_index_ddl"`
+	DeferIndexStatus string `gorm:"type:varchar(30);comment:'全量任务目标端索引外键延迟创建状态'" json:"defer_index_status"`
 	*BaseModel
 }
 
@@ -166,6 +170,27 @@ func (rw *WaitSyncMeta) UpdateWaitSyncMeta(ctx context.Context, detailS *WaitSyn
 	return nil
 }
 
+func (rw *WaitSyncMeta) UpdateWaitSyncMetaTimeZone(ctx context.Context, detailS *WaitSyncMeta, tables []string) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Model(&WaitSyncMeta{}).
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND task_mode = ? AND table_name_s IN (?)",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			detailS.TaskMode,
+			tables).
+		Updates(map[string]interface{}{
+			"TimeZone": detailS.TimeZone,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("update table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
 func (rw *WaitSyncMeta) CountsErrWaitSyncMetaBySchema(ctx context.Context, dataErr *WaitSyncMeta) (int64, error) {
 	var countsErr int64
 	table, err := rw.ParseSchemaTable()
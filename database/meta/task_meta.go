@@ -0,0 +1,186 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sort"
+)
+
+// 任务元数据表（包含 task_name 字段）读写隔离
+// 元数据库引擎指定任务名后，注册 gorm 回调：
+// 1、写入时任务名为空自动填充当前任务名
+// 2、查询、更新、删除自动追加 task_name 条件，不同任务相同 schema/表元数据互不影响
+const taskScopeCallback = "transferdb:task_scope"
+
+func registerTaskScope(db *gorm.DB, taskName string) error {
+	if err := db.Callback().Create().Before("gorm:create").Register(taskScopeCallback, taskScopeCreate(taskName)); err != nil {
+		return fmt.Errorf("register meta task [%s] create callback failed: %v", taskName, err)
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register(taskScopeCallback, taskScopeWhere(taskName)); err != nil {
+		return fmt.Errorf("register meta task [%s] query callback failed: %v", taskName, err)
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register(taskScopeCallback, taskScopeWhere(taskName)); err != nil {
+		return fmt.Errorf("register meta task [%s] update callback failed: %v", taskName, err)
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register(taskScopeCallback, taskScopeWhere(taskName)); err != nil {
+		return fmt.Errorf("register meta task [%s] delete callback failed: %v", taskName, err)
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(taskScopeCallback, taskScopeWhere(taskName)); err != nil {
+		return fmt.Errorf("register meta task [%s] row callback failed: %v", taskName, err)
+	}
+	return nil
+}
+
+func taskScopeCreate(taskName string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.Statement.Schema == nil {
+			return
+		}
+		field := db.Statement.Schema.LookUpField("TaskName")
+		if field == nil {
+			return
+		}
+		rv := db.Statement.ReflectValue
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				setTaskName(db, field, reflect.Indirect(rv.Index(i)), taskName)
+			}
+		case reflect.Struct:
+			setTaskName(db, field, rv, taskName)
+		}
+	}
+}
+
+func setTaskName(db *gorm.DB, field *schema.Field, rv reflect.Value, taskName string) {
+	if _, isZero := field.ValueOf(db.Statement.Context, rv); !isZero {
+		return
+	}
+	if err := field.Set(db.Statement.Context, rv, taskName); err != nil {
+		db.AddError(fmt.Errorf("set meta task [%s] field failed: %v", taskName, err))
+	}
+}
+
+func taskScopeWhere(taskName string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		// 原生 SQL 不追加条件
+		if db.Error != nil || db.Statement.Schema == nil || db.Statement.SQL.Len() > 0 {
+			return
+		}
+		field := db.Statement.Schema.LookUpField("TaskName")
+		if field == nil {
+			return
+		}
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: taskName},
+		}})
+	}
+}
+
+// 任务元数据表，任务删除需同步清理
+func taskMetaModels() []interface{} {
+	return []interface{}{
+		new(WaitSyncMeta),
+		new(FullSyncMeta),
+		new(IncrSyncMeta),
+		new(DataCompareMeta),
+		new(ChunkErrorDetail),
+		new(IncrParkDetail),
+		new(ErrorLogDetail),
+		new(AssessResultMeta),
+		new(IncrSnapshotMeta),
+	}
+}
+
+// 任务列表，wait_sync_meta 按任务名、数据库类型、schema 以及任务模式统计表任务状态
+// 其余任务元数据表存在而 wait_sync_meta 不存在的任务（例如 reverse/check 错误日志）只记录任务名
+type TaskMeta struct {
+	TaskName      string        `json:"task_name"`
+	DBTypeS       string        `json:"db_type_s"`
+	DBTypeT       string        `json:"db_type_t"`
+	SchemaNameS   string        `json:"schema_name_s"`
+	TaskMode      string        `json:"task_mode"`
+	TableTotals   int64         `json:"table_totals"`
+	SuccessTables int64         `json:"success_tables"`
+	FailedTables  int64         `json:"failed_tables"`
+	UpdatedAt     AggregateTime `json:"updated_at"`
+}
+
+func (rw *Transaction) ListTaskMeta(ctx context.Context, successStatus, failedStatus string) ([]TaskMeta, error) {
+	var taskMetas []TaskMeta
+	if err := rw.DB(ctx).Model(&WaitSyncMeta{}).
+		Select(`task_name, db_type_s, db_type_t, schema_name_s, task_mode, COUNT(1) AS table_totals,
+SUM(CASE WHEN task_status = ? THEN 1 ELSE 0 END) AS success_tables,
+SUM(CASE WHEN task_status = ? THEN 1 ELSE 0 END) AS failed_tables,
+MAX(updated_at) AS updated_at`, successStatus, failedStatus).
+		Group(`task_name, db_type_s, db_type_t, schema_name_s, task_mode`).
+		Order(`task_name, db_type_s, db_type_t, schema_name_s, task_mode`).
+		Scan(&taskMetas).Error; err != nil {
+		return nil, fmt.Errorf("list table [wait_sync_meta] task record failed: %v", err)
+	}
+
+	tasks := make(map[string]struct{})
+	for _, t := range taskMetas {
+		tasks[t.TaskName] = struct{}{}
+	}
+	var others []string
+	for _, model := range taskMetaModels() {
+		var taskNames []string
+		if err := rw.DB(ctx).Model(model).Distinct("task_name").Pluck("task_name", &taskNames).Error; err != nil {
+			return nil, fmt.Errorf("list task name failed: %v", err)
+		}
+		for _, t := range taskNames {
+			if _, ok := tasks[t]; !ok {
+				tasks[t] = struct{}{}
+				others = append(others, t)
+			}
+		}
+	}
+	sort.Strings(others)
+	for _, t := range others {
+		taskMetas = append(taskMetas, TaskMeta{TaskName: t})
+	}
+	return taskMetas, nil
+}
+
+// 删除任务全部元数据，返回各元数据表删除记录数
+func (rw *Transaction) RemoveTaskMeta(ctx context.Context, taskName string) (map[string]int64, error) {
+	removes := make(map[string]int64)
+	txn := rw.DB(ctx).Begin()
+	for _, model := range taskMetaModels() {
+		stmt := &gorm.Statement{DB: txn}
+		if err := stmt.Parse(model); err != nil {
+			txn.Rollback()
+			return nil, fmt.Errorf("parse struct [%T] get table_name failed: %v", model, err)
+		}
+		res := txn.Where("task_name = ?", taskName).Delete(model)
+		if res.Error != nil {
+			txn.Rollback()
+			return nil, fmt.Errorf("delete table [%s] task [%s] record failed: %v", stmt.Schema.Table, taskName, res.Error)
+		}
+		removes[stmt.Schema.Table] = res.RowsAffected
+	}
+	if err := txn.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit remove task [%s] record failed: %v", taskName, err)
+	}
+	return removes, nil
+}
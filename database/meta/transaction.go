@@ -21,6 +21,7 @@ import (
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"strings"
 )
 
 type Transaction struct {
@@ -35,6 +36,7 @@ func (rw *Transaction) CreateErrorDetailAndUpdateWaitSyncMetaTaskStatus(ctx cont
 	txn := rw.DB(ctx).Begin()
 	err := txn.Create(errLogDetail).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("create table [check_error_detail] reocrd by transaction failed: %v", err)
 	}
 	err = txn.Model(&WaitSyncMeta{}).
@@ -48,6 +50,7 @@ func (rw *Transaction) CreateErrorDetailAndUpdateWaitSyncMetaTaskStatus(ctx cont
 			"TaskStatus": waitSyncMeta.TaskStatus,
 		}).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("update table [wait_sync_meta] reocrd by transaction failed: %v", err)
 	}
 	txn.Commit()
@@ -64,6 +67,7 @@ func (rw *Transaction) DeleteTableDataCompareMetaAndUpdateWaitSyncMeta(ctx conte
 			common.StringUPPER(deleteS.TableNameS),
 			deleteS.TaskMode).
 		Delete(&DataCompareMeta{}).Error; err != nil {
+		txn.Rollback()
 		return fmt.Errorf("delete table [data_compare_meta] record failed: %v", err)
 	}
 	if err := txn.Model(WaitSyncMeta{}).
@@ -78,6 +82,7 @@ func (rw *Transaction) DeleteTableDataCompareMetaAndUpdateWaitSyncMeta(ctx conte
 			"ChunkSuccessNums": updateS.ChunkSuccessNums,
 			"ChunkFailedNums":  updateS.ChunkFailedNums,
 		}).Error; err != nil {
+		txn.Rollback()
 		return fmt.Errorf("delete table [wait_sync_meta] record failed: %v", err)
 	}
 	txn.Commit()
@@ -94,6 +99,7 @@ func (rw *Transaction) DeleteTableFullSyncMetaAndUpdateWaitSyncMeta(ctx context.
 			common.StringUPPER(deleteS.TableNameS),
 			deleteS.TaskMode).
 		Delete(&FullSyncMeta{}).Error; err != nil {
+		txn.Rollback()
 		return fmt.Errorf("delete table [full_sync_meta] record failed: %v", err)
 	}
 	if err := txn.Model(WaitSyncMeta{}).
@@ -108,6 +114,7 @@ func (rw *Transaction) DeleteTableFullSyncMetaAndUpdateWaitSyncMeta(ctx context.
 			"ChunkSuccessNums": updateS.ChunkSuccessNums,
 			"ChunkFailedNums":  updateS.ChunkFailedNums,
 		}).Error; err != nil {
+		txn.Rollback()
 		return fmt.Errorf("delete table [wait_sync_meta] record failed: %v", err)
 	}
 	txn.Commit()
@@ -118,6 +125,7 @@ func (rw *Transaction) CreateDataCompareMetaAndUpdateWaitSyncMeta(ctx context.Co
 	txn := rw.DB(ctx).Begin()
 	err := txn.Create(dataDiffMeta).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("create table [data_compare_meta] reocrd by transaction failed: %v", err)
 	}
 	err = txn.Model(&WaitSyncMeta{}).
@@ -135,6 +143,7 @@ func (rw *Transaction) CreateDataCompareMetaAndUpdateWaitSyncMeta(ctx context.Co
 			"IsPartition":      waitSyncMeta.IsPartition,
 		}).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("update table [wait_sync_meta] reocrd by transaction failed: %v", err)
 	}
 	txn.Commit()
@@ -146,6 +155,7 @@ func (rw *Transaction) UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(ctx cont
 	txn := rw.DB(ctx).Begin()
 	err := txn.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(chunkErrorS).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("create table [chunk_error_detail] record by transaction failed: %v", err)
 	}
 
@@ -156,6 +166,7 @@ func (rw *Transaction) UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(ctx cont
 		common.StringUPPER(detailS.TaskMode),
 		detailS.ChunkDetailS).Updates(updateS).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("update table [full_sync_meta] record by transaction failed: %v", err)
 	}
 	txn.Commit()
@@ -195,6 +206,7 @@ func (rw *Transaction) CreateFullSyncMetaAndUpdateWaitSyncMeta(ctx context.Conte
 	txn := rw.DB(ctx).Begin()
 	err := txn.Create(fullSyncMeta).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("create table [full_sync_meta] reocrd by transaction failed: %v", err)
 	}
 	err = txn.Model(&WaitSyncMeta{}).
@@ -214,6 +226,7 @@ func (rw *Transaction) CreateFullSyncMetaAndUpdateWaitSyncMeta(ctx context.Conte
 			"IsPartition":      waitSyncMeta.IsPartition,
 		}).Error
 	if err != nil {
+		txn.Rollback()
 		return fmt.Errorf("update table [wait_sync_meta] reocrd by transaction failed: %v", err)
 	}
 	txn.Commit()
@@ -277,6 +290,191 @@ func (rw *Transaction) DeleteIncrSyncMetaAndWaitSyncMeta(ctx context.Context, in
 	return nil
 }
 
+func (rw *Transaction) CreateIncrParkDetailAndUpdateIncrSyncMeta(ctx context.Context, parkDetail *IncrParkDetail, incrSyncMeta *IncrSyncMeta) error {
+	if err := rw.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(parkDetail).Error; err != nil {
+			return fmt.Errorf("create table [incr_park_detail] record by transaction failed: %v", err)
+		}
+		if err := tx.Model(&IncrSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? and table_name_s = ?",
+			common.StringUPPER(incrSyncMeta.DBTypeS),
+			common.StringUPPER(incrSyncMeta.DBTypeT),
+			common.StringUPPER(incrSyncMeta.SchemaNameS),
+			common.StringUPPER(incrSyncMeta.TableNameS)).
+			Updates(IncrSyncMeta{GlobalScnS: incrSyncMeta.GlobalScnS, TableScnS: incrSyncMeta.TableScnS}).Error; err != nil {
+			return fmt.Errorf("update table [incr_sync_meta] record by transaction failed: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 失败表重试：失败 chunk 重置为 WAITING 并清理 chunk 错误记录，表状态由 FAILED 更新为 RUNNING，重新运行断点续传
+func (rw *Transaction) RetryTableChunkMetaAndUpdateWaitSyncMeta(ctx context.Context, waitSyncMeta *WaitSyncMeta) error {
+	if err := rw.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if strings.EqualFold(waitSyncMeta.TaskMode, common.TaskModeCompare) {
+			if err := tx.Model(&DataCompareMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND task_status = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				waitSyncMeta.TaskMode,
+				common.TaskStatusFailed).
+				Updates(map[string]interface{}{
+					"TaskStatus": common.TaskStatusWaiting,
+				}).Error; err != nil {
+				return fmt.Errorf("update table [data_compare_meta] record by transaction failed: %v", err)
+			}
+		} else {
+			if err := tx.Model(&FullSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND task_status = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				waitSyncMeta.TaskMode,
+				common.TaskStatusFailed).
+				Updates(map[string]interface{}{
+					"TaskStatus": common.TaskStatusWaiting,
+				}).Error; err != nil {
+				return fmt.Errorf("update table [full_sync_meta] record by transaction failed: %v", err)
+			}
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				waitSyncMeta.TaskMode).
+				Delete(&ChunkErrorDetail{}).Error; err != nil {
+				return fmt.Errorf("delete table [chunk_error_detail] record by transaction failed: %v", err)
+			}
+		}
+		if err := tx.Model(&WaitSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND task_status = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS),
+			common.StringUPPER(waitSyncMeta.TableNameS),
+			waitSyncMeta.TaskMode,
+			common.TaskStatusFailed).
+			Updates(map[string]interface{}{
+				"TaskStatus":      common.TaskStatusRunning,
+				"ChunkFailedNums": 0,
+			}).Error; err != nil {
+			return fmt.Errorf("update table [wait_sync_meta] record by transaction failed: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// 表重置：清理 chunk 元数据以及错误记录（all 模式同时清理增量元数据以及暂存事件），表状态重置为未初始化 WAITING，重新运行从头迁移
+func (rw *Transaction) ResetTableChunkMetaAndWaitSyncMeta(ctx context.Context, waitSyncMeta *WaitSyncMeta) error {
+	if err := rw.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if strings.EqualFold(waitSyncMeta.TaskMode, common.TaskModeCompare) {
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				waitSyncMeta.TaskMode).
+				Delete(&DataCompareMeta{}).Error; err != nil {
+				return fmt.Errorf("delete table [data_compare_meta] record by transaction failed: %v", err)
+			}
+		} else {
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				waitSyncMeta.TaskMode).
+				Delete(&FullSyncMeta{}).Error; err != nil {
+				return fmt.Errorf("delete table [full_sync_meta] record by transaction failed: %v", err)
+			}
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS),
+				waitSyncMeta.TaskMode).
+				Delete(&ChunkErrorDetail{}).Error; err != nil {
+				return fmt.Errorf("delete table [chunk_error_detail] record by transaction failed: %v", err)
+			}
+		}
+		if strings.EqualFold(waitSyncMeta.TaskMode, common.TaskModeAll) {
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS)).
+				Delete(&IncrSyncMeta{}).Error; err != nil {
+				return fmt.Errorf("delete table [incr_sync_meta] record by transaction failed: %v", err)
+			}
+			if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+				common.StringUPPER(waitSyncMeta.DBTypeS),
+				common.StringUPPER(waitSyncMeta.DBTypeT),
+				common.StringUPPER(waitSyncMeta.SchemaNameS),
+				common.StringUPPER(waitSyncMeta.TableNameS)).
+				Delete(&IncrParkDetail{}).Error; err != nil {
+				return fmt.Errorf("delete table [incr_park_detail] record by transaction failed: %v", err)
+			}
+		}
+		if err := tx.Model(&WaitSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS),
+			common.StringUPPER(waitSyncMeta.TableNameS),
+			waitSyncMeta.TaskMode).
+			Updates(map[string]interface{}{
+				"TaskStatus":       common.TaskStatusWaiting,
+				"GlobalScnS":       common.TaskTableDefaultSourceGlobalSCN,
+				"TableNumRows":     0,
+				"ChunkTotalNums":   common.TaskTableDefaultSplitChunkNums,
+				"ChunkSuccessNums": 0,
+				"ChunkFailedNums":  0,
+				"TimeZone":         "",
+			}).Error; err != nil {
+			return fmt.Errorf("update table [wait_sync_meta] record by transaction failed: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// all 模式整个任务重置：清理 schema 增量元数据（含心跳表）、暂存事件以及一致性校验快照记录，重新运行以全量 SCN 重新初始化
+func (rw *Transaction) ResetSchemaIncrMeta(ctx context.Context, waitSyncMeta *WaitSyncMeta) error {
+	if err := rw.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS)).
+			Delete(&IncrSyncMeta{}).Error; err != nil {
+			return fmt.Errorf("delete table [incr_sync_meta] record by transaction failed: %v", err)
+		}
+		if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS)).
+			Delete(&IncrParkDetail{}).Error; err != nil {
+			return fmt.Errorf("delete table [incr_park_detail] record by transaction failed: %v", err)
+		}
+		if err := tx.Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+			common.StringUPPER(waitSyncMeta.DBTypeS),
+			common.StringUPPER(waitSyncMeta.DBTypeT),
+			common.StringUPPER(waitSyncMeta.SchemaNameS)).
+			Delete(&IncrSnapshotMeta{}).Error; err != nil {
+			return fmt.Errorf("delete table [incr_snapshot_meta] record by transaction failed: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (rw *Transaction) UpdateIncrSyncMetaSCNByCurrentRedo(ctx context.Context,
 	dbTypeS, dbTypeT, sourceSchemaName string, lastRedoLogMaxSCN, logFileStartSCN, logFileEndSCN uint64) error {
 	var logFileSCN uint64
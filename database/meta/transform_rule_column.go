@@ -0,0 +1,119 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"gorm.io/gorm"
+)
+
+// 自定义字段数据转换（脱敏）规则 - 字段列级别
+// 配置文件 [[schema-config.transform-config]] 优先级高于元数据表
+type ColumnTransformRule struct {
+	ID             uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS        string `gorm:"type:varchar(30);index:idx_dbtype_st_transform,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT        string `gorm:"type:varchar(30);index:idx_dbtype_st_transform,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_transform,unique;comment:'源端库 schema'" json:"schema_name_s"`
+	TableNameS     string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_transform,unique;comment:'源端表名'" json:"table_name_s"`
+	ColumnNameS    string `gorm:"type:varchar(200);not null;index:idx_dbtype_st_transform,unique;comment:'源端表字段列名'" json:"column_name_s"`
+	TransformType  string `gorm:"type:varchar(30);not null;comment:'转换类型 hash/mask/constant/nullify/random/expr'" json:"transform_type"`
+	TransformParam string `gorm:"type:varchar(1000);comment:'转换参数'" json:"transform_param"`
+	*BaseModel
+}
+
+func NewColumnTransformRuleModel(m *Meta) *ColumnTransformRule {
+	return &ColumnTransformRule{BaseModel: &BaseModel{
+		Meta: m,
+	}}
+}
+
+func (rw *ColumnTransformRule) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [ColumnTransformRule] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *ColumnTransformRule) CreateColumnTransformRule(ctx context.Context, createS *ColumnTransformRule) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err := rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *ColumnTransformRule) DetailColumnTransformRule(ctx context.Context, detailS *ColumnTransformRule) ([]ColumnTransformRule, error) {
+	var transformRules []ColumnTransformRule
+
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rw.DB(ctx).Where("UPPER(db_type_s) = ? AND UPPER(db_type_t) = ? AND UPPER(schema_name_s) = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS)).Find(&transformRules).Error; err != nil {
+		return transformRules, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+
+	return transformRules, nil
+}
+
+// 获取源端 schema 字段数据转换规则 -> map[表名]map[字段名]规则，配置文件优先级高于元数据表
+func (rw *ColumnTransformRule) GetSchemaColumnTransform(ctx context.Context, cfg *config.Config) (map[string]map[string]*common.ColumnTransform, error) {
+	transformRules, err := rw.DetailColumnTransformRule(ctx, &ColumnTransformRule{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columnTransform := make(map[string]map[string]*common.ColumnTransform)
+	addTransform := func(tableName, columnName, transformType, transformParam string) error {
+		ct, err := common.NewColumnTransform(columnName, transformType, transformParam)
+		if err != nil {
+			return fmt.Errorf("schema [%s] table [%s] %v", cfg.SchemaConfig.SourceSchema, tableName, err)
+		}
+		if _, ok := columnTransform[common.StringUPPER(tableName)]; !ok {
+			columnTransform[common.StringUPPER(tableName)] = make(map[string]*common.ColumnTransform)
+		}
+		columnTransform[common.StringUPPER(tableName)][ct.ColumnName] = ct
+		return nil
+	}
+
+	for _, r := range transformRules {
+		if err = addTransform(r.TableNameS, r.ColumnNameS, r.TransformType, r.TransformParam); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range cfg.SchemaConfig.TransformConfig {
+		if err = addTransform(t.SourceTable, t.ColumnName, t.TransformType, t.TransformParam); err != nil {
+			return nil, err
+		}
+	}
+	return columnTransform, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mysql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 初始化增量同步心跳表，数据由增量同步应用
+func (m *MySQL) InitMySQLHeartbeatTable(schemaName, tableName string) error {
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (ID BIGINT PRIMARY KEY, HEARTBEAT_TS BIGINT)", schemaName, tableName)
+	if _, err := m.MySQLDB.ExecContext(m.Ctx, createSQL); err != nil {
+		return fmt.Errorf("mysql create heartbeat table failed: %v, sql: %v", err, createSQL)
+	}
+	return nil
+}
+
+// 获取已应用的心跳毫秒时间戳，不存在记录返回 0
+func (m *MySQL) GetMySQLHeartbeat(schemaName, tableName string) (int64, error) {
+	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf("SELECT HEARTBEAT_TS FROM %s.%s WHERE ID = 1", schemaName, tableName))
+	if err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	heartbeatTS, err := strconv.ParseInt(res[0]["HEARTBEAT_TS"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("get mysql heartbeat [%s] strconv.ParseInt failed: %v", res[0]["HEARTBEAT_TS"], err)
+	}
+	return heartbeatTS, nil
+}
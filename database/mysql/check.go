@@ -17,6 +17,7 @@ package mysql
 
 import (
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"strings"
 )
 
@@ -28,6 +29,19 @@ func (m *MySQL) GetMySQLDBVersion() (string, error) {
 	return res[0]["VERSION"], nil
 }
 
+// 当前会话时区偏移量，比如：+08:00
+func (m *MySQL) GetMySQLTimeZoneOffset() (string, error) {
+	_, res, err := Query(m.Ctx, m.MySQLDB, `SELECT TIME_FORMAT(TIMEDIFF(NOW(), UTC_TIMESTAMP()), '%H:%i') AS OFFSET`)
+	if err != nil {
+		return "", err
+	}
+	offset := res[0]["OFFSET"]
+	if !strings.HasPrefix(offset, "-") {
+		offset = common.StringsBuilder("+", offset)
+	}
+	return offset, nil
+}
+
 func (m *MySQL) GetMySQLTableCharacterSetAndCollation(schemaName, tableName string) (string, string, error) {
 	_, res, err := Query(m.Ctx, m.MySQLDB, fmt.Sprintf(`SELECT
 	IFNULL(CCSA.CHARACTER_SET_NAME,'UNKNOWN') CHARACTER_SET_NAME,
@@ -61,7 +75,8 @@ func (m *MySQL) GetMySQLTableColumn(schemaName, tableName string) ([]map[string]
 		IFNULL(COLUMN_DEFAULT,'NULLSTRING') DATA_DEFAULT,
 		IFNULL(COLUMN_COMMENT,'') COMMENTS,
 		IFNULL(CHARACTER_SET_NAME,'UNKNOWN') CHARACTER_SET_NAME,
-		IFNULL(COLLATION_NAME,'UNKNOWN') COLLATION_NAME
+		IFNULL(COLLATION_NAME,'UNKNOWN') COLLATION_NAME,
+		COLUMN_TYPE
  FROM information_schema.COLUMNS
  WHERE UPPER(TABLE_SCHEMA) = UPPER('%s')
    AND UPPER(TABLE_NAME) = UPPER('%s')
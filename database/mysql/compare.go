@@ -45,48 +45,122 @@ func (m *MySQL) GetMySQLTableName(schemaName, tableName string) ([]string, error
 	return tbls, nil
 }
 
-func (m *MySQL) GetMySQLTableActualRows(mysqlQuery string) (int64, error) {
-	_, res, err := Query(m.Ctx, m.MySQLDB, mysqlQuery)
-	if err != nil {
-		return 0, err
+// snapshotTSO 非空以 TiDB tidb_snapshot 快照读，为空当前读
+func (m *MySQL) GetMySQLTableActualRows(mysqlQuery, snapshotTSO string) (int64, error) {
+	if snapshotTSO == "" {
+		_, res, err := Query(m.Ctx, m.MySQLDB, mysqlQuery)
+		if err != nil {
+			return 0, err
+		}
+		rowsCount, err := strconv.ParseInt(res[0]["COUNT(1)"], 10, 64)
+		if err != nil {
+			return rowsCount, fmt.Errorf("error on FUNC GetMySQLTableActualRows failed: %v", err)
+		}
+		return rowsCount, nil
 	}
-	rowsCount, err := strconv.ParseInt(res[0]["COUNT(1)"], 10, 64)
+
+	var rowsCount int64
+	rows, release, err := m.snapshotQuery(mysqlQuery, snapshotTSO)
 	if err != nil {
+		return rowsCount, err
+	}
+	defer release()
+	defer rows.Close()
+	for rows.Next() {
+		if err = rows.Scan(&rowsCount); err != nil {
+			return rowsCount, fmt.Errorf("error on FUNC GetMySQLTableActualRows failed: %v", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
 		return rowsCount, fmt.Errorf("error on FUNC GetMySQLTableActualRows failed: %v", err)
 	}
 	return rowsCount, nil
 }
 
-func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set, uint32, error) {
+func (m *MySQL) GetMySQLDataRowStrings(querySQL, snapshotTSO string) ([]string, *strset.Set, uint32, error) {
+	cols, stringSet, crc32Value, _, err := m.getMySQLDataRowStrings(querySQL, snapshotTSO, true)
+	return cols, stringSet, crc32Value, err
+}
+
+// GetMySQLDataRowCRC32 流式聚合数据行数以及 CRC32，不缓存行数据，用于无主键/唯一键表全表对比
+func (m *MySQL) GetMySQLDataRowCRC32(querySQL, snapshotTSO string) (int64, uint32, error) {
+	_, _, crc32Value, rowCounts, err := m.getMySQLDataRowStrings(querySQL, snapshotTSO, false)
+	return rowCounts, crc32Value, err
+}
+
+// GetTiDBCurrentTSO 获取 TiDB 当前 TSO，晚于此前已提交事务，用于一致性校验快照读
+func (m *MySQL) GetTiDBCurrentTSO() (string, error) {
+	var tso string
+	txn, err := m.MySQLDB.BeginTx(m.Ctx, nil)
+	if err != nil {
+		return tso, fmt.Errorf("tidb current tso begin txn failed: %v", err)
+	}
+	defer txn.Rollback()
+	if err = txn.QueryRowContext(m.Ctx, `SELECT @@tidb_current_ts`).Scan(&tso); err != nil {
+		return tso, fmt.Errorf("tidb current tso query failed: %v", err)
+	}
+	return tso, nil
+}
+
+// 快照读独占连接设置 tidb_snapshot，查询结束重置后归还连接池
+func (m *MySQL) snapshotQuery(querySQL, snapshotTSO string) (*sql.Rows, func(), error) {
+	if snapshotTSO == "" {
+		rows, err := m.MySQLDB.Query(querySQL)
+		if err != nil {
+			return nil, func() {}, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+		}
+		return rows, func() {}, nil
+	}
+	conn, err := m.MySQLDB.Conn(m.Ctx)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("tidb snapshot [%s] get conn failed: %v", snapshotTSO, err)
+	}
+	release := func() {
+		_, _ = conn.ExecContext(m.Ctx, `SET @@tidb_snapshot = ''`)
+		_ = conn.Close()
+	}
+	if _, err = conn.ExecContext(m.Ctx, common.StringsBuilder(`SET @@tidb_snapshot = '`, snapshotTSO, `'`)); err != nil {
+		release()
+		return nil, func() {}, fmt.Errorf("tidb snapshot [%s] set failed: %v", snapshotTSO, err)
+	}
+	rows, err := conn.QueryContext(m.Ctx, querySQL)
+	if err != nil {
+		release()
+		return nil, func() {}, fmt.Errorf("general sql [%v] query with tidb snapshot [%s] failed: [%v]", querySQL, snapshotTSO, err.Error())
+	}
+	return rows, release, nil
+}
+
+func (m *MySQL) getMySQLDataRowStrings(querySQL, snapshotTSO string, keepRows bool) ([]string, *strset.Set, uint32, int64, error) {
 	var (
-		cols     []string
-		rowsTMP  []string
-		rows     *sql.Rows
-		err      error
-		crc32SUM uint32
+		cols      []string
+		rowsTMP   []string
+		crc32SUM  uint32
+		rowCounts int64
 	)
 	var crc32Value uint32 = 0
 
 	stringSet := set.NewStringSet()
 
-	rows, err = m.MySQLDB.Query(querySQL)
+	rows, release, err := m.snapshotQuery(querySQL, snapshotTSO)
 	if err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, err
 	}
 
+	defer release()
 	defer rows.Close()
 
 	//不确定字段通用查询，自动获取字段名称
 	cols, err = rows.Columns()
 	if err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
 	}
 
 	// 用于判断字段值是数字还是字符
 	var columnTypes []string
 	colTypes, err := rows.ColumnTypes()
 	if err != nil {
-		return cols, stringSet, crc32Value, err
+		return cols, stringSet, crc32Value, rowCounts, err
 	}
 
 	for _, ct := range colTypes {
@@ -97,7 +171,7 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 	//不确定字段通用查询，自动获取字段名称
 	cols, err = rows.Columns()
 	if err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
 	}
 
 	rawResult := make([][]byte, len(cols))
@@ -109,7 +183,7 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 	for rows.Next() {
 		err = rows.Scan(scans...)
 		if err != nil {
-			return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+			return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
 		}
 
 		for i, raw := range rawResult {
@@ -123,67 +197,67 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 				case "int8":
 					r, err := common.StrconvIntBitSize(string(raw), 8)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "int16":
 					r, err := common.StrconvIntBitSize(string(raw), 16)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "int32", "sql.NullInt32":
 					r, err := common.StrconvIntBitSize(string(raw), 32)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "int64", "sql.NullInt64":
 					r, err := common.StrconvIntBitSize(string(raw), 64)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "uint8":
 					r, err := common.StrconvUintBitSize(string(raw), 8)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "uint16":
 					r, err := common.StrconvUintBitSize(string(raw), 16)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "uint32":
 					r, err := common.StrconvUintBitSize(string(raw), 32)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "uint64":
 					r, err := common.StrconvUintBitSize(string(raw), 64)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "float32":
 					r, err := common.StrconvFloatBitSize(string(raw), 32)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "float64", "sql.NullFloat64":
 					r, err := common.StrconvFloatBitSize(string(raw), 64)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "rune":
 					r, err := common.StrconvRune(string(raw))
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				default:
@@ -197,15 +271,18 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 
 		// 计算 CRC32
 		crc32SUM = atomic.AddUint32(&crc32Value, crc32.ChecksumIEEE([]byte(rowS)))
-		stringSet.Add(rowS)
+		rowCounts++
+		if keepRows {
+			stringSet.Add(rowS)
+		}
 
 		// 数组清空
 		rowsTMP = rowsTMP[0:0]
 	}
 
 	if err = rows.Err(); err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
 	}
 
-	return cols, stringSet, crc32SUM, err
+	return cols, stringSet, crc32SUM, rowCounts, err
 }
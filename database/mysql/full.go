@@ -16,7 +16,10 @@ limitations under the License.
 package mysql
 
 import (
+	"context"
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
 )
 
 func (m *MySQL) TruncateMySQLTable(targetSchema string, targetTable string) error {
@@ -34,3 +37,66 @@ func (m *MySQL) WriteMySQLTable(sql string, args ...any) error {
 	}
 	return nil
 }
+
+func (m *MySQL) DropMySQLTable(targetSchema string, targetTable string) error {
+	_, err := m.MySQLDB.ExecContext(m.Ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s.%s", targetSchema, targetTable))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// 获取目标端表延迟创建二级索引、唯一索引以及外键定义
+func (m *MySQL) GetMySQLTableDeferIndex(schemaName, tableName string) ([]common.MigrateDeferIndex, error) {
+	createTableDDL, err := m.GetMySQLTableOriginDDL(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return common.ParseMigrateDeferIndex(schemaName, tableName, createTableDDL), nil
+}
+
+func (m *MySQL) DropMySQLTableDeferIndex(deferIndex common.MigrateDeferIndex) error {
+	return m.execMySQLTableDeferIndex(deferIndex.SchemaNameT, deferIndex.DropSQL())
+}
+
+func (m *MySQL) AddMySQLTableDeferIndex(deferIndex common.MigrateDeferIndex) error {
+	return m.execMySQLTableDeferIndex(deferIndex.SchemaNameT, deferIndex.AddSQL())
+}
+
+// 外键引用表未带库名，以目标端表所在库为当前库执行
+func (m *MySQL) execMySQLTableDeferIndex(schemaName, sqlStr string) error {
+	conn, err := m.MySQLDB.Conn(m.Ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(m.Ctx, fmt.Sprintf("USE %s", schemaName)); err != nil {
+		return err
+	}
+	if _, err = conn.ExecContext(m.Ctx, sqlStr); err != nil {
+		return fmt.Errorf("mysql sql [%s] exec failed: %v", sqlStr, err)
+	}
+	return nil
+}
+
+// TiDB 快速 DDL 索引回填全局变量（tidb_ddl_enable_fast_reorg），要求 TiDB v6.3.0 及以上
+func (m *MySQL) GetTiDBDDLFastReorg() (string, error) {
+	var value string
+	if err := m.MySQLDB.QueryRowContext(m.Ctx, "SELECT @@GLOBAL.tidb_ddl_enable_fast_reorg").Scan(&value); err != nil {
+		return value, fmt.Errorf("tidb get global variable [tidb_ddl_enable_fast_reorg] failed: %v", err)
+	}
+	return value, nil
+}
+
+// 任务取消同样需恢复原值，忽略上下文取消
+func (m *MySQL) SetTiDBDDLFastReorg(value string) error {
+	switch strings.ToUpper(value) {
+	case "ON", "OFF", "1", "0":
+	default:
+		return fmt.Errorf("tidb global variable [tidb_ddl_enable_fast_reorg] value [%s] isn't support", value)
+	}
+	if _, err := m.MySQLDB.ExecContext(context.WithoutCancel(m.Ctx), fmt.Sprintf("SET GLOBAL tidb_ddl_enable_fast_reorg = %s", value)); err != nil {
+		return fmt.Errorf("tidb set global variable [tidb_ddl_enable_fast_reorg] failed: %v", err)
+	}
+	return nil
+}
@@ -22,18 +22,25 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"net/url"
 	"strings"
 )
 
 type MySQL struct {
 	Ctx     context.Context
 	MySQLDB *sql.DB
+	// 目标端会话时区，ORACLE TIMESTAMP WITH (LOCAL) TIME ZONE 数据统一转换为该时区
+	TimeZone string
 }
 
 func NewMySQLDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig) (*MySQL, error) {
 	if !strings.EqualFold(mysqlCfg.Charset, "") {
 		mysqlCfg.ConnectParams = fmt.Sprintf("charset=%s&%s", strings.ToLower(mysqlCfg.Charset), mysqlCfg.ConnectParams)
 	}
+	// 指定时区则统一设置目标端会话时区 time_zone
+	if !strings.EqualFold(mysqlCfg.TimeZone, "") {
+		mysqlCfg.ConnectParams = fmt.Sprintf("time_zone=%s&%s", url.QueryEscape(common.StringsBuilder("'", mysqlCfg.TimeZone, "'")), mysqlCfg.ConnectParams)
+	}
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/?%s",
 		mysqlCfg.Username, mysqlCfg.Password, mysqlCfg.Host, mysqlCfg.Port, mysqlCfg.ConnectParams)
 
@@ -51,10 +58,19 @@ func NewMySQLDBEngine(ctx context.Context, mysqlCfg config.MySQLConfig) (*MySQL,
 		return nil, fmt.Errorf("error on ping mysql database connection: %v", err)
 	}
 
-	return &MySQL{
-		Ctx:     ctx,
-		MySQLDB: mysqlDB,
-	}, nil
+	m := &MySQL{
+		Ctx:      ctx,
+		MySQLDB:  mysqlDB,
+		TimeZone: mysqlCfg.TimeZone,
+	}
+	// 未指定时区以目标端当前会话时区偏移量为准
+	if strings.EqualFold(m.TimeZone, "") {
+		m.TimeZone, err = m.GetMySQLTimeZoneOffset()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
 }
 
 func Query(ctx context.Context, db *sql.DB, querySQL string) ([]string, []map[string]string, error) {
@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
+	"strconv"
 )
 
 func (o *Oracle) GetOracleRedoLogSCN(scn string) (uint64, error) {
@@ -182,3 +183,43 @@ END;`))
 	}
 	return nil
 }
+
+// 初始化增量同步心跳表，需开启表级别附加日志
+func (o *Oracle) InitOracleHeartbeatTable(schemaName, tableName string) error {
+	_, res, err := Query(o.Ctx, o.OracleDB, common.StringsBuilder(`SELECT COUNT(1) COUNT FROM DBA_TABLES WHERE OWNER = '`,
+		common.StringUPPER(schemaName), `' AND TABLE_NAME = '`, common.StringUPPER(tableName), `'`))
+	if err != nil {
+		return err
+	}
+	if res[0]["COUNT"] == "0" {
+		createSQL := common.StringsBuilder(`CREATE TABLE `, schemaName, `.`, tableName, ` (ID NUMBER PRIMARY KEY, HEARTBEAT_TS NUMBER(20))`)
+		if _, err = o.OracleDB.ExecContext(o.Ctx, createSQL); err != nil {
+			return fmt.Errorf("oracle create heartbeat table failed: %v, sql: %v", err, createSQL)
+		}
+		logSQL := common.StringsBuilder(`ALTER TABLE `, schemaName, `.`, tableName, ` ADD SUPPLEMENTAL LOG DATA (ALL) COLUMNS`)
+		if _, err = o.OracleDB.ExecContext(o.Ctx, logSQL); err != nil {
+			return fmt.Errorf("oracle heartbeat table add supplemental log failed: %v, sql: %v", err, logSQL)
+		}
+	}
+
+	_, res, err = Query(o.Ctx, o.OracleDB, common.StringsBuilder(`SELECT COUNT(1) COUNT FROM `, schemaName, `.`, tableName, ` WHERE ID = 1`))
+	if err != nil {
+		return err
+	}
+	if res[0]["COUNT"] == "0" {
+		insertSQL := common.StringsBuilder(`INSERT INTO `, schemaName, `.`, tableName, ` (ID, HEARTBEAT_TS) VALUES (1, 0)`)
+		if _, err = o.OracleDB.ExecContext(o.Ctx, insertSQL); err != nil {
+			return fmt.Errorf("oracle init heartbeat table record failed: %v, sql: %v", err, insertSQL)
+		}
+	}
+	return nil
+}
+
+// 更新增量同步心跳，heartbeatTS 为毫秒时间戳
+func (o *Oracle) UpdateOracleHeartbeat(schemaName, tableName string, heartbeatTS int64) error {
+	updateSQL := common.StringsBuilder(`UPDATE `, schemaName, `.`, tableName, ` SET HEARTBEAT_TS = `, strconv.FormatInt(heartbeatTS, 10), ` WHERE ID = 1`)
+	if _, err := o.OracleDB.ExecContext(o.Ctx, updateSQL); err != nil {
+		return fmt.Errorf("oracle update heartbeat table failed: %v, sql: %v", err, updateSQL)
+	}
+	return nil
+}
@@ -303,6 +303,39 @@ ORDER BY GB DESC
 	return vals, err
 }
 
+// 表行数（统计信息）、平均行长以及段大小，按段大小倒序，用于采样压测选取代表表以及全量迁移耗时、存储估算
+func (o *Oracle) GetOracleSchemaTableRowsSize(schemaName []string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT
+	T.OWNER,
+	T.TABLE_NAME,
+	NVL(T.NUM_ROWS,0) NUM_ROWS,
+	NVL(T.AVG_ROW_LEN,0) AVG_ROW_LEN,
+	NVL(S.BYTES,0) BYTES
+FROM DBA_TABLES T
+LEFT JOIN (
+	SELECT
+		OWNER,
+		SEGMENT_NAME,
+		SUM(BYTES) BYTES
+	FROM DBA_SEGMENTS
+	WHERE OWNER IN (%s)
+		AND SEGMENT_TYPE IN ('TABLE','TABLE PARTITION','TABLE SUBPARTITION')
+	GROUP BY OWNER,SEGMENT_NAME
+) S ON T.OWNER = S.OWNER AND T.TABLE_NAME = S.SEGMENT_NAME
+WHERE T.OWNER IN (%s)
+	AND T.TEMPORARY = 'N'
+	AND T.DROPPED = 'NO'
+	AND T.NESTED = 'NO'
+	AND (T.IOT_TYPE IS NULL OR T.IOT_TYPE = 'IOT')
+ORDER BY T.OWNER, BYTES DESC, NUM_ROWS DESC`, strings.Join(schemaName, ","), strings.Join(schemaName, ","))
+
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
 func (o *Oracle) GetOracleSchemaCodeObject(schemaName []string) ([]map[string]string, error) {
 	querySQL := fmt.Sprintf(`SELECT OWNER,NAME,TYPE,MAX(LINE) LINES from DBA_SOURCE where OWNER IN (%s) GROUP BY OWNER,NAME,TYPE ORDER BY LINES DESC`, strings.Join(schemaName, ","))
 
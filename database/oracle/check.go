@@ -57,6 +57,15 @@ func (o *Oracle) GetOracleDBCharacterSet() (string, error) {
 	return res[0]["LANG"], nil
 }
 
+func (o *Oracle) GetOracleDBTimeZone() (string, error) {
+	querySQL := fmt.Sprintf(`SELECT DBTIMEZONE AS DBTIMEZONE FROM DUAL`)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return "", err
+	}
+	return res[0]["DBTIMEZONE"], nil
+}
+
 func (o *Oracle) GetOracleSchemaCollation(schemaName string) (string, error) {
 	querySQL := fmt.Sprintf(`SELECT DECODE(DEFAULT_COLLATION,
 'USING_NLS_COMP',(SELECT VALUE from NLS_DATABASE_PARAMETERS WHERE PARAMETER = 'NLS_COMP'),DEFAULT_COLLATION) DEFAULT_COLLATION FROM DBA_USERS WHERE USERNAME = '%s'`, strings.ToUpper(schemaName))
@@ -0,0 +1,381 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 主键/唯一键范围切分字段
+type ChunkKeyColumn struct {
+	ColumnName string
+	DataType   string
+}
+
+// GetOracleTableChunks 表 chunk 切分，返回 chunk 查询条件 CMD
+// rowid 切分方式索引组织表 (IOT) 无物理 ROWID 范围，自动以主键范围切分
+func (o *Oracle) GetOracleTableChunks(chunkMethod, schemaName, tableName string, chunkSize, callTimeout int) ([]map[string]string, error) {
+	if strings.EqualFold(chunkMethod, common.MigrateChunkMethodRowID) {
+		isIOT, err := o.IsOracleIOTTable(schemaName, tableName)
+		if err != nil {
+			return nil, err
+		}
+		if !isIOT {
+			return o.getOracleTableChunksByRowID(schemaName, tableName, chunkSize, callTimeout)
+		}
+		zap.L().Warn("oracle index organized table chunk by primary key",
+			zap.String("schema", schemaName),
+			zap.String("table", tableName))
+	}
+	return o.GetOracleTableChunksByKey(schemaName, tableName, chunkSize, callTimeout)
+}
+
+func (o *Oracle) getOracleTableChunksByRowID(schemaName, tableName string, chunkSize, callTimeout int) ([]map[string]string, error) {
+	taskName := uuid.New().String()
+
+	if err := o.StartOracleChunkCreateTask(taskName); err != nil {
+		return nil, err
+	}
+
+	if err := o.StartOracleCreateChunkByRowID(taskName, schemaName, tableName, strconv.Itoa(chunkSize), callTimeout); err != nil {
+		return nil, err
+	}
+
+	chunkRes, err := o.GetOracleTableChunksByRowID(taskName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = o.CloseOracleChunkTask(taskName); err != nil {
+		return nil, err
+	}
+	return chunkRes, nil
+}
+
+func (o *Oracle) IsOracleIOTTable(schemaName, tableName string) (bool, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT NVL(IOT_TYPE,'NO') IOT_TYPE FROM DBA_TABLES WHERE OWNER = '%s' AND TABLE_NAME = '%s'`,
+		common.StringUPPER(schemaName), common.StringUPPER(tableName)))
+	if err != nil {
+		return false, err
+	}
+	if len(res) == 0 {
+		return false, fmt.Errorf("oracle schema [%s] table [%s] isn't exist", schemaName, tableName)
+	}
+	return strings.EqualFold(res[0]["IOT_TYPE"], "IOT"), nil
+}
+
+// GetOracleTableChunksByKey 主键/唯一键范围切分，源端只读
+// 1、按主键 > 唯一约束 > 唯一索引选取字段均非空且数据类型支持的键，字段数少者优先
+// 2、按统计信息行数计算 chunk 数，采样 (SAMPLE) 后 NTILE 分桶取每桶最大键值作为 chunk 边界
+// 3、字符串键边界按二进制排序选取，范围比较在源端执行
+// 4、复合键范围条件按字段逐级展开，切分条件超出元数据长度限制，以首字段作为边界
+// 无可用主键/唯一键或者数据量不足两个 chunk，全表作为单个 chunk
+func (o *Oracle) GetOracleTableChunksByKey(schemaName, tableName string, chunkSize, callTimeout int) ([]map[string]string, error) {
+	startTime := time.Now()
+	wholeTable := []map[string]string{{"CMD": `1 = 1`}}
+
+	chunkKeys, err := o.GetOracleTableChunkKeys(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunkKeys) == 0 {
+		zap.L().Warn("oracle table hasn't available primary or unique key, chunk by whole table",
+			zap.String("schema", schemaName),
+			zap.String("table", tableName))
+		return wholeTable, nil
+	}
+	keyColumns := chunkKeys[0]
+
+	tableRows, err := o.GetOracleTableRowsByStatistics(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if tableRows == 0 {
+		zap.L().Warn("oracle table statistics rows is zero, chunk by whole table, please gather table statistics",
+			zap.String("schema", schemaName),
+			zap.String("table", tableName))
+	}
+
+	bounds, err := o.GetOracleTableChunkKeyBounds(schemaName, tableName, keyColumns, tableRows, chunkSize, callTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(bounds) == 0 {
+		return wholeTable, nil
+	}
+
+	var columns []string
+	for _, c := range keyColumns {
+		columns = append(columns, common.StringsBuilder(`"`, c.ColumnName, `"`))
+	}
+	var literals [][]string
+	for _, b := range bounds {
+		var literal []string
+		for i, c := range keyColumns {
+			literal = append(literal, ChunkKeyLiteral(b[i], c.DataType))
+		}
+		literals = append(literals, literal)
+	}
+
+	chunkRanges := common.GenChunkKeyRange(columns, literals)
+	if len(keyColumns) > 1 && chunkKeyRangeOverLength(chunkRanges) {
+		chunkRanges = common.GenChunkKeyRange(columns[:1], common.ChunkKeyLeadingBounds(literals))
+	}
+	if chunkKeyRangeOverLength(chunkRanges) {
+		return nil, fmt.Errorf("oracle schema [%s] table [%s] chunk by key [%v] range condition exceeds max length [%d], please use chunk-method rowid or custom range",
+			schemaName, tableName, keyColumns, common.MigrateChunkDetailMaxLength)
+	}
+
+	var chunkRes []map[string]string
+	for _, r := range chunkRanges {
+		chunkRes = append(chunkRes, map[string]string{"CMD": r})
+	}
+
+	zap.L().Info("oracle table chunk by key finished",
+		zap.String("schema", schemaName),
+		zap.String("table", tableName),
+		zap.Int("table rows", tableRows),
+		zap.Int("chunks", len(chunkRes)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return chunkRes, nil
+}
+
+// GetOracleTableChunkKeyBounds 切分键 chunk 边界原始值，按 chunk 顺序返回，数据量不足两个 chunk 返回空
+// 采样 (SAMPLE) 后 NTILE 分桶取每桶最大键值，字符类型键以二进制排序 (NLSSORT BINARY)，与二进制范围比较保持一致，避免 NLS_SORT 语言排序导致边界乱序
+// callTimeout <= 0 不限制查询时长
+func (o *Oracle) GetOracleTableChunkKeyBounds(schemaName, tableName string, keyColumns []ChunkKeyColumn, tableRows, chunkSize, callTimeout int) ([][]string, error) {
+	if chunkSize <= 0 || tableRows <= chunkSize {
+		return nil, nil
+	}
+	chunkNums := int(math.Ceil(float64(tableRows) / float64(chunkSize)))
+
+	// 采样比例，每 chunk 采样 MigrateChunkKeySampleRows 行，采样比例过高全表扫描
+	var sampleClause string
+	samplePercent := float64(chunkNums*common.MigrateChunkKeySampleRows) / float64(tableRows) * 100
+	if samplePercent < 50 {
+		samplePercent = math.Max(samplePercent, 0.000001)
+		sampleClause = common.StringsBuilder(` SAMPLE (`, strconv.FormatFloat(samplePercent, 'f', 6, 64), `)`)
+	}
+
+	var (
+		orderCols, descCols, selectCols, boundCols []string
+	)
+	for i, c := range keyColumns {
+		alias := common.StringsBuilder("K", strconv.Itoa(i))
+		orderCol := alias
+		if IsChunkKeyCharacterType(c.DataType) {
+			orderCol = common.StringsBuilder(`NLSSORT(`, alias, `,'NLS_SORT=BINARY')`)
+		}
+		orderCols = append(orderCols, orderCol)
+		descCols = append(descCols, common.StringsBuilder(orderCol, " DESC"))
+		selectCols = append(selectCols, common.StringsBuilder(`"`, c.ColumnName, `" `, alias))
+		boundCols = append(boundCols, common.StringsBuilder(chunkKeyBoundExpr(alias, c.DataType), " B", strconv.Itoa(i)))
+	}
+	querySQL := common.StringsBuilder(`SELECT * FROM (
+SELECT `, strings.Join(boundCols, ","), `,NT,ROW_NUMBER() OVER (PARTITION BY NT ORDER BY `, strings.Join(descCols, ","), `) RN FROM (
+SELECT `, strings.Join(selectCols, ","), `,NTILE(`, strconv.Itoa(chunkNums), `) OVER (ORDER BY `, strings.Join(orderCols, ","), `) NT FROM `,
+		common.StringUPPER(schemaName), `."`, tableName, `"`, sampleClause, `)
+) WHERE RN = 1 AND NT < `, strconv.Itoa(chunkNums), ` ORDER BY NT`)
+
+	ctx := o.Ctx
+	if callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(o.Ctx, time.Now().Add(time.Duration(callTimeout)*time.Second))
+		defer cancel()
+	}
+
+	_, res, err := Query(ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return nil, fmt.Errorf("oracle table chunk by key boundary query failed: %v, sql: %v", err, querySQL)
+	}
+
+	var bounds [][]string
+	for _, r := range res {
+		var bound []string
+		for i := range keyColumns {
+			bound = append(bound, r[common.StringsBuilder("B", strconv.Itoa(i))])
+		}
+		bounds = append(bounds, bound)
+	}
+	return bounds, nil
+}
+
+// GetOracleTableChunkKeys 表可用切分键，按主键 > 唯一约束 > 唯一索引、字段数少者优先排序，无可用键返回空
+func (o *Oracle) GetOracleTableChunkKeys(schemaName, tableName string) ([][]ChunkKeyColumn, error) {
+	querySQL := fmt.Sprintf(`SELECT DECODE(C.CONSTRAINT_TYPE,'P',1,2) KEY_ORDER,
+	C.CONSTRAINT_NAME KEY_NAME,
+	CC.POSITION KEY_POSITION,
+	CC.COLUMN_NAME,
+	TC.DATA_TYPE,
+	TC.NULLABLE
+FROM DBA_CONSTRAINTS C, DBA_CONS_COLUMNS CC, DBA_TAB_COLUMNS TC
+WHERE C.OWNER = CC.OWNER
+	AND C.TABLE_NAME = CC.TABLE_NAME
+	AND C.CONSTRAINT_NAME = CC.CONSTRAINT_NAME
+	AND CC.OWNER = TC.OWNER
+	AND CC.TABLE_NAME = TC.TABLE_NAME
+	AND CC.COLUMN_NAME = TC.COLUMN_NAME
+	AND C.CONSTRAINT_TYPE IN ('P','U')
+	AND C.STATUS = 'ENABLED'
+	AND C.VALIDATED = 'VALIDATED'
+	AND C.OWNER = '%[1]s'
+	AND C.TABLE_NAME = '%[2]s'
+UNION ALL
+SELECT 3 KEY_ORDER,
+	I.INDEX_NAME KEY_NAME,
+	IC.COLUMN_POSITION KEY_POSITION,
+	IC.COLUMN_NAME,
+	TC.DATA_TYPE,
+	TC.NULLABLE
+FROM DBA_INDEXES I, DBA_IND_COLUMNS IC, DBA_TAB_COLUMNS TC
+WHERE I.OWNER = IC.INDEX_OWNER
+	AND I.INDEX_NAME = IC.INDEX_NAME
+	AND IC.TABLE_OWNER = TC.OWNER
+	AND IC.TABLE_NAME = TC.TABLE_NAME
+	AND IC.COLUMN_NAME = TC.COLUMN_NAME
+	AND I.UNIQUENESS = 'UNIQUE'
+	AND I.INDEX_TYPE IN ('NORMAL','IOT - TOP')
+	AND I.STATUS IN ('VALID','N/A')
+	AND I.TABLE_OWNER = '%[1]s'
+	AND I.TABLE_NAME = '%[2]s'`, common.StringUPPER(schemaName), tableName)
+
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return nil, err
+	}
+
+	type chunkKey struct {
+		order     int
+		name      string
+		columns   map[int]ChunkKeyColumn
+		available bool
+	}
+	keys := make(map[string]*chunkKey)
+	for _, r := range res {
+		keyOrder, err := strconv.Atoi(r["KEY_ORDER"])
+		if err != nil {
+			return nil, fmt.Errorf("oracle table key order [%s] strconv.Atoi failed: %v", r["KEY_ORDER"], err)
+		}
+		position, err := strconv.Atoi(r["KEY_POSITION"])
+		if err != nil {
+			return nil, fmt.Errorf("oracle table key position [%s] strconv.Atoi failed: %v", r["KEY_POSITION"], err)
+		}
+		keyName := common.StringsBuilder(r["KEY_ORDER"], ".", r["KEY_NAME"])
+		if _, ok := keys[keyName]; !ok {
+			keys[keyName] = &chunkKey{order: keyOrder, name: r["KEY_NAME"], columns: make(map[int]ChunkKeyColumn), available: true}
+		}
+		// 可空字段以及不支持的数据类型无法作为切分键
+		if !strings.EqualFold(r["NULLABLE"], "N") || chunkKeyBoundExpr("", r["DATA_TYPE"]) == "" {
+			keys[keyName].available = false
+		}
+		keys[keyName].columns[position] = ChunkKeyColumn{ColumnName: r["COLUMN_NAME"], DataType: r["DATA_TYPE"]}
+	}
+
+	var availableKeys []*chunkKey
+	for _, k := range keys {
+		if k.available {
+			availableKeys = append(availableKeys, k)
+		}
+	}
+	if len(availableKeys) == 0 {
+		return nil, nil
+	}
+	sort.Slice(availableKeys, func(i, j int) bool {
+		if availableKeys[i].order != availableKeys[j].order {
+			return availableKeys[i].order < availableKeys[j].order
+		}
+		if len(availableKeys[i].columns) != len(availableKeys[j].columns) {
+			return len(availableKeys[i].columns) < len(availableKeys[j].columns)
+		}
+		return availableKeys[i].name < availableKeys[j].name
+	})
+
+	var chunkKeys [][]ChunkKeyColumn
+	for _, k := range availableKeys {
+		var positions []int
+		for p := range k.columns {
+			positions = append(positions, p)
+		}
+		sort.Ints(positions)
+		var keyColumns []ChunkKeyColumn
+		for _, p := range positions {
+			keyColumns = append(keyColumns, k.columns[p])
+		}
+		chunkKeys = append(chunkKeys, keyColumns)
+	}
+	return chunkKeys, nil
+}
+
+// 切分键边界值查询表达式，不支持的数据类型返回空
+func chunkKeyBoundExpr(column, dataType string) string {
+	dataType = common.StringUPPER(dataType)
+	switch {
+	case common.IsContainString([]string{"NUMBER", "FLOAT", "INTEGER", "DECIMAL", "NUMERIC", "SMALLINT"}, dataType):
+		return common.StringsBuilder(`TO_CHAR(`, column, `)`)
+	case common.IsContainString([]string{"CHAR", "VARCHAR2", "NCHAR", "NVARCHAR2"}, dataType):
+		return column
+	case dataType == "DATE":
+		return common.StringsBuilder(`TO_CHAR(`, column, `,'YYYY-MM-DD HH24:MI:SS')`)
+	case strings.HasPrefix(dataType, "TIMESTAMP") && !strings.Contains(dataType, "TIME ZONE"):
+		return common.StringsBuilder(`TO_CHAR(`, column, `,'YYYY-MM-DD HH24:MI:SS.FF9')`)
+	case dataType == "RAW":
+		return common.StringsBuilder(`RAWTOHEX(`, column, `)`)
+	default:
+		return ""
+	}
+}
+
+// ChunkKeyLiteral 切分键边界值 SQL 字面量
+func ChunkKeyLiteral(value, dataType string) string {
+	dataType = common.StringUPPER(dataType)
+	switch {
+	case common.IsContainString([]string{"CHAR", "VARCHAR2"}, dataType):
+		return common.StringsBuilder(`'`, strings.ReplaceAll(value, `'`, `''`), `'`)
+	case common.IsContainString([]string{"NCHAR", "NVARCHAR2"}, dataType):
+		return common.StringsBuilder(`N'`, strings.ReplaceAll(value, `'`, `''`), `'`)
+	case dataType == "DATE":
+		return common.StringsBuilder(`TO_DATE('`, value, `','YYYY-MM-DD HH24:MI:SS')`)
+	case strings.HasPrefix(dataType, "TIMESTAMP"):
+		return common.StringsBuilder(`TO_TIMESTAMP('`, value, `','YYYY-MM-DD HH24:MI:SS.FF9')`)
+	case dataType == "RAW":
+		return common.StringsBuilder(`HEXTORAW('`, value, `')`)
+	default:
+		return value
+	}
+}
+
+// IsChunkKeyCharacterType 字符类型切分键
+func IsChunkKeyCharacterType(dataType string) bool {
+	return common.IsContainString([]string{"CHAR", "VARCHAR2", "NCHAR", "NVARCHAR2"}, common.StringUPPER(dataType))
+}
+
+func chunkKeyRangeOverLength(chunkRanges []string) bool {
+	for _, c := range chunkRanges {
+		if len(c) > common.MigrateChunkDetailMaxLength {
+			return true
+		}
+	}
+	return false
+}
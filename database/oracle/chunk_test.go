@@ -0,0 +1,66 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkKeyLiteral(t *testing.T) {
+	for dataType, want := range map[string]string{
+		"NUMBER":       `10`,
+		"VARCHAR2":     `'10'`,
+		"char":         `'10'`,
+		"NVARCHAR2":    `N'10'`,
+		"RAW":          `HEXTORAW('10')`,
+		"DATE":         `TO_DATE('10','YYYY-MM-DD HH24:MI:SS')`,
+		"TIMESTAMP(6)": `TO_TIMESTAMP('10','YYYY-MM-DD HH24:MI:SS.FF9')`,
+	} {
+		if got := ChunkKeyLiteral("10", dataType); got != want {
+			t.Errorf("ChunkKeyLiteral(%s) = %v, want %v", dataType, got, want)
+		}
+	}
+	if got := ChunkKeyLiteral("a'b", "VARCHAR2"); got != `'a''b'` {
+		t.Errorf("ChunkKeyLiteral() quote escape = %v", got)
+	}
+}
+
+func TestChunkKeyBoundExpr(t *testing.T) {
+	// 带时区时间类型以及 LOB 不作为切分键
+	for _, dataType := range []string{"TIMESTAMP(6) WITH TIME ZONE", "CLOB", "BLOB"} {
+		if got := chunkKeyBoundExpr(`"C"`, dataType); got != "" {
+			t.Errorf("chunkKeyBoundExpr(%s) = %v, want unsupported", dataType, got)
+		}
+	}
+	if got := chunkKeyBoundExpr(`"C"`, "NUMBER"); got != `TO_CHAR("C")` {
+		t.Errorf("chunkKeyBoundExpr(NUMBER) = %v", got)
+	}
+	if got := chunkKeyBoundExpr(`"C"`, "VARCHAR2"); got != `"C"` {
+		t.Errorf("chunkKeyBoundExpr(VARCHAR2) = %v", got)
+	}
+}
+
+func TestChunkKeyRangeOverLength(t *testing.T) {
+	short := []string{`"ID" <= 100`}
+	if chunkKeyRangeOverLength(short) {
+		t.Errorf("chunkKeyRangeOverLength(%v) = true, want false", short)
+	}
+	long := append(short, strings.Repeat("X", 301))
+	if !chunkKeyRangeOverLength(long) {
+		t.Error("chunkKeyRangeOverLength() over chunk_detail_s length = false, want true")
+	}
+}
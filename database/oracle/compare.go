@@ -144,12 +144,24 @@ func (o *Oracle) GetOracleTableActualRows(oraQuery string) (int64, error) {
 }
 
 func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set, uint32, error) {
+	cols, stringSet, crc32Value, _, err := o.getOracleDataRowStrings(querySQL, true)
+	return cols, stringSet, crc32Value, err
+}
+
+// GetOracleDataRowCRC32 流式聚合数据行数以及 CRC32，不缓存行数据，用于无主键/唯一键表全表对比
+func (o *Oracle) GetOracleDataRowCRC32(querySQL string) (int64, uint32, error) {
+	_, _, crc32Value, rowCounts, err := o.getOracleDataRowStrings(querySQL, false)
+	return rowCounts, crc32Value, err
+}
+
+func (o *Oracle) getOracleDataRowStrings(querySQL string, keepRows bool) ([]string, *strset.Set, uint32, int64, error) {
 	var (
-		cols     []string
-		rowsTMP  []string
-		rows     *sql.Rows
-		err      error
-		crc32SUM uint32
+		cols      []string
+		rowsTMP   []string
+		rows      *sql.Rows
+		err       error
+		crc32SUM  uint32
+		rowCounts int64
 	)
 
 	var crc32Value uint32 = 0
@@ -158,7 +170,7 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 
 	rows, err = o.OracleDB.Query(querySQL)
 	if err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
 	}
 
 	defer rows.Close()
@@ -167,7 +179,7 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 	var columnTypes []string
 	colTypes, err := rows.ColumnTypes()
 	if err != nil {
-		return cols, stringSet, crc32Value, err
+		return cols, stringSet, crc32Value, rowCounts, err
 	}
 
 	for _, ct := range colTypes {
@@ -178,7 +190,7 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 	//不确定字段通用查询，自动获取字段名称
 	cols, err = rows.Columns()
 	if err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Columns failed: [%v]", querySQL, err.Error())
 	}
 
 	rawResult := make([][]byte, len(cols))
@@ -190,7 +202,7 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 	for rows.Next() {
 		err = rows.Scan(scans...)
 		if err != nil {
-			return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+			return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
 		}
 
 		for i, raw := range rawResult {
@@ -204,48 +216,48 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 				case "int64":
 					r, err := common.StrconvIntBitSize(string(raw), 64)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "uint64":
 					r, err := common.StrconvUintBitSize(string(raw), 64)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "float32":
 					r, err := common.StrconvFloatBitSize(string(raw), 32)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "float64":
 					r, err := common.StrconvFloatBitSize(string(raw), 64)
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "rune":
 					r, err := common.StrconvRune(string(raw))
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
 				case "godror.Number":
 					r, err := decimal.NewFromString(string(raw))
 					if err != nil {
-						return cols, stringSet, crc32Value, err
+						return cols, stringSet, crc32Value, rowCounts, err
 					}
 					if r.IsInteger() {
 						si, err := common.StrconvIntBitSize(string(raw), 64)
 						if err != nil {
-							return cols, stringSet, crc32Value, err
+							return cols, stringSet, crc32Value, rowCounts, err
 						}
 						rowsTMP = append(rowsTMP, fmt.Sprintf("%v", si))
 					} else {
 						rf, err := common.StrconvFloatBitSize(string(raw), 64)
 						if err != nil {
-							return cols, stringSet, crc32Value, err
+							return cols, stringSet, crc32Value, rowCounts, err
 						}
 						rowsTMP = append(rowsTMP, fmt.Sprintf("%v", rf))
 					}
@@ -260,15 +272,18 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 
 		// 计算 CRC32
 		crc32SUM = atomic.AddUint32(&crc32Value, crc32.ChecksumIEEE([]byte(rowS)))
-		stringSet.Add(rowS)
+		rowCounts++
+		if keepRows {
+			stringSet.Add(rowS)
+		}
 
 		// 数组清空
 		rowsTMP = rowsTMP[0:0]
 	}
 
 	if err = rows.Err(); err != nil {
-		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+		return cols, stringSet, crc32Value, rowCounts, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
 	}
 
-	return cols, stringSet, crc32SUM, err
+	return cols, stringSet, crc32SUM, rowCounts, err
 }
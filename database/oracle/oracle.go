@@ -23,6 +23,7 @@ import (
 	"github.com/godror/godror/dsn"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/filter"
 	"runtime"
 	"strconv"
 	"strings"
@@ -135,6 +136,9 @@ func NewOracleLogminerEngine(ctx context.Context, oraCfg config.OracleConfig) (*
 
 	oraDSN.Username, oraDSN.Password = oraCfg.Username, godror.NewPassword(oraCfg.Password)
 
+	// 固定 TIMESTAMP WITH TIME ZONE 输出格式，用于 Logminer SQL 时区转换
+	oraCfg.SessionParams = append(oraCfg.SessionParams, fmt.Sprintf(`ALTER SESSION SET NLS_TIMESTAMP_TZ_FORMAT = '%s'`, common.OracleNLSTimestampTZFormat))
+
 	// 关闭外部认证
 	oraDSN.ExternalAuth = false
 	oraDSN.OnInitStmts = oraCfg.SessionParams
@@ -258,3 +262,101 @@ func (o *Oracle) GetOracleSchemaTable(schemaName string) ([]string, error) {
 
 	return tables, nil
 }
+
+// 表过滤对象，按过滤规则引用的对象属性查询数据字典
+func (o *Oracle) GetOracleSchemaFilterTable(schemaName string, tables []string, properties []string) ([]filter.Table, error) {
+	var (
+		sizes       map[string]float64
+		iots        map[string]bool
+		pks         map[string]bool
+		partitions  map[string]bool
+		filterTable []filter.Table
+		err         error
+	)
+	for _, p := range properties {
+		switch p {
+		case filter.PropertySize:
+			// 表大小，包含分区、子分区以及 IOT 索引段
+			_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT TABLE_NAME, SUM(BYTES) AS BYTES
+  FROM (SELECT s.segment_name AS TABLE_NAME, s.bytes AS BYTES
+          FROM DBA_SEGMENTS s
+         WHERE UPPER(s.owner) = UPPER('%[1]s')
+           AND s.segment_type IN ('TABLE', 'TABLE PARTITION', 'TABLE SUBPARTITION')
+        UNION ALL
+        SELECT i.table_name AS TABLE_NAME, s.bytes AS BYTES
+          FROM DBA_INDEXES i, DBA_SEGMENTS s
+         WHERE s.owner = i.owner
+           AND s.segment_name = i.index_name
+           AND UPPER(i.owner) = UPPER('%[1]s')
+           AND i.index_type = 'IOT - TOP')
+ GROUP BY TABLE_NAME`, schemaName))
+			if err != nil {
+				return filterTable, err
+			}
+			sizes = make(map[string]float64)
+			for _, r := range res {
+				size, err := strconv.ParseFloat(r["BYTES"], 64)
+				if err != nil {
+					return filterTable, fmt.Errorf("oracle schema [%s] table [%s] size [%s] strconv.ParseFloat failed: %v", schemaName, r["TABLE_NAME"], r["BYTES"], err)
+				}
+				sizes[strings.ToUpper(r["TABLE_NAME"])] = size
+			}
+		case filter.PropertyIOT:
+			iots, err = o.queryOracleSchemaTableName(fmt.Sprintf(`SELECT table_name AS TABLE_NAME FROM DBA_TABLES WHERE UPPER(owner) = UPPER('%s') AND IOT_TYPE = 'IOT'`, schemaName))
+			if err != nil {
+				return filterTable, err
+			}
+		case filter.PropertyPK:
+			pks, err = o.queryOracleSchemaTableName(fmt.Sprintf(`SELECT table_name AS TABLE_NAME FROM DBA_CONSTRAINTS WHERE UPPER(owner) = UPPER('%s') AND CONSTRAINT_TYPE = 'P'`, schemaName))
+			if err != nil {
+				return filterTable, err
+			}
+		case filter.PropertyPartition:
+			partitions, err = o.queryOracleSchemaTableName(fmt.Sprintf(`SELECT table_name AS TABLE_NAME FROM DBA_TABLES WHERE UPPER(owner) = UPPER('%s') AND PARTITIONED = 'YES'`, schemaName))
+			if err != nil {
+				return filterTable, err
+			}
+		}
+	}
+
+	for _, t := range tables {
+		filterTable = append(filterTable, filter.Table{
+			Schema:      strings.ToUpper(schemaName),
+			Name:        t,
+			SizeBytes:   sizes[strings.ToUpper(t)],
+			IsIOT:       iots[strings.ToUpper(t)],
+			HasPK:       pks[strings.ToUpper(t)],
+			IsPartition: partitions[strings.ToUpper(t)],
+		})
+	}
+	return filterTable, nil
+}
+
+func (o *Oracle) queryOracleSchemaTableName(querySQL string) (map[string]bool, error) {
+	tables := make(map[string]bool)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return tables, err
+	}
+	for _, r := range res {
+		tables[strings.ToUpper(r["TABLE_NAME"])] = true
+	}
+	return tables, nil
+}
+
+// 源端 schema 表字段，table -> column，用于规则文件校验表以及字段是否存在
+func (o *Oracle) GetOracleSchemaTableColumnName(schemaName string) (map[string]map[string]bool, error) {
+	tableColumns := make(map[string]map[string]bool)
+	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT table_name AS TABLE_NAME, column_name AS COLUMN_NAME FROM DBA_TAB_COLUMNS WHERE UPPER(owner) = UPPER('%s')`, schemaName))
+	if err != nil {
+		return tableColumns, err
+	}
+	for _, r := range res {
+		tableName := strings.ToUpper(r["TABLE_NAME"])
+		if _, ok := tableColumns[tableName]; !ok {
+			tableColumns[tableName] = make(map[string]bool)
+		}
+		tableColumns[tableName][strings.ToUpper(r["COLUMN_NAME"])] = true
+	}
+	return tableColumns, nil
+}
@@ -16,19 +16,42 @@ limitations under the License.
 package filter
 
 // 表过滤接口
+// 规则按配置顺序求值，最后一条匹配的规则生效，! 开头规则匹配表示不匹配（取反），无规则匹配表示不匹配
 type Filter interface {
-	// MatchTable 检查表是否匹配
+	// MatchTable 检查表是否匹配，不含 schema 以及对象属性
 	MatchTable(table string) bool
+	// Match 检查表对象是否匹配
+	Match(table Table) bool
+	// Properties 规则引用的对象属性，调用方据此加载 Table 对象属性
+	Properties() []string
+}
+
+// 过滤表对象
+type Table struct {
+	Schema string
+	Name   string
+	// 对象属性
+	SizeBytes   float64
+	IsIOT       bool
+	HasPK       bool
+	IsPartition bool
 }
 
 // tableFilter Filter 接口具体实现
 type tableFilter []tableRule
 
 // Parse 序列化 tableFilter 规则列表的 tableFilter
-func Parse(args []string) (Filter, error) {
+// legacy 兼容历史规则格式，仅表名通配符，'.' 为任意单个字符，不支持 schema、取反、正则表达式以及对象属性
+func Parse(args []string, legacy bool) (Filter, error) {
 	p := tableRulesParser{make([]tableRule, 0, len(args))}
 
 	for _, arg := range args {
+		if legacy {
+			if err := p.parseLegacy(arg); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		if err := p.parse(arg); err != nil {
 			return nil, err
 		}
@@ -39,8 +62,35 @@ func Parse(args []string) (Filter, error) {
 
 // MatchTable 检查应用 tableFilter `f` 是否匹配
 func (f tableFilter) MatchTable(table string) bool {
+	return f.Match(Table{Name: table})
+}
+
+// Match 检查应用 tableFilter `f` 是否匹配，逆序求值，最后一条匹配规则生效
+func (f tableFilter) Match(table Table) bool {
+	for i := len(f) - 1; i >= 0; i-- {
+		if f[i].match(table) {
+			return f[i].positive
+		}
+	}
+	return false
+}
+
+// Properties 规则引用的对象属性
+func (f tableFilter) Properties() []string {
+	var props []string
 	for _, rule := range f {
-		if rule.table.matchString(table) {
+		for _, p := range rule.properties {
+			if !containsString(props, p.property()) {
+				props = append(props, p.property())
+			}
+		}
+	}
+	return props
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
 			return true
 		}
 	}
@@ -0,0 +1,226 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantRest string
+		match    []string
+		notMatch []string
+		wantErr  bool
+	}{
+		{name: "literal", line: "marvin01", match: []string{"MARVIN01", "marvin01"}, notMatch: []string{"MARVIN010", "MARVIN0"}},
+		{name: "star", line: "marvin*", match: []string{"MARVIN", "MARVIN01"}, notMatch: []string{"XMARVIN"}},
+		{name: "only star", line: "*", match: []string{"", "ANY"}},
+		{name: "question", line: "marvin0?", match: []string{"MARVIN01"}, notMatch: []string{"MARVIN0", "MARVIN011"}},
+		{name: "range", line: "t[a-c]", match: []string{"TA", "tc"}, notMatch: []string{"TD"}},
+		{name: "negative range", line: "t[!a-c]", match: []string{"TD"}, notMatch: []string{"TA"}},
+		{name: "caret range", line: "t[^a]", match: []string{"T^", "TA"}, notMatch: []string{"TB"}},
+		{name: "escape", line: `t\*1`, match: []string{"T*1"}, notMatch: []string{"TX1"}},
+		{name: "escape dot", line: `t\.1`, match: []string{"T.1"}, notMatch: []string{"TX1"}},
+		{name: "dollar and multibyte", line: "t$表", match: []string{"T$表"}},
+		{name: "stop at dot", line: "marvin.tab", wantRest: ".tab", match: []string{"MARVIN"}},
+		{name: "stop at property", line: "tab*@pk", wantRest: "@pk", match: []string{"TAB1"}},
+		{name: "empty", line: ".tab", wantErr: true},
+		{name: "missing escaped character", line: `t\`, wantErr: true},
+		{name: "invalid range", line: "t[", wantErr: true},
+		{name: "special character", line: "t-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &tableRulesParser{}
+			m, rest, err := p.parsePattern(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePattern() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if rest != tt.wantRest {
+				t.Errorf("parsePattern() rest = %v, want %v", rest, tt.wantRest)
+			}
+			for _, s := range tt.match {
+				if !m.matchString(s) {
+					t.Errorf("parsePattern() [%s] not match [%s], want match", tt.line, s)
+				}
+			}
+			for _, s := range tt.notMatch {
+				if m.matchString(s) {
+					t.Errorf("parsePattern() [%s] match [%s], want not match", tt.line, s)
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		wantErr bool
+	}{
+		{name: "table", rules: []string{"marvin*"}},
+		{name: "schema table", rules: []string{"marvin.tab*"}},
+		{name: "negation", rules: []string{"*", "!tmp_*"}},
+		{name: "regexp", rules: []string{`/^t_\d+$/`}},
+		{name: "regexp with slash", rules: []string{`/a\/b/`}},
+		{name: "properties", rules: []string{"*@size>=10G@no-pk"}},
+		{name: "only properties", rules: []string{"@iot"}},
+		{name: "empty", rules: []string{""}, wantErr: true},
+		{name: "only negation", rules: []string{"!"}, wantErr: true},
+		{name: "too many names", rules: []string{"a.b.c"}, wantErr: true},
+		{name: "missing table", rules: []string{"marvin."}, wantErr: true},
+		{name: "missing table before property", rules: []string{"marvin.@pk"}, wantErr: true},
+		{name: "regexp unterminated", rules: []string{"/abc"}, wantErr: true},
+		{name: "regexp invalid", rules: []string{"/a(/"}, wantErr: true},
+		{name: "unknown property", rules: []string{"*@lob"}, wantErr: true},
+		{name: "invalid size property", rules: []string{"*@size>10X"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.rules, false); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	const gb = 1 << 30
+	tests := []struct {
+		name  string
+		rules []string
+		table Table
+		want  bool
+	}{
+		{name: "no rule", rules: nil, table: Table{Schema: "S", Name: "T"}, want: false},
+		{name: "table", rules: []string{"t*"}, table: Table{Schema: "S", Name: "T1"}, want: true},
+		{name: "schema match", rules: []string{"s.t*"}, table: Table{Schema: "S", Name: "T1"}, want: true},
+		{name: "schema not match", rules: []string{"x.t*"}, table: Table{Schema: "S", Name: "T1"}, want: false},
+		{name: "schema wildcard", rules: []string{"s*.t1"}, table: Table{Schema: "S01", Name: "T1"}, want: true},
+		{name: "negation last wins", rules: []string{"*", "!tmp_*"}, table: Table{Name: "TMP_1"}, want: false},
+		{name: "negation not matched", rules: []string{"*", "!tmp_*"}, table: Table{Name: "T1"}, want: true},
+		{name: "positive after negation", rules: []string{"*", "!tmp_*", "tmp_keep"}, table: Table{Name: "TMP_KEEP"}, want: true},
+		{name: "regexp ignore case", rules: []string{`/^t_\d+$/`}, table: Table{Name: "T_12"}, want: true},
+		{name: "regexp not match", rules: []string{`/^t_\d+$/`}, table: Table{Name: "T_1A"}, want: false},
+		{name: "regexp slash", rules: []string{`/^a\/b$/`}, table: Table{Name: "A/B"}, want: true},
+		{name: "size greater", rules: []string{"*@size>10G"}, table: Table{Name: "T", SizeBytes: 11 * gb}, want: true},
+		{name: "size not greater", rules: []string{"*@size>10G"}, table: Table{Name: "T", SizeBytes: 10 * gb}, want: false},
+		{name: "size greater equal", rules: []string{"*@size>=10"}, table: Table{Name: "T", SizeBytes: 10 * gb}, want: true},
+		{name: "size less megabyte", rules: []string{"*@size<512MB"}, table: Table{Name: "T", SizeBytes: 1 << 20}, want: true},
+		{name: "size equal", rules: []string{"*@size=1K"}, table: Table{Name: "T", SizeBytes: 1024}, want: true},
+		{name: "iot", rules: []string{"*@iot"}, table: Table{Name: "T", IsIOT: true}, want: true},
+		{name: "no pk", rules: []string{"*@no-pk"}, table: Table{Name: "T", HasPK: true}, want: false},
+		{name: "partition and pk", rules: []string{"t*@partition@pk"}, table: Table{Name: "T1", IsPartition: true, HasPK: true}, want: true},
+		{name: "partition without pk", rules: []string{"t*@partition@pk"}, table: Table{Name: "T1", IsPartition: true}, want: false},
+		{name: "negation with property", rules: []string{"*", "!*@size>1G"}, table: Table{Name: "T", SizeBytes: 2 * gb}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.rules, false)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := f.Match(tt.table); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterProperties(t *testing.T) {
+	f, err := Parse([]string{"*@size>1G@pk", "!t*@no-pk", "x@iot"}, false)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []string{PropertySize, PropertyPK, PropertyIOT}
+	if got := f.Properties(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Properties() = %v, want %v", got, want)
+	}
+}
+
+// 历史规则格式回归，table-filter-version = 1 与升级前行为保持一致
+func TestParseLegacy(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []string
+		match    []string
+		notMatch []string
+		wantErr  bool
+	}{
+		{name: "literal", rules: []string{"marvin01"}, match: []string{"MARVIN01"}, notMatch: []string{"MARVIN011"}},
+		{name: "dot any character", rules: []string{"tab.1"}, match: []string{"TAB_1", "TABX1"}, notMatch: []string{"TAB1", "TAB_11"}},
+		{name: "dot star", rules: []string{"tab.*"}, match: []string{"TAB1", "TAB_12"}, notMatch: []string{"TAB", "XTAB1"}},
+		{name: "star", rules: []string{"marvin*"}, match: []string{"MARVIN01"}, notMatch: []string{"XMARVIN"}},
+		{name: "question", rules: []string{"marvin0?"}, match: []string{"MARVIN01"}, notMatch: []string{"MARVIN011"}},
+		{name: "range", rules: []string{"t[!a-c]"}, match: []string{"TD"}, notMatch: []string{"TA"}},
+		{name: "backslash regexp escape", rules: []string{`tab\.1`}, match: []string{"TAB.1"}, notMatch: []string{"TAB_1"}},
+		{name: "multiple rules", rules: []string{"a*", "b*"}, match: []string{"A1", "B1"}, notMatch: []string{"C1"}},
+		{name: "negation unsupported", rules: []string{"!tab"}, wantErr: true},
+		{name: "property unsupported", rules: []string{"tab@pk"}, wantErr: true},
+		{name: "regexp unsupported", rules: []string{"/tab/"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.rules, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, s := range tt.match {
+				if !f.MatchTable(s) {
+					t.Errorf("MatchTable() %v not match [%s], want match", tt.rules, s)
+				}
+			}
+			for _, s := range tt.notMatch {
+				if f.MatchTable(s) {
+					t.Errorf("MatchTable() %v match [%s], want not match", tt.rules, s)
+				}
+			}
+		})
+	}
+}
+
+// 历史规则 '.' 在当前规则格式下为 schema 与表名分隔符，schema 不匹配规则不生效
+func TestParseLegacyDotChanged(t *testing.T) {
+	legacy, err := Parse([]string{"tab.1"}, true)
+	if err != nil {
+		t.Fatalf("Parse() legacy error = %v", err)
+	}
+	current, err := Parse([]string{"tab.1"}, false)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	table := Table{Schema: "MARVIN", Name: "TAB_1"}
+	if !legacy.Match(table) {
+		t.Errorf("legacy Match() = false, want true")
+	}
+	if current.Match(table) {
+		t.Errorf("Match() = true, want false")
+	}
+	if !current.Match(Table{Schema: "TAB", Name: "1"}) {
+		t.Errorf("Match() schema [TAB] table [1] = false, want true")
+	}
+}
@@ -24,8 +24,27 @@ import (
 // 表过滤器的表过滤规则
 // 过滤匹配成功是接受(positive)
 // 过滤匹配不成功是拒绝(negative)
+// schema 为空表示匹配任意 schema，properties 全部满足才匹配
 type tableRule struct {
-	table matcher
+	schema     matcher
+	table      matcher
+	properties []propertyMatcher
+	positive   bool
+}
+
+func (r tableRule) match(t Table) bool {
+	if r.schema != nil && !r.schema.matchString(t.Schema) {
+		return false
+	}
+	if !r.table.matchString(t.Name) {
+		return false
+	}
+	for _, p := range r.properties {
+		if !p.matchTable(t) {
+			return false
+		}
+	}
+	return true
 }
 
 // matcher 表规则过滤接口
@@ -70,3 +89,65 @@ func newRegexpMatcher(pat string) (matcher, error) {
 func (m regexpMatcher) matchString(name string) bool {
 	return m.pattern.MatchString(name)
 }
+
+// 对象属性
+const (
+	PropertySize      = "size"
+	PropertyIOT       = "iot"
+	PropertyPK        = "pk"
+	PropertyPartition = "partition"
+)
+
+// propertyMatcher 对象属性匹配接口
+type propertyMatcher interface {
+	property() string
+	matchTable(t Table) bool
+}
+
+// sizeMatcher 表大小匹配器，比如 @size>10G
+type sizeMatcher struct {
+	op    string
+	bytes float64
+}
+
+func (m sizeMatcher) property() string {
+	return PropertySize
+}
+
+func (m sizeMatcher) matchTable(t Table) bool {
+	switch m.op {
+	case ">":
+		return t.SizeBytes > m.bytes
+	case ">=":
+		return t.SizeBytes >= m.bytes
+	case "<":
+		return t.SizeBytes < m.bytes
+	case "<=":
+		return t.SizeBytes <= m.bytes
+	default:
+		return t.SizeBytes == m.bytes
+	}
+}
+
+// boolMatcher 布尔对象属性匹配器，比如 @iot、@no-pk
+type boolMatcher struct {
+	prop string
+	want bool
+}
+
+func (m boolMatcher) property() string {
+	return m.prop
+}
+
+func (m boolMatcher) matchTable(t Table) bool {
+	switch m.prop {
+	case PropertyIOT:
+		return t.IsIOT == m.want
+	case PropertyPK:
+		return t.HasPK == m.want
+	case PropertyPartition:
+		return t.IsPartition == m.want
+	default:
+		return false
+	}
+}
@@ -17,7 +17,9 @@ package filter
 
 import (
 	"fmt"
+	"go.uber.org/zap"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -25,24 +27,137 @@ type tableRulesParser struct {
 	rules []tableRule
 }
 
-func (p *tableRulesParser) parse(pat string) error {
-	var tm matcher
-	tm, err := p.parsePattern(pat)
-	if err != nil {
-		return err
+// 规则格式 [!][schema.]table[@property...]
+// schema、table 支持通配符（*、?、[...]）以及 /正则表达式/，\ 转义特殊字符
+// property 支持 size>10G（>、>=、<、<=、=，单位 K/M/G/T，默认 G）、iot、pk、partition，布尔属性 no- 前缀取反，比如 no-pk
+func (p *tableRulesParser) parse(line string) error {
+	line = strings.TrimSpace(line)
+	origin := line
+	rule := tableRule{positive: true}
+	if strings.HasPrefix(line, "!") {
+		rule.positive = false
+		line = strings.TrimSpace(line[1:])
+	}
+	if line == "" {
+		return fmt.Errorf("syntax error: table filter rule cannot be empty")
 	}
 
-	p.rules = append(p.rules, tableRule{
-		table: tm,
-	})
+	// 对象名称规则
+	var names []matcher
+	for line != "" && line[0] != '@' {
+		m, rest, err := p.parseName(line)
+		if err != nil {
+			return err
+		}
+		names = append(names, m)
+		line = rest
+		if line != "" && line[0] == '.' {
+			if len(names) == 2 {
+				return fmt.Errorf("syntax error: table filter rule only support [schema.]table")
+			}
+			line = line[1:]
+			if line == "" || line[0] == '@' {
+				return fmt.Errorf("syntax error: missing table pattern after '.'")
+			}
+		}
+	}
+	switch len(names) {
+	case 0:
+		rule.table = trueMatcher{}
+	case 1:
+		rule.table = names[0]
+	default:
+		rule.schema, rule.table = names[0], names[1]
+		// 历史版本 '.' 为任意单个字符，升级后规则含义变化，schema 不匹配规则不生效
+		zap.L().Warn("table filter rule '.' is schema and table separator, rule only takes effect on matched schema",
+			zap.String("rule", origin),
+			zap.String("tips", "legacy '.' any single character rule please use '?' instead, or set [app] table-filter-version = 1"))
+	}
+
+	// 对象属性规则
+	if line != "" {
+		for _, prop := range strings.Split(line[1:], "@") {
+			pm, err := parseProperty(strings.TrimSpace(prop))
+			if err != nil {
+				return err
+			}
+			rule.properties = append(rule.properties, pm)
+		}
+	}
+
+	p.rules = append(p.rules, rule)
 	return nil
 }
 
+// parseName 解析对象名称规则，返回未解析部分
+func (p *tableRulesParser) parseName(line string) (matcher, string, error) {
+	if line[0] != '/' {
+		return p.parsePattern(line)
+	}
+
+	// 正则表达式 /.../，\/ 转义
+	var patternBuilder strings.Builder
+	for i := 1; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '/':
+			patternBuilder.WriteByte('/')
+			i++
+		case line[i] == '/':
+			pattern, err := regexp.Compile("(?i)" + patternBuilder.String())
+			if err != nil {
+				return nil, "", fmt.Errorf("syntax error: regexp [%s] compile failed: %v", patternBuilder.String(), err)
+			}
+			return regexpMatcher{pattern: pattern}, line[i+1:], nil
+		default:
+			patternBuilder.WriteByte(line[i])
+		}
+	}
+	return nil, "", fmt.Errorf("syntax error: regexp [%s] missing terminating '/'", line)
+}
+
+var (
+	sizePropertyRegexp = regexp.MustCompile(`(?i)^size\s*(>=|<=|>|<|=)\s*([0-9]+(?:\.[0-9]+)?)\s*([KMGT]?)B?$`)
+	sizeUnits          = map[string]float64{
+		"K": 1 << 10,
+		"M": 1 << 20,
+		"G": 1 << 30,
+		"T": 1 << 40,
+	}
+)
+
+func parseProperty(prop string) (propertyMatcher, error) {
+	if m := sizePropertyRegexp.FindStringSubmatch(prop); m != nil {
+		size, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("syntax error: property [%s] size parse failed: %v", prop, err)
+		}
+		unit := strings.ToUpper(m[3])
+		if unit == "" {
+			unit = "G"
+		}
+		return sizeMatcher{op: m[1], bytes: size * sizeUnits[unit]}, nil
+	}
+
+	want := true
+	name := strings.ToLower(prop)
+	if strings.HasPrefix(name, "no-") {
+		want = false
+		name = strings.TrimPrefix(name, "no-")
+	}
+	switch name {
+	case PropertyIOT, PropertyPK, PropertyPartition:
+		return boolMatcher{prop: name, want: want}, nil
+	default:
+		return nil, fmt.Errorf("syntax error: property [%s] isn't support, support property [size iot pk partition]", prop)
+	}
+}
+
 var (
 	wildcardRangeRegexp = regexp.MustCompile(`^\[!?(?:\\[^0-9a-zA-Z]|[^\\\]])+\]`)
 )
 
-func (p *tableRulesParser) parsePattern(line string) (matcher, error) {
+// parsePattern 解析通配符规则，遇到 '.' 或者 '@' 结束，返回未解析部分
+func (p *tableRulesParser) parsePattern(line string) (matcher, string, error) {
 	var (
 		literalStringBuilder   strings.Builder
 		wildcardPatternBuilder strings.Builder
@@ -53,17 +168,17 @@ func (p *tableRulesParser) parsePattern(line string) (matcher, error) {
 	wildcardPatternBuilder.Grow(len(line) + 6)
 	wildcardPatternBuilder.WriteString("(?i)(^|([\\s\\t\\n]+))")
 
-	for i < len(line) {
+	for i < len(line) && line[i] != '.' && line[i] != '@' {
 		c := line[i]
 		switch c {
 		case '\\':
-			isLiteralString = false
-			wildcardPatternBuilder.WriteString("\\")
-			i++
-		case '.':
-			isLiteralString = false
-			wildcardPatternBuilder.WriteString(".")
-			i++
+			// 转义字符，按字面值匹配
+			if i+1 >= len(line) {
+				return nil, "", fmt.Errorf("syntax error: missing escaped character")
+			}
+			literalStringBuilder.WriteByte(line[i+1])
+			wildcardPatternBuilder.WriteString(regexp.QuoteMeta(line[i+1 : i+2]))
+			i += 2
 		case '*':
 			// wildcard
 			isLiteralString = false
@@ -80,7 +195,7 @@ func (p *tableRulesParser) parsePattern(line string) (matcher, error) {
 			isLiteralString = false
 			rangeLoc := wildcardRangeRegexp.FindStringIndex(line[i:])
 			if len(rangeLoc) < 2 {
-				return nil, fmt.Errorf("syntax error: failed to parse character class")
+				return nil, "", fmt.Errorf("syntax error: failed to parse character class")
 			}
 			end := i + rangeLoc[1]
 			switch line[i+1] {
@@ -101,24 +216,103 @@ func (p *tableRulesParser) parsePattern(line string) (matcher, error) {
 				wildcardPatternBuilder.WriteByte(c)
 				i++
 			} else {
-				return nil, fmt.Errorf("unexpected special character '%c'", c)
+				return nil, "", fmt.Errorf("unexpected special character '%c'", c)
 			}
 		}
 	}
 
+	if i == 0 {
+		return nil, "", fmt.Errorf("syntax error: object name pattern cannot be empty")
+	}
 	line = line[i:]
 	if isLiteralString {
-		return stringMatcher(literalStringBuilder.String()), nil
+		return stringMatcher(literalStringBuilder.String()), line, nil
 	}
 	wildcardPatternBuilder.WriteByte('$')
 
 	m, err := newRegexpMatcher(wildcardPatternBuilder.String())
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return m, nil
+	return m, line, nil
 }
 
 func isASCIIAlphanumeric(b byte) bool {
 	return '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
 }
+
+// 历史规则格式，table-filter-version = 1，仅表名通配符，'.' 为任意单个字符，\ 按正则表达式转义
+func (p *tableRulesParser) parseLegacy(line string) error {
+	m, err := p.parseLegacyPattern(line)
+	if err != nil {
+		return err
+	}
+	p.rules = append(p.rules, tableRule{table: m, positive: true})
+	return nil
+}
+
+func (p *tableRulesParser) parseLegacyPattern(line string) (matcher, error) {
+	var (
+		literalStringBuilder   strings.Builder
+		wildcardPatternBuilder strings.Builder
+		isLiteralString        = true
+		i                      = 0
+	)
+	literalStringBuilder.Grow(len(line))
+	wildcardPatternBuilder.Grow(len(line) + 6)
+	wildcardPatternBuilder.WriteString("(?i)(^|([\\s\\t\\n]+))")
+
+	for i < len(line) {
+		c := line[i]
+		switch c {
+		case '\\':
+			isLiteralString = false
+			wildcardPatternBuilder.WriteString("\\")
+			i++
+		case '.':
+			isLiteralString = false
+			wildcardPatternBuilder.WriteString(".")
+			i++
+		case '*':
+			isLiteralString = false
+			wildcardPatternBuilder.WriteString(".*")
+			i++
+		case '?':
+			isLiteralString = false
+			wildcardPatternBuilder.WriteByte('.')
+			i++
+		case '[':
+			isLiteralString = false
+			rangeLoc := wildcardRangeRegexp.FindStringIndex(line[i:])
+			if len(rangeLoc) < 2 {
+				return nil, fmt.Errorf("syntax error: failed to parse character class")
+			}
+			end := i + rangeLoc[1]
+			switch line[i+1] {
+			case '!':
+				wildcardPatternBuilder.WriteString("[^")
+				wildcardPatternBuilder.WriteString(line[i+2 : end])
+			case '^':
+				wildcardPatternBuilder.WriteString(`[\^`)
+				wildcardPatternBuilder.WriteString(line[i+2 : end])
+			default:
+				wildcardPatternBuilder.WriteString(line[i:end])
+			}
+			i = end
+		default:
+			if c == '$' || c == '_' || isASCIIAlphanumeric(c) || c >= 0x80 {
+				literalStringBuilder.WriteByte(c)
+				wildcardPatternBuilder.WriteByte(c)
+				i++
+			} else {
+				return nil, fmt.Errorf("unexpected special character '%c'", c)
+			}
+		}
+	}
+
+	if isLiteralString {
+		return stringMatcher(literalStringBuilder.String()), nil
+	}
+	wildcardPatternBuilder.WriteByte('$')
+	return newRegexpMatcher(wildcardPatternBuilder.String())
+}
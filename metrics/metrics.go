@@ -0,0 +1,190 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const (
+	TypeRead  = "read"
+	TypeWrite = "write"
+
+	StatusSuccess  = "success"
+	StatusFailed   = "failed"
+	StatusMismatch = "mismatch"
+
+	StageCaptured = "captured"
+	StageApplied  = "applied"
+	StageSkipped  = "skipped"
+	StageParked   = "parked"
+)
+
+// full/csv 数据迁移
+var (
+	MigrateRowsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "migrate",
+			Name:      "rows_total",
+			Help:      "Total number of rows read from source or written to target.",
+		}, []string{"mode", "schema", "table", "type"})
+
+	MigrateBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "migrate",
+			Name:      "bytes_total",
+			Help:      "Total number of bytes read from source or written to target.",
+		}, []string{"mode", "schema", "table", "type"})
+
+	MigrateChunkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "transferdb",
+			Subsystem: "migrate",
+			Name:      "chunk_duration_seconds",
+			Help:      "Bucketed histogram of table chunk migrate duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 16),
+		}, []string{"mode", "schema", "table", "status"})
+
+	// 来源于元数据表 [wait_sync_meta]
+	MigrateTableChunks = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "migrate",
+			Name:      "table_chunks",
+			Help:      "Table chunk success and failed counts recorded in meta table wait_sync_meta.",
+		}, []string{"mode", "schema", "table", "status"})
+)
+
+// all 增量同步
+var (
+	IncrEventsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "events_total",
+			Help:      "Total number of increment events captured, applied, skipped or parked.",
+		}, []string{"schema", "table", "operation", "stage"})
+
+	IncrApplyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "apply_duration_seconds",
+			Help:      "Bucketed histogram of increment event apply duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 18),
+		}, []string{"schema", "table"})
+
+	IncrLogminerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "logminer_duration_seconds",
+			Help:      "Bucketed histogram of log file logminer mining duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 16),
+		}, []string{"schema"})
+
+	IncrCheckpointSCN = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "checkpoint_scn",
+			Help:      "Table checkpoint scn recorded in meta table incr_sync_meta.",
+		}, []string{"schema", "table"})
+
+	IncrSCNLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "scn_lag",
+			Help:      "Difference between source current scn and table checkpoint scn.",
+		}, []string{"schema", "table"})
+
+	IncrHeartbeatLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "transferdb",
+			Subsystem: "incr",
+			Name:      "heartbeat_lag_seconds",
+			Help:      "End to end replication lag computed by heartbeat table.",
+		}, []string{"schema"})
+)
+
+// compare 数据校验
+var (
+	CompareChunkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "transferdb",
+			Subsystem: "compare",
+			Name:      "chunk_duration_seconds",
+			Help:      "Bucketed histogram of table chunk compare duration.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 16),
+		}, []string{"schema", "table", "status"})
+
+	CompareMismatchTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "transferdb",
+			Subsystem: "compare",
+			Name:      "mismatch_chunks_total",
+			Help:      "Total number of table chunks whose data isn't equal.",
+		}, []string{"schema", "table"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		MigrateRowsTotal,
+		MigrateBytesTotal,
+		MigrateChunkDuration,
+		MigrateTableChunks,
+		IncrEventsTotal,
+		IncrApplyDuration,
+		IncrLogminerDuration,
+		IncrCheckpointSCN,
+		IncrSCNLag,
+		IncrHeartbeatLag,
+		CompareChunkDuration,
+		CompareMismatchTotal,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// 记录表 chunk 成功以及失败数
+func SetMigrateTableChunks(mode, schema, table string, success, failed int64) {
+	MigrateTableChunks.WithLabelValues(mode, schema, table, StatusSuccess).Set(float64(success))
+	MigrateTableChunks.WithLabelValues(mode, schema, table, StatusFailed).Set(float64(failed))
+}
+
+// 数据值字节数，NULL 记 0
+func ValueBytes(vals ...interface{}) int {
+	var size int
+	for _, v := range vals {
+		switch val := v.(type) {
+		case nil:
+		case string:
+			size += len(val)
+		case []byte:
+			size += len(val)
+		default:
+			size += 8
+		}
+	}
+	return size
+}
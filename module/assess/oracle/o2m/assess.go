@@ -21,8 +21,10 @@ import (
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
 	"github.com/wentaojin/transferdb/module/assess/oracle/public"
+	migrate "github.com/wentaojin/transferdb/module/migrate/sql/oracle/o2m"
 	"go.uber.org/zap"
 	"os"
 	"path/filepath"
@@ -42,7 +44,7 @@ func NewAssess(ctx context.Context, cfg *config.Config) (*Assess, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -153,6 +155,21 @@ func (r *Assess) Assess() error {
 	if err != nil {
 		return err
 	}
+
+	// 评估结果记录，用于多次评估对比，以及不兼容对象改造工作量评估
+	assessID := startTime.Format(common.AssessIDTimeFormat)
+	report.ReportEffort, err = public.SaveAssessResult(r.ctx, r.cfg, r.metaDB, assessID, report.ReportCompatible)
+	if err != nil {
+		return err
+	}
+
+	// 采样压测，估算全量迁移耗时以及目标端存储
+	if r.cfg.AssessConfig.Benchmark {
+		report.ReportBenchmark, err = r.benchmark(usernameArray)
+		if err != nil {
+			return err
+		}
+	}
 	finishedTime := time.Now()
 	zap.L().Info("assess database result finish",
 		zap.Strings("schema", usernameArray),
@@ -167,11 +184,39 @@ func (r *Assess) Assess() error {
 		zap.Strings("schema", usernameArray),
 		zap.String("cost", finishHTMLTime.Sub(startHTMLTime).String()))
 
+	resultReport := public.GenAssessReport(r.cfg, report, filepath.Join(pwdDir, fileName), startTime)
+	if r.cfg.ReportConfig.Format != "" {
+		reportFile, err := resultReport.Write(r.cfg.ReportConfig.ReportDir, r.cfg.ReportConfig.Format)
+		if err != nil {
+			return err
+		}
+		zap.L().Info("assess report", zap.String("output", reportFile), zap.String("status", resultReport.Status))
+	}
+
 	endTime := time.Now()
 	zap.L().Info("assess oracle migrate mysql cost finished",
+		zap.String("assess id", assessID),
 		zap.String("cost", endTime.Sub(startTime).String()),
 		zap.String("output", filepath.Join(pwdDir, fileName)))
-	return nil
+	return resultReport.Gate(r.cfg.ReportConfig.FailOn)
+}
+
+func (r *Assess) benchmark(schemaName []string) (*public.ReportBenchmark, error) {
+	mysqlDB, err := mysql.NewMySQLDBEngine(r.ctx, r.cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	return public.GenAssessBenchmark(r.cfg, r.oracle, schemaName, func(schemaName string) public.Benchmarker {
+		cfg := *r.cfg
+		cfg.SchemaConfig.SourceSchema = schemaName
+		return &migrate.Migrate{
+			Ctx:    r.ctx,
+			Cfg:    &cfg,
+			Oracle: r.oracle,
+			Mysql:  mysqlDB,
+			MetaDB: r.metaDB,
+		}
+	})
 }
 
 func GetAssessDatabaseReport(ctx context.Context, metaDB *meta.Meta, oracle *oracle.Oracle, schemaName []string, reportName, reportUser, dbTypeS, dbTypeT string) (*public.Report, error) {
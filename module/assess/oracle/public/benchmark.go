@@ -0,0 +1,207 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"go.uber.org/zap"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Benchmarker 采样压测，由 o2m/o2t 全量迁移实现，复用全量迁移读写链路
+type Benchmarker interface {
+	Benchmark(sourceTable, benchSchema string, numRows int64, sampleRows int, oracleCollation bool) (int64, int64, time.Duration, error)
+}
+
+// GenAssessBenchmark 每个 schema 按段大小选取代表表采样压测，并据此估算全量迁移耗时以及目标端存储
+// 1、耗时 = 估算数据量 / (采样单并发写入速率 * 并发数)，并发数 = min(table-threads * sql-threads, 表 chunk 总数)
+// 2、估算数据量 = 源端统计信息数据量 (NUM_ROWS * AVG_ROW_LEN) * 采样目标端写入字节与源端平均行长比值
+// 3、目标端存储 = 估算数据量 * 副本数 / 压缩比
+// newBenchmarker 以 schema 生成采样压测对象
+func GenAssessBenchmark(cfg *config.Config, oracleDB *oracle.Oracle, schemaName []string, newBenchmarker func(schemaName string) Benchmarker) (*ReportBenchmark, error) {
+	startTime := time.Now()
+	benchSchema := cfg.AssessConfig.BenchmarkSchema
+	if benchSchema == "" {
+		benchSchema = cfg.SchemaConfig.TargetSchema
+	}
+	if benchSchema == "" {
+		return nil, fmt.Errorf("assess config benchmark-schema and schema-config target-schema cannot be empty at the same time when benchmark is enabled")
+	}
+
+	oracleDBVersion, err := oracleDB.GetOracleDBVersion()
+	if err != nil {
+		return nil, err
+	}
+	oracleCollation := false
+	if common.VersionOrdinal(oracleDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		oracleCollation = true
+	}
+
+	tableRowsSize, err := oracleDB.GetOracleSchemaTableRowsSize(schemaName)
+	if err != nil {
+		return nil, err
+	}
+
+	rb := &ReportBenchmark{
+		BenchmarkSchema:  benchSchema,
+		SampleRows:       cfg.AssessConfig.BenchmarkRows,
+		TableThreads:     cfg.FullConfig.TableThreads,
+		SQLThreads:       cfg.FullConfig.SQLThreads,
+		ApplyThreads:     cfg.FullConfig.ApplyThreads,
+		ReplicaFactor:    cfg.AssessConfig.ReplicaFactor,
+		CompressionRatio: strconv.FormatFloat(cfg.AssessConfig.CompressionRatio, 'f', 2, 64),
+	}
+
+	// 结果按 schema 以及段大小倒序
+	var schemas []string
+	schemaTables := make(map[string][]map[string]string)
+	for _, t := range tableRowsSize {
+		if _, ok := schemaTables[t["OWNER"]]; !ok {
+			schemas = append(schemas, t["OWNER"])
+		}
+		schemaTables[t["OWNER"]] = append(schemaTables[t["OWNER"]], t)
+	}
+
+	for _, s := range schemas {
+		var (
+			totalRows, totalChunks       int64
+			sourceBytes, sourceStatBytes float64
+			sampleRows, sampleBytes      int64
+			sampleStatBytes, sampleCost  float64
+			benchTables                  int
+		)
+		b := newBenchmarker(s)
+		for _, t := range schemaTables[s] {
+			numRows, err := strconv.ParseInt(t["NUM_ROWS"], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("oracle schema [%s] table [%s] num_rows [%s] parse failed: %v", s, t["TABLE_NAME"], t["NUM_ROWS"], err)
+			}
+			avgRowLen, err := strconv.ParseFloat(t["AVG_ROW_LEN"], 64)
+			if err != nil {
+				return nil, fmt.Errorf("oracle schema [%s] table [%s] avg_row_len [%s] parse failed: %v", s, t["TABLE_NAME"], t["AVG_ROW_LEN"], err)
+			}
+			segmentBytes, err := strconv.ParseFloat(t["BYTES"], 64)
+			if err != nil {
+				return nil, fmt.Errorf("oracle schema [%s] table [%s] bytes [%s] parse failed: %v", s, t["TABLE_NAME"], t["BYTES"], err)
+			}
+			totalRows += numRows
+			sourceBytes += segmentBytes
+			sourceStatBytes += float64(numRows) * avgRowLen
+			if cfg.FullConfig.ChunkSize > 0 {
+				totalChunks += int64(math.Ceil(float64(numRows) / float64(cfg.FullConfig.ChunkSize)))
+			} else if numRows > 0 {
+				totalChunks++
+			}
+
+			// 代表表，按段大小倒序选取前 benchmark-tables 张非空表
+			if benchTables >= cfg.AssessConfig.BenchmarkTables || numRows == 0 {
+				continue
+			}
+			benchTables++
+			tb := TableBenchmark{
+				Schema:    s,
+				TableName: t["TABLE_NAME"],
+				NumRows:   numRows,
+			}
+			rows, bytes, cost, err := b.Benchmark(t["TABLE_NAME"], benchSchema, numRows, cfg.AssessConfig.BenchmarkRows, oracleCollation)
+			if err != nil {
+				// 单表采样失败不影响评估，记录错误并跳过
+				zap.L().Warn("assess schema table benchmark failed",
+					zap.String("schema", s),
+					zap.String("table", t["TABLE_NAME"]),
+					zap.Error(err))
+				tb.ErrorDetail = err.Error()
+				rb.ListTableBenchmarks = append(rb.ListTableBenchmarks, tb)
+				continue
+			}
+			tb.SampleRows = rows
+			tb.SampleBytes = bytes
+			tb.SampleCost = cost.String()
+			tb.RowsPerSecond = formatRate(float64(rows), cost.Seconds())
+			tb.BytesPerSecond = "-"
+			if cost > 0 {
+				tb.BytesPerSecond = formatSize(float64(bytes)/cost.Seconds()) + "/s"
+			}
+			tb.AvgRowBytes = formatRate(float64(bytes), float64(rows))
+			rb.ListTableBenchmarks = append(rb.ListTableBenchmarks, tb)
+
+			sampleRows += rows
+			sampleBytes += bytes
+			sampleStatBytes += float64(rows) * avgRowLen
+			sampleCost += cost.Seconds()
+		}
+
+		sb := SchemaBenchmark{
+			Schema:            s,
+			TableCounts:       len(schemaTables[s]),
+			TotalRows:         totalRows,
+			SourceSize:        formatSize(sourceBytes),
+			RowsPerSecond:     "-",
+			BytesPerSecond:    "-",
+			EstimatedDuration: "-",
+			TargetStorage:     "-",
+		}
+		sb.Concurrency = cfg.FullConfig.TableThreads * cfg.FullConfig.SQLThreads
+		if totalChunks < int64(sb.Concurrency) {
+			sb.Concurrency = int(totalChunks)
+		}
+
+		if sampleRows > 0 && sampleCost > 0 {
+			// 源端统计信息缺失，以采样平均行字节数估算
+			dataBytes := float64(totalRows) * float64(sampleBytes) / float64(sampleRows)
+			if sampleStatBytes > 0 {
+				dataBytes = sourceStatBytes * float64(sampleBytes) / sampleStatBytes
+			}
+			bytesPerSecond := float64(sampleBytes) / sampleCost
+			sb.RowsPerSecond = formatRate(float64(sampleRows), sampleCost)
+			sb.BytesPerSecond = formatSize(bytesPerSecond) + "/s"
+			if sb.Concurrency > 0 && bytesPerSecond > 0 {
+				sb.EstimatedDuration = time.Duration(dataBytes / (bytesPerSecond * float64(sb.Concurrency)) * float64(time.Second)).Round(time.Second).String()
+			}
+			sb.TargetStorage = formatSize(dataBytes * float64(cfg.AssessConfig.ReplicaFactor) / cfg.AssessConfig.CompressionRatio)
+		}
+		rb.ListSchemaBenchmarks = append(rb.ListSchemaBenchmarks, sb)
+	}
+
+	zap.L().Info("assess database benchmark finished",
+		zap.Strings("schema", schemas),
+		zap.String("benchmark schema", benchSchema),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return rb, nil
+}
+
+func formatRate(value, per float64) string {
+	if per <= 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(value/per, 'f', 2, 64)
+}
+
+func formatSize(bytes float64) string {
+	switch {
+	case bytes >= 1<<30:
+		return strconv.FormatFloat(bytes/(1<<30), 'f', 2, 64) + " GB"
+	case bytes >= 1<<20:
+		return strconv.FormatFloat(bytes/(1<<20), 'f', 2, 64) + " MB"
+	default:
+		return strconv.FormatFloat(bytes/(1<<10), 'f', 2, 64) + " KB"
+	}
+}
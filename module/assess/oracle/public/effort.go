@@ -0,0 +1,407 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenAssessResultMetas 兼容性评估结果按 schema、评估类别、对象展开，并按权重计算不兼容对象改造工作量
+// 权重优先级：[buildin_object_compatible] effort_weight 非 0 -> [assess] 配置默认权重（按是否可改造区分）
+func GenAssessResultMetas(cfg *config.Config, assessID string, rc *ReportCompatible, compatibles []meta.BuildinObjectCompatible) []meta.AssessResultMeta {
+	weights := make(map[string]float64)
+	for _, c := range compatibles {
+		if c.EffortWeight > 0 {
+			weights[common.StringUPPER(c.ObjectNameS)] = c.EffortWeight
+		}
+	}
+
+	var (
+		keys    []string
+		results = make(map[string]*meta.AssessResultMeta)
+	)
+	appendItem := func(schema, assessName, objectName, counts, isCompatible, isConvertible string) {
+		key := common.StringsBuilder(schema, "|", assessName, "|", objectName)
+		objectCounts, _ := strconv.Atoi(strings.TrimSpace(counts))
+		// 同一对象多条记录（比如不同属主视图类型）合并计数
+		if r, ok := results[key]; ok {
+			r.ObjectCounts += objectCounts
+			r.EffortDays = float64(r.ObjectCounts) * r.EffortWeight
+			return
+		}
+		r := &meta.AssessResultMeta{
+			DBTypeS:       cfg.DBTypeS,
+			DBTypeT:       cfg.DBTypeT,
+			AssessID:      assessID,
+			SchemaNameS:   common.StringUPPER(schema),
+			AssessName:    assessName,
+			ObjectNameS:   objectName,
+			ObjectCounts:  objectCounts,
+			IsCompatible:  isCompatible,
+			IsConvertible: isConvertible,
+		}
+		if !strings.EqualFold(isCompatible, common.AssessYesCompatible) {
+			switch {
+			case weights[common.StringUPPER(objectName)] > 0:
+				r.EffortWeight = weights[common.StringUPPER(objectName)]
+			case strings.EqualFold(isConvertible, common.AssessYesConvertible):
+				r.EffortWeight = cfg.AssessConfig.ConvertibleEffort
+			default:
+				r.EffortWeight = cfg.AssessConfig.InConvertibleEffort
+			}
+			r.EffortDays = float64(objectCounts) * r.EffortWeight
+		}
+		keys = append(keys, key)
+		results[key] = r
+	}
+
+	if rc != nil {
+		for _, c := range rc.ListSchemaTableTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameTableTypeCompatible, c.TableType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		// 字段类型不存在内置转换规则视为不兼容且不可改造
+		for _, c := range rc.ListSchemaColumnTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameColumnTypeCompatible, c.ColumnType, c.ObjectCounts, c.IsEquivalent, c.IsEquivalent)
+		}
+		for _, c := range rc.ListSchemaConstraintTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameConstraintTypeCompatible, c.ConstraintType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaIndexTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameIndexTypeCompatible, c.IndexType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaDefaultValueCompatibles {
+			appendItem(c.Schema, common.AssessNameDefaultValueCompatible, c.ColumnDefaultValue, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaViewTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameViewTypeCompatible, c.ViewType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaObjectTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameObjectTypeCompatible, c.ObjectType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaPartitionTypeCompatibles {
+			appendItem(c.Schema, common.AssessNamePartitionTypeCompatible, c.PartitionType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaSubPartitionTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameSubPartitionTypeCompatible, c.SubPartitionType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+		for _, c := range rc.ListSchemaTemporaryTableTypeCompatibles {
+			appendItem(c.Schema, common.AssessNameTemporaryTableTypeCompatible, c.TemporaryTableType, c.ObjectCounts, c.IsCompatible, c.IsConvertible)
+		}
+	}
+
+	var assessResults []meta.AssessResultMeta
+	for _, key := range keys {
+		assessResults = append(assessResults, *results[key])
+	}
+	return assessResults
+}
+
+// GenAssessEffort 按 schema 汇总不兼容对象改造工作量
+func GenAssessEffort(assessID string, assessResults []meta.AssessResultMeta) *ReportEffort {
+	effort := &ReportEffort{AssessID: assessID}
+
+	schemaEfforts := make(map[string]*SchemaEffort)
+	schemaDays := make(map[string]float64)
+	for _, r := range assessResults {
+		if strings.EqualFold(r.IsCompatible, common.AssessYesCompatible) || r.ObjectCounts == 0 {
+			continue
+		}
+		se, ok := schemaEfforts[r.SchemaNameS]
+		if !ok {
+			se = &SchemaEffort{Schema: r.SchemaNameS}
+			schemaEfforts[r.SchemaNameS] = se
+		}
+		se.IncompatibleObjects += r.ObjectCounts
+		if !strings.EqualFold(r.IsConvertible, common.AssessYesConvertible) {
+			se.InconvertibleObjects += r.ObjectCounts
+		}
+		schemaDays[r.SchemaNameS] += r.EffortDays
+
+		effort.ListEffortItems = append(effort.ListEffortItems, EffortItem{
+			Schema:        r.SchemaNameS,
+			AssessName:    r.AssessName,
+			ObjectName:    r.ObjectNameS,
+			ObjectCounts:  r.ObjectCounts,
+			IsConvertible: r.IsConvertible,
+			EffortWeight:  formatEffortDays(r.EffortWeight),
+			EffortDays:    formatEffortDays(r.EffortDays),
+		})
+	}
+
+	for schema, se := range schemaEfforts {
+		se.EffortDays = formatEffortDays(schemaDays[schema])
+		effort.ListSchemaEfforts = append(effort.ListSchemaEfforts, *se)
+	}
+	sort.Slice(effort.ListSchemaEfforts, func(i, j int) bool {
+		return effort.ListSchemaEfforts[i].Schema < effort.ListSchemaEfforts[j].Schema
+	})
+	return effort
+}
+
+// GenAssessDiff 对比两次评估不兼容对象，新增、解决以及数量增减，按评估类别以及 schema 工作量汇总
+func GenAssessDiff(baseAssessID, assessID string, baseResults, assessResults []meta.AssessResultMeta) *ReportDiff {
+	diff := &ReportDiff{
+		BaseAssessID: baseAssessID,
+		AssessID:     assessID,
+	}
+
+	type diffCounts struct {
+		schema        string
+		assessName    string
+		objectName    string
+		baseCounts    int
+		counts        int
+		isConvertible string
+	}
+	var keys []string
+	items := make(map[string]*diffCounts)
+	collect := func(results []meta.AssessResultMeta, isBase bool) {
+		for _, r := range results {
+			if strings.EqualFold(r.IsCompatible, common.AssessYesCompatible) {
+				continue
+			}
+			key := common.StringsBuilder(r.SchemaNameS, "|", r.AssessName, "|", r.ObjectNameS)
+			d, ok := items[key]
+			if !ok {
+				d = &diffCounts{schema: r.SchemaNameS, assessName: r.AssessName, objectName: r.ObjectNameS}
+				items[key] = d
+				keys = append(keys, key)
+			}
+			if isBase {
+				d.baseCounts += r.ObjectCounts
+			} else {
+				d.counts += r.ObjectCounts
+			}
+			if !isBase || d.isConvertible == "" {
+				d.isConvertible = r.IsConvertible
+			}
+		}
+	}
+	collect(baseResults, true)
+	collect(assessResults, false)
+	sort.Strings(keys)
+
+	categoryDiffs := make(map[string]*CategoryDiff)
+	for _, key := range keys {
+		d := items[key]
+		var diffType string
+		switch {
+		case d.baseCounts == d.counts:
+			continue
+		case d.baseCounts == 0:
+			diffType = common.AssessDiffTypeNew
+		case d.counts == 0:
+			diffType = common.AssessDiffTypeResolved
+		case d.baseCounts < d.counts:
+			diffType = common.AssessDiffTypeIncreased
+		default:
+			diffType = common.AssessDiffTypeDecreased
+		}
+
+		cd, ok := categoryDiffs[d.assessName]
+		if !ok {
+			cd = &CategoryDiff{AssessName: d.assessName}
+			categoryDiffs[d.assessName] = cd
+		}
+		switch diffType {
+		case common.AssessDiffTypeNew:
+			cd.NewItems++
+		case common.AssessDiffTypeResolved:
+			cd.ResolvedItems++
+		}
+		if d.counts > d.baseCounts {
+			cd.NewObjects += d.counts - d.baseCounts
+		} else {
+			cd.ResolvedObjects += d.baseCounts - d.counts
+		}
+
+		diff.ListDiffItems = append(diff.ListDiffItems, DiffItem{
+			Schema:        d.schema,
+			AssessName:    d.assessName,
+			ObjectName:    d.objectName,
+			DiffType:      diffType,
+			BaseCounts:    d.baseCounts,
+			ObjectCounts:  d.counts,
+			IsConvertible: d.isConvertible,
+		})
+	}
+	for _, cd := range categoryDiffs {
+		diff.ListCategoryDiffs = append(diff.ListCategoryDiffs, *cd)
+	}
+	sort.Slice(diff.ListCategoryDiffs, func(i, j int) bool {
+		return diff.ListCategoryDiffs[i].AssessName < diff.ListCategoryDiffs[j].AssessName
+	})
+
+	var schemas []string
+	baseDays := make(map[string]float64)
+	days := make(map[string]float64)
+	for _, r := range baseResults {
+		baseDays[r.SchemaNameS] += r.EffortDays
+	}
+	for _, r := range assessResults {
+		days[r.SchemaNameS] += r.EffortDays
+	}
+	for s := range baseDays {
+		schemas = append(schemas, s)
+	}
+	for s := range days {
+		if _, ok := baseDays[s]; !ok {
+			schemas = append(schemas, s)
+		}
+	}
+	sort.Strings(schemas)
+	for _, s := range schemas {
+		diff.ListSchemaEffortDiffs = append(diff.ListSchemaEffortDiffs, SchemaEffortDiff{
+			Schema:         s,
+			BaseEffortDays: formatEffortDays(baseDays[s]),
+			EffortDays:     formatEffortDays(days[s]),
+			DeltaDays:      formatEffortDays(days[s] - baseDays[s]),
+		})
+	}
+	return diff
+}
+
+// SaveAssessResult 记录本次评估兼容性结果至 [assess_result_meta]，并返回改造工作量评估
+func SaveAssessResult(ctx context.Context, cfg *config.Config, metaDB *meta.Meta, assessID string, rc *ReportCompatible) (*ReportEffort, error) {
+	compatibles, err := meta.NewBuildinObjectCompatibleModel(metaDB).BatchQueryObjAssessCompatible(ctx, &meta.BuildinObjectCompatible{
+		DBTypeS: cfg.DBTypeS,
+		DBTypeT: cfg.DBTypeT,
+	})
+	if err != nil {
+		return nil, err
+	}
+	assessResults := GenAssessResultMetas(cfg, assessID, rc, compatibles)
+
+	batchSize := cfg.AppConfig.InsertBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if err = meta.NewAssessResultMetaModel(metaDB).BatchCreateAssessResultMeta(ctx, assessResults, batchSize); err != nil {
+		return nil, err
+	}
+	return GenAssessEffort(assessID, assessResults), nil
+}
+
+// AssessList 输出评估编号列表，最近评估在前
+func AssessList(ctx context.Context, cfg *config.Config, metaDB *meta.Meta) error {
+	assessIDs, err := meta.NewAssessResultMetaModel(metaDB).ListAssessID(ctx, &meta.AssessResultMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+	zap.L().Info("assess list",
+		zap.String("task", cfg.AppConfig.TaskName),
+		zap.String("schema", cfg.SchemaConfig.SourceSchema),
+		zap.Strings("assess id", assessIDs))
+	return nil
+}
+
+// AssessDiff 对比两次评估结果并输出 HTML 对比报告
+// 未指定 -assess-id 以最近一次评估为准，未指定 -base-assess-id 以 assess-id 前一次评估为准
+func AssessDiff(ctx context.Context, cfg *config.Config, metaDB *meta.Meta) error {
+	assessIDs, err := meta.NewAssessResultMetaModel(metaDB).ListAssessID(ctx, &meta.AssessResultMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+
+	assessID, baseAssessID := cfg.AssessID, cfg.BaseAssessID
+	if assessID == "" {
+		if len(assessIDs) == 0 {
+			return fmt.Errorf("assess task [%s] schema [%s] result record isn't exist, please run mode assess first", cfg.AppConfig.TaskName, cfg.SchemaConfig.SourceSchema)
+		}
+		assessID = assessIDs[0]
+	} else if !common.IsContainString(assessIDs, assessID) {
+		return fmt.Errorf("flag [assess-id] value [%s] isn't exist, exist assess id [%v]", assessID, assessIDs)
+	}
+	if baseAssessID == "" {
+		// 评估编号倒序，取 assess-id 前一次评估
+		for i, id := range assessIDs {
+			if id == assessID && i+1 < len(assessIDs) {
+				baseAssessID = assessIDs[i+1]
+			}
+		}
+		if baseAssessID == "" {
+			return fmt.Errorf("assess id [%s] previous assess record isn't exist, please specify flag [base-assess-id]", assessID)
+		}
+	} else if !common.IsContainString(assessIDs, baseAssessID) {
+		return fmt.Errorf("flag [base-assess-id] value [%s] isn't exist, exist assess id [%v]", baseAssessID, assessIDs)
+	}
+
+	detailS := &meta.AssessResultMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+		AssessID:    baseAssessID,
+	}
+	baseResults, err := meta.NewAssessResultMetaModel(metaDB).DetailAssessResultMeta(ctx, detailS)
+	if err != nil {
+		return err
+	}
+	detailS.AssessID = assessID
+	assessResults, err := meta.NewAssessResultMetaModel(metaDB).DetailAssessResultMeta(ctx, detailS)
+	if err != nil {
+		return err
+	}
+
+	diff := GenAssessDiff(baseAssessID, assessID, baseResults, assessResults)
+
+	pwdDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	schemaName := common.StringUPPER(cfg.SchemaConfig.SourceSchema)
+	if schemaName == "" {
+		schemaName = "ALL"
+	}
+	fileName := filepath.Join(pwdDir, fmt.Sprintf("report_diff_%s_%s_%s.html", schemaName, baseAssessID, assessID))
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err = GenDiffHTMLReport(diff, file); err != nil {
+		return err
+	}
+	zap.L().Info("assess diff finished",
+		zap.String("schema", schemaName),
+		zap.String("base assess id", baseAssessID),
+		zap.String("assess id", assessID),
+		zap.Int("diff items", len(diff.ListDiffItems)),
+		zap.String("output", fileName))
+	return nil
+}
+
+func formatEffortDays(days float64) string {
+	return strconv.FormatFloat(days, 'f', 2, 64)
+}
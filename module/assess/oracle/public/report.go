@@ -18,8 +18,11 @@ package public
 import (
 	"embed"
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
 	"os"
 	"text/template"
+	"time"
 )
 
 //go:embed template
@@ -31,6 +34,8 @@ type Report struct {
 	*ReportCompatible
 	*ReportCheck
 	*ReportRelated
+	*ReportEffort
+	*ReportBenchmark
 }
 
 func GenNewHTMLReport(report *Report, file *os.File) error {
@@ -55,6 +60,18 @@ func GenNewHTMLReport(report *Report, file *os.File) error {
 		return fmt.Errorf("template FS Execute [report_summary] template HTML failed: %v", err)
 	}
 
+	if report.ReportEffort != nil {
+		if err = tf.ExecuteTemplate(file, "report_effort", report.ReportEffort); err != nil {
+			return fmt.Errorf("template FS Execute [report_effort] template HTML failed: %v", err)
+		}
+	}
+
+	if report.ReportBenchmark != nil {
+		if err = tf.ExecuteTemplate(file, "report_benchmark", report.ReportBenchmark); err != nil {
+			return fmt.Errorf("template FS Execute [report_benchmark] template HTML failed: %v", err)
+		}
+	}
+
 	if err = tf.ExecuteTemplate(file, "report_detail", nil); err != nil {
 		return fmt.Errorf("template FS Execute [report_detail] template HTML failed: %v", err)
 	}
@@ -77,3 +94,66 @@ func GenNewHTMLReport(report *Report, file *os.File) error {
 
 	return nil
 }
+
+// GenDiffHTMLReport 多次评估结果对比报告
+func GenDiffHTMLReport(diff *ReportDiff, file *os.File) error {
+	tf, err := template.ParseFS(fs, "template/*.html")
+	if err != nil {
+		return fmt.Errorf("template parse FS failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_header", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_header] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_body", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_body] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_diff", diff); err != nil {
+		return fmt.Errorf("template FS Execute [report_diff] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_footer", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_footer] template HTML failed: %v", err)
+	}
+
+	return nil
+}
+
+// 结果报告评估不一致类别
+const (
+	AssessCategoryIncompatible  = "incompatible"
+	AssessCategoryInConvertible = "inconvertible"
+)
+
+// GenAssessReport 评估结果转换为机器可读结果报告，存在不兼容或者无法转换对象视为 MISMATCH
+// 评估以数据库为粒度，结果报告仅一条汇总记录，评估明细见 detail
+func GenAssessReport(cfg *config.Config, report *Report, htmlFile string, startTime time.Time) *common.Report {
+	schemaName := common.StringUPPER(cfg.SchemaConfig.SourceSchema)
+	if schemaName == "" {
+		schemaName = "ALL"
+	}
+	r := common.NewReport(cfg.AppConfig.TaskName, cfg.TaskMode, cfg.DBTypeS, cfg.DBTypeT,
+		schemaName, cfg.SchemaConfig.TargetSchema, startTime)
+
+	t := common.ReportTable{
+		SchemaNameS: schemaName,
+		TableNameS:  "ASSESS_SUMMARY",
+		SchemaNameT: cfg.SchemaConfig.TargetSchema,
+		Categories:  make(map[string]int),
+	}
+	if report.ReportSummary != nil {
+		if report.Incompatible > 0 {
+			t.Categories[AssessCategoryIncompatible] = report.Incompatible
+		}
+		if report.InConvertible > 0 {
+			t.Categories[AssessCategoryInConvertible] = report.InConvertible
+		}
+	}
+	r.AppendTable(t)
+	r.AppendFile(htmlFile)
+	r.Detail = report
+	r.Finish(time.Now())
+	return r
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import "encoding/json"
+
+type ReportBenchmark struct {
+	BenchmarkSchema      string            `json:"benchmark_schema"`
+	SampleRows           int               `json:"sample_rows"`
+	TableThreads         int               `json:"table_threads"`
+	SQLThreads           int               `json:"sql_threads"`
+	ApplyThreads         int               `json:"apply_threads"`
+	ReplicaFactor        int               `json:"replica_factor"`
+	CompressionRatio     string            `json:"compression_ratio"`
+	ListSchemaBenchmarks []SchemaBenchmark `json:"list_schema_benchmarks"`
+	ListTableBenchmarks  []TableBenchmark  `json:"list_table_benchmarks"`
+}
+
+func (rb *ReportBenchmark) String() string {
+	jsonStr, _ := json.Marshal(rb)
+	return string(jsonStr)
+}
+
+type SchemaBenchmark struct {
+	Schema            string `json:"schema"`
+	TableCounts       int    `json:"table_counts"`
+	TotalRows         int64  `json:"total_rows"`
+	SourceSize        string `json:"source_size"`
+	RowsPerSecond     string `json:"rows_per_second"`
+	BytesPerSecond    string `json:"bytes_per_second"`
+	Concurrency       int    `json:"concurrency"`
+	EstimatedDuration string `json:"estimated_duration"`
+	TargetStorage     string `json:"target_storage"`
+}
+
+func (sb *SchemaBenchmark) String() string {
+	jsonStr, _ := json.Marshal(sb)
+	return string(jsonStr)
+}
+
+type TableBenchmark struct {
+	Schema         string `json:"schema"`
+	TableName      string `json:"table_name"`
+	NumRows        int64  `json:"num_rows"`
+	SampleRows     int64  `json:"sample_rows"`
+	SampleBytes    int64  `json:"sample_bytes"`
+	SampleCost     string `json:"sample_cost"`
+	RowsPerSecond  string `json:"rows_per_second"`
+	BytesPerSecond string `json:"bytes_per_second"`
+	AvgRowBytes    string `json:"avg_row_bytes"`
+	ErrorDetail    string `json:"error_detail"`
+}
+
+func (tb *TableBenchmark) String() string {
+	jsonStr, _ := json.Marshal(tb)
+	return string(jsonStr)
+}
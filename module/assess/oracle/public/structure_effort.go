@@ -0,0 +1,109 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import "encoding/json"
+
+type ReportEffort struct {
+	AssessID          string         `json:"assess_id"`
+	ListSchemaEfforts []SchemaEffort `json:"list_schema_efforts"`
+	ListEffortItems   []EffortItem   `json:"list_effort_items"`
+}
+
+func (re *ReportEffort) String() string {
+	jsonStr, _ := json.Marshal(re)
+	return string(jsonStr)
+}
+
+type SchemaEffort struct {
+	Schema               string `json:"schema"`
+	IncompatibleObjects  int    `json:"incompatible_objects"`
+	InconvertibleObjects int    `json:"inconvertible_objects"`
+	EffortDays           string `json:"effort_days"`
+}
+
+func (se *SchemaEffort) String() string {
+	jsonStr, _ := json.Marshal(se)
+	return string(jsonStr)
+}
+
+type EffortItem struct {
+	Schema        string `json:"schema"`
+	AssessName    string `json:"assess_name"`
+	ObjectName    string `json:"object_name"`
+	ObjectCounts  int    `json:"object_counts"`
+	IsConvertible string `json:"is_convertible"`
+	EffortWeight  string `json:"effort_weight"`
+	EffortDays    string `json:"effort_days"`
+}
+
+func (ei *EffortItem) String() string {
+	jsonStr, _ := json.Marshal(ei)
+	return string(jsonStr)
+}
+
+type ReportDiff struct {
+	BaseAssessID          string             `json:"base_assess_id"`
+	AssessID              string             `json:"assess_id"`
+	ListCategoryDiffs     []CategoryDiff     `json:"list_category_diffs"`
+	ListSchemaEffortDiffs []SchemaEffortDiff `json:"list_schema_effort_diffs"`
+	ListDiffItems         []DiffItem         `json:"list_diff_items"`
+}
+
+func (rd *ReportDiff) String() string {
+	jsonStr, _ := json.Marshal(rd)
+	return string(jsonStr)
+}
+
+type CategoryDiff struct {
+	AssessName      string `json:"assess_name"`
+	NewItems        int    `json:"new_items"`
+	ResolvedItems   int    `json:"resolved_items"`
+	NewObjects      int    `json:"new_objects"`
+	ResolvedObjects int    `json:"resolved_objects"`
+}
+
+func (cd *CategoryDiff) String() string {
+	jsonStr, _ := json.Marshal(cd)
+	return string(jsonStr)
+}
+
+type SchemaEffortDiff struct {
+	Schema         string `json:"schema"`
+	BaseEffortDays string `json:"base_effort_days"`
+	EffortDays     string `json:"effort_days"`
+	DeltaDays      string `json:"delta_days"`
+}
+
+func (sd *SchemaEffortDiff) String() string {
+	jsonStr, _ := json.Marshal(sd)
+	return string(jsonStr)
+}
+
+type DiffItem struct {
+	Schema        string `json:"schema"`
+	AssessName    string `json:"assess_name"`
+	ObjectName    string `json:"object_name"`
+	DiffType      string `json:"diff_type"`
+	BaseCounts    int    `json:"base_counts"`
+	ObjectCounts  int    `json:"object_counts"`
+	IsConvertible string `json:"is_convertible"`
+}
+
+func (di *DiffItem) String() string {
+	jsonStr, _ := json.Marshal(di)
+	return string(jsonStr)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 修复语句执行阶段，数值越小越先执行
+// 先调整表级属性以及新增、修改字段，再补齐主键、唯一键、索引以及约束，删除字段放在最后
+const (
+	FixStageTable = iota
+	FixStageAddColumn
+	FixStageModifyColumn
+	FixStagePrimaryUniqueKey
+	FixStageIndex
+	FixStageForeignKey
+	FixStageCheckKey
+	FixStageDropColumn
+)
+
+var fixStageName = map[int]string{
+	FixStageTable:            "table",
+	FixStageAddColumn:        "add column",
+	FixStageModifyColumn:     "modify column",
+	FixStagePrimaryUniqueKey: "primary and unique key",
+	FixStageIndex:            "index",
+	FixStageForeignKey:       "foreign key",
+	FixStageCheckKey:         "check key",
+	FixStageDropColumn:       "drop column",
+}
+
+type FixSQL struct {
+	Stage     int
+	TableName string
+	SQL       string
+	// 可能丢失数据的语句，例如字段类型收窄、删除字段
+	Unsafe bool
+	Reason string
+}
+
+type Fixer struct {
+	SQLs  []FixSQL
+	Mutex *sync.Mutex
+}
+
+func NewFixer() *Fixer {
+	return &Fixer{Mutex: &sync.Mutex{}}
+}
+
+func (f *Fixer) Append(sqls ...FixSQL) {
+	if f == nil {
+		return
+	}
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	for _, s := range sqls {
+		s.SQL = strings.TrimSpace(s.SQL)
+		if s.SQL == "" {
+			continue
+		}
+		if !strings.HasSuffix(s.SQL, ";") {
+			s.SQL = s.SQL + ";"
+		}
+		f.SQLs = append(f.SQLs, s)
+	}
+}
+
+// Sorted 按执行阶段、表名排序，同一表同一阶段保持生成顺序
+func (f *Fixer) Sorted() []FixSQL {
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	sqls := make([]FixSQL, len(f.SQLs))
+	copy(sqls, f.SQLs)
+	sort.SliceStable(sqls, func(i, j int) bool {
+		if sqls[i].Stage != sqls[j].Stage {
+			return sqls[i].Stage < sqls[j].Stage
+		}
+		return sqls[i].TableName < sqls[j].TableName
+	})
+	return sqls
+}
+
+func (f *Fixer) UnsafeCounts() int {
+	f.Mutex.Lock()
+	defer f.Mutex.Unlock()
+	counts := 0
+	for _, s := range f.SQLs {
+		if s.Unsafe {
+			counts++
+		}
+	}
+	return counts
+}
+
+// Writer 输出有序修复脚本，不安全语句整体注释输出，避免直接执行脚本丢失数据，需人工确认后取消注释执行，
+// 或者配置 fix-apply 以及 fix-unsafe 并命令行 -confirm 确认由程序执行
+func (f *Fixer) Writer(schemaName, fixFile string) error {
+	sqls := f.Sorted()
+
+	w, err := NewWriter(fixFile)
+	if err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	builder.WriteString("/*\n")
+	builder.WriteString(fmt.Sprintf(" schema [%s] target fix sql, statements are ordered by execution stage\n", schemaName))
+	builder.WriteString(" statements marked [UNSAFE] may lose data and are commented out, please confirm and uncomment them before executing\n")
+	builder.WriteString("*/\n")
+
+	stage := -1
+	for _, s := range sqls {
+		if s.Stage != stage {
+			stage = s.Stage
+			builder.WriteString(fmt.Sprintf("\n-- stage: %s\n", fixStageName[stage]))
+		}
+		if s.Unsafe {
+			builder.WriteString(fmt.Sprintf("-- [UNSAFE] table [%s]: %s\n", s.TableName, s.Reason))
+			builder.WriteString(commentFixSQL(s.SQL) + "\n")
+			continue
+		}
+		builder.WriteString(s.SQL + "\n")
+	}
+
+	if _, err = w.CWriteFile(builder.String()); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// 多行语句逐行注释
+func commentFixSQL(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, l := range lines {
+		lines[i] = "-- " + l
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Apply 按序执行修复语句，allowUnsafe 为 false 时跳过不安全语句
+func (f *Fixer) Apply(exec func(sql string, args ...any) error, allowUnsafe bool) (int, []FixSQL, error) {
+	var (
+		applied int
+		skipped []FixSQL
+	)
+	for _, s := range f.Sorted() {
+		if s.Unsafe && !allowUnsafe {
+			skipped = append(skipped, s)
+			continue
+		}
+		if err := exec(s.SQL); err != nil {
+			return applied, skipped, fmt.Errorf("table [%s] fix sql [%s] execute failed: %v", s.TableName, s.SQL, err)
+		}
+		applied++
+	}
+	return applied, skipped, nil
+}
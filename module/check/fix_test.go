@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixerWriterCommentUnsafe(t *testing.T) {
+	fixer := NewFixer()
+	fixer.Append(
+		FixSQL{Stage: FixStageDropColumn, TableName: "T1", SQL: "ALTER TABLE MARVIN.T1 DROP COLUMN C2", Unsafe: true, Reason: "drop column data will be lost"},
+		FixSQL{Stage: FixStageModifyColumn, TableName: "T1", SQL: "ALTER TABLE MARVIN.T1\nMODIFY COLUMN C1 VARCHAR(10)", Unsafe: true, Reason: "varchar narrow"},
+		FixSQL{Stage: FixStageAddColumn, TableName: "T1", SQL: "ALTER TABLE MARVIN.T1 ADD COLUMN C3 INT"},
+	)
+	fixFile := filepath.Join(t.TempDir(), "fix_marvin.sql")
+	if err := fixer.Writer("MARVIN", fixFile); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(fixFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var executable []string
+	for _, l := range strings.Split(string(b), "\n") {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "ALTER") || strings.HasPrefix(l, "MODIFY") {
+			executable = append(executable, l)
+		}
+	}
+	if len(executable) != 1 || executable[0] != "ALTER TABLE MARVIN.T1 ADD COLUMN C3 INT;" {
+		t.Errorf("Writer() executable statements = %v, want only safe add column", executable)
+	}
+	for _, want := range []string{
+		"-- [UNSAFE] table [T1]: varchar narrow\n-- ALTER TABLE MARVIN.T1\n-- MODIFY COLUMN C1 VARCHAR(10);\n",
+		"-- [UNSAFE] table [T1]: drop column data will be lost\n-- ALTER TABLE MARVIN.T1 DROP COLUMN C2;\n",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("Writer() missing commented unsafe statement %q", want)
+		}
+	}
+
+	// 程序执行仍以原语句为准，fix-unsafe 控制是否执行
+	var applied []string
+	if _, _, err = fixer.Apply(func(sql string, args ...any) error {
+		applied = append(applied, sql)
+		return nil
+	}, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 3 || strings.HasPrefix(applied[2], "--") {
+		t.Errorf("Apply() allow unsafe statements = %v", applied)
+	}
+}
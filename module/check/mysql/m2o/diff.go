@@ -32,14 +32,15 @@ import (
 
 type Diff struct {
 	Ctx                context.Context
-	DBTypeS            string        `json:"db_type_s"`
-	DBTypeT            string        `json:"db_type_t"`
-	OracleTableINFO    *public.Table `json:"oracle_table_info"`
-	MySQLTableINFO     *public.Table `json:"mysql_table_info"`
-	MySQLDBVersion     string        `json:"mysqldb_version"`
-	OracleDBVersion    string        `json:"oracle_db_version"`
-	OracleDBExtendMode bool          `json:"oracle_db_extend_mode"`
-	MetaDB             *meta.Meta    `json:"-"`
+	DBTypeS            string         `json:"db_type_s"`
+	DBTypeT            string         `json:"db_type_t"`
+	OracleTableINFO    *public.Table  `json:"oracle_table_info"`
+	MySQLTableINFO     *public.Table  `json:"mysql_table_info"`
+	MySQLDBVersion     string         `json:"mysqldb_version"`
+	OracleDBVersion    string         `json:"oracle_db_version"`
+	OracleDBExtendMode bool           `json:"oracle_db_extend_mode"`
+	MetaDB             *meta.Meta     `json:"-"`
+	Categories         map[string]int `json:"-"`
 }
 
 func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *public.Table, dbTypeS, dbTypeT, mysqlDBVersion, oracleDBVersion string, oracleDBExtendMode bool, metaDB *meta.Meta) *Diff {
@@ -599,15 +600,19 @@ func (c *Diff) Writer(f *check.File) error {
 		zap.String("mysql table", fmt.Sprintf("%s.%s", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)))
 
 	var builder strings.Builder
+	c.Categories = make(map[string]int)
 
-	if !strings.EqualFold(c.CheckPartitionTableType(), "") {
-		builder.WriteString(c.CheckPartitionTableType())
+	if partitionType := c.CheckPartitionTableType(); !strings.EqualFold(partitionType, "") {
+		builder.WriteString(partitionType)
+		c.Categories[check.CategoryPartitionType] = check.StatementCounts(partitionType)
 	}
-	if !strings.EqualFold(c.CheckTableComment(), "") {
-		builder.WriteString(c.CheckTableComment())
+	if comment := c.CheckTableComment(); !strings.EqualFold(comment, "") {
+		builder.WriteString(comment)
+		c.Categories[check.CategoryTableComment] = check.StatementCounts(comment)
 	}
-	if !strings.EqualFold(c.CheckTableCharacterSetAndCollation(), "") {
-		builder.WriteString(c.CheckTableCharacterSetAndCollation())
+	if charsetAndCollation := c.CheckTableCharacterSetAndCollation(); !strings.EqualFold(charsetAndCollation, "") {
+		builder.WriteString(charsetAndCollation)
+		c.Categories[check.CategoryTableCharset] = check.StatementCounts(charsetAndCollation)
 	}
 
 	counts, err := c.CheckColumnCounts()
@@ -616,6 +621,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(counts, "") {
 		builder.WriteString(counts)
+		c.Categories[check.CategoryColumnCounts] = check.StatementCounts(counts)
 	}
 	key, err := c.CheckPrimaryAndUniqueKey()
 	if err != nil {
@@ -623,6 +629,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(key, "") {
 		builder.WriteString(key)
+		c.Categories[check.CategoryPrimaryUniqueKey] = check.StatementCounts(key)
 	}
 	foreignKey, err := c.CheckForeignKey()
 	if err != nil {
@@ -630,6 +637,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(foreignKey, "") {
 		builder.WriteString(foreignKey)
+		c.Categories[check.CategoryForeignKey] = check.StatementCounts(foreignKey)
 	}
 	checkKey, err := c.CheckCheckKey()
 	if err != nil {
@@ -637,6 +645,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(checkKey, "") {
 		builder.WriteString(checkKey)
+		c.Categories[check.CategoryCheckKey] = check.StatementCounts(checkKey)
 	}
 	index, err := c.CheckIndex()
 	if err != nil {
@@ -644,6 +653,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(index, "") {
 		builder.WriteString(index)
+		c.Categories[check.CategoryIndex] = check.StatementCounts(index)
 	}
 
 	partitionTable, err := c.CheckPartitionTable()
@@ -652,6 +662,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(partitionTable, "") {
 		builder.WriteString(partitionTable)
+		c.Categories[check.CategoryPartition] = check.StatementCounts(partitionTable)
 	}
 
 	column, err := c.CheckColumn()
@@ -660,6 +671,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(column, "") {
 		builder.WriteString(column)
+		c.Categories[check.CategoryColumn] = check.StatementCounts(column)
 	}
 	// diff 记录不为空
 	if builder.String() != "" {
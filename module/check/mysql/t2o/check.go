@@ -41,7 +41,7 @@ type Check struct {
 }
 
 func NewCheck(ctx context.Context, cfg *config.Config) (*Check, error) {
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -295,12 +295,22 @@ func (r *Check) Check() error {
 		return err
 	}
 
+	// 机器可读结果报告
+	report := common.NewReport(r.cfg.AppConfig.TaskName, r.cfg.TaskMode, r.cfg.DBTypeS, r.cfg.DBTypeT,
+		r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, startTime)
+
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.CheckConfig.CheckThreads)
 
 	for _, task := range tasks {
 		t := task
 		g.Go(func() error {
+			release, err := r.cfg.AcquireThread(r.ctx, common.ThreadBudgetCheck, r.cfg.CheckConfig.CheckThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			oracleTableInfo, err := t.GenOracleTable()
 			if err != nil {
 				return err
@@ -322,9 +332,17 @@ func (r *Check) Check() error {
 			if err != nil {
 				return err
 			}
-			err = NewChecker(r.ctx, oracleTableInfo, mysqlTableInfo,
-				r.cfg.DBTypeS, r.cfg.DBTypeT, mysqlDBVersion, oracleDBVersion, oracleDBExtendMode, r.metaDB).Writer(f)
+			checker := NewChecker(r.ctx, oracleTableInfo, mysqlTableInfo,
+				r.cfg.DBTypeS, r.cfg.DBTypeT, mysqlDBVersion, oracleDBVersion, oracleDBExtendMode, r.metaDB)
+			err = checker.Writer(f)
 			if err != nil {
+				report.AppendTable(common.ReportTable{
+					SchemaNameS: t.SourceSchemaName,
+					TableNameS:  t.SourceTableName,
+					SchemaNameT: t.TargetSchemaName,
+					TableNameT:  t.TargetTableName,
+					Error:       err.Error(),
+				})
 				// skip error and continue
 				errMeta := meta.NewCommonModel(r.metaDB).CreateErrorDetailAndUpdateWaitSyncMetaTaskStatus(r.ctx, &meta.ErrorLogDetail{
 					DBTypeS:     r.cfg.DBTypeS,
@@ -349,6 +367,18 @@ func (r *Check) Check() error {
 					return errMeta
 				}
 			} else {
+				reportTable := common.ReportTable{
+					SchemaNameS: t.SourceSchemaName,
+					TableNameS:  t.SourceTableName,
+					SchemaNameT: t.TargetSchemaName,
+					TableNameT:  t.TargetTableName,
+					Categories:  checker.Categories,
+				}
+				if len(checker.Categories) > 0 {
+					reportTable.FixFile = checkFile
+				}
+				report.AppendTable(reportTable)
+
 				errMeta := meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
 					DBTypeS:     r.cfg.DBTypeS,
 					DBTypeT:     r.cfg.DBTypeT,
@@ -373,6 +403,7 @@ func (r *Check) Check() error {
 	if err = f.Close(); err != nil {
 		return err
 	}
+	report.AppendFile(checkFile)
 
 	// 任务详情
 	succTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
@@ -396,7 +427,16 @@ func (r *Check) Check() error {
 		return err
 	}
 
-	zap.L().Info("check", zap.String("output", filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.sql", r.cfg.SchemaConfig.SourceSchema))))
+	report.Finish(time.Now())
+	if r.cfg.ReportConfig.Format != "" {
+		reportFile, err := report.Write(r.cfg.ReportConfig.ReportDir, r.cfg.ReportConfig.Format)
+		if err != nil {
+			return err
+		}
+		zap.L().Info("check report", zap.String("output", reportFile), zap.String("status", report.Status))
+	}
+
+	zap.L().Info("check", zap.String("output", checkFile))
 	if len(failedTotals) == 0 {
 		zap.L().Info("check table mysql to oracle finished",
 			zap.Int("table totals", len(waitSyncMetas)),
@@ -412,5 +452,5 @@ func (r *Check) Check() error {
 			zap.String("cost", time.Now().Sub(startTime).String()))
 	}
 
-	return nil
+	return report.Gate(r.cfg.ReportConfig.FailOn)
 }
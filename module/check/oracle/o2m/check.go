@@ -41,7 +41,7 @@ type Check struct {
 }
 
 func NewCheck(ctx context.Context, cfg *config.Config) (*Check, error) {
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -270,9 +270,15 @@ func (r *Check) Check() error {
 			zap.String("cost", finishTime.Sub(beginTime).String()))
 	}
 
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.metaDB).GetSchemaColumnNameRule(r.ctx, r.cfg)
+	if err != nil {
+		return err
+	}
+
 	// 任务检查表
 	tasks := GenCheckTaskTable(r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, oracleDBCharacterSet,
-		nlsSort, nlsComp, oracleTableCollation, oracleSchemaCollation, oracleDBCollation, r.oracle, r.mysql, sourceTableNameRuleMap, waitSyncMetas)
+		nlsSort, nlsComp, oracleTableCollation, oracleSchemaCollation, oracleDBCollation, r.oracle, r.mysql, sourceTableNameRuleMap, columnNameRule, waitSyncMetas)
 
 	err = common.PathExist(r.cfg.CheckConfig.CheckSQLDir)
 	if err != nil {
@@ -287,12 +293,25 @@ func (r *Check) Check() error {
 		return err
 	}
 
+	// 有序修复脚本
+	fixer := check.NewFixer()
+
+	// 机器可读结果报告
+	report := common.NewReport(r.cfg.AppConfig.TaskName, r.cfg.TaskMode, r.cfg.DBTypeS, r.cfg.DBTypeT,
+		r.cfg.SchemaConfig.SourceSchema, r.cfg.SchemaConfig.TargetSchema, startTime)
+
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.CheckConfig.CheckThreads)
 
 	for _, task := range tasks {
 		t := task
 		g.Go(func() error {
+			release, err := r.cfg.AcquireThread(r.ctx, common.ThreadBudgetCheck, r.cfg.CheckConfig.CheckThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			oracleTableInfo, err := t.GenOracleTable()
 			if err != nil {
 				return err
@@ -314,9 +333,17 @@ func (r *Check) Check() error {
 			if err != nil {
 				return err
 			}
-			err = NewChecker(r.ctx, oracleTableInfo, mysqlTableInfo,
-				r.cfg.DBTypeS, r.cfg.DBTypeT, mysqlDBVersion, r.metaDB).Writer(f)
+			checker := NewChecker(r.ctx, oracleTableInfo, mysqlTableInfo,
+				r.cfg.DBTypeS, r.cfg.DBTypeT, mysqlDBVersion, r.metaDB, t.ColumnNameRule, fixer)
+			err = checker.Writer(f)
 			if err != nil {
+				report.AppendTable(common.ReportTable{
+					SchemaNameS: t.SourceSchemaName,
+					TableNameS:  t.SourceTableName,
+					SchemaNameT: t.TargetSchemaName,
+					TableNameT:  t.TargetTableName,
+					Error:       err.Error(),
+				})
 				// skip error and continue
 				errMeta := meta.NewCommonModel(r.metaDB).CreateErrorDetailAndUpdateWaitSyncMetaTaskStatus(r.ctx, &meta.ErrorLogDetail{
 					DBTypeS:     r.cfg.DBTypeS,
@@ -341,6 +368,18 @@ func (r *Check) Check() error {
 					return errMeta
 				}
 			} else {
+				reportTable := common.ReportTable{
+					SchemaNameS: t.SourceSchemaName,
+					TableNameS:  t.SourceTableName,
+					SchemaNameT: t.TargetSchemaName,
+					TableNameT:  t.TargetTableName,
+					Categories:  checker.Categories,
+				}
+				if len(checker.Categories) > 0 {
+					reportTable.FixFile = checkFile
+				}
+				report.AppendTable(reportTable)
+
 				errMeta := meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
 					DBTypeS:     r.cfg.DBTypeS,
 					DBTypeT:     r.cfg.DBTypeT,
@@ -366,6 +405,12 @@ func (r *Check) Check() error {
 		return err
 	}
 
+	fixFile, err := r.fix(fixer)
+	if err != nil {
+		return err
+	}
+	report.AppendFile(checkFile, fixFile)
+
 	// 任务详情
 	succTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
 		DBTypeS:     r.cfg.DBTypeS,
@@ -388,7 +433,16 @@ func (r *Check) Check() error {
 		return err
 	}
 
-	zap.L().Info("check", zap.String("output", filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.sql", r.cfg.SchemaConfig.SourceSchema))))
+	report.Finish(time.Now())
+	if r.cfg.ReportConfig.Format != "" {
+		reportFile, err := report.Write(r.cfg.ReportConfig.ReportDir, r.cfg.ReportConfig.Format)
+		if err != nil {
+			return err
+		}
+		zap.L().Info("check report", zap.String("output", reportFile), zap.String("status", report.Status))
+	}
+
+	zap.L().Info("check", zap.String("output", checkFile))
 	if len(failedTotals) == 0 {
 		zap.L().Info("check table oracle to mysql finished",
 			zap.Int("table totals", len(waitSyncMetas)),
@@ -404,5 +458,44 @@ func (r *Check) Check() error {
 			zap.String("cost", time.Now().Sub(startTime).String()))
 	}
 
-	return nil
+	return report.Gate(r.cfg.ReportConfig.FailOn)
+}
+
+// 输出有序修复脚本，开启 fix-apply 且命令行确认后在目标端执行
+func (r *Check) fix(fixer *check.Fixer) (string, error) {
+	fixFile := filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("fix_%s.sql", r.cfg.SchemaConfig.SourceSchema))
+	if err := fixer.Writer(r.cfg.SchemaConfig.SourceSchema, fixFile); err != nil {
+		return fixFile, err
+	}
+	zap.L().Info("check fix sql",
+		zap.String("output", fixFile),
+		zap.Int("sql totals", len(fixer.SQLs)),
+		zap.Int("unsafe totals", fixer.UnsafeCounts()))
+
+	if !r.cfg.CheckConfig.FixApply || len(fixer.SQLs) == 0 {
+		return fixFile, nil
+	}
+	if !r.cfg.Confirm {
+		zap.L().Warn("check fix sql dry run, not applied",
+			zap.String("fix file", fixFile),
+			zap.String("tips", "please review the fix file, then rerun with flag -confirm to apply"))
+		return fixFile, nil
+	}
+
+	startTime := time.Now()
+	applied, skipped, err := fixer.Apply(r.mysql.WriteMySQLTable, r.cfg.CheckConfig.FixUnsafe)
+	if err != nil {
+		return fixFile, err
+	}
+	for _, s := range skipped {
+		zap.L().Warn("check fix sql unsafe skip",
+			zap.String("table", s.TableName),
+			zap.String("sql", s.SQL),
+			zap.String("reason", s.Reason))
+	}
+	zap.L().Info("check fix sql applied",
+		zap.Int("applied totals", applied),
+		zap.Int("skipped unsafe totals", len(skipped)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return fixFile, nil
 }
@@ -32,15 +32,18 @@ import (
 
 type Diff struct {
 	Ctx             context.Context
-	DBTypeS         string        `json:"db_type_s"`
-	DBTypeT         string        `json:"db_type_t"`
-	OracleTableINFO *public.Table `json:"oracle_table_info"`
-	MySQLTableINFO  *public.Table `json:"mysql_table_info"`
-	MySQLDBVersion  string        `json:"mysqldb_version"`
-	MetaDB          *meta.Meta    `json:"-"`
+	DBTypeS         string                      `json:"db_type_s"`
+	DBTypeT         string                      `json:"db_type_t"`
+	OracleTableINFO *public.Table               `json:"oracle_table_info"`
+	MySQLTableINFO  *public.Table               `json:"mysql_table_info"`
+	MySQLDBVersion  string                      `json:"mysqldb_version"`
+	MetaDB          *meta.Meta                  `json:"-"`
+	ColumnNameRule  *common.TableColumnNameRule `json:"-"`
+	Categories      map[string]int              `json:"-"`
+	Fixer           *check.Fixer                `json:"-"`
 }
 
-func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *public.Table, dbTypeS, dbTypeT, mysqlDBVersion string, metaDB *meta.Meta) *Diff {
+func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *public.Table, dbTypeS, dbTypeT, mysqlDBVersion string, metaDB *meta.Meta, columnNameRule *common.TableColumnNameRule, fixer *check.Fixer) *Diff {
 	return &Diff{
 		Ctx:             ctx,
 		DBTypeS:         dbTypeS,
@@ -49,6 +52,8 @@ func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *public.Tab
 		MySQLTableINFO:  mysqlTableInfo,
 		MySQLDBVersion:  mysqlDBVersion,
 		MetaDB:          metaDB,
+		ColumnNameRule:  columnNameRule,
+		Fixer:           fixer,
 	}
 }
 
@@ -103,7 +108,9 @@ func (c *Diff) CheckTableComment() string {
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
 
 		builder.WriteString("*/\n")
-		builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s COMMENT '%s';\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.OracleTableINFO.TableComment))
+		commentSQL := fmt.Sprintf("ALTER TABLE %s.%s COMMENT '%s';", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.OracleTableINFO.TableComment)
+		builder.WriteString(commentSQL + "\n")
+		c.Fixer.Append(check.FixSQL{Stage: check.FixStageTable, TableName: c.MySQLTableINFO.TableName, SQL: commentSQL})
 	}
 	return builder.String()
 }
@@ -136,9 +143,10 @@ func (c *Diff) CheckTableCharacterSetAndCollation() string {
 
 		builder.WriteString("*/\n")
 
-		builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s CHARACTER SET %s COLLATE %s;\n\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName,
-			mysqlTableCharacterSet,
-			mysqlTableCollation))
+		charsetSQL := fmt.Sprintf("ALTER TABLE %s.%s CHARACTER SET %s COLLATE %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName,
+			mysqlTableCharacterSet, mysqlTableCollation)
+		builder.WriteString(charsetSQL + "\n\n")
+		c.Fixer.Append(check.FixSQL{Stage: check.FixStageTable, TableName: c.MySQLTableINFO.TableName, SQL: charsetSQL})
 	}
 
 	return builder.String()
@@ -188,10 +196,19 @@ func (c *Diff) CheckColumnCharacterSetAndCollation() string {
 			mysqlColumnCharacterSet := common.MigrateTableStructureDatabaseCharsetMap[common.TaskTypeOracle2MySQL][c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet]
 			mysqlColumnCollation := common.MigrateTableStructureDatabaseCollationMap[common.TaskTypeOracle2MySQL][c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].Collation][mysqlColumnCharacterSet]
 
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s MODIFY %s %s(%s) CHARACTER SET %s COLLATE %s;",
+			modifySQL := fmt.Sprintf("ALTER TABLE %s.%s MODIFY %s %s(%s) CHARACTER SET %s COLLATE %s;",
 				c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName, mysqlColInfo.DataType, mysqlColInfo.DataLength,
 				strings.ToLower(mysqlColumnCharacterSet),
-				strings.ToLower(mysqlColumnCollation)))
+				strings.ToLower(mysqlColumnCollation))
+			sqlStrings = append(sqlStrings, modifySQL)
+
+			// 字符集变更会转换已有数据，可能存在无法转换的字符
+			fixSQL := check.FixSQL{Stage: check.FixStageModifyColumn, TableName: c.MySQLTableINFO.TableName, SQL: modifySQL}
+			if !strings.EqualFold(mysqlColInfo.CharacterSet, mysqlColumnCharacterSet) {
+				fixSQL.Unsafe = true
+				fixSQL.Reason = fmt.Sprintf("column [%s] character set converted from %s to %s", mysqlColName, mysqlColInfo.CharacterSet, mysqlColumnCharacterSet)
+			}
+			c.Fixer.Append(fixSQL)
 		}
 
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
@@ -223,7 +240,10 @@ func (c *Diff) CheckColumnCharacterSetAndCollation() string {
 				})
 			}
 
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName))
+			dropSQL := fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName)
+			sqlStrings = append(sqlStrings, dropSQL)
+			c.Fixer.Append(check.FixSQL{Stage: check.FixStageDropColumn, TableName: c.MySQLTableINFO.TableName, SQL: dropSQL,
+				Unsafe: true, Reason: fmt.Sprintf("column [%s] isn't exist in oracle, drop column data will be lost", mysqlColName)})
 		}
 
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
@@ -261,10 +281,15 @@ func (c *Diff) CheckColumnCounts() (string, error) {
 				columnMeta string
 				err        error
 			)
-			columnMeta, err = public.GenOracleTableColumnMeta(c.Ctx, c.MetaDB, c.DBTypeS, c.DBTypeT, c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName, oracleColName, oracleColInfo)
+			// 字段名映射规则，以源端字段名获取自定义转换规则
+			columnNameS, _ := c.ColumnNameRule.SourceColumnName(oracleColName)
+			columnMeta, err = public.GenOracleTableColumnMeta(c.Ctx, c.MetaDB, c.DBTypeS, c.DBTypeT, c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableName, columnNameS, oracleColInfo)
 			if err != nil {
 				return columnMeta, err
 			}
+			if columnNameS != oracleColName {
+				columnMeta = strings.Replace(columnMeta, common.StringsBuilder("`", columnNameS, "`"), common.StringsBuilder("`", oracleColName, "`"), 1)
+			}
 			// TIMESTAMP 时间字段特殊处理
 			// 数据类型内自带精度
 			if strings.Contains(strings.ToUpper(oracleColInfo.DataType), "TIMESTAMP") {
@@ -278,7 +303,9 @@ func (c *Diff) CheckColumnCounts() (string, error) {
 						fmt.Sprintf("%s(%s)", oracleColInfo.DataType, oracleColInfo.DataLength), "Add MySQL Table Column"},
 				})
 			}
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, columnMeta))
+			addSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, columnMeta)
+			sqlStrings = append(sqlStrings, addSQL)
+			c.Fixer.Append(check.FixSQL{Stage: check.FixStageAddColumn, TableName: c.MySQLTableINFO.TableName, SQL: addSQL})
 		}
 
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
@@ -286,6 +313,22 @@ func (c *Diff) CheckColumnCounts() (string, error) {
 		builder.WriteString(strings.Join(sqlStrings, "\n") + "\n\n")
 	}
 
+	// 字段名映射规则目标端新增字段检查
+	var addSQLStrings []string
+	for _, r := range c.ColumnNameRule.AddColumns() {
+		if _, ok := c.MySQLTableINFO.Columns[common.StringUPPER(r.ColumnNameT)]; !ok {
+			addSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, r.TargetColumnMeta())
+			addSQLStrings = append(addSQLStrings, addSQL)
+			c.Fixer.Append(check.FixSQL{Stage: check.FixStageAddColumn, TableName: c.MySQLTableINFO.TableName, SQL: addSQL})
+		}
+	}
+	if len(addSQLStrings) > 0 {
+		builder.WriteString("/*\n")
+		builder.WriteString(" column name rule target-only column isn't exist, generate add sql\n")
+		builder.WriteString("*/\n")
+		builder.WriteString(strings.Join(addSQLStrings, "\n") + "\n\n")
+	}
+
 	return builder.String(), nil
 }
 
@@ -318,10 +361,14 @@ func (c *Diff) CheckPrimaryAndUniqueKey() (string, error) {
 			if ok {
 				switch value.ConstraintType {
 				case "PK":
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD PRIMARY KEY(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
+					pkSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD PRIMARY KEY(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn)
+					builder.WriteString(pkSQL + "\n")
+					c.Fixer.Append(check.FixSQL{Stage: check.FixStagePrimaryUniqueKey, TableName: c.MySQLTableINFO.TableName, SQL: pkSQL})
 					continue
 				case "UK":
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD UNIQUE(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
+					ukSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD UNIQUE(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn)
+					builder.WriteString(ukSQL + "\n")
+					c.Fixer.Append(check.FixSQL{Stage: check.FixStagePrimaryUniqueKey, TableName: c.MySQLTableINFO.TableName, SQL: ukSQL})
 					continue
 				default:
 					return builder.String(), fmt.Errorf("table constraint primary and unique key diff failed: not support type [%s]", value.ConstraintType)
@@ -361,7 +408,9 @@ func (c *Diff) CheckForeignKey() (string, error) {
 		for _, fk := range addDiffFK {
 			value, ok := fk.(public.ConstraintForeign)
 			if ok {
-				builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s.%s(%s）ON DELETE %s;\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ColumnName, c.MySQLTableINFO.SchemaName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule))
+				fkSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s.%s(%s) ON DELETE %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ColumnName, c.MySQLTableINFO.SchemaName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule)
+				builder.WriteString(fkSQL + "\n")
+				c.Fixer.Append(check.FixSQL{Stage: check.FixStageForeignKey, TableName: c.MySQLTableINFO.TableName, SQL: fkSQL})
 				continue
 			}
 			return builder.String(), fmt.Errorf("oracle table [%s] constraint foreign key [%v] assert ConstraintForeign failed, type: [%v]", c.OracleTableINFO.TableName, fk, reflect.TypeOf(fk))
@@ -403,7 +452,9 @@ func (c *Diff) CheckCheckKey() (string, error) {
 			for _, ck := range addDiffCK {
 				value, ok := ck.(public.ConstraintCheck)
 				if ok {
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s CHECK(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, fmt.Sprintf("%s_check_key", c.MySQLTableINFO.TableName), value.ConstraintExpression))
+					ckSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s CHECK(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, fmt.Sprintf("%s_check_key", c.MySQLTableINFO.TableName), value.ConstraintExpression)
+					builder.WriteString(ckSQL + "\n")
+					c.Fixer.Append(check.FixSQL{Stage: check.FixStageCheckKey, TableName: c.MySQLTableINFO.TableName, SQL: ckSQL})
 					continue
 				}
 				return builder.String(), fmt.Errorf("oracle table [%s] constraint check key [%v] assert ConstraintCheck failed, type: [%v]", c.OracleTableINFO.TableName, ck, reflect.TypeOf(ck))
@@ -438,6 +489,7 @@ func (c *Diff) CheckIndex() (string, error) {
 					if len(equalArray) == 0 {
 						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
+						c.appendIndexFixSQL("CREATE UNIQUE INDEX", value)
 					}
 					continue
 				}
@@ -452,6 +504,7 @@ func (c *Diff) CheckIndex() (string, error) {
 					if len(equalArray) == 0 {
 						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
+						c.appendIndexFixSQL("CREATE UNIQUE INDEX", value)
 					}
 					continue
 				}
@@ -466,22 +519,28 @@ func (c *Diff) CheckIndex() (string, error) {
 					if len(equalArray) == 0 {
 						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
+						c.appendIndexFixSQL("CREATE INDEX", value)
 					}
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "BITMAP" {
 					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
 						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
+					// 目标端不支持 BITMAP 索引，修复脚本以普通索引创建
+					c.appendIndexFixSQL("CREATE INDEX", value)
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED NORMAL" {
 					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
 						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
+					c.appendIndexFixSQL("CREATE INDEX", value)
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED BITMAP" {
 					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
 						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
+					// 目标端不支持 BITMAP 索引，修复脚本以普通索引创建
+					c.appendIndexFixSQL("CREATE INDEX", value)
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "DOMAIN" {
@@ -575,7 +634,7 @@ func (c *Diff) CheckColumn() (string, error) {
 	for oracleColName, oracleColInfo := range c.OracleTableINFO.Columns {
 		mysqlColInfo, ok := c.MySQLTableINFO.Columns[oracleColName]
 		if ok {
-			diffColumnMsg, tableRows, err := OracleTableColumnMapRuleCheck(
+			diffColumnMsg, suggestDataType, tableRows, err := OracleTableColumnMapRuleCheck(
 				common.StringUPPER(c.OracleTableINFO.SchemaName),
 				common.StringUPPER(c.MySQLTableINFO.SchemaName),
 				common.StringUPPER(c.OracleTableINFO.TableName),
@@ -588,6 +647,14 @@ func (c *Diff) CheckColumn() (string, error) {
 			if diffColumnMsg != "" && len(tableRows) != 0 {
 				diffColumnMsgs = append(diffColumnMsgs, diffColumnMsg)
 				tableRowArray = append(tableRowArray, tableRows)
+
+				// 以建议字段类型判断是否收窄下游字段
+				fixSQL := check.FixSQL{Stage: check.FixStageModifyColumn, TableName: c.MySQLTableINFO.TableName, SQL: diffColumnMsg}
+				if unsafe, reason := mysqlColInfo.NarrowCheck(suggestDataType); unsafe {
+					fixSQL.Unsafe = true
+					fixSQL.Reason = fmt.Sprintf("column [%s] %s", oracleColName, reason)
+				}
+				c.Fixer.Append(fixSQL)
 			}
 			continue
 		}
@@ -619,6 +686,11 @@ func (c *Diff) CheckColumn() (string, error) {
 	return builder.String(), nil
 }
 
+func (c *Diff) appendIndexFixSQL(createIndex string, index public.Index) {
+	c.Fixer.Append(check.FixSQL{Stage: check.FixStageIndex, TableName: c.MySQLTableINFO.TableName,
+		SQL: fmt.Sprintf("%s %s ON %s.%s (%s);", createIndex, index.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, index.IndexColumn)})
+}
+
 func (c *Diff) Writer(f *check.File) error {
 	startTime := time.Now()
 	zap.L().Info("check table start",
@@ -626,15 +698,19 @@ func (c *Diff) Writer(f *check.File) error {
 		zap.String("mysql table", fmt.Sprintf("%s.%s", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName)))
 
 	var builder strings.Builder
+	c.Categories = make(map[string]int)
 
-	if !strings.EqualFold(c.CheckPartitionTableType(), "") {
-		builder.WriteString(c.CheckPartitionTableType())
+	if partitionType := c.CheckPartitionTableType(); !strings.EqualFold(partitionType, "") {
+		builder.WriteString(partitionType)
+		c.Categories[check.CategoryPartitionType] = check.StatementCounts(partitionType)
 	}
-	if !strings.EqualFold(c.CheckTableComment(), "") {
-		builder.WriteString(c.CheckTableComment())
+	if comment := c.CheckTableComment(); !strings.EqualFold(comment, "") {
+		builder.WriteString(comment)
+		c.Categories[check.CategoryTableComment] = check.StatementCounts(comment)
 	}
-	if !strings.EqualFold(c.CheckTableCharacterSetAndCollation(), "") {
-		builder.WriteString(c.CheckTableCharacterSetAndCollation())
+	if charsetAndCollation := c.CheckTableCharacterSetAndCollation(); !strings.EqualFold(charsetAndCollation, "") {
+		builder.WriteString(charsetAndCollation)
+		c.Categories[check.CategoryTableCharset] = check.StatementCounts(charsetAndCollation)
 	}
 
 	counts, err := c.CheckColumnCounts()
@@ -643,6 +719,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(counts, "") {
 		builder.WriteString(counts)
+		c.Categories[check.CategoryColumnCounts] = check.StatementCounts(counts)
 	}
 	key, err := c.CheckPrimaryAndUniqueKey()
 	if err != nil {
@@ -650,6 +727,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(key, "") {
 		builder.WriteString(key)
+		c.Categories[check.CategoryPrimaryUniqueKey] = check.StatementCounts(key)
 	}
 	foreignKey, err := c.CheckForeignKey()
 	if err != nil {
@@ -657,6 +735,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(foreignKey, "") {
 		builder.WriteString(foreignKey)
+		c.Categories[check.CategoryForeignKey] = check.StatementCounts(foreignKey)
 	}
 	checkKey, err := c.CheckCheckKey()
 	if err != nil {
@@ -664,6 +743,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(checkKey, "") {
 		builder.WriteString(checkKey)
+		c.Categories[check.CategoryCheckKey] = check.StatementCounts(checkKey)
 	}
 	index, err := c.CheckIndex()
 	if err != nil {
@@ -671,6 +751,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(index, "") {
 		builder.WriteString(index)
+		c.Categories[check.CategoryIndex] = check.StatementCounts(index)
 	}
 
 	partitionTable, err := c.CheckPartitionTable()
@@ -679,6 +760,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(partitionTable, "") {
 		builder.WriteString(partitionTable)
+		c.Categories[check.CategoryPartition] = check.StatementCounts(partitionTable)
 	}
 
 	column, err := c.CheckColumn()
@@ -687,6 +769,7 @@ func (c *Diff) Writer(f *check.File) error {
 	}
 	if !strings.EqualFold(column, "") {
 		builder.WriteString(column)
+		c.Categories[check.CategoryColumn] = check.StatementCounts(column)
 	}
 	// diff 记录不为空
 	if builder.String() != "" {
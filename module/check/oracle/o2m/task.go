@@ -36,13 +36,14 @@ type Task struct {
 	SourceTableCollation  string `json:"source_table_collation"`
 	SourceSchemaCollation string `json:"source_schema_collation"`
 
-	Oracle *oracle.Oracle `json:"-"`
-	MySQL  *mysql.MySQL   `json:"-"`
+	ColumnNameRule *common.TableColumnNameRule `json:"-"`
+	Oracle         *oracle.Oracle              `json:"-"`
+	MySQL          *mysql.MySQL                `json:"-"`
 }
 
 func GenCheckTaskTable(sourceSchemaName, targetSchemaName, sourceDBCharacterSet, nlsSort, nlsComp string,
 	sourceTableCollation map[string]string, sourceSchemaCollation string,
-	sourceDBCollation bool, oracle *oracle.Oracle, mysql *mysql.MySQL, tableNameRule map[string]string, waitSyncMetas []meta.WaitSyncMeta) []*Task {
+	sourceDBCollation bool, oracle *oracle.Oracle, mysql *mysql.MySQL, tableNameRule map[string]string, columnNameRule map[string]*common.TableColumnNameRule, waitSyncMetas []meta.WaitSyncMeta) []*Task {
 	var tasks []*Task
 	for _, t := range waitSyncMetas {
 		// 库名、表名规则
@@ -63,6 +64,7 @@ func GenCheckTaskTable(sourceSchemaName, targetSchemaName, sourceDBCharacterSet,
 			SourceDBCollation:     sourceDBCollation,
 			SourceTableCollation:  sourceTableCollation[t.TableNameS],
 			SourceSchemaCollation: sourceSchemaCollation,
+			ColumnNameRule:        columnNameRule[common.StringUPPER(t.TableNameS)],
 			Oracle:                oracle,
 			MySQL:                 mysql,
 		})
@@ -75,6 +77,8 @@ func (t *Task) GenOracleTable() (*public.Table, error) {
 	if err != nil {
 		return info, err
 	}
+	// 字段名映射规则，以目标端字段名对比
+	info.ApplyColumnNameRule(t.ColumnNameRule)
 	return info, nil
 }
 
@@ -28,7 +28,22 @@ import (
 	Oracle 表规则映射检查
 */
 
+// 返回 fix SQL、建议目标字段类型以及报告行
 func OracleTableColumnMapRuleCheck(
+	sourceSchema, targetSchema, tableName, columnName string,
+	oracleColInfo, mysqlColInfo public.Column) (string, string, table.Row, error) {
+	fixedMsg, tableRows, err := oracleTableColumnMapRuleCheck(sourceSchema, targetSchema, tableName, columnName, oracleColInfo, mysqlColInfo)
+	if err != nil || len(tableRows) == 0 {
+		return fixedMsg, "", tableRows, err
+	}
+	// 报告行建议列格式为 "建议字段类型 源端字段元信息"，去除源端字段元信息即建议字段类型
+	oracleColMeta := genColumnNullCommentDefaultMeta(oracleColInfo.NULLABLE,
+		common.SpecialLettersUsingMySQL([]byte(oracleColInfo.Comment)), oracleColInfo.OracleOriginDataDefault)
+	suggestDataType := strings.TrimSpace(strings.TrimSuffix(fmt.Sprintf("%v", tableRows[len(tableRows)-1]), oracleColMeta))
+	return fixedMsg, suggestDataType, tableRows, nil
+}
+
+func oracleTableColumnMapRuleCheck(
 	sourceSchema, targetSchema, tableName, columnName string,
 	oracleColInfo, mysqlColInfo public.Column) (string, table.Row, error) {
 	var tableRows table.Row
@@ -961,7 +976,8 @@ func OracleTableColumnMapRuleCheck(
 			)
 			return fixedMsg, tableRows, nil
 		} else if strings.Contains(oracleDataType, "TIMESTAMP") {
-			if strings.Contains(oracleDataType, "WITH TIME ZONE") || strings.Contains(oracleDataType, "WITH LOCAL TIME ZONE") {
+			// WITH TIME ZONE 转换为目标端时区存储 DATETIME，WITH LOCAL TIME ZONE 与 TIMESTAMP 同为会话时区语义
+			if strings.Contains(oracleDataType, "WITH TIME ZONE") {
 				if oracleDataScale <= 6 {
 					if mysqlDataType == "DATETIME" && mysqlDatetimePrecision == oracleDataScale && oracleDiffColMeta == mysqlDiffColMeta {
 						return "", nil, nil
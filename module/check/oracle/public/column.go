@@ -17,7 +17,10 @@ package public
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/wentaojin/transferdb/common"
+	"strconv"
+	"strings"
 )
 
 type Table struct {
@@ -118,3 +121,191 @@ func (c *Column) String() string {
 	jsonStr, _ := json.Marshal(c)
 	return string(jsonStr)
 }
+
+// 字段名映射规则，源端字段重命名以及不迁移字段移除，引用不迁移字段的索引、约束一并移除
+func (t *Table) ApplyColumnNameRule(columnNameRule *common.TableColumnNameRule) {
+	if columnNameRule == nil {
+		return
+	}
+	columns := make(map[string]Column, len(t.Columns))
+	for columnName, column := range t.Columns {
+		if columnNameT, ok := columnNameRule.TargetColumnName(columnName); ok {
+			columns[common.StringUPPER(columnNameT)] = column
+		}
+	}
+	t.Columns = columns
+
+	var indexes []Index
+	for _, idx := range t.Indexes {
+		if columnList, ok := targetColumnList(idx.IndexColumn, columnNameRule); ok {
+			idx.IndexColumn = columnList
+			indexes = append(indexes, idx)
+		}
+	}
+	t.Indexes = indexes
+
+	var puConstraints []ConstraintPUKey
+	for _, pu := range t.PUConstraints {
+		if columnList, ok := targetColumnList(pu.ConstraintColumn, columnNameRule); ok {
+			pu.ConstraintColumn = columnList
+			puConstraints = append(puConstraints, pu)
+		}
+	}
+	t.PUConstraints = puConstraints
+
+	var fkConstraints []ConstraintForeign
+	for _, fk := range t.ForeignConstraints {
+		if columnList, ok := targetColumnList(fk.ColumnName, columnNameRule); ok {
+			fk.ColumnName = columnList
+			fkConstraints = append(fkConstraints, fk)
+		}
+	}
+	t.ForeignConstraints = fkConstraints
+}
+
+func targetColumnList(columnList string, columnNameRule *common.TableColumnNameRule) (string, bool) {
+	columns := strings.Split(columnList, ",")
+	for i, c := range columns {
+		columnNameT, ok := columnNameRule.TargetColumnName(strings.TrimSpace(c))
+		if !ok {
+			return columnList, false
+		}
+		columns[i] = common.StringUPPER(columnNameT)
+	}
+	return strings.Join(columns, ","), true
+}
+
+// 目标端字段类型容量，用于判断字段修改是否收窄
+var (
+	integerTypeDigits = map[string]int{
+		"TINYINT":   3,
+		"SMALLINT":  5,
+		"MEDIUMINT": 8,
+		"INT":       10,
+		"INTEGER":   10,
+		"BIGINT":    20,
+	}
+	stringTypeLength = map[string]int{
+		"TINYTEXT":   255,
+		"TEXT":       65535,
+		"MEDIUMTEXT": 16777215,
+		"LONGTEXT":   4294967295,
+		"TINYBLOB":   255,
+		"BLOB":       65535,
+		"MEDIUMBLOB": 16777215,
+		"LONGBLOB":   4294967295,
+	}
+)
+
+// NarrowCheck 以下游现有字段为基准，判断修改为 targetDataType (例如 DECIMAL(10,2)、VARCHAR(20)) 是否可能丢失数据
+func (c *Column) NarrowCheck(targetDataType string) (bool, string) {
+	sourceType := common.StringUPPER(c.DataType)
+	targetType, targetArgs := splitDataType(targetDataType)
+
+	sourceFamily, targetFamily := dataTypeFamily(sourceType), dataTypeFamily(targetType)
+
+	switch {
+	case sourceFamily == "integer" && targetFamily == "integer":
+		if integerTypeDigits[targetType] < integerTypeDigits[sourceType] {
+			return true, fmt.Sprintf("integer type narrowing from %s to %s", sourceType, targetType)
+		}
+	case sourceFamily == "integer" && targetFamily == "decimal":
+		precision, scale := decimalArgs(targetArgs)
+		if precision-scale < integerTypeDigits[sourceType] {
+			return true, fmt.Sprintf("integer digits narrowing from %s to %s", sourceType, targetDataType)
+		}
+	case sourceFamily == "decimal" && targetFamily == "integer":
+		precision, scale := atoi(c.DataPrecision), atoi(c.DataScale)
+		if scale > 0 || precision > integerTypeDigits[targetType] {
+			return true, fmt.Sprintf("decimal narrowing from %s(%d,%d) to %s", sourceType, precision, scale, targetType)
+		}
+	case sourceFamily == "decimal" && targetFamily == "decimal":
+		precision, scale := atoi(c.DataPrecision), atoi(c.DataScale)
+		targetPrecision, targetScale := decimalArgs(targetArgs)
+		if targetScale < scale || targetPrecision-targetScale < precision-scale {
+			return true, fmt.Sprintf("decimal narrowing from %s(%d,%d) to %s", sourceType, precision, scale, targetDataType)
+		}
+	case sourceFamily == "float" && targetFamily == "float":
+		if sourceType == "DOUBLE" && targetType == "FLOAT" {
+			return true, fmt.Sprintf("float narrowing from %s to %s", sourceType, targetType)
+		}
+	case (sourceFamily == "string" && targetFamily == "string") || (sourceFamily == "binary" && targetFamily == "binary"):
+		targetLength, ok := stringTypeLength[targetType]
+		if !ok && len(targetArgs) > 0 {
+			targetLength = atoi(targetArgs[0])
+		}
+		if targetLength < atoi(c.DataLength) {
+			return true, fmt.Sprintf("length narrowing from %s(%s) to %s", sourceType, c.DataLength, targetDataType)
+		}
+	case sourceFamily == "datetime" && targetFamily == "datetime":
+		if targetType == "DATE" && sourceType != "DATE" {
+			return true, fmt.Sprintf("time part lost from %s to %s", sourceType, targetType)
+		}
+		if sourceType != "DATE" && targetType != "DATE" && len(targetArgs) > 0 && atoi(targetArgs[0]) < atoi(c.DatetimePrecision) {
+			return true, fmt.Sprintf("fractional seconds narrowing from %s(%s) to %s", sourceType, c.DatetimePrecision, targetDataType)
+		}
+	case sourceFamily != targetFamily && !(sourceFamily != "string" && targetFamily == "string"):
+		// 非字符类型转换为字符类型不丢失数据，其余跨类型修改均视为不安全
+		return true, fmt.Sprintf("data type changed from %s to %s", sourceType, targetDataType)
+	}
+	return false, ""
+}
+
+// 同义数据类型统一，便于按类型族比较
+var dataTypeSynonyms = map[string]string{
+	"DOUBLE PRECISION": "DOUBLE",
+	"NVARCHAR":         "VARCHAR",
+	"NCHAR VARYING":    "VARCHAR",
+	"NCHAR":            "CHAR",
+}
+
+func splitDataType(dataType string) (string, []string) {
+	dataType = common.StringUPPER(strings.TrimSpace(dataType))
+	var args []string
+	if idx := strings.Index(dataType, "("); idx != -1 {
+		args = strings.Split(strings.TrimSuffix(dataType[idx+1:], ")"), ",")
+		for i, a := range args {
+			args[i] = strings.TrimSpace(a)
+		}
+		dataType = strings.TrimSpace(dataType[:idx])
+	}
+	if t, ok := dataTypeSynonyms[dataType]; ok {
+		dataType = t
+	}
+	return dataType, args
+}
+
+func dataTypeFamily(dataType string) string {
+	switch {
+	case integerTypeDigits[dataType] > 0:
+		return "integer"
+	case dataType == "DECIMAL" || dataType == "NUMERIC":
+		return "decimal"
+	case dataType == "FLOAT" || dataType == "DOUBLE" || dataType == "REAL":
+		return "float"
+	case dataType == "CHAR" || dataType == "VARCHAR" || strings.HasSuffix(dataType, "TEXT"):
+		return "string"
+	case dataType == "BINARY" || dataType == "VARBINARY" || strings.HasSuffix(dataType, "BLOB"):
+		return "binary"
+	case dataType == "DATE" || dataType == "DATETIME" || dataType == "TIMESTAMP":
+		return "datetime"
+	default:
+		return dataType
+	}
+}
+
+func decimalArgs(args []string) (int, int) {
+	switch len(args) {
+	case 0:
+		return 10, 0
+	case 1:
+		return atoi(args[0]), 0
+	default:
+		return atoi(args[0]), atoi(args[1])
+	}
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(strings.TrimSpace(s))
+	return i
+}
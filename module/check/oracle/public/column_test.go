@@ -0,0 +1,53 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import "testing"
+
+func TestColumnNarrowCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		column         Column
+		targetDataType string
+		wantUnsafe     bool
+	}{
+		{name: "integer widen", column: Column{DataType: "INT"}, targetDataType: "BIGINT"},
+		{name: "integer narrow", column: Column{DataType: "BIGINT"}, targetDataType: "INT", wantUnsafe: true},
+		{name: "integer to decimal", column: Column{DataType: "INT"}, targetDataType: "DECIMAL(65,30)"},
+		{name: "integer to small decimal", column: Column{DataType: "BIGINT"}, targetDataType: "DECIMAL(10,2)", wantUnsafe: true},
+		{name: "decimal scale narrow", column: Column{DataType: "DECIMAL", ColumnInfo: ColumnInfo{DataPrecision: "10", DataScale: "4"}}, targetDataType: "DECIMAL(10,2)", wantUnsafe: true},
+		{name: "decimal widen", column: Column{DataType: "DECIMAL", ColumnInfo: ColumnInfo{DataPrecision: "10", DataScale: "2"}}, targetDataType: "DECIMAL(38,2)"},
+		{name: "decimal to integer", column: Column{DataType: "DECIMAL", ColumnInfo: ColumnInfo{DataPrecision: "10", DataScale: "2"}}, targetDataType: "BIGINT", wantUnsafe: true},
+		{name: "double precision", column: Column{DataType: "DOUBLE"}, targetDataType: "DOUBLE PRECISION"},
+		{name: "double to float", column: Column{DataType: "DOUBLE"}, targetDataType: "FLOAT", wantUnsafe: true},
+		{name: "varchar widen", column: Column{DataType: "VARCHAR", ColumnInfo: ColumnInfo{DataLength: "20"}}, targetDataType: "VARCHAR(30)"},
+		{name: "varchar narrow", column: Column{DataType: "VARCHAR", ColumnInfo: ColumnInfo{DataLength: "20"}}, targetDataType: "VARCHAR(10)", wantUnsafe: true},
+		{name: "nchar varying narrow", column: Column{DataType: "VARCHAR", ColumnInfo: ColumnInfo{DataLength: "20"}}, targetDataType: "NCHAR VARYING(10)", wantUnsafe: true},
+		{name: "nvarchar widen", column: Column{DataType: "VARCHAR", ColumnInfo: ColumnInfo{DataLength: "20"}}, targetDataType: "NVARCHAR(20)"},
+		{name: "varchar to text", column: Column{DataType: "VARCHAR", ColumnInfo: ColumnInfo{DataLength: "2000"}}, targetDataType: "LONGTEXT"},
+		{name: "datetime to date", column: Column{DataType: "DATETIME"}, targetDataType: "DATE", wantUnsafe: true},
+		{name: "datetime fraction narrow", column: Column{DataType: "DATETIME", ColumnInfo: ColumnInfo{DatetimePrecision: "6"}}, targetDataType: "DATETIME(3)", wantUnsafe: true},
+		{name: "number to string", column: Column{DataType: "INT"}, targetDataType: "VARCHAR(30)"},
+		{name: "string to number", column: Column{DataType: "VARCHAR", ColumnInfo: ColumnInfo{DataLength: "20"}}, targetDataType: "DECIMAL(38)", wantUnsafe: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if unsafe, reason := tt.column.NarrowCheck(tt.targetDataType); unsafe != tt.wantUnsafe {
+				t.Errorf("NarrowCheck() = (%v, %v), want unsafe %v", unsafe, reason, tt.wantUnsafe)
+			}
+		})
+	}
+}
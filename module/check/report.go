@@ -0,0 +1,49 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"strings"
+)
+
+// 表结构对比不一致类别，用于机器可读结果报告
+const (
+	CategoryPartitionType    = "partition_type"
+	CategoryTableComment     = "table_comment"
+	CategoryTableCharset     = "table_charset_collation"
+	CategoryColumnCounts     = "column_counts"
+	CategoryPrimaryUniqueKey = "primary_unique_key"
+	CategoryForeignKey       = "foreign_key"
+	CategoryCheckKey         = "check_key"
+	CategoryIndex            = "index"
+	CategoryPartition        = "partition"
+	CategoryColumn           = "column"
+)
+
+// StatementCounts 统计对比输出内修复语句数作为不一致数，无修复语句 (需人工处理) 计为 1
+func StatementCounts(s string) int {
+	counts := 0
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.ToUpper(strings.TrimSpace(line))
+		if strings.HasPrefix(line, "ALTER ") || strings.HasPrefix(line, "CREATE ") {
+			counts++
+		}
+	}
+	if counts == 0 && strings.TrimSpace(s) != "" {
+		return 1
+	}
+	return counts
+}
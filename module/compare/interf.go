@@ -15,9 +15,13 @@ limitations under the License.
 */
 package compare
 
+import "github.com/wentaojin/transferdb/common"
+
 type Processor interface {
 	AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo string, err error)
 	FilterDBWhereColumn() (string, error)
+	FilterDBCompareKey() ([]common.CompareKeyColumn, error)
+	FilterDBCompareColumn() ([]common.CompareColumn, error)
 	IsPartitionTable() (string, error)
 }
 
@@ -32,6 +36,7 @@ type Reporter interface {
 	CheckMySQLRows(mysqlQuery string) (int64, error)
 	ReportCheckRows() (string, error)
 	ReportCheckCRC32() (string, error)
+	ReportCheckHash() (string, error)
 	Report() (string, error)
 }
 
@@ -32,25 +32,27 @@ import (
 
 // Chunk 数据对比
 type Chunk struct {
-	Ctx              context.Context `json:"-"`
-	ChunkID          int             `json:"chunk_id"`
-	SourceGlobalSCN  uint64          `json:"source_global_scn"`
-	SourceTable      string          `json:"source_table"`
-	TargetTable      string          `json:"target_table"`
-	IsPartition      string          `json:"is_partition"`
-	SourceColumnInfo string          `json:"source_column_info"`
-	TargetColumnInfo string          `json:"target_column_info"`
-	WhereColumn      string          `json:"where_column"`
-	WhereRange       string          `json:"where_range"` // chunk split need
-	Cfg              *config.Config  `json:"-"`
-	Oracle           *oracle.Oracle  `json:"-"`
-	MySQL            *mysql.MySQL    `json:"-"`
-	MetaDB           *meta.Meta      `json:"-"`
+	Ctx              context.Context           `json:"-"`
+	ChunkID          int                       `json:"chunk_id"`
+	SourceGlobalSCN  uint64                    `json:"source_global_scn"`
+	SourceTable      string                    `json:"source_table"`
+	TargetTable      string                    `json:"target_table"`
+	IsPartition      string                    `json:"is_partition"`
+	SourceColumnInfo string                    `json:"source_column_info"`
+	TargetColumnInfo string                    `json:"target_column_info"`
+	WhereColumn      string                    `json:"where_column"`
+	WhereRange       string                    `json:"where_range"` // chunk split need
+	CompareKey       []common.CompareKeyColumn `json:"compare_key"`
+	TableRoute       *common.TableRoute        `json:"-"`
+	Cfg              *config.Config            `json:"-"`
+	Oracle           *oracle.Oracle            `json:"-"`
+	MySQL            *mysql.MySQL              `json:"-"`
+	MetaDB           *meta.Meta                `json:"-"`
 }
 
 func NewChunk(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta,
 	chunkID int, sourceGlobalSCN uint64, sourceTable, targetTable string, isPartition string, sourceColumnInfo, targetColumnInfo string,
-	whereColumn string) *Chunk {
+	whereColumn string, compareKey []common.CompareKeyColumn, tableRoute *common.TableRoute) *Chunk {
 	return &Chunk{
 		Ctx:              ctx,
 		ChunkID:          chunkID,
@@ -61,6 +63,8 @@ func NewChunk(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, my
 		SourceColumnInfo: sourceColumnInfo,
 		TargetColumnInfo: targetColumnInfo,
 		WhereColumn:      whereColumn,
+		CompareKey:       compareKey,
+		TableRoute:       tableRoute,
 		Oracle:           oracle,
 		MySQL:            mysql,
 		MetaDB:           metaDB,
@@ -109,7 +113,7 @@ func (c *Chunk) Split() error {
 	startTime := time.Now()
 
 	// 配置文件参数优先级
-	// onlyCheckRows > configRange > configIndexFiled > DBFilter Integer Column
+	// onlyCheckRows > configRange > configIndexFiled > DBFilter Integer Column > DBFilter Compare Key > Whole Table Hash
 	// first
 	if c.Cfg.DiffConfig.OnlyCheckRows {
 		// SELECT COUNT(1) FROM TAB WHERE 1=1
@@ -118,7 +122,7 @@ func (c *Chunk) Split() error {
 		c.WhereColumn = ""
 		c.WhereRange = "1 = 1"
 
-		err := meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err := c.createDataCompareMeta([]meta.DataCompareMeta{{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
@@ -132,18 +136,7 @@ func (c *Chunk) Split() error {
 			TaskMode:      c.Cfg.TaskMode,
 			TaskStatus:    common.TaskStatusWaiting,
 			IsPartition:   c.IsPartition,
-		}, &meta.WaitSyncMeta{
-			DBTypeS:          c.Cfg.DBTypeS,
-			DBTypeT:          c.Cfg.DBTypeT,
-			SchemaNameS:      common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
-			TableNameS:       common.StringUPPER(c.SourceTable),
-			TaskMode:         c.Cfg.TaskMode,
-			GlobalScnS:       c.SourceGlobalSCN,
-			ChunkTotalNums:   1,
-			ChunkSuccessNums: 0,
-			ChunkFailedNums:  0,
-			IsPartition:      c.IsPartition,
-		})
+		}})
 		if err != nil {
 			return err
 		}
@@ -163,7 +156,7 @@ func (c *Chunk) Split() error {
 		// select xxx from tab where age > 1 and age < 10
 		c.WhereRange = customRange
 		c.WhereColumn = ""
-		err = meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err = c.createDataCompareMeta([]meta.DataCompareMeta{{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
@@ -177,18 +170,7 @@ func (c *Chunk) Split() error {
 			TaskMode:      c.Cfg.TaskMode,
 			TaskStatus:    common.TaskStatusWaiting,
 			IsPartition:   c.IsPartition,
-		}, &meta.WaitSyncMeta{
-			DBTypeS:          c.Cfg.DBTypeS,
-			DBTypeT:          c.Cfg.DBTypeT,
-			SchemaNameS:      common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
-			TableNameS:       common.StringUPPER(c.SourceTable),
-			TaskMode:         c.Cfg.TaskMode,
-			GlobalScnS:       c.SourceGlobalSCN,
-			ChunkTotalNums:   1,
-			ChunkSuccessNums: 0,
-			ChunkFailedNums:  0,
-			IsPartition:      c.IsPartition,
-		})
+		}})
 		if err != nil {
 			return err
 		}
@@ -209,7 +191,7 @@ func (c *Chunk) Split() error {
 			zap.Int("statistics rows", tableRowsByStatistics))
 		c.WhereRange = "1 = 1"
 		c.WhereColumn = ""
-		err = meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err = c.createDataCompareMeta([]meta.DataCompareMeta{{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
@@ -223,18 +205,8 @@ func (c *Chunk) Split() error {
 			TaskMode:      c.Cfg.TaskMode,
 			TaskStatus:    common.TaskStatusWaiting,
 			IsPartition:   c.IsPartition,
-		}, &meta.WaitSyncMeta{
-			DBTypeS:          c.Cfg.DBTypeS,
-			DBTypeT:          c.Cfg.DBTypeT,
-			SchemaNameS:      common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
-			TableNameS:       common.StringUPPER(c.SourceTable),
-			TaskMode:         c.Cfg.TaskMode,
-			GlobalScnS:       c.SourceGlobalSCN,
-			ChunkTotalNums:   1,
-			ChunkSuccessNums: 0,
-			ChunkFailedNums:  0,
-			IsPartition:      c.IsPartition,
-		})
+			CompareMethod: c.wholeTableCompareMethod(customColumn),
+		}})
 		if err != nil {
 			return err
 		}
@@ -252,6 +224,19 @@ func (c *Chunk) Split() error {
 		c.WhereColumn = customColumn
 	}
 
+	// fifth
+	// 无 NUMBER 字段，主键/唯一键范围切分，无可用切分键全表流式聚合对比
+	if strings.EqualFold(c.WhereColumn, "") {
+		if err = c.splitByCompareKey(tableRowsByStatistics); err != nil {
+			return err
+		}
+		zap.L().Info("pre split oracle and mysql table chunk finished",
+			zap.String("schema", c.Cfg.SchemaConfig.SourceSchema),
+			zap.String("table", c.SourceTable),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+		return nil
+	}
+
 	taskName := common.StringsBuilder(common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), `_`, c.SourceTable, `_`, `TASK`, strconv.Itoa(c.ChunkID))
 
 	if err = c.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
@@ -278,7 +263,7 @@ func (c *Chunk) Split() error {
 
 		c.WhereRange = "1 = 1"
 		c.WhereColumn = ""
-		err = meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err = c.createDataCompareMeta([]meta.DataCompareMeta{{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
@@ -292,18 +277,7 @@ func (c *Chunk) Split() error {
 			TaskMode:      c.Cfg.TaskMode,
 			TaskStatus:    common.TaskStatusWaiting,
 			IsPartition:   c.IsPartition,
-		}, &meta.WaitSyncMeta{
-			DBTypeS:          c.Cfg.DBTypeS,
-			DBTypeT:          c.Cfg.DBTypeT,
-			SchemaNameS:      common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
-			TableNameS:       common.StringUPPER(c.SourceTable),
-			TaskMode:         c.Cfg.TaskMode,
-			GlobalScnS:       c.SourceGlobalSCN,
-			ChunkTotalNums:   1,
-			ChunkSuccessNums: 0,
-			ChunkFailedNums:  0,
-			IsPartition:      c.IsPartition,
-		})
+		}})
 		if err != nil {
 			return err
 		}
@@ -369,19 +343,7 @@ func (c *Chunk) Split() error {
 	}
 
 	// 元数据库信息 batch 写入
-	err = meta.NewCommonModel(c.MetaDB).BatchCreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx,
-		fullMetas, c.Cfg.AppConfig.InsertBatchSize, &meta.WaitSyncMeta{
-			DBTypeS:          c.Cfg.DBTypeS,
-			DBTypeT:          c.Cfg.DBTypeT,
-			SchemaNameS:      common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
-			TableNameS:       common.StringUPPER(c.SourceTable),
-			TaskMode:         c.Cfg.TaskMode,
-			GlobalScnS:       c.SourceGlobalSCN,
-			ChunkTotalNums:   int64(len(fullMetas)),
-			ChunkSuccessNums: 0,
-			ChunkFailedNums:  0,
-			IsPartition:      c.IsPartition,
-		})
+	err = c.createDataCompareMeta(fullMetas)
 	if err != nil {
 		return fmt.Errorf("create table [%s.%s] data_diff_meta [batch size] failed: %v", common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), c.SourceTable, err)
 	}
@@ -398,6 +360,169 @@ func (c *Chunk) Split() error {
 
 }
 
+// 主键/唯一键范围切分，源端采样选取切分键边界，上下游按各自二进制比较表达式以及字面量生成范围条件
+// 复合键范围条件超出元数据长度限制，以首字段作为边界
+func (c *Chunk) splitByCompareKey(tableRows int) error {
+	wholeTable := meta.DataCompareMeta{
+		DBTypeS:       c.Cfg.DBTypeS,
+		DBTypeT:       c.Cfg.DBTypeT,
+		SchemaNameS:   common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
+		TableNameS:    common.StringUPPER(c.SourceTable),
+		ColumnDetailS: c.SourceColumnInfo,
+		SchemaNameT:   common.StringUPPER(c.Cfg.SchemaConfig.TargetSchema),
+		TableNameT:    common.StringUPPER(c.TargetTable),
+		ColumnDetailT: c.TargetColumnInfo,
+		WhereRange:    "1 = 1",
+		TaskMode:      c.Cfg.TaskMode,
+		TaskStatus:    common.TaskStatusWaiting,
+		IsPartition:   c.IsPartition,
+		CompareMethod: c.wholeTableCompareMethod(""),
+	}
+	if len(c.CompareKey) == 0 {
+		zap.L().Warn("oracle table hasn't available compare key, compare by whole table hash",
+			zap.String("schema", common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema)),
+			zap.String("table", c.SourceTable))
+		return c.createDataCompareMeta([]meta.DataCompareMeta{wholeTable})
+	}
+
+	var keyColumns []oracle.ChunkKeyColumn
+	for _, k := range c.CompareKey {
+		keyColumns = append(keyColumns, oracle.ChunkKeyColumn{ColumnName: k.ColumnName, DataType: k.DataType})
+	}
+	bounds, err := c.Oracle.GetOracleTableChunkKeyBounds(common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema), c.SourceTable, keyColumns, tableRows, c.Cfg.DiffConfig.ChunkSize, 0)
+	if err != nil {
+		return err
+	}
+	if len(bounds) == 0 {
+		return c.createDataCompareMeta([]meta.DataCompareMeta{wholeTable})
+	}
+
+	rangesS, rangesT, ok := compareKeyRanges(c.CompareKey, bounds)
+	if !ok {
+		return fmt.Errorf("oracle schema [%s] table [%s] compare key [%v] range condition exceeds max length [%d], please config index-fields or range",
+			c.Cfg.SchemaConfig.SourceSchema, c.SourceTable, keyColumns, common.MigrateChunkDetailMaxLength)
+	}
+
+	var fullMetas []meta.DataCompareMeta
+	for i := range rangesS {
+		fullMetas = append(fullMetas, meta.DataCompareMeta{
+			DBTypeS:       c.Cfg.DBTypeS,
+			DBTypeT:       c.Cfg.DBTypeT,
+			SchemaNameS:   common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:    common.StringUPPER(c.SourceTable),
+			SchemaNameT:   common.StringUPPER(c.Cfg.SchemaConfig.TargetSchema),
+			TableNameT:    common.StringUPPER(c.TargetTable),
+			ColumnDetailS: c.SourceColumnInfo,
+			ColumnDetailT: c.TargetColumnInfo,
+			WhereRange:    rangesS[i],
+			WhereRangeT:   rangesT[i],
+			IsPartition:   c.IsPartition,
+			TaskMode:      c.Cfg.TaskMode,
+			TaskStatus:    common.TaskStatusWaiting})
+	}
+
+	zap.L().Info("oracle table chunk by compare key finished",
+		zap.String("schema", c.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", c.SourceTable),
+		zap.Any("key", keyColumns),
+		zap.Int("chunks", len(fullMetas)))
+
+	return c.createDataCompareMeta(fullMetas)
+}
+
+// 切分键边界生成上下游 chunk 范围条件，复合键范围条件超出元数据长度限制以首字段作为边界，仍超出返回 false
+func compareKeyRanges(compareKey []common.CompareKeyColumn, bounds [][]string) ([]string, []string, bool) {
+	var (
+		columnsS, columnsT   []string
+		literalsS, literalsT [][]string
+	)
+	for _, k := range compareKey {
+		columnsS = append(columnsS, k.ColumnS)
+		columnsT = append(columnsT, k.ColumnT)
+	}
+	for _, b := range bounds {
+		var literalS, literalT []string
+		for i, k := range compareKey {
+			literalS = append(literalS, oracle.ChunkKeyLiteral(b[i], k.DataType))
+			literalT = append(literalT, common.MySQLCompareKeyLiteral(b[i], k))
+		}
+		literalsS = append(literalsS, literalS)
+		literalsT = append(literalsT, literalT)
+	}
+
+	rangesS := common.GenChunkKeyRange(columnsS, literalsS)
+	rangesT := common.GenChunkKeyRange(columnsT, literalsT)
+	if len(compareKey) > 1 && compareKeyRangeOverLength(rangesS) {
+		rangesS = common.GenChunkKeyRange(columnsS[:1], common.ChunkKeyLeadingBounds(literalsS))
+		rangesT = common.GenChunkKeyRange(columnsT[:1], common.ChunkKeyLeadingBounds(literalsT))
+	}
+	if compareKeyRangeOverLength(rangesS) || len(rangesS) != len(rangesT) {
+		return nil, nil, false
+	}
+	return rangesS, rangesT, true
+}
+
+// 全表单 chunk 对比方式，无 NUMBER 字段以及可用切分键的表全表流式聚合对比，避免缓存全表数据
+func (c *Chunk) wholeTableCompareMethod(customColumn string) string {
+	if strings.EqualFold(c.WhereColumn, "") && strings.EqualFold(customColumn, "") && len(c.CompareKey) == 0 {
+		return common.CompareMethodHash
+	}
+	return common.CompareMethodRows
+}
+
+func compareKeyRangeOverLength(chunkRanges []string) bool {
+	for _, r := range chunkRanges {
+		if len(r) > common.MigrateChunkDetailMaxLength {
+			return true
+		}
+	}
+	return false
+}
+
+// 元数据库信息写入
+// 表路由规则，merge 目标端以区分字段过滤，hash/range 每个 chunk 按分片拆分，源端以分片条件过滤
+func (c *Chunk) createDataCompareMeta(compareMetas []meta.DataCompareMeta) error {
+	for i := range compareMetas {
+		if strings.EqualFold(compareMetas[i].CompareMethod, "") {
+			compareMetas[i].CompareMethod = common.CompareMethodRows
+		}
+	}
+	switch {
+	case c.TableRoute.IsMerge():
+		for i := range compareMetas {
+			compareMetas[i].SchemaNameT = c.TableRoute.SchemaNameT
+			compareMetas[i].TableNameT = c.TableRoute.TableNameT
+			compareMetas[i].RouteWhereT = c.TableRoute.DiscriminatorWhereT()
+		}
+	case c.TableRoute.IsSplit():
+		var routeMetas []meta.DataCompareMeta
+		for _, m := range compareMetas {
+			for _, shard := range c.TableRoute.Shards {
+				routeMeta := m
+				routeMeta.SchemaNameT = shard.SchemaNameT
+				routeMeta.TableNameT = shard.TableNameT
+				routeMeta.RouteWhereS = c.TableRoute.ShardWhereS(shard)
+				routeMetas = append(routeMetas, routeMeta)
+			}
+		}
+		compareMetas = routeMetas
+	}
+
+	return meta.NewCommonModel(c.MetaDB).BatchCreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx,
+		compareMetas, c.Cfg.AppConfig.InsertBatchSize, &meta.WaitSyncMeta{
+			DBTypeS:          c.Cfg.DBTypeS,
+			DBTypeT:          c.Cfg.DBTypeT,
+			SchemaNameS:      common.StringUPPER(c.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:       common.StringUPPER(c.SourceTable),
+			TaskMode:         c.Cfg.TaskMode,
+			GlobalScnS:       c.SourceGlobalSCN,
+			ChunkTotalNums:   int64(len(compareMetas)),
+			ChunkSuccessNums: 0,
+			ChunkFailedNums:  0,
+			IsPartition:      c.IsPartition,
+		})
+}
+
 func (c *Chunk) String() string {
 	jsonByte, _ := json.Marshal(c)
 	return string(jsonByte)
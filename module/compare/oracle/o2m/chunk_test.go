@@ -0,0 +1,61 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareKeyRanges(t *testing.T) {
+	id := common.CompareKeyColumn{ColumnName: "ID", DataType: "NUMBER", ColumnS: `"ID"`, ColumnT: "`ID`"}
+	name := common.CompareKeyColumn{ColumnName: "NAME", DataType: "VARCHAR2", ColumnS: `"NAME"`, ColumnT: "`NAME`"}
+
+	rangesS, rangesT, ok := compareKeyRanges([]common.CompareKeyColumn{id, name}, [][]string{{"10", "a"}})
+	if !ok {
+		t.Fatal("compareKeyRanges() composite key = false, want true")
+	}
+	wantS := []string{
+		`("ID" < 10 OR "ID" = 10 AND "NAME" <= 'a')`,
+		`("ID" > 10 OR "ID" = 10 AND "NAME" > 'a')`,
+	}
+	wantT := []string{
+		"(`ID` < 10 OR `ID` = 10 AND `NAME` <= _utf8mb4 X'61')",
+		"(`ID` > 10 OR `ID` = 10 AND `NAME` > _utf8mb4 X'61')",
+	}
+	if !reflect.DeepEqual(rangesS, wantS) || !reflect.DeepEqual(rangesT, wantT) {
+		t.Errorf("compareKeyRanges() = %v, %v, want %v, %v", rangesS, rangesT, wantS, wantT)
+	}
+
+	// 复合键超出 chunk_detail_s 长度，退化为首字段边界且相同首字段边界去重
+	long := strings.Repeat("x", 120)
+	rangesS, rangesT, ok = compareKeyRanges([]common.CompareKeyColumn{id, name}, [][]string{{"10", long}, {"10", long + "y"}, {"20", long}})
+	if !ok {
+		t.Fatal("compareKeyRanges() leading column fallback = false, want true")
+	}
+	wantS = []string{`"ID" <= 10`, `"ID" > 10 AND "ID" <= 20`, `"ID" > 20`}
+	wantT = []string{"`ID` <= 10", "`ID` > 10 AND `ID` <= 20", "`ID` > 20"}
+	if !reflect.DeepEqual(rangesS, wantS) || !reflect.DeepEqual(rangesT, wantT) {
+		t.Errorf("compareKeyRanges() fallback = %v, %v, want %v, %v", rangesS, rangesT, wantS, wantT)
+	}
+
+	// 单字段键无法退化
+	if _, _, ok = compareKeyRanges([]common.CompareKeyColumn{name}, [][]string{{strings.Repeat("x", 300)}}); ok {
+		t.Error("compareKeyRanges() over length single key = true, want false")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"strings"
+	"testing"
+)
+
+func newColumnDiffReport(compareColumns []common.CompareColumn) *Report {
+	return &Report{
+		DataCompareMeta: meta.DataCompareMeta{SchemaNameT: "MARVIN", TableNameT: "T", WhereRange: "1 = 1"},
+		CompareColumns:  compareColumns,
+	}
+}
+
+func TestReportColumnDiff(t *testing.T) {
+	r := newColumnDiffReport([]common.CompareColumn{
+		{ColumnName: "ID", DataTypeS: "NUMBER", DataTypeT: "DECIMAL(10,0)", IsKey: true},
+		{ColumnName: "NAME", DataTypeS: "VARCHAR2(10)", DataTypeT: "VARCHAR(10)"},
+		{ColumnName: "AMT", DataTypeS: "NUMBER(10,2)", DataTypeT: "DECIMAL(10,2)"},
+	})
+
+	// ID=1 NAME 不一致，ID=2 AMT 不一致，ID=3 以及 ID=4 无法配对
+	diff := r.reportColumnDiff(
+		[]string{"1,a,1.00", "2,b,2.00", "3,c,3.00"},
+		[]string{"1,x,1.00", "2,b,2.50", "4,d,4.00"})
+
+	for _, want := range []string{
+		"mysql table [MARVIN.T] chunk [1 = 1] data rows column differences, paired rows [2] by key",
+		"ID=1", "ID=2", "2.50", "50.00%",
+	} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("reportColumnDiff() missing %q in:\n%s", want, diff)
+		}
+	}
+	for _, unwanted := range []string{"ID=3", "ID=4"} {
+		if strings.Contains(diff, unwanted) {
+			t.Errorf("reportColumnDiff() unpaired row %q reported in:\n%s", unwanted, diff)
+		}
+	}
+}
+
+func TestReportColumnDiffWithoutPair(t *testing.T) {
+	cols := []common.CompareColumn{
+		{ColumnName: "ID", DataTypeS: "NUMBER", DataTypeT: "DECIMAL(10,0)"},
+		{ColumnName: "NAME", DataTypeS: "VARCHAR2(10)", DataTypeT: "VARCHAR(10)"},
+	}
+	if diff := newColumnDiffReport(cols).reportColumnDiff([]string{"1,a"}, []string{"1,b"}); diff != "" {
+		t.Errorf("reportColumnDiff() without key = %q, want empty", diff)
+	}
+
+	cols[0].IsKey = true
+	if diff := newColumnDiffReport(cols).reportColumnDiff([]string{"1,a"}, []string{"2,a"}); diff != "" {
+		t.Errorf("reportColumnDiff() without paired rows = %q, want empty", diff)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"path/filepath"
+	"testing"
+)
+
+func newSampleCompare(t *testing.T, samplePercent int) *Compare {
+	t.Helper()
+	metaDB, err := meta.NewSQLiteMetaDBEngine(filepath.Join(t.TempDir(), "meta.db"), "", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	return &Compare{
+		ctx:    context.Background(),
+		metaDB: metaDB,
+		cfg: &config.Config{
+			DBTypeS:      common.DatabaseTypeOracle,
+			DBTypeT:      common.DatabaseTypeMySQL,
+			TaskMode:     common.TaskModeCompare,
+			SchemaConfig: config.SchemaConfig{SourceSchema: "MARVIN"},
+			DiffConfig:   config.DiffConfig{SamplePercent: samplePercent},
+		},
+	}
+}
+
+// 两个路由分片各 4 个 chunk，每轮抽样 25%，多轮抽样逐步覆盖且每轮覆盖各分片
+func TestCompareSampleRounds(t *testing.T) {
+	r := newSampleCompare(t, 25)
+
+	var chunks []meta.DataCompareMeta
+	for _, shard := range []string{"SHARD_1", "SHARD_2"} {
+		for i := 0; i < 4; i++ {
+			chunks = append(chunks, meta.DataCompareMeta{
+				DBTypeS:     r.cfg.DBTypeS,
+				DBTypeT:     r.cfg.DBTypeT,
+				SchemaNameS: "MARVIN",
+				TableNameS:  "T",
+				SchemaNameT: "MARVIN",
+				TableNameT:  "T",
+				WhereRange:  fmt.Sprintf("ID > %d AND ID <= %d", i*10, (i+1)*10),
+				RouteWhereS: shard,
+				TaskMode:    r.cfg.TaskMode,
+				TaskStatus:  common.TaskStatusWaiting,
+			})
+		}
+	}
+	model := meta.NewDataCompareMetaModel(r.metaDB)
+	if err := model.BatchCreateDataCompareMeta(r.ctx, chunks, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	sampled := make(map[uint]int)
+	for round := 1; round <= 4; round++ {
+		waitMetas, err := model.DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: "MARVIN",
+			TableNameS:  "T",
+			TaskMode:    r.cfg.TaskMode,
+			TaskStatus:  common.TaskStatusWaiting,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sample, err := r.newCompareSample("T", waitMetas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sample.round != round || sample.totalChunks != 8 || sample.waitChunks != int64(8-(round-1)*2) {
+			t.Fatalf("round %d: newCompareSample() = round %d totals %d wait %d", round, sample.round, sample.totalChunks, sample.waitChunks)
+		}
+		if len(sample.pickMetas) != 2 {
+			t.Fatalf("round %d: picked %d chunks, want 2", round, len(sample.pickMetas))
+		}
+		if sample.pickMetas[0].RouteWhereS == sample.pickMetas[1].RouteWhereS {
+			t.Errorf("round %d: picked chunks both in shard %s", round, sample.pickMetas[0].RouteWhereS)
+		}
+		// 模拟当前轮次校验完成
+		for _, m := range sample.pickMetas {
+			if _, ok := sampled[m.ID]; ok {
+				t.Errorf("round %d: chunk [%s] already sampled in round %d", round, m.WhereRange, sampled[m.ID])
+			}
+			sampled[m.ID] = round
+			if err = model.UpdateDataCompareMeta(r.ctx, &m, map[string]interface{}{
+				"TaskStatus": common.TaskStatusSuccess,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	all, err := model.DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T",
+		TaskMode:    r.cfg.TaskMode,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range all {
+		if m.SampleRound != sampled[m.ID] {
+			t.Errorf("chunk [%s %s] sample round = %d, want %d", m.RouteWhereS, m.WhereRange, m.SampleRound, sampled[m.ID])
+		}
+	}
+}
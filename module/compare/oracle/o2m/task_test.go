@@ -0,0 +1,123 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"testing"
+)
+
+func TestTaskCompareKeyColumn(t *testing.T) {
+	binTarget := map[string]string{"CHARACTER_SET_NAME": "utf8mb4", "COLLATION_NAME": "utf8mb4_bin"}
+	ciTarget := map[string]string{"CHARACTER_SET_NAME": "utf8mb4", "COLLATION_NAME": "utf8mb4_general_ci"}
+
+	tests := []struct {
+		name            string
+		charset         string
+		oracleCollation bool
+		nlsComp         string
+		column          oracle.ChunkKeyColumn
+		sourceColumn    map[string]string
+		targetColumn    map[string]string
+		wantColumnT     string
+		wantReason      bool
+	}{
+		{
+			name:         "number",
+			column:       oracle.ChunkKeyColumn{ColumnName: "ID", DataType: "NUMBER"},
+			sourceColumn: map[string]string{},
+			targetColumn: map[string]string{},
+			wantColumnT:  "`ID`",
+		},
+		{
+			name:         "timestamp scale over target",
+			column:       oracle.ChunkKeyColumn{ColumnName: "TS", DataType: "TIMESTAMP(9)"},
+			sourceColumn: map[string]string{"DATA_SCALE": "9"},
+			wantReason:   true,
+		},
+		{
+			name:         "nls_comp binary with target bin collation",
+			charset:      common.ORACLECharsetAL32UTF8,
+			nlsComp:      "BINARY",
+			column:       oracle.ChunkKeyColumn{ColumnName: "C", DataType: "VARCHAR2"},
+			targetColumn: binTarget,
+			wantColumnT:  "`C`",
+		},
+		{
+			name:       "nls_comp linguistic",
+			charset:    common.ORACLECharsetAL32UTF8,
+			nlsComp:    "LINGUISTIC",
+			column:     oracle.ChunkKeyColumn{ColumnName: "C", DataType: "VARCHAR2"},
+			wantReason: true,
+		},
+		{
+			// 12.2 及以上版本以字段排序规则为准，不回退数据库 NLS_COMP
+			name:            "column collation overrides nls_comp",
+			charset:         common.ORACLECharsetAL32UTF8,
+			oracleCollation: true,
+			nlsComp:         "BINARY",
+			column:          oracle.ChunkKeyColumn{ColumnName: "C", DataType: "VARCHAR2"},
+			sourceColumn:    map[string]string{"COLLATION": "BINARY_CI"},
+			targetColumn:    binTarget,
+			wantReason:      true,
+		},
+		{
+			name:         "target ci collation compare by binary",
+			charset:      common.ORACLECharsetAL32UTF8,
+			nlsComp:      "BINARY",
+			column:       oracle.ChunkKeyColumn{ColumnName: "C", DataType: "VARCHAR2"},
+			targetColumn: ciTarget,
+			wantColumnT:  "CAST(CONVERT(`C` USING utf8mb4) AS BINARY)",
+		},
+		{
+			name:         "source gbk compare by gbk binary",
+			charset:      common.ORACLECharsetZHS16GBK,
+			nlsComp:      "BINARY",
+			column:       oracle.ChunkKeyColumn{ColumnName: "C", DataType: "CHAR"},
+			targetColumn: binTarget,
+			wantColumnT:  "CAST(CONVERT(`C` USING gbk) AS BINARY)",
+		},
+		{
+			name:         "national character compare by utf16 binary",
+			charset:      common.ORACLECharsetAL32UTF8,
+			nlsComp:      "BINARY",
+			column:       oracle.ChunkKeyColumn{ColumnName: "C", DataType: "NVARCHAR2"},
+			targetColumn: binTarget,
+			wantColumnT:  "CAST(CONVERT(`C` USING utf16) AS BINARY)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &Task{
+				cfg:             &config.Config{OracleConfig: config.OracleConfig{Charset: tt.charset}},
+				sourceTableName: "T",
+				oracleCollation: tt.oracleCollation,
+			}
+			key, reason, err := task.compareKeyColumn(tt.column, tt.sourceColumn, tt.targetColumn, tt.nlsComp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tt.wantReason {
+				t.Fatalf("compareKeyColumn() reason = %q, want reason %v", reason, tt.wantReason)
+			}
+			if !tt.wantReason && key.ColumnT != tt.wantColumnT {
+				t.Errorf("compareKeyColumn() ColumnT = %v, want %v", key.ColumnT, tt.wantColumnT)
+			}
+		})
+	}
+}
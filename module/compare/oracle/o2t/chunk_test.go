@@ -0,0 +1,55 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+	"testing"
+)
+
+func TestCompareKeyRangesLeadingFallback(t *testing.T) {
+	compareKey := []common.CompareKeyColumn{
+		{ColumnName: "TENANT", DataType: "NUMBER", ColumnS: `"TENANT"`, ColumnT: "`TENANT`"},
+		{ColumnName: "CODE", DataType: "VARCHAR2", ColumnS: `"CODE"`, ColumnT: "`CODE`"},
+	}
+	long := strings.Repeat("z", 200)
+
+	rangesS, rangesT, ok := compareKeyRanges(compareKey, [][]string{{"1", long}, {"2", long}})
+	if !ok {
+		t.Fatal("expected leading column fallback")
+	}
+	if len(rangesS) != 3 || len(rangesT) != 3 {
+		t.Fatalf("expected 3 ranges, got %d/%d", len(rangesS), len(rangesT))
+	}
+	for i := range rangesS {
+		if strings.Contains(rangesS[i], "CODE") || strings.Contains(rangesT[i], "CODE") {
+			t.Errorf("range [%d] still contains non-leading column: %s / %s", i, rangesS[i], rangesT[i])
+		}
+	}
+	if rangesT[1] != "`TENANT` > 1 AND `TENANT` <= 2" {
+		t.Errorf("unexpected middle range: %s", rangesT[1])
+	}
+}
+
+func TestCompareKeyRangesOverLength(t *testing.T) {
+	compareKey := []common.CompareKeyColumn{
+		{ColumnName: "CODE", DataType: "VARCHAR2", ColumnS: `"CODE"`, ColumnT: "`CODE`"},
+	}
+	if _, _, ok := compareKeyRanges(compareKey, [][]string{{strings.Repeat("z", 300)}}); ok {
+		t.Error("expected single long key range to be rejected")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/compare"
 	"github.com/wentaojin/transferdb/module/compare/oracle/public"
 	"go.uber.org/zap"
@@ -49,7 +50,7 @@ func NewCompare(ctx context.Context, cfg *config.Config) (*Compare, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +105,12 @@ func (r *Compare) NewCompare() error {
 
 	// 关于全量断点恢复
 	if !r.cfg.DiffConfig.EnableCheckpoint {
-		err = meta.NewDataCompareMetaModel(r.metaDB).TruncateDataCompareMeta(r.ctx)
+		err = meta.NewDataCompareMetaModel(r.metaDB).TruncateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+			TaskMode:    r.cfg.TaskMode,
+		})
 		if err != nil {
 			return err
 		}
@@ -194,7 +200,7 @@ func (r *Compare) NewCompare() error {
 		return err
 	}
 	if errTotals > 0 {
-		return fmt.Errorf(`compare schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, update meta table [wait_sync_meta] column [task_status] table status RUNNING (Need UPPER); finally rerunning`, strings.ToUpper(r.cfg.SchemaConfig.SourceSchema), r.cfg.TaskMode)
+		return fmt.Errorf(`compare schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, run [-mode task -task-mode %s -action retry] to retry failed chunks, or run [-mode task -task-mode %s -action reset -table <table>] to reset table; finally rerunning`, strings.ToUpper(r.cfg.SchemaConfig.SourceSchema), r.cfg.TaskMode, strings.ToLower(r.cfg.TaskMode), strings.ToLower(r.cfg.TaskMode))
 	}
 
 	// 判断并记录待同步表列表
@@ -252,10 +258,6 @@ func (r *Compare) NewCompare() error {
 	}
 
 	// 判断未同步完成的表列表能否断点续传
-	var (
-		partSyncTables    []string
-		panicTblFullSlice []string
-	)
 	partWaitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).QueryWaitSyncMetaByPartTask(r.ctx, &meta.WaitSyncMeta{
 		DBTypeS:     r.cfg.DBTypeS,
 		DBTypeT:     r.cfg.DBTypeT,
@@ -266,25 +268,9 @@ func (r *Compare) NewCompare() error {
 	if err != nil {
 		return err
 	}
-	if len(partWaitSyncMetas) > 0 {
-		for _, t := range partWaitSyncMetas {
-			// 判断 running 状态表 chunk 数是否一致，一致可断点续传
-			chunkCounts, err := meta.NewDataCompareMetaModel(r.metaDB).CountsDataCompareMetaByTaskTable(r.ctx, &meta.DataCompareMeta{
-				DBTypeS:     t.DBTypeS,
-				DBTypeT:     t.DBTypeT,
-				SchemaNameS: t.SchemaNameS,
-				TaskMode:    t.TaskMode,
-				TaskStatus:  t.TaskStatus,
-			})
-			if err != nil {
-				return err
-			}
-			if chunkCounts != t.ChunkTotalNums {
-				panicTblFullSlice = append(panicTblFullSlice, t.TableNameS)
-			} else {
-				partSyncTables = append(partSyncTables, t.TableNameS)
-			}
-		}
+	partSyncTables, panicTblFullSlice, err := public.CheckpointTables(r.ctx, r.metaDB, partWaitSyncMetas)
+	if err != nil {
+		return err
 	}
 
 	if len(panicTblFullSlice) > 0 {
@@ -297,6 +283,29 @@ func (r *Compare) NewCompare() error {
 		return fmt.Errorf("checkpoint isn't consistent, can't be resume, please reruning [enable-checkpoint = fase]")
 	}
 
+	// 记录带时区时间类型数据转换时区，断点续传需与已记录时区保持一致
+	var panicTimeZoneTables []string
+	for _, t := range partWaitSyncMetas {
+		if t.TimeZone != "" && !strings.EqualFold(t.TimeZone, r.mysql.TimeZone) {
+			panicTimeZoneTables = append(panicTimeZoneTables, t.TableNameS)
+		}
+	}
+	if len(panicTimeZoneTables) > 0 {
+		return fmt.Errorf("table %v checkpoint time zone isn't equal to current time zone [%s], can't be resume, please keep config [mysql] time-zone consistent or reset table", panicTimeZoneTables, r.mysql.TimeZone)
+	}
+	if len(waitSyncTables) > 0 || len(partSyncTables) > 0 {
+		err = meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMetaTimeZone(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.cfg.SchemaConfig.SourceSchema),
+			TaskMode:    r.cfg.TaskMode,
+			TimeZone:    r.mysql.TimeZone,
+		}, append(waitSyncTables, partSyncTables...))
+		if err != nil {
+			return err
+		}
+	}
+
 	// ORACLE 环境信息
 	beginTime := time.Now()
 	oracleDBCharacterSet, err := r.oracle.GetOracleDBCharacterSet()
@@ -374,8 +383,26 @@ func (r *Compare) NewCompare() error {
 		}
 	}
 
+	// 获取字段数据转换规则
+	columnTransform, err := meta.NewColumnTransformRuleModel(r.metaDB).GetSchemaColumnTransform(r.ctx, r.cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.metaDB).GetSchemaColumnNameRule(r.ctx, r.cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取表路由规则
+	tableRoute, err := meta.NewTableRouteRuleModel(r.metaDB).GetSchemaTableRoute(r.ctx, r.cfg)
+	if err != nil {
+		return err
+	}
+
 	partTableTasks := NewPartCompareTableTask(r.ctx, r.cfg, partSyncTables, r.mysql, r.oracle, tableNameRuleMap)
-	waitTableTasks := NewWaitCompareTableTask(r.ctx, r.cfg, waitSyncTables, oracleCollation, r.mysql, r.oracle, tableNameRuleMap)
+	waitTableTasks := NewWaitCompareTableTask(r.ctx, r.cfg, waitSyncTables, oracleCollation, r.mysql, r.oracle, tableNameRuleMap, columnTransform, columnNameRule, tableRoute)
 
 	// 数据对比
 	err = common.PathExist(r.cfg.DiffConfig.FixSqlDir)
@@ -441,6 +468,18 @@ func (r *Compare) NewCompare() error {
 		return err
 	}
 
+	report, err := public.GenCompareReport(r.ctx, r.cfg, r.metaDB, exporters, checkFile, startTime)
+	if err != nil {
+		return err
+	}
+	if r.cfg.ReportConfig.Format != "" {
+		reportFile, err := report.Write(r.cfg.ReportConfig.ReportDir, r.cfg.ReportConfig.Format)
+		if err != nil {
+			return err
+		}
+		zap.L().Info("compare report", zap.String("output", reportFile), zap.String("status", report.Status))
+	}
+
 	zap.L().Info("compare", zap.String("fix sql file output", checkFile))
 	if len(failedTotals) == 0 {
 		zap.L().Info("compare table oracle to mysql finished",
@@ -456,7 +495,8 @@ func (r *Compare) NewCompare() error {
 			zap.String("failed tips", "failed detail, please see table [data_compare_meta]"),
 			zap.String("cost", time.Now().Sub(startTime).String()))
 	}
-	return nil
+
+	return report.Gate(r.cfg.ReportConfig.FailOn)
 }
 
 func (r *Compare) comparePartTableTasks(f *compare.File, partTableTasks []*Task) error {
@@ -500,84 +540,139 @@ func (r *Compare) comparePartTableTasks(f *compare.File, partTableTasks []*Task)
 			return err
 		}
 
+		// 抽样校验，按比例选取未校验 chunk，失败 chunk 全部重新校验
+		var sample *compareSample
+		if r.cfg.DiffConfig.SamplePercent > 0 && r.cfg.DiffConfig.SamplePercent < 100 {
+			sample, err = r.newCompareSample(task.sourceTableName, waitCompareMetas)
+			if err != nil {
+				return err
+			}
+			waitCompareMetas = sample.pickMetas
+		}
+
 		waitCompareMetas = append(waitCompareMetas, failedCompareMetas...)
 
-		// 设置工作池
-		// 设置 goroutine 数
-		g1 := &errgroup.Group{}
-		g1.SetLimit(r.cfg.DiffConfig.DiffThreads)
+		// 数据校验字段信息，数据不一致行按键配对输出字段级差异
+		var compareColumns []common.CompareColumn
+		if !r.cfg.DiffConfig.OnlyCheckRows && len(waitCompareMetas) > 0 {
+			compareColumns, err = task.FilterDBCompareColumn()
+			if err != nil {
+				return err
+			}
+		}
 
-		for _, compareMeta := range waitCompareMetas {
-			newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows)
-			g1.Go(func() error {
-				// 数据对比报告
-				report, err := public.IReport(newReport)
+		for _, batchMetas := range public.CompareSnapshotBatches(waitCompareMetas, r.cfg.DiffConfig.Consistent, r.cfg.DiffConfig.ConsistentBatch) {
+			// 一致性校验，按 chunk 批次申请表快照
+			var snapshot meta.IncrSnapshotMeta
+			if r.cfg.DiffConfig.Consistent {
+				snapshot, err = r.acquireSnapshot(task.sourceTableName)
 				if err != nil {
-					// error skip, continue
-					if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
-						DBTypeS:     newReport.DataCompareMeta.DBTypeS,
-						DBTypeT:     newReport.DataCompareMeta.DBTypeT,
-						SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
-						TableNameS:  newReport.DataCompareMeta.TableNameS,
-						TaskMode:    newReport.DataCompareMeta.TaskMode,
-						WhereRange:  newReport.DataCompareMeta.WhereRange,
-					}, map[string]interface{}{
-						"TaskStatus":  common.TaskStatusFailed,
-						"InfoDetail":  newReport.String(),
-						"ErrorDetail": err.Error(),
-					}); err != nil {
-						return err
-					}
-
-					return nil
+					return err
 				}
+			}
 
-				// 数据对比是否不一致
-				if !strings.EqualFold(report, "") {
-					var errMsg error
-					errMsg = fmt.Errorf("schema table data chunk isn't euqal")
+			// 设置工作池
+			// 设置 goroutine 数
+			g1 := &errgroup.Group{}
+			g1.SetLimit(r.cfg.DiffConfig.DiffThreads)
 
-					if _, err := f.CWriteString(report); err != nil {
-						errMsg = fmt.Errorf("fix sql file write failed: %v", err.Error())
+			for _, compareMeta := range batchMetas {
+				newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows, snapshot, compareColumns)
+				g1.Go(func() error {
+					release, err := r.cfg.AcquireThread(r.ctx, common.ThreadBudgetDiff, r.cfg.DiffConfig.DiffThreads)
+					if err != nil {
+						return err
 					}
-					// error skip, continue
-					if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+					defer release()
+
+					// 数据对比报告
+					chunkStartTime := time.Now()
+					report, err := public.IReport(newReport)
+					chunkStatus := metrics.StatusSuccess
+					switch {
+					case err != nil:
+						chunkStatus = metrics.StatusFailed
+					case !strings.EqualFold(report, ""):
+						chunkStatus = metrics.StatusMismatch
+						metrics.CompareMismatchTotal.WithLabelValues(newReport.DataCompareMeta.SchemaNameS, newReport.DataCompareMeta.TableNameS).Inc()
+					}
+					metrics.CompareChunkDuration.WithLabelValues(newReport.DataCompareMeta.SchemaNameS, newReport.DataCompareMeta.TableNameS, chunkStatus).Observe(time.Since(chunkStartTime).Seconds())
+					if err != nil {
+						// error skip, continue
+						if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+							DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+							DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+							SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+							TableNameS:  newReport.DataCompareMeta.TableNameS,
+							TaskMode:    newReport.DataCompareMeta.TaskMode,
+							WhereRange:  newReport.DataCompareMeta.WhereRange,
+							RouteWhereS: newReport.DataCompareMeta.RouteWhereS,
+						}, map[string]interface{}{
+							"TaskStatus":  common.TaskStatusFailed,
+							"InfoDetail":  newReport.String(),
+							"ErrorDetail": err.Error(),
+						}); err != nil {
+							return err
+						}
+
+						return nil
+					}
+
+					// 数据对比是否不一致
+					if !strings.EqualFold(report, "") {
+						var errMsg error
+						errMsg = fmt.Errorf(common.CompareChunkMismatchError)
+
+						if _, err := f.CWriteString(report); err != nil {
+							errMsg = fmt.Errorf("fix sql file write failed: %v", err.Error())
+						}
+						// error skip, continue
+						if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+							DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+							DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+							SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+							TableNameS:  newReport.DataCompareMeta.TableNameS,
+							TaskMode:    newReport.DataCompareMeta.TaskMode,
+							WhereRange:  newReport.DataCompareMeta.WhereRange,
+							RouteWhereS: newReport.DataCompareMeta.RouteWhereS,
+						}, map[string]interface{}{
+							"TaskStatus":  common.TaskStatusFailed,
+							"InfoDetail":  newReport.String(),
+							"ErrorDetail": errMsg.Error(),
+						}); err != nil {
+							return err
+						}
+
+						return nil
+					}
+
+					err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
 						DBTypeS:     newReport.DataCompareMeta.DBTypeS,
 						DBTypeT:     newReport.DataCompareMeta.DBTypeT,
 						SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
 						TableNameS:  newReport.DataCompareMeta.TableNameS,
 						TaskMode:    newReport.DataCompareMeta.TaskMode,
 						WhereRange:  newReport.DataCompareMeta.WhereRange,
+						RouteWhereS: newReport.DataCompareMeta.RouteWhereS,
 					}, map[string]interface{}{
-						"TaskStatus":  common.TaskStatusFailed,
-						"InfoDetail":  newReport.String(),
-						"ErrorDetail": errMsg.Error(),
-					}); err != nil {
+						"TaskStatus": common.TaskStatusSuccess,
+					})
+					if err != nil {
 						return err
 					}
-
 					return nil
-				}
-
-				err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
-					DBTypeS:     newReport.DataCompareMeta.DBTypeS,
-					DBTypeT:     newReport.DataCompareMeta.DBTypeT,
-					SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
-					TableNameS:  newReport.DataCompareMeta.TableNameS,
-					TaskMode:    newReport.DataCompareMeta.TaskMode,
-					WhereRange:  newReport.DataCompareMeta.WhereRange,
-				}, map[string]interface{}{
-					"TaskStatus": common.TaskStatusSuccess,
 				})
-				if err != nil {
-					return err
-				}
-				return nil
-			})
-		}
+			}
 
-		if err = g1.Wait(); err != nil {
-			return fmt.Errorf("compare table task failed, update table [data_compare_meta] failed: %v", err)
+			err = g1.Wait()
+			if r.cfg.DiffConfig.Consistent {
+				if releaseErr := r.releaseSnapshot(snapshot); releaseErr != nil {
+					return releaseErr
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("compare table task failed, update table [data_compare_meta] failed: %v", err)
+			}
 		}
 
 		// 清理元数据记录
@@ -606,6 +701,22 @@ func (r *Compare) comparePartTableTasks(f *compare.File, partTableTasks []*Task)
 			return fmt.Errorf("get meta table [data_compare_meta] counts failed, error: %v", err)
 		}
 
+		// 抽样校验，存在未校验 chunk 保留 data_compare_meta 记录，下次运行继续抽样校验未校验 chunk
+		if sample != nil {
+			if err = r.reportCompareSample(f, task.sourceTableName, sample, successTotalErrs); err != nil {
+				return err
+			}
+			if failedTotalErrs == 0 && successTotalErrs < sample.totalChunks {
+				zap.L().Info("diff single table oracle to tidb sample finished, table isn't fully verified",
+					zap.String("schema", r.cfg.SchemaConfig.SourceSchema),
+					zap.String("table", task.sourceTableName),
+					zap.Int64("chunk verified", successTotalErrs),
+					zap.Int64("chunk totals", sample.totalChunks),
+					zap.String("cost", time.Now().Sub(diffStartTime).String()))
+				continue
+			}
+		}
+
 		// 不存在错误，清理 data_compare_meta 记录, 更新 wait_sync_meta 记录
 		if failedTotalErrs == 0 {
 			err = meta.NewCommonModel(r.metaDB).DeleteTableDataCompareMetaAndUpdateWaitSyncMeta(r.ctx,
@@ -677,13 +788,20 @@ func (r *Compare) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task)
 		if err != nil {
 			return err
 		}
+		var compareKey []common.CompareKeyColumn
+		if strings.EqualFold(whereColumn, "") {
+			compareKey, err = task.FilterDBCompareKey()
+			if err != nil {
+				return err
+			}
+		}
 		isPartition, err := task.IsPartitionTable()
 		if err != nil {
 			return err
 		}
 		chunks = append(chunks, NewChunk(r.ctx, r.cfg, r.oracle, r.mysql, r.metaDB,
 			cid, globalSCN, task.sourceTableName, task.targetTableName, isPartition, sourceColumnInfo, targetColumnInfo,
-			whereColumn))
+			whereColumn, compareKey, task.tableRoute))
 	}
 
 	// chunk split
@@ -692,7 +810,13 @@ func (r *Compare) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task)
 	for _, chunk := range chunks {
 		c := chunk
 		g.Go(func() error {
-			err := public.IChunker(c)
+			release, err := r.cfg.AcquireThread(r.ctx, common.ThreadBudgetDiff, r.cfg.DiffConfig.DiffThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
+			err = public.IChunker(c)
 			if err != nil {
 				return err
 			}
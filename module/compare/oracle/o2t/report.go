@@ -27,6 +27,8 @@ import (
 	"github.com/wentaojin/transferdb/database/oracle"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -42,35 +44,71 @@ type Report struct {
 	Mysql           *mysql.MySQL         `json:"-"`
 	Oracle          *oracle.Oracle       `json:"-"`
 	OnlyCheckRows   bool                 `json:"only_check_rows"`
+	// 一致性校验快照，源端 AS OF SCN 查询，目标端 TiDB tidb_snapshot 快照读，为空当前读
+	SnapshotSCN uint64 `json:"snapshot_scn"`
+	SnapshotT   string `json:"snapshot_t"`
+	// 数据校验字段信息，用于数据不一致行按键配对输出字段级差异
+	CompareColumns []common.CompareColumn `json:"-"`
 }
 
-func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle *oracle.Oracle, onlyCheckRows bool) *Report {
+func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle *oracle.Oracle, onlyCheckRows bool, snapshot meta.IncrSnapshotMeta, compareColumns []common.CompareColumn) *Report {
 	return &Report{
 		DataCompareMeta: dataCompareMeta,
 		Mysql:           mysql,
 		Oracle:          oracle,
 		OnlyCheckRows:   onlyCheckRows,
+		SnapshotSCN:     snapshot.ScnS,
+		SnapshotT:       snapshot.SnapshotT,
+		CompareColumns:  compareColumns,
 	}
 }
 
 func (r *Report) GenDBQuery() (oracleQuery string, mysqlQuery string) {
 	if r.DataCompareMeta.WhereColumn == "" {
 		oracleQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.tableS(), " WHERE ", r.whereS())
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.whereT())
 	} else {
 		oracleQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange,
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.tableS(), " WHERE ", r.whereS(),
 			" ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.whereT(), " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 	}
 	return
 }
 
+// 源端查询表，一致性校验以快照 SCN 闪回查询
+func (r *Report) tableS() string {
+	if r.SnapshotSCN == 0 {
+		return common.StringsBuilder(r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS)
+	}
+	return common.StringsBuilder(r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " AS OF SCN ", strconv.FormatUint(r.SnapshotSCN, 10))
+}
+
+// 源端查询条件，表路由拆分追加分片过滤条件
+func (r *Report) whereS() string {
+	if strings.EqualFold(r.DataCompareMeta.RouteWhereS, "") {
+		return r.DataCompareMeta.WhereRange
+	}
+	return common.StringsBuilder("(", r.DataCompareMeta.WhereRange, ") AND (", r.DataCompareMeta.RouteWhereS, ")")
+}
+
+// 目标端查询条件，切分键范围条件以目标端二进制比较表达式为准，表路由合并追加区分字段过滤条件
+func (r *Report) whereT() string {
+	whereRange := r.DataCompareMeta.WhereRange
+	if !strings.EqualFold(r.DataCompareMeta.WhereRangeT, "") {
+		whereRange = r.DataCompareMeta.WhereRangeT
+	}
+	if strings.EqualFold(r.DataCompareMeta.RouteWhereT, "") {
+		return whereRange
+	}
+	return common.StringsBuilder("(", whereRange, ") AND (", r.DataCompareMeta.RouteWhereT, ")")
+}
+
 func (r *Report) CheckOracleRows(oracleQuery string) (int64, error) {
 	rows, err := r.Oracle.GetOracleTableActualRows(oracleQuery)
 	if err != nil {
@@ -80,7 +118,7 @@ func (r *Report) CheckOracleRows(oracleQuery string) (int64, error) {
 }
 
 func (r *Report) CheckMySQLRows(mysqlQuery string) (int64, error) {
-	rows, err := r.Mysql.GetMySQLTableActualRows(mysqlQuery)
+	rows, err := r.Mysql.GetMySQLTableActualRows(mysqlQuery, r.SnapshotT)
 	if err != nil {
 		return rows, err
 	}
@@ -187,7 +225,7 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 	})
 
 	errMySQL.Go(func() error {
-		mysqlColumns, mysqlStringSet, mysqlCrc32Val, err := r.Mysql.GetMySQLDataRowStrings(mysqlQuery)
+		mysqlColumns, mysqlStringSet, mysqlCrc32Val, err := r.Mysql.GetMySQLDataRowStrings(mysqlQuery, r.SnapshotT)
 		if err != nil {
 			return fmt.Errorf("get tidb data row strings failed: %v", err)
 		}
@@ -242,6 +280,11 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 
 	// 判断下游数据是否多
 	targetMore := strset.Difference(mysqlReport.StringSet, oraReport.StringSet).List()
+	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
+
+	// 上下游不一致数据行按键配对，输出字段级差异
+	fixSQL.WriteString(r.reportColumnDiff(sourceMore, targetMore))
+
 	if len(targetMore) > 0 {
 		fixSQL.WriteString("/*\n")
 		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are more \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
@@ -251,51 +294,59 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 		sw.AppendHeader(table.Row{"DATABASE", "DATA COUNTS SQL", "CRC32"})
 		sw.AppendRows([]table.Row{
 			{"ORACLE",
-				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.whereS()),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.whereT()),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
 		fixSQL.WriteString("*/\n")
-		deletePrefix := common.StringsBuilder("DELETE FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ")
+		deletePrefix := common.StringsBuilder("DELETE FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ")
 		for _, t := range targetMore {
 			var whereCond []string
 
 			// 计算字段列个数
-			colValues := strings.Split(t, ",")
+			colValues := common.SplitCompareRowValues(t)
 			if len(mysqlReport.Columns) != len(colValues) {
-				return "", fmt.Errorf("tidb schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, len(mysqlReport.Columns), len(colValues))
+				return "", fmt.Errorf("tidb schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, len(mysqlReport.Columns), len(colValues))
 			}
 			for i := 0; i < len(mysqlReport.Columns); i++ {
 				whereCond = append(whereCond, common.StringsBuilder(mysqlReport.Columns[i], "=", colValues[i]))
 			}
 
+			// 表路由合并，目标端以区分字段限定当前源端表数据
+			if !strings.EqualFold(r.DataCompareMeta.RouteWhereT, "") {
+				whereCond = append(whereCond, r.DataCompareMeta.RouteWhereT)
+			}
+
 			fixSQL.WriteString(fmt.Sprintf("%v;\n", common.StringsBuilder(deletePrefix, exstrings.Join(whereCond, " AND "))))
 		}
 	}
 
 	// 判断上游数据是否多
-	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
 	if len(sourceMore) > 0 {
 		fixSQL.WriteString("/*\n")
-		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, r.DataCompareMeta.WhereRange))
+		fixSQL.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
 
 		sw := table.NewWriter()
 		sw.SetStyle(table.StyleLight)
 		sw.AppendHeader(table.Row{"DATABASE", "DATA COUNTS SQL", "CRC32"})
 		sw.AppendRows([]table.Row{
 			{"ORACLE",
-				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.whereS()),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.whereT()),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
+		// 表路由合并，修复语句需补充区分字段值
+		if !strings.EqualFold(r.DataCompareMeta.RouteWhereT, "") {
+			fixSQL.WriteString(fmt.Sprintf(" table route merge, insert need fill route column [%s]\n", r.DataCompareMeta.RouteWhereT))
+		}
 		fixSQL.WriteString("*/\n")
-		insertPrefix := common.StringsBuilder("INSERT INTO ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " (", strings.Join(oraReport.Columns, ","), ") VALUES (")
+		insertPrefix := common.StringsBuilder("INSERT INTO ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " (", strings.Join(oraReport.Columns, ","), ") VALUES (")
 		for _, s := range sourceMore {
 			fixSQL.WriteString(fmt.Sprintf("%v;\n", common.StringsBuilder(insertPrefix, s, ")")))
 		}
@@ -303,10 +354,184 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 	return fixSQL.String(), nil
 }
 
+// 无主键/唯一键表全表流式聚合行数以及 CRC32 对比，不缓存行数据
+// 重复行无法按行定位，数据不一致仅输出上下游聚合差异，不生成修复 SQL
+// 上下游不一致数据行按配对键（主键/唯一键）配对，输出配对行字段级差异（源端值 vs 目标端值以及字段类型）以及差异字段汇总
+// 未配对数据行为上游或者下游多余数据行，无配对键或者字段信息与数据行不匹配不输出
+func (r *Report) reportColumnDiff(sourceMore, targetMore []string) string {
+	var keyIndex []int
+	for i, c := range r.CompareColumns {
+		if c.IsKey {
+			keyIndex = append(keyIndex, i)
+		}
+	}
+	if len(keyIndex) == 0 || len(sourceMore) == 0 || len(targetMore) == 0 {
+		return ""
+	}
+
+	pairKey := func(values []string) string {
+		var keys []string
+		for _, i := range keyIndex {
+			keys = append(keys, common.StringsBuilder(r.CompareColumns[i].ColumnName, "=", values[i]))
+		}
+		return strings.Join(keys, " AND ")
+	}
+
+	targetRows := make(map[string][]string)
+	for _, t := range targetMore {
+		values := common.SplitCompareRowValues(t)
+		if len(values) != len(r.CompareColumns) {
+			return ""
+		}
+		targetRows[pairKey(values)] = values
+	}
+
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.AppendHeader(table.Row{"KEY", "COLUMN", "ORACLE DATA TYPE", "TiDB DATA TYPE", "ORACLE VALUE", "TiDB VALUE"})
+	var (
+		pairRows   int
+		diffCounts = make(map[int]int)
+	)
+	for _, s := range sourceMore {
+		values := common.SplitCompareRowValues(s)
+		if len(values) != len(r.CompareColumns) {
+			return ""
+		}
+		key := pairKey(values)
+		valuesT, ok := targetRows[key]
+		if !ok {
+			continue
+		}
+		pairRows++
+		for i, c := range r.CompareColumns {
+			if values[i] == valuesT[i] {
+				continue
+			}
+			diffCounts[i]++
+			sw.AppendRow(table.Row{key, c.ColumnName, c.DataTypeS, c.DataTypeT, values[i], valuesT[i]})
+		}
+	}
+	if pairRows == 0 {
+		return ""
+	}
+
+	// 差异字段汇总，按差异行数降序
+	var diffColumns []int
+	for i := range diffCounts {
+		diffColumns = append(diffColumns, i)
+	}
+	sort.Slice(diffColumns, func(i, j int) bool {
+		if diffCounts[diffColumns[i]] == diffCounts[diffColumns[j]] {
+			return diffColumns[i] < diffColumns[j]
+		}
+		return diffCounts[diffColumns[i]] > diffCounts[diffColumns[j]]
+	})
+	sc := table.NewWriter()
+	sc.SetStyle(table.StyleLight)
+	sc.AppendHeader(table.Row{"COLUMN", "ORACLE DATA TYPE", "TiDB DATA TYPE", "DIFF ROWS", "DIFF RATIO"})
+	for _, i := range diffColumns {
+		sc.AppendRow(table.Row{r.CompareColumns[i].ColumnName, r.CompareColumns[i].DataTypeS, r.CompareColumns[i].DataTypeT,
+			diffCounts[i], fmt.Sprintf("%.2f%%", float64(diffCounts[i])*100/float64(pairRows))})
+	}
+
+	var b strings.Builder
+	b.WriteString("/*\n")
+	b.WriteString(fmt.Sprintf(" tidb table [%s.%s] chunk [%s] data rows column differences, paired rows [%d] by key\n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange, pairRows))
+	b.WriteString(fmt.Sprintf("%v\n", sc.Render()))
+	b.WriteString(fmt.Sprintf("%v\n", sw.Render()))
+	b.WriteString("*/\n")
+	return b.String()
+}
+
+func (r *Report) ReportCheckHash() (string, error) {
+	errORA := &errgroup.Group{}
+	errMySQL := &errgroup.Group{}
+	oraChan := make(chan DBSummary, 1)
+	mysqlChan := make(chan DBSummary, 1)
+
+	oracleQuery, mysqlQuery := r.GenDBQuery()
+
+	errORA.Go(func() error {
+		oraRows, oraCrc32Val, err := r.Oracle.GetOracleDataRowCRC32(oracleQuery)
+		if err != nil {
+			return fmt.Errorf("get oracle data row crc32 failed: %v", err)
+		}
+		oraChan <- DBSummary{
+			Crc32Val: oraCrc32Val,
+			Rows:     oraRows,
+		}
+		return nil
+	})
+
+	errMySQL.Go(func() error {
+		mysqlRows, mysqlCrc32Val, err := r.Mysql.GetMySQLDataRowCRC32(mysqlQuery, r.SnapshotT)
+		if err != nil {
+			return fmt.Errorf("get tidb data row crc32 failed: %v", err)
+		}
+		mysqlChan <- DBSummary{
+			Crc32Val: mysqlCrc32Val,
+			Rows:     mysqlRows,
+		}
+		return nil
+	})
+
+	if err := errORA.Wait(); err != nil {
+		return "", err
+	}
+	if err := errMySQL.Wait(); err != nil {
+		return "", err
+	}
+
+	oraReport := <-oraChan
+	mysqlReport := <-mysqlChan
+
+	if oraReport.Rows == mysqlReport.Rows && oraReport.Crc32Val == mysqlReport.Crc32Val {
+		zap.L().Info("oracle table whole table hash diff equal",
+			zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+			zap.String("tidb schema", r.DataCompareMeta.SchemaNameT),
+			zap.String("oracle table", r.DataCompareMeta.TableNameS),
+			zap.String("tidb table", r.DataCompareMeta.TableNameT),
+			zap.Int64("oracle rows count", oraReport.Rows),
+			zap.Int64("tidb rows count", mysqlReport.Rows),
+			zap.Uint32("oracle crc32 values", oraReport.Crc32Val),
+			zap.Uint32("tidb crc32 values", mysqlReport.Crc32Val))
+		return "", nil
+	}
+
+	zap.L().Info("oracle table whole table hash diff isn't equal",
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("tidb schema", r.DataCompareMeta.SchemaNameT),
+		zap.String("oracle table", r.DataCompareMeta.TableNameS),
+		zap.String("tidb table", r.DataCompareMeta.TableNameT),
+		zap.Int64("oracle rows count", oraReport.Rows),
+		zap.Int64("tidb rows count", mysqlReport.Rows),
+		zap.Uint32("oracle crc32 values", oraReport.Crc32Val),
+		zap.Uint32("tidb crc32 values", mysqlReport.Crc32Val),
+		zap.String("oracle sql", oracleQuery),
+		zap.String("tidb sql", mysqlQuery))
+
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.AppendHeader(table.Row{"DATABASE", "TABLE", "DATA SQL", "ROWS", "CRC32"})
+	sw.AppendRows([]table.Row{
+		{"ORACLE", common.StringsBuilder(r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS), oracleQuery, oraReport.Rows, oraReport.Crc32Val},
+		{"TiDB", common.StringsBuilder(r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT), mysqlQuery, mysqlReport.Rows, mysqlReport.Crc32Val},
+	})
+
+	fixSQLStr := fmt.Sprintf("/* \n\toracle and tidb table [%s.%s] hasn't primary or unique key, whole table hash data aren't equal, fix sql isn't generated\n",
+		r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS) + sw.Render() + "\n*/\n"
+
+	return fixSQLStr, nil
+}
+
 func (r *Report) Report() (string, error) {
 	if r.OnlyCheckRows {
 		return r.ReportCheckRows()
 	}
+	if strings.EqualFold(r.DataCompareMeta.CompareMethod, common.CompareMethodHash) {
+		return r.ReportCheckHash()
+	}
 	return r.ReportCheckCRC32()
 }
 
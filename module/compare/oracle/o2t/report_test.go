@@ -0,0 +1,65 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"strings"
+	"testing"
+)
+
+func TestReportColumnDiffCompositeKey(t *testing.T) {
+	r := &Report{
+		DataCompareMeta: meta.DataCompareMeta{SchemaNameT: "MARVIN", TableNameT: "ORDERS", WhereRange: `"ID" > 10`},
+		CompareColumns: []common.CompareColumn{
+			{ColumnName: "TENANT", DataTypeS: "NUMBER", DataTypeT: "BIGINT", IsKey: true},
+			{ColumnName: "ID", DataTypeS: "NUMBER", DataTypeT: "BIGINT", IsKey: true},
+			{ColumnName: "NOTE", DataTypeS: "VARCHAR2(20)", DataTypeT: "VARCHAR(20)"},
+			{ColumnName: "STATUS", DataTypeS: "CHAR(1)", DataTypeT: "CHAR(1)"},
+		},
+	}
+
+	// 字段值包含转义逗号，配对键由 TENANT 以及 ID 组成
+	diff := r.reportColumnDiff(
+		[]string{`1,11,a\,b,Y`, `1,12,c,Y`, `2,11,d,Y`},
+		[]string{`1,11,a\,c,N`, `1,12,c,N`, `2,11,d,N`})
+
+	if !strings.Contains(diff, "tidb table [MARVIN.ORDERS] chunk [\"ID\" > 10] data rows column differences, paired rows [3] by key") {
+		t.Fatalf("unexpected column diff header:\n%s", diff)
+	}
+	if !strings.Contains(diff, "TENANT=1 AND ID=11") {
+		t.Errorf("expected composite pair key in:\n%s", diff)
+	}
+	// 差异字段汇总按差异行数降序，STATUS 3 行差异排在 NOTE 1 行差异之前
+	status, note := strings.Index(diff, "STATUS"), strings.Index(diff, "NOTE")
+	if status < 0 || note < 0 || status > note {
+		t.Errorf("expected STATUS summarized before NOTE in:\n%s", diff)
+	}
+	if !strings.Contains(diff, "100.00%") || !strings.Contains(diff, "33.33%") {
+		t.Errorf("expected diff ratio in:\n%s", diff)
+	}
+}
+
+func TestReportColumnDiffColumnMismatch(t *testing.T) {
+	r := &Report{CompareColumns: []common.CompareColumn{
+		{ColumnName: "ID", DataTypeS: "NUMBER", DataTypeT: "BIGINT", IsKey: true},
+		{ColumnName: "NOTE", DataTypeS: "VARCHAR2(20)", DataTypeT: "VARCHAR(20)"},
+	}}
+	if diff := r.reportColumnDiff([]string{"1,a,extra"}, []string{"1,b"}); diff != "" {
+		t.Errorf("expected no column diff for mismatched row values, got:\n%s", diff)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"math"
+	"sort"
+)
+
+// 表抽样校验
+type compareSample struct {
+	round       int
+	totalChunks int64
+	waitChunks  int64
+	pickMetas   []meta.DataCompareMeta
+}
+
+// 抽样校验选取当前轮次 chunk，每轮选取表 chunk 总数 sample-percent 比例，未校验 chunk 按表路由分片分层，层内按 chunk 顺序（切分范围）等距选取
+func (r *Compare) newCompareSample(sourceTable string, waitCompareMetas []meta.DataCompareMeta) (*compareSample, error) {
+	compareMetas, err := meta.NewDataCompareMetaModel(r.metaDB).DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+		TableNameS:  sourceTable,
+		TaskMode:    r.cfg.TaskMode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sample := &compareSample{
+		round:       1,
+		totalChunks: int64(len(compareMetas)),
+		waitChunks:  int64(len(waitCompareMetas)),
+	}
+	for _, m := range compareMetas {
+		if m.SampleRound >= sample.round {
+			sample.round = m.SampleRound + 1
+		}
+	}
+
+	sort.Slice(waitCompareMetas, func(i, j int) bool {
+		return waitCompareMetas[i].ID < waitCompareMetas[j].ID
+	})
+	sampleChunks := int(math.Ceil(float64(sample.totalChunks) * float64(r.cfg.DiffConfig.SamplePercent) / 100))
+	strata := make([]string, len(waitCompareMetas))
+	for i, m := range waitCompareMetas {
+		strata[i] = m.RouteWhereS
+	}
+	for _, i := range common.CompareSampleStratified(strata, sampleChunks) {
+		sample.pickMetas = append(sample.pickMetas, waitCompareMetas[i])
+	}
+
+	for _, m := range sample.pickMetas {
+		if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &m, map[string]interface{}{
+			"SampleRound": sample.round,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	zap.L().Info("compare table sample chunks",
+		zap.String("schema", r.cfg.SchemaConfig.SourceSchema),
+		zap.String("table", sourceTable),
+		zap.Int("sample round", sample.round),
+		zap.Int("sample percent", r.cfg.DiffConfig.SamplePercent),
+		zap.Int64("chunk totals", sample.totalChunks),
+		zap.Int64("chunk unverified", sample.waitChunks),
+		zap.Int("chunk sampled", len(sample.pickMetas)))
+	return sample, nil
+}
+
+// 抽样校验覆盖率以及置信度报告，不一致比例以当前轮次抽样 chunk 估计未校验 chunk
+func (r *Compare) reportCompareSample(f *compare.File, sourceTable string, sample *compareSample, verifiedChunks int64) error {
+	failedMetas, err := meta.NewDataCompareMetaModel(r.metaDB).DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.SchemaConfig.SourceSchema,
+		TableNameS:  sourceTable,
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return err
+	}
+	failedChunks := make(map[uint]struct{})
+	for _, m := range failedMetas {
+		failedChunks[m.ID] = struct{}{}
+	}
+	var mismatchChunks int64
+	for _, m := range sample.pickMetas {
+		if _, ok := failedChunks[m.ID]; ok {
+			mismatchChunks++
+		}
+	}
+	sampled := int64(len(sample.pickMetas))
+	estimate, upper := common.CompareSampleConfidence(sampled, mismatchChunks, sample.waitChunks)
+	coverage := float64(verifiedChunks) * 100 / float64(sample.totalChunks)
+
+	zap.L().Info("compare table sample finished",
+		zap.String("schema", r.cfg.SchemaConfig.SourceSchema),
+		zap.String("table", sourceTable),
+		zap.Int("sample round", sample.round),
+		zap.Int64("chunk sampled", sampled),
+		zap.Int64("chunk mismatch", mismatchChunks),
+		zap.Int64("chunk verified", verifiedChunks),
+		zap.Int64("chunk totals", sample.totalChunks),
+		zap.String("coverage", fmt.Sprintf("%.2f%%", coverage)),
+		zap.String("mismatch estimate", fmt.Sprintf("%.2f%%", estimate*100)),
+		zap.String("mismatch upper bound (95%)", fmt.Sprintf("%.2f%%", upper*100)))
+
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.AppendHeader(table.Row{"TABLE", "ROUND", "SAMPLED CHUNKS", "MISMATCH CHUNKS", "VERIFIED CHUNKS", "TOTAL CHUNKS", "COVERAGE", "MISMATCH ESTIMATE", "UPPER BOUND (95%)"})
+	sw.AppendRow(table.Row{common.StringsBuilder(r.cfg.SchemaConfig.SourceSchema, ".", sourceTable), sample.round, sampled, mismatchChunks, verifiedChunks, sample.totalChunks,
+		fmt.Sprintf("%.2f%%", coverage), fmt.Sprintf("%.2f%%", estimate*100), fmt.Sprintf("%.2f%%", upper*100)})
+	if _, err = f.CWriteString(fmt.Sprintf("/*\n oracle table [%s.%s] sample compare, mismatch estimate of unverified chunks\n%v\n*/\n",
+		r.cfg.SchemaConfig.SourceSchema, sourceTable, sw.Render())); err != nil {
+		return fmt.Errorf("fix sql file write failed: %v", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"path/filepath"
+	"testing"
+)
+
+// 任务中断后恢复，沿用元数据已有抽样轮次继续下一轮，仅在未校验 chunk 中抽样
+func TestCompareSampleResume(t *testing.T) {
+	ctx := context.Background()
+	metaDB, err := meta.NewSQLiteMetaDBEngine(filepath.Join(t.TempDir(), "meta.db"), "", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	r := &Compare{
+		ctx:    ctx,
+		metaDB: metaDB,
+		cfg: &config.Config{
+			DBTypeS:      common.DatabaseTypeOracle,
+			DBTypeT:      common.DatabaseTypeTiDB,
+			TaskMode:     common.TaskModeCompare,
+			SchemaConfig: config.SchemaConfig{SourceSchema: "MARVIN"},
+			DiffConfig:   config.DiffConfig{SamplePercent: 30},
+		},
+	}
+
+	chunk := func(whereRange string, sampleRound int, status string) meta.DataCompareMeta {
+		return meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: "MARVIN",
+			TableNameS:  "T",
+			SchemaNameT: "MARVIN",
+			TableNameT:  "T",
+			WhereRange:  whereRange,
+			TaskMode:    r.cfg.TaskMode,
+			TaskStatus:  status,
+			SampleRound: sampleRound,
+		}
+	}
+	if err = meta.NewDataCompareMetaModel(metaDB).BatchCreateDataCompareMeta(ctx, []meta.DataCompareMeta{
+		chunk("ID <= 10", 1, common.TaskStatusSuccess),
+		chunk("ID > 10 AND ID <= 20", 2, common.TaskStatusSuccess),
+		chunk("ID > 20 AND ID <= 30", 3, common.TaskStatusFailed),
+		chunk("ID > 30 AND ID <= 40", 0, common.TaskStatusWaiting),
+		chunk("ID > 40", 0, common.TaskStatusWaiting),
+	}, 10); err != nil {
+		t.Fatal(err)
+	}
+	waitMetas, err := meta.NewDataCompareMetaModel(metaDB).DetailDataCompareMeta(ctx, &meta.DataCompareMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T",
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusWaiting,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample, err := r.newCompareSample("T", waitMetas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.round != 4 {
+		t.Errorf("expected sample round 4, got %d", sample.round)
+	}
+	if sample.totalChunks != 5 || sample.waitChunks != 2 {
+		t.Errorf("expected 5 total and 2 wait chunks, got %d and %d", sample.totalChunks, sample.waitChunks)
+	}
+	// ceil(5 * 30%) = 2，未校验 chunk 全部选取
+	if len(sample.pickMetas) != 2 {
+		t.Fatalf("expected 2 sampled chunks, got %d", len(sample.pickMetas))
+	}
+	for _, m := range sample.pickMetas {
+		if m.TaskStatus != common.TaskStatusWaiting {
+			t.Errorf("sampled chunk [%s] already verified", m.WhereRange)
+		}
+	}
+}
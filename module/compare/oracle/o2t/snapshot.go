@@ -0,0 +1,97 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"time"
+)
+
+// 一致性校验表快照申请，等待 all 模式增量同步于日志文件批次边界记录源端一致 SCN 以及目标端 TSO
+// TiDB 目标端以 tidb_snapshot 快照读，增量同步无需暂停应用
+func (r *Compare) acquireSnapshot(sourceTable string) (meta.IncrSnapshotMeta, error) {
+	snapshotS := &meta.IncrSnapshotMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.cfg.SchemaConfig.SourceSchema),
+		TableNameS:  common.StringUPPER(sourceTable),
+	}
+	counts, err := meta.NewIncrSyncMetaModel(r.metaDB).CountsIncrSyncMetaBySchemaTable(r.ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: snapshotS.SchemaNameS,
+		TableNameS:  snapshotS.TableNameS,
+	})
+	if err != nil {
+		return meta.IncrSnapshotMeta{}, err
+	}
+	if counts == 0 {
+		return meta.IncrSnapshotMeta{}, fmt.Errorf("consistent compare table [%s.%s] isn't exist in meta table [incr_sync_meta], please check all mode task is running with the same task-name", snapshotS.SchemaNameS, snapshotS.TableNameS)
+	}
+
+	if err = meta.NewIncrSnapshotMetaModel(r.metaDB).CreateIncrSnapshotMeta(r.ctx, &meta.IncrSnapshotMeta{
+		DBTypeS:        snapshotS.DBTypeS,
+		DBTypeT:        snapshotS.DBTypeT,
+		SchemaNameS:    snapshotS.SchemaNameS,
+		TableNameS:     snapshotS.TableNameS,
+		SnapshotStatus: common.CompareSnapshotRequested,
+		PauseApply:     "N",
+		PauseTimeout:   r.cfg.DiffConfig.ConsistentTimeout,
+	}); err != nil {
+		return meta.IncrSnapshotMeta{}, err
+	}
+
+	startTime := time.Now()
+	for {
+		snapshot, err := meta.NewIncrSnapshotMetaModel(r.metaDB).GetIncrSnapshotMeta(r.ctx, snapshotS)
+		if err != nil {
+			return snapshot, err
+		}
+		if snapshot.SnapshotStatus == common.CompareSnapshotReady {
+			zap.L().Info("consistent compare table snapshot ready",
+				zap.String("schema", snapshot.SchemaNameS),
+				zap.String("table", snapshot.TableNameS),
+				zap.Uint64("snapshot scn", snapshot.ScnS),
+				zap.String("snapshot tso", snapshot.SnapshotT),
+				zap.String("wait", time.Since(startTime).String()))
+			return snapshot, nil
+		}
+		if time.Since(startTime) > time.Duration(r.cfg.DiffConfig.ConsistentTimeout)*time.Second {
+			if _, err = meta.NewIncrSnapshotMetaModel(r.metaDB).UpdateIncrSnapshotMetaByStatus(r.ctx, snapshotS, common.CompareSnapshotRequested, map[string]interface{}{
+				"SnapshotStatus": common.CompareSnapshotReleased,
+			}); err != nil {
+				return snapshot, err
+			}
+			return snapshot, fmt.Errorf("consistent compare table [%s.%s] wait snapshot timeout [%ds], please check all mode task is running with the same task-name", snapshotS.SchemaNameS, snapshotS.TableNameS, r.cfg.DiffConfig.ConsistentTimeout)
+		}
+		select {
+		case <-r.ctx.Done():
+			return snapshot, r.ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// 一致性校验表快照释放
+func (r *Compare) releaseSnapshot(snapshot meta.IncrSnapshotMeta) error {
+	_, err := meta.NewIncrSnapshotMetaModel(r.metaDB).UpdateIncrSnapshotMetaByStatus(r.ctx, &snapshot, common.CompareSnapshotReady, map[string]interface{}{
+		"SnapshotStatus": common.CompareSnapshotReleased,
+	})
+	return err
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/wentaojin/transferdb/module/check/oracle/o2t"
 	"github.com/wentaojin/transferdb/module/check/oracle/public"
 	"go.uber.org/zap"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -39,6 +40,9 @@ type Task struct {
 	oracleCollation bool
 	mysql           *mysql.MySQL
 	oracle          *oracle.Oracle
+	columnTransform map[string]*common.ColumnTransform
+	columnNameRule  *common.TableColumnNameRule
+	tableRoute      *common.TableRoute
 }
 
 func NewPartCompareTableTask(ctx context.Context, cfg *config.Config, compareTables []string, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string) []*Task {
@@ -64,7 +68,7 @@ func NewPartCompareTableTask(ctx context.Context, cfg *config.Config, compareTab
 }
 
 func NewWaitCompareTableTask(ctx context.Context, cfg *config.Config, compareTables []string, oracleCollation bool, mysql *mysql.MySQL, oracle *oracle.Oracle,
-	tableNameRule map[string]string) []*Task {
+	tableNameRule map[string]string, columnTransform map[string]map[string]*common.ColumnTransform, columnNameRule map[string]*common.TableColumnNameRule, tableRoute map[string]*common.TableRoute) []*Task {
 	var tasks []*Task
 	for _, table := range compareTables {
 		// 库名、表名规则
@@ -82,6 +86,9 @@ func NewWaitCompareTableTask(ctx context.Context, cfg *config.Config, compareTab
 			oracleCollation: oracleCollation,
 			mysql:           mysql,
 			oracle:          oracle,
+			columnTransform: columnTransform[common.StringUPPER(table)],
+			columnNameRule:  columnNameRule[common.StringUPPER(table)],
+			tableRoute:      tableRoute[common.StringUPPER(table)],
 		})
 	}
 	return tasks
@@ -91,12 +98,28 @@ func PreTableStructCheck(ctx context.Context, cfg *config.Config, metaDB *meta.M
 	// 表结构检查
 	if !cfg.DiffConfig.IgnoreStructCheck {
 		startTime := time.Now()
-		cfg.SchemaConfig.SourceIncludeTable = exporters
 
-		var (
-			r   check.Reporter
-			err error
-		)
+		// 表结构检查不支持表路由，路由表跳过表结构检查
+		tableRoute, err := meta.NewTableRouteRuleModel(metaDB).GetSchemaTableRoute(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		var checkTables []string
+		for _, t := range exporters {
+			if _, ok := tableRoute[common.StringUPPER(t)]; ok {
+				zap.L().Warn("route table skip table structure check",
+					zap.String("schema", cfg.SchemaConfig.SourceSchema),
+					zap.String("table", t))
+				continue
+			}
+			checkTables = append(checkTables, t)
+		}
+		if len(checkTables) == 0 {
+			return nil
+		}
+		cfg.SchemaConfig.SourceIncludeTable = checkTables
+
+		var r check.Reporter
 		switch {
 		case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
 			r, err = o2t.NewCheck(ctx, cfg)
@@ -116,7 +139,7 @@ func PreTableStructCheck(ctx context.Context, cfg *config.Config, metaDB *meta.M
 		})
 
 		if errTotals != 0 || err != nil {
-			return fmt.Errorf("compare schema [%s] mode [%s] table structure task failed: %v, please check log, error: %v", strings.ToUpper(cfg.SchemaConfig.SourceSchema), cfg.TaskMode, err)
+			return fmt.Errorf("compare schema [%s] mode [%s] table structure task failed: %v, please check log, error: %v", strings.ToUpper(cfg.SchemaConfig.SourceSchema), cfg.TaskMode, errTotals, err)
 		}
 		endTime := time.Now()
 		zap.L().Info("pre check schema oracle to mysql finished",
@@ -131,6 +154,8 @@ func PreTableStructCheck(ctx context.Context, cfg *config.Config, metaDB *meta.M
 // 字段查询以 ORACLE 字段为主
 // Date/Timestamp 字段类型格式化
 // Interval Year/Day 数据字符 TO_CHAR 格式化
+// 字段数据转换（脱敏）字段上下游数据不一致，不参与数据校验
+// 字段名映射规则不迁移字段不参与数据校验，重命名字段目标端以映射字段名查询
 func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo string, err error) {
 	var (
 		sourceColumnInfos, targetColumnInfos []string
@@ -140,57 +165,133 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 		return sourceColumnInfo, targetColumnInfo, err
 	}
 
+	// 目标端时间类型字段小数秒精度
+	targetSchema, targetTable := t.targetSchemaTable()
+	targetColumns, err := t.mysql.GetMySQLTableColumn(targetSchema, targetTable)
+	if err != nil {
+		return sourceColumnInfo, targetColumnInfo, err
+	}
+	targetPrecisions := make(map[string]int)
+	for _, colsInfo := range targetColumns {
+		precision, err := strconv.Atoi(colsInfo["DATETIME_PRECISION"])
+		if err != nil {
+			return sourceColumnInfo, targetColumnInfo, fmt.Errorf("mysql schema [%s] table [%s] column [%s] datetime precision [%s] strconv.Atoi failed: %v",
+				targetSchema, targetTable, colsInfo["COLUMN_NAME"], colsInfo["DATETIME_PRECISION"], err)
+		}
+		targetPrecisions[common.StringUPPER(colsInfo["COLUMN_NAME"])] = precision
+	}
+	precisionRule := t.timePrecisionRule()
+
 	for _, colsInfo := range columnInfo {
 		colName := colsInfo["COLUMN_NAME"]
+		if _, ok := t.columnTransform[common.StringUPPER(colName)]; ok {
+			zap.L().Warn("compare table column transform, skip column",
+				zap.String("schema", t.cfg.SchemaConfig.SourceSchema),
+				zap.String("table", t.sourceTableName),
+				zap.String("column", colName))
+			continue
+		}
+		colNameT, ok := t.columnNameRule.TargetColumnName(colName)
+		if !ok {
+			continue
+		}
+		targetColumn := colNameT
+		if colNameT != colName {
+			targetColumn = common.StringsBuilder(colNameT, " AS ", colName)
+		}
 		switch strings.ToUpper(colsInfo["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", colName, ",1,1),'.','0' || ", colName, ",", colName, ") AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colName, " AS CHAR) AS CHAR) AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colNameT, " AS CHAR) AS CHAR) AS ", colName))
 		case "DECIMAL", "DEC", "DOUBLE PRECISION", "FLOAT", "INTEGER", "INT", "REAL", "NUMERIC", "BINARY_FLOAT", "BINARY_DOUBLE", "SMALLINT":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", colName, ",1,1),'.','0' || ", colName, ",", colName, ") AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colName, " AS CHAR) AS CHAR) AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colNameT, " AS CHAR) AS CHAR) AS ", colName))
 		// 字符
 		case "BFILE", "CHARACTER", "LONG", "NCHAR VARYING", "ROWID", "UROWID", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "NCLOB", "CLOB":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(", colName, ",'') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colName, ",'') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colNameT, ",'') AS ", colName))
 		case "XMLTYPE":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(XMLSERIALIZE(CONTENT ", colName, " AS CLOB),'') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colName, ",'') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colNameT, ",'') AS ", colName))
 		// 二进制
 		case "BLOB", "LONG RAW", "RAW":
 			sourceColumnInfos = append(sourceColumnInfos, colName)
-			targetColumnInfos = append(targetColumnInfos, colName)
+			targetColumnInfos = append(targetColumnInfos, targetColumn)
 		// 时间
 		case "DATE":
-			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("DATE_FORMAT(", colName, ",'%Y-%m-%d %H:%i:%s') AS ", colName))
+			precision := common.CompareTimePrecision(0, t.targetTimePrecision(targetPrecisions, colNameT, 0), precisionRule)
+			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder(common.OracleCompareTimeColumn(colName, true, 0, precision, precisionRule), " AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder(common.MySQLCompareTimeColumn(colNameT, precision), " AS ", colName))
 		// 默认其他类型
 		default:
 			if strings.Contains(colsInfo["DATA_TYPE"], "INTERVAL") {
 				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ") AS ", colName))
-				targetColumnInfos = append(targetColumnInfos, colName)
+				targetColumnInfos = append(targetColumnInfos, targetColumn)
 			} else if strings.Contains(colsInfo["DATA_TYPE"], "TIMESTAMP") {
-				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
-				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("FROM_UNIXTIME(UNIX_TIMESTAMP(", colName, "),'%Y-%m-%d %H:%i:%s') AS ", colName))
+				sourceScale, err := strconv.Atoi(colsInfo["DATA_SCALE"])
+				if err != nil {
+					return sourceColumnInfo, targetColumnInfo, fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", colsInfo["DATA_SCALE"], err)
+				}
+				precision := common.CompareTimePrecision(sourceScale, t.targetTimePrecision(targetPrecisions, colNameT, sourceScale), precisionRule)
+				// 带时区时间类型与迁移保持一致，统一转换为目标端时区
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder(common.OracleCompareTimeColumn(
+					common.OracleTimeZoneColumn(colName, colsInfo["DATA_TYPE"], t.mysql.TimeZone), false, sourceScale, precision, precisionRule), " AS ", colName))
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder(common.MySQLCompareTimeColumn(colNameT, precision), " AS ", colName))
 			} else {
 				sourceColumnInfos = append(sourceColumnInfos, colName)
-				targetColumnInfos = append(targetColumnInfos, colName)
+				targetColumnInfos = append(targetColumnInfos, targetColumn)
 			}
 		}
 	}
 
+	if len(sourceColumnInfos) == 0 {
+		return sourceColumnInfo, targetColumnInfo, fmt.Errorf("oracle schema [%s] table [%s] all columns are transform or excluded by column name rule, not support compare, please exclude skip", t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+	}
+
 	sourceColumnInfo = strings.Join(sourceColumnInfos, ",")
 	targetColumnInfo = strings.Join(targetColumnInfos, ",")
 
 	return sourceColumnInfo, targetColumnInfo, nil
 }
 
+// 目标端表，表路由规则以路由目标端表为准，hash/range 各分片表结构一致，取首个分片表
+func (t *Task) targetSchemaTable() (string, string) {
+	switch {
+	case t.tableRoute.IsMerge():
+		return t.tableRoute.SchemaNameT, t.tableRoute.TableNameT
+	case t.tableRoute.IsSplit():
+		return t.tableRoute.Shards[0].SchemaNameT, t.tableRoute.Shards[0].TableNameT
+	}
+	return t.cfg.SchemaConfig.TargetSchema, t.targetTableName
+}
+
+// 时间类型小数秒精度规则，表级别配置优先级高于全局配置
+func (t *Task) timePrecisionRule() string {
+	for _, tableCfg := range t.cfg.SchemaConfig.CompareConfig {
+		if strings.EqualFold(tableCfg.SourceTable, t.sourceTableName) && tableCfg.TimePrecisionRule != "" {
+			return tableCfg.TimePrecisionRule
+		}
+	}
+	return t.cfg.DiffConfig.TimePrecisionRule
+}
+
+// 目标端字段小数秒精度，目标端字段不存在则以内置规则映射精度为准
+func (t *Task) targetTimePrecision(targetPrecisions map[string]int, columnName string, sourceScale int) int {
+	if precision, ok := targetPrecisions[common.StringUPPER(columnName)]; ok {
+		return precision
+	}
+	if sourceScale > common.MySQLMaxTimePrecision {
+		return common.MySQLMaxTimePrecision
+	}
+	return sourceScale
+}
+
 // 筛选 NUMBER 字段以及判断表是否存在主键/唯一键/唯一索引
 // 第一优先级配置文件指定字段【忽略是否存在索引】
 // 第二优先级任意取某个主键/唯一索引 NUMBER 字段
 // 第三优先级取某个唯一性 DISTINCT 高的索引 NUMBER 字段
-// 如果表没有索引 NUMBER 字段或者没有 NUMBER 字段则报错
+// 如果表没有索引 NUMBER 字段或者没有 NUMBER 字段返回空，由 FilterDBCompareKey 筛选切分键
 func (t *Task) FilterDBWhereColumn() (string, error) {
 	// 以参数配置文件 indexFiledName 忽略是否存在索引，需要人工确认
 	// 字段筛选优先级：配置文件优先级 > PK > UK > Index > Distinct Value
@@ -201,9 +302,27 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 		return "", err
 	}
 
-	// number 数据类型字段
+	// 配置文件指定字段不得为数据转换字段以及字段名映射字段，否则上下游数据范围不一致
+	for _, tableCfg := range t.cfg.SchemaConfig.CompareConfig {
+		if strings.EqualFold(tableCfg.SourceTable, t.sourceTableName) && tableCfg.IndexFields != "" && tableCfg.Range == "" {
+			if _, ok := t.columnTransform[common.StringUPPER(tableCfg.IndexFields)]; ok {
+				return "", fmt.Errorf("oracle schema [%s] table [%s] config index-fields [%s] is transform column, not support, please adjust index-fields", t.cfg.SchemaConfig.SourceSchema, t.sourceTableName, tableCfg.IndexFields)
+			}
+			if colNameT, ok := t.columnNameRule.TargetColumnName(tableCfg.IndexFields); !ok || !strings.EqualFold(colNameT, tableCfg.IndexFields) {
+				return "", fmt.Errorf("oracle schema [%s] table [%s] config index-fields [%s] is renamed or excluded by column name rule, not support, please adjust index-fields", t.cfg.SchemaConfig.SourceSchema, t.sourceTableName, tableCfg.IndexFields)
+			}
+		}
+	}
+
+	// number 数据类型字段，数据转换字段以及字段名映射字段除外
 	var integerColumns []string
 	for _, colsInfo := range columnInfo {
+		if _, ok := t.columnTransform[common.StringUPPER(colsInfo["COLUMN_NAME"])]; ok {
+			continue
+		}
+		if colNameT, ok := t.columnNameRule.TargetColumnName(colsInfo["COLUMN_NAME"]); !ok || !strings.EqualFold(colNameT, colsInfo["COLUMN_NAME"]) {
+			continue
+		}
 		// 数字
 		if strings.EqualFold(strings.ToUpper(colsInfo["DATA_TYPE"]), "NUMBER") {
 			integerColumns = append(integerColumns, colsInfo["COLUMN_NAME"])
@@ -211,7 +330,10 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 	}
 
 	if len(integerColumns) == 0 {
-		return "", fmt.Errorf("oracle schema [%s] table [%s] number column isn't exist, not support, pelase exclude skip or add number column index", t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+		zap.L().Warn("compare table number column isn't exist, chunk by primary or unique key",
+			zap.String("schema", t.cfg.SchemaConfig.SourceSchema),
+			zap.String("table", t.sourceTableName))
+		return "", nil
 	}
 
 	// PK、UK
@@ -317,9 +439,12 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 		}
 	}
 
-	// 如果表不存在主键/唯一键/唯一索引，直接返回报错中断，因为可能导致数据校验不准
+	// 如果表不存在主键/唯一键/唯一索引，NUMBER 普通索引字段无法保证数据校验准确，全表流式聚合对比
 	if len(puConstraints) == 0 && len(ukIndex) == 0 {
-		return "", fmt.Errorf("oracle schema [%s] table [%s] pk/uk/unique index isn't exist, it's not support, please skip", t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+		zap.L().Warn("compare table pk/uk/unique index isn't exist, compare by whole table hash",
+			zap.String("schema", t.cfg.SchemaConfig.SourceSchema),
+			zap.String("table", t.sourceTableName))
+		return "", nil
 	}
 
 	// 普通索引、联合主键/联合唯一键/联合唯一索引，选择 number distinct 高的字段
@@ -339,7 +464,197 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 			}
 		}
 	}
-	return "", fmt.Errorf("oracle schema [%s] table [%s] pk/uk/index number datatype column isn't exist, please skip or fixed", t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+	zap.L().Warn("compare table pk/uk/index number datatype column isn't exist, chunk by primary or unique key",
+		zap.String("schema", t.cfg.SchemaConfig.SourceSchema),
+		zap.String("table", t.sourceTableName))
+	return "", nil
+}
+
+// 筛选数据校验切分键，用于无 NUMBER 索引字段表，支持字符、时间以及复合主键/唯一键
+// 1、按主键 > 唯一约束 > 唯一索引、字段数少者优先，取首个字段均可上下游一致比较的键
+// 2、数据转换字段、字段名映射字段以及目标端不存在字段除外，时间类型小数秒精度不得超过目标端最大精度
+// 3、字符类型源端字段排序规则需为 BINARY，目标端字段为 utf8mb4 二进制排序规则直接比较，否则按源端字符集编码转换后二进制比较，国家字符集字段除外
+// 无可用切分键返回空，全表流式聚合对比
+func (t *Task) FilterDBCompareKey() ([]common.CompareKeyColumn, error) {
+	chunkKeys, err := t.oracle.GetOracleTableChunkKeys(t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunkKeys) == 0 {
+		return nil, nil
+	}
+
+	columnInfo, err := t.oracle.GetOracleSchemaTableColumn(t.cfg.SchemaConfig.SourceSchema, t.sourceTableName, t.oracleCollation)
+	if err != nil {
+		return nil, err
+	}
+	sourceColumns := make(map[string]map[string]string)
+	for _, colsInfo := range columnInfo {
+		sourceColumns[colsInfo["COLUMN_NAME"]] = colsInfo
+	}
+
+	targetSchema, targetTable := t.targetSchemaTable()
+	targetColumnInfo, err := t.mysql.GetMySQLTableColumn(targetSchema, targetTable)
+	if err != nil {
+		return nil, err
+	}
+	targetColumns := make(map[string]map[string]string)
+	for _, colsInfo := range targetColumnInfo {
+		targetColumns[common.StringUPPER(colsInfo["COLUMN_NAME"])] = colsInfo
+	}
+
+	// 12.2 以下版本无字段级排序规则，以数据库 NLS_COMP 为准
+	var nlsComp string
+	if !t.oracleCollation {
+		nlsComp, err = t.oracle.GetOracleDBCharacterNLSCompCollation()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, keyColumns := range chunkKeys {
+		var (
+			compareKey []common.CompareKeyColumn
+			reason     string
+		)
+		for _, c := range keyColumns {
+			if _, ok := t.columnTransform[common.StringUPPER(c.ColumnName)]; ok {
+				reason = "transform column"
+				break
+			}
+			if colNameT, ok := t.columnNameRule.TargetColumnName(c.ColumnName); !ok || !strings.EqualFold(colNameT, c.ColumnName) {
+				reason = "renamed or excluded column"
+				break
+			}
+			sourceColumn, ok := sourceColumns[c.ColumnName]
+			if !ok {
+				reason = "source column isn't exist"
+				break
+			}
+			targetColumn, ok := targetColumns[common.StringUPPER(c.ColumnName)]
+			if !ok {
+				reason = "target column isn't exist"
+				break
+			}
+			key, keyReason, err := t.compareKeyColumn(c, sourceColumn, targetColumn, nlsComp)
+			if err != nil {
+				return nil, err
+			}
+			if keyReason != "" {
+				reason = keyReason
+				break
+			}
+			compareKey = append(compareKey, key)
+		}
+		if reason == "" {
+			return compareKey, nil
+		}
+		zap.L().Warn("compare table key isn't available, skip key",
+			zap.String("schema", t.cfg.SchemaConfig.SourceSchema),
+			zap.String("table", t.sourceTableName),
+			zap.Any("key", keyColumns),
+			zap.String("reason", reason))
+	}
+	return nil, nil
+}
+
+// 切分键字段上下游比较方式，字段不可作为切分键返回原因
+func (t *Task) compareKeyColumn(c oracle.ChunkKeyColumn, sourceColumn, targetColumn map[string]string, nlsComp string) (common.CompareKeyColumn, string, error) {
+	key := common.CompareKeyColumn{
+		ColumnName: c.ColumnName,
+		DataType:   c.DataType,
+		ColumnS:    common.StringsBuilder(`"`, c.ColumnName, `"`),
+		ColumnT:    common.StringsBuilder("`", c.ColumnName, "`"),
+	}
+	switch {
+	case strings.HasPrefix(common.StringUPPER(c.DataType), "TIMESTAMP"):
+		scale, err := strconv.Atoi(sourceColumn["DATA_SCALE"])
+		if err != nil {
+			return key, "", fmt.Errorf("oracle schema [%s] table [%s] column [%s] timestamp scale [%s] strconv.Atoi failed: %v",
+				t.cfg.SchemaConfig.SourceSchema, t.sourceTableName, c.ColumnName, sourceColumn["DATA_SCALE"], err)
+		}
+		if scale > common.MySQLMaxTimePrecision {
+			return key, "timestamp scale exceeds target max precision", nil
+		}
+	case oracle.IsChunkKeyCharacterType(c.DataType):
+		collation := nlsComp
+		if t.oracleCollation {
+			collation = sourceColumn["COLLATION"]
+		}
+		if !strings.EqualFold(collation, "BINARY") {
+			return key, "character column collation isn't binary", nil
+		}
+		// 国家字符集二进制排序为 UTF-16 编码顺序，TiDB 不支持 utf16 字符集转换
+		if common.IsContainString([]string{"NCHAR", "NVARCHAR2"}, common.StringUPPER(c.DataType)) {
+			return key, "national character column isn't support", nil
+		}
+		charsetT := common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(t.cfg.OracleConfig.Charset)]
+		if strings.EqualFold(charsetT, common.CharsetUTF8MB4) &&
+			strings.EqualFold(targetColumn["CHARACTER_SET_NAME"], common.CharsetUTF8MB4) &&
+			strings.HasSuffix(strings.ToLower(targetColumn["COLLATION_NAME"]), "_bin") {
+			return key, "", nil
+		}
+		key.CharsetT = charsetT
+		key.ColumnT = common.StringsBuilder("CAST(CONVERT(", key.ColumnT, " USING ", strings.ToLower(charsetT), ") AS BINARY)")
+	}
+	return key, "", nil
+}
+
+// 数据校验字段信息，与 AdjustDBSelectColumn 字段顺序一致，配对键按主键 > 唯一约束 > 唯一索引选取首个全部字段参与对比的键
+func (t *Task) FilterDBCompareColumn() ([]common.CompareColumn, error) {
+	var compareColumns []common.CompareColumn
+	columnInfo, err := t.oracle.GetOracleSchemaTableColumn(t.cfg.SchemaConfig.SourceSchema, t.sourceTableName, t.oracleCollation)
+	if err != nil {
+		return compareColumns, err
+	}
+	targetSchema, targetTable := t.targetSchemaTable()
+	targetColumnInfo, err := t.mysql.GetMySQLTableColumn(targetSchema, targetTable)
+	if err != nil {
+		return compareColumns, err
+	}
+	targetColumns := make(map[string]map[string]string)
+	for _, colsInfo := range targetColumnInfo {
+		targetColumns[common.StringUPPER(colsInfo["COLUMN_NAME"])] = colsInfo
+	}
+
+	columnIndex := make(map[string]int)
+	for _, colsInfo := range columnInfo {
+		colName := colsInfo["COLUMN_NAME"]
+		if _, ok := t.columnTransform[common.StringUPPER(colName)]; ok {
+			continue
+		}
+		colNameT, ok := t.columnNameRule.TargetColumnName(colName)
+		if !ok {
+			continue
+		}
+		columnIndex[colName] = len(compareColumns)
+		compareColumns = append(compareColumns, common.CompareColumn{
+			ColumnName: colName,
+			DataTypeS:  common.OracleCompareColumnType(colsInfo["DATA_TYPE"], colsInfo["CHAR_LENGTH"], colsInfo["DATA_LENGTH"], colsInfo["DATA_PRECISION"], colsInfo["DATA_SCALE"]),
+			DataTypeT:  targetColumns[common.StringUPPER(colNameT)]["COLUMN_TYPE"],
+		})
+	}
+
+	chunkKeys, err := t.oracle.GetOracleTableChunkKeys(t.cfg.SchemaConfig.SourceSchema, t.sourceTableName)
+	if err != nil {
+		return compareColumns, err
+	}
+	for _, keyColumns := range chunkKeys {
+		var keyIndex []int
+		for _, c := range keyColumns {
+			if i, ok := columnIndex[c.ColumnName]; ok {
+				keyIndex = append(keyIndex, i)
+			}
+		}
+		if len(keyIndex) != len(keyColumns) {
+			continue
+		}
+		for _, i := range keyIndex {
+			compareColumns[i].IsKey = true
+		}
+		break
+	}
+	return compareColumns, nil
 }
 
 func (t *Task) IsPartitionTable() (string, error) {
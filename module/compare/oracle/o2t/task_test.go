@@ -0,0 +1,102 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"testing"
+)
+
+func newTestTask(charset string, oracleCollation bool) *Task {
+	return &Task{
+		cfg:             &config.Config{OracleConfig: config.OracleConfig{Charset: charset}},
+		sourceTableName: "T",
+		oracleCollation: oracleCollation,
+	}
+}
+
+func TestTaskCompareKeyColumnBinary(t *testing.T) {
+	target := map[string]string{"CHARACTER_SET_NAME": "utf8mb4", "COLLATION_NAME": "utf8mb4_bin"}
+
+	// 12.2 以下版本按 NLS_COMP 判断
+	key, reason, err := newTestTask(common.ORACLECharsetAL32UTF8, false).compareKeyColumn(
+		oracle.ChunkKeyColumn{ColumnName: "CODE", DataType: "VARCHAR2"}, map[string]string{}, target, "BINARY")
+	if err != nil || reason != "" {
+		t.Fatalf("unexpected reason [%s] or error: %v", reason, err)
+	}
+	if key.ColumnT != "`CODE`" {
+		t.Errorf("expected plain target column, got %s", key.ColumnT)
+	}
+
+	// 12.2 及以上版本按字段排序规则判断，USING_NLS_COMP 非二进制排序
+	_, reason, err = newTestTask(common.ORACLECharsetAL32UTF8, true).compareKeyColumn(
+		oracle.ChunkKeyColumn{ColumnName: "CODE", DataType: "VARCHAR2"},
+		map[string]string{"COLLATION": "USING_NLS_COMP"}, target, "BINARY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason == "" {
+		t.Error("expected non binary column collation to be rejected")
+	}
+
+	key, reason, err = newTestTask(common.ORACLECharsetAL32UTF8, true).compareKeyColumn(
+		oracle.ChunkKeyColumn{ColumnName: "CODE", DataType: "VARCHAR2"},
+		map[string]string{"COLLATION": "BINARY"}, target, "LINGUISTIC")
+	if err != nil || reason != "" {
+		t.Fatalf("unexpected reason [%s] or error: %v", reason, err)
+	}
+	if key.ColumnT != "`CODE`" {
+		t.Errorf("expected plain target column, got %s", key.ColumnT)
+	}
+}
+
+func TestTaskCompareKeyColumnCast(t *testing.T) {
+	for want, targetCollation := range map[string]string{
+		"`CODE`": "utf8mb4_bin",
+		"CAST(CONVERT(`CODE` USING utf8mb4) AS BINARY)": "utf8mb4_general_ci",
+	} {
+		key, reason, err := newTestTask(common.ORACLECharsetAL32UTF8, false).compareKeyColumn(
+			oracle.ChunkKeyColumn{ColumnName: "CODE", DataType: "CHAR"}, map[string]string{},
+			map[string]string{"CHARACTER_SET_NAME": "utf8mb4", "COLLATION_NAME": targetCollation}, "BINARY")
+		if err != nil || reason != "" {
+			t.Fatalf("unexpected reason [%s] or error: %v", reason, err)
+		}
+		if key.ColumnT != want {
+			t.Errorf("target collation [%s]: expected %s, got %s", targetCollation, want, key.ColumnT)
+		}
+	}
+}
+
+func TestTaskCompareKeyColumnUnsupported(t *testing.T) {
+	task := newTestTask(common.ORACLECharsetAL32UTF8, false)
+	for _, c := range []struct {
+		column oracle.ChunkKeyColumn
+		source map[string]string
+	}{
+		{oracle.ChunkKeyColumn{ColumnName: "NAME", DataType: "NVARCHAR2"}, map[string]string{}},
+		{oracle.ChunkKeyColumn{ColumnName: "TS", DataType: "TIMESTAMP(9)"}, map[string]string{"DATA_SCALE": "9"}},
+	} {
+		_, reason, err := task.compareKeyColumn(c.column, c.source, map[string]string{}, "BINARY")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reason == "" {
+			t.Errorf("expected column [%s] to be rejected", c.column.ColumnName)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/database/meta"
+)
+
+// CheckpointTables 判断 running 状态表 chunk 数是否一致，一致可断点续传，不一致无法续传
+func CheckpointTables(ctx context.Context, metaDB *meta.Meta, partWaitSyncMetas []meta.WaitSyncMeta) ([]string, []string, error) {
+	var (
+		partSyncTables    []string
+		panicTblFullSlice []string
+	)
+	for _, t := range partWaitSyncMetas {
+		chunkCounts, err := meta.NewDataCompareMetaModel(metaDB).CountsDataCompareMetaByTaskTable(ctx, &meta.DataCompareMeta{
+			DBTypeS:     t.DBTypeS,
+			DBTypeT:     t.DBTypeT,
+			SchemaNameS: t.SchemaNameS,
+			TableNameS:  t.TableNameS,
+			TaskMode:    t.TaskMode,
+			TaskStatus:  t.TaskStatus,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if chunkCounts != t.ChunkTotalNums {
+			panicTblFullSlice = append(panicTblFullSlice, t.TableNameS)
+		} else {
+			partSyncTables = append(partSyncTables, t.TableNameS)
+		}
+	}
+	return partSyncTables, panicTblFullSlice, nil
+}
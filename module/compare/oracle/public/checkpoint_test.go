@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 同一 schema 多张表断点续传，chunk 数需按表统计
+func TestCheckpointTables(t *testing.T) {
+	ctx := context.Background()
+	metaDB, err := meta.NewSQLiteMetaDBEngine(filepath.Join(t.TempDir(), "meta.db"), "", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks []meta.DataCompareMeta
+	for table, ranges := range map[string][]string{
+		"T1": {"ID <= 10", "ID > 10"},
+		"T2": {"ID <= 10"},
+	} {
+		for _, r := range ranges {
+			chunks = append(chunks, meta.DataCompareMeta{
+				DBTypeS:     common.DatabaseTypeOracle,
+				DBTypeT:     common.DatabaseTypeMySQL,
+				SchemaNameS: "MARVIN",
+				TableNameS:  table,
+				SchemaNameT: "MARVIN",
+				TableNameT:  table,
+				WhereRange:  r,
+				TaskMode:    common.TaskModeCompare,
+				TaskStatus:  common.TaskStatusWaiting,
+			})
+		}
+	}
+	if err = meta.NewDataCompareMetaModel(metaDB).BatchCreateDataCompareMeta(ctx, chunks, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	waitMeta := func(table string, chunkTotal int64) meta.WaitSyncMeta {
+		return meta.WaitSyncMeta{
+			DBTypeS:        common.DatabaseTypeOracle,
+			DBTypeT:        common.DatabaseTypeMySQL,
+			SchemaNameS:    "MARVIN",
+			TableNameS:     table,
+			TaskMode:       common.TaskModeCompare,
+			TaskStatus:     common.TaskStatusRunning,
+			ChunkTotalNums: chunkTotal,
+		}
+	}
+	// T1 chunk 元数据完整，T2 切分过程中断
+	partSyncTables, panicTables, err := CheckpointTables(ctx, metaDB, []meta.WaitSyncMeta{waitMeta("T1", 2), waitMeta("T2", 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(partSyncTables, []string{"T1"}) {
+		t.Errorf("CheckpointTables() resume tables = %v, want [T1]", partSyncTables)
+	}
+	if !reflect.DeepEqual(panicTables, []string{"T2"}) {
+		t.Errorf("CheckpointTables() panic tables = %v, want [T2]", panicTables)
+	}
+}
@@ -52,26 +52,34 @@ func FilterCFGTable(cfg *config.Config, oracle *oracle.Oracle) ([]string, error)
 	switch {
 	case len(cfg.SchemaConfig.SourceIncludeTable) != 0 && len(cfg.SchemaConfig.SourceExcludeTable) == 0:
 		// 过滤规则加载
-		f, err := filter.Parse(cfg.SchemaConfig.SourceIncludeTable)
+		f, err := filter.Parse(cfg.SchemaConfig.SourceIncludeTable, cfg.TableFilterLegacy())
 		if err != nil {
-			panic(err)
+			return exporterTableSlice, fmt.Errorf("source config params include-table parse failed: %v", err)
+		}
+		filterTables, err := oracle.GetOracleSchemaFilterTable(common.StringUPPER(cfg.SchemaConfig.SourceSchema), allTables, f.Properties())
+		if err != nil {
+			return exporterTableSlice, err
 		}
 
-		for _, t := range allTables {
-			if f.MatchTable(t) {
-				exporterTableSlice = append(exporterTableSlice, t)
+		for _, t := range filterTables {
+			if f.Match(t) {
+				exporterTableSlice = append(exporterTableSlice, t.Name)
 			}
 		}
 	case len(cfg.SchemaConfig.SourceIncludeTable) == 0 && len(cfg.SchemaConfig.SourceExcludeTable) != 0:
 		// 过滤规则加载
-		f, err := filter.Parse(cfg.SchemaConfig.SourceExcludeTable)
+		f, err := filter.Parse(cfg.SchemaConfig.SourceExcludeTable, cfg.TableFilterLegacy())
+		if err != nil {
+			return exporterTableSlice, fmt.Errorf("source config params exclude-table parse failed: %v", err)
+		}
+		filterTables, err := oracle.GetOracleSchemaFilterTable(common.StringUPPER(cfg.SchemaConfig.SourceSchema), allTables, f.Properties())
 		if err != nil {
-			panic(err)
+			return exporterTableSlice, err
 		}
 
-		for _, t := range allTables {
-			if f.MatchTable(t) {
-				excludeTables = append(excludeTables, t)
+		for _, t := range filterTables {
+			if f.Match(t) {
+				excludeTables = append(excludeTables, t.Name)
 			}
 		}
 		exporterTableSlice = common.FilterDifferenceStringItems(allTables, excludeTables)
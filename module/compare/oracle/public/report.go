@@ -0,0 +1,100 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"strings"
+	"time"
+)
+
+// GenCompareReport 根据 [wait_sync_meta] 以及 [data_compare_meta] 生成数据校验结果报告
+// 表级别成功视为 PASS，失败 chunk 均为数据不一致视为 MISMATCH，否则视为 FAILED
+func GenCompareReport(ctx context.Context, cfg *config.Config, metaDB *meta.Meta, exporters []string, fixFile string, startTime time.Time) (*common.Report, error) {
+	report := common.NewReport(cfg.AppConfig.TaskName, cfg.TaskMode, cfg.DBTypeS, cfg.DBTypeT,
+		common.StringUPPER(cfg.SchemaConfig.SourceSchema), cfg.SchemaConfig.TargetSchema, startTime)
+
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(ctx, &meta.WaitSyncMeta{
+		DBTypeS:     cfg.DBTypeS,
+		DBTypeT:     cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+		TaskMode:    cfg.TaskMode,
+	})
+	if err != nil {
+		return report, err
+	}
+	tableStatus := make(map[string]string)
+	for _, w := range waitSyncMetas {
+		tableStatus[common.StringUPPER(w.TableNameS)] = w.TaskStatus
+	}
+
+	for _, tableName := range exporters {
+		t := common.ReportTable{
+			SchemaNameS: common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+			TableNameS:  tableName,
+			SchemaNameT: cfg.SchemaConfig.TargetSchema,
+		}
+		status, ok := tableStatus[common.StringUPPER(tableName)]
+		switch {
+		case ok && strings.EqualFold(status, common.TaskStatusSuccess):
+			// PASS
+		case ok && strings.EqualFold(status, common.TaskStatusFailed):
+			chunks, err := meta.NewDataCompareMetaModel(metaDB).DetailDataCompareMeta(ctx, &meta.DataCompareMeta{
+				DBTypeS:     cfg.DBTypeS,
+				DBTypeT:     cfg.DBTypeT,
+				SchemaNameS: common.StringUPPER(cfg.SchemaConfig.SourceSchema),
+				TableNameS:  tableName,
+				TaskMode:    cfg.TaskMode,
+				TaskStatus:  common.TaskStatusFailed,
+			})
+			if err != nil {
+				return report, err
+			}
+			t.Categories = make(map[string]int)
+			var errs []string
+			for _, c := range chunks {
+				if t.TableNameT == "" {
+					t.TableNameT = c.TableNameT
+				}
+				if strings.EqualFold(c.ErrorDetail, common.CompareChunkMismatchError) {
+					t.Categories[common.CompareCategoryChunkMismatch]++
+					continue
+				}
+				t.Categories[common.CompareCategoryChunkError]++
+				errs = append(errs, c.ErrorDetail)
+			}
+			switch {
+			case len(errs) > 0:
+				t.Error = fmt.Sprintf("table chunk failed [%d], first error: %s", len(errs), errs[0])
+			case len(t.Categories) == 0:
+				// chunk 元数据缺失，表结构预检查或者其他阶段失败
+				t.Error = "table compare failed, please see table [data_compare_meta] and log"
+			default:
+				t.FixFile = fixFile
+			}
+		default:
+			t.Error = fmt.Sprintf("table compare isn't finished, status [%s]", status)
+		}
+		report.AppendTable(t)
+	}
+	report.AppendFile(fixFile)
+	report.Finish(time.Now())
+	return report, nil
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import "github.com/wentaojin/transferdb/database/meta"
+
+// 一致性校验按 chunk 批次申请快照，MySQL 目标端增量应用只在批次校验期间暂停，批次之间恢复应用追平延迟
+// 非一致性校验或者批次大小不大于 0 整表为一个批次
+func CompareSnapshotBatches(compareMetas []meta.DataCompareMeta, consistent bool, batchSize int) [][]meta.DataCompareMeta {
+	if len(compareMetas) == 0 {
+		return nil
+	}
+	if !consistent || batchSize <= 0 || batchSize >= len(compareMetas) {
+		return [][]meta.DataCompareMeta{compareMetas}
+	}
+	var batches [][]meta.DataCompareMeta
+	for i := 0; i < len(compareMetas); i += batchSize {
+		end := i + batchSize
+		if end > len(compareMetas) {
+			end = len(compareMetas)
+		}
+		batches = append(batches, compareMetas[i:end])
+	}
+	return batches
+}
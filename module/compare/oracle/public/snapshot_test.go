@@ -0,0 +1,57 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/database/meta"
+	"testing"
+)
+
+func TestCompareSnapshotBatches(t *testing.T) {
+	var compareMetas []meta.DataCompareMeta
+	for i := 0; i < 5; i++ {
+		compareMetas = append(compareMetas, meta.DataCompareMeta{WhereRange: fmt.Sprintf("ID = %d", i)})
+	}
+	for name, tt := range map[string]struct {
+		metas      []meta.DataCompareMeta
+		consistent bool
+		batchSize  int
+		want       []int
+	}{
+		"empty":              {metas: nil, consistent: true, batchSize: 2, want: nil},
+		"not consistent":     {metas: compareMetas, consistent: false, batchSize: 2, want: []int{5}},
+		"batch size zero":    {metas: compareMetas, consistent: true, batchSize: 0, want: []int{5}},
+		"batch size exceeds": {metas: compareMetas, consistent: true, batchSize: 8, want: []int{5}},
+		"batches":            {metas: compareMetas, consistent: true, batchSize: 2, want: []int{2, 2, 1}},
+	} {
+		batches := CompareSnapshotBatches(tt.metas, tt.consistent, tt.batchSize)
+		var got []int
+		var ranges []string
+		for _, b := range batches {
+			got = append(got, len(b))
+			for _, m := range b {
+				ranges = append(ranges, m.WhereRange)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: CompareSnapshotBatches() batch sizes = %v, want %v", name, got, tt.want)
+		}
+		if len(ranges) != len(tt.metas) {
+			t.Errorf("%s: CompareSnapshotBatches() chunks = %d, want %d", name, len(ranges), len(tt.metas))
+		}
+	}
+}
@@ -18,12 +18,12 @@ package o2m
 import (
 	"context"
 	"fmt"
-	"github.com/google/uuid"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/csv/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -50,7 +50,7 @@ func NewCSV(ctx context.Context, cfg *config.Config) (*CSV, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -210,7 +210,7 @@ func (r *CSV) CSV() error {
 		return err
 	}
 	if errTotals > 0 {
-		return fmt.Errorf(`csv schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, update meta table [wait_sync_meta] column [task_status] table status RUNNING (Need UPPER) and delete meta table [chunk_error_detail] current task all records; finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode)
+		return fmt.Errorf(`csv schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, run [-mode task -task-mode %s -action retry] to retry failed chunks, or run [-mode task -task-mode %s -action reset -table <table>] to reset table; finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode, strings.ToLower(r.Cfg.TaskMode), strings.ToLower(r.Cfg.TaskMode))
 	}
 
 	// 判断并记录待同步表列表
@@ -313,6 +313,29 @@ func (r *CSV) CSV() error {
 		return fmt.Errorf("checkpoint isn't consistent, can't be resume, please reruning [enable-checkpoint = fase]")
 	}
 
+	// 记录带时区时间类型数据转换时区，断点续传需与已记录时区保持一致
+	var panicTimeZoneTables []string
+	for _, t := range partSyncDetails {
+		if t.TimeZone != "" && !strings.EqualFold(t.TimeZone, r.Mysql.TimeZone) {
+			panicTimeZoneTables = append(panicTimeZoneTables, t.TableNameS)
+		}
+	}
+	if len(panicTimeZoneTables) > 0 {
+		return fmt.Errorf("table %v checkpoint time zone isn't equal to current time zone [%s], can't be resume, please keep config [mysql] time-zone consistent or reset table", panicTimeZoneTables, r.Mysql.TimeZone)
+	}
+	if len(waitSyncTables) > 0 || len(partSyncTables) > 0 {
+		err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMetaTimeZone(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TaskMode:    r.Cfg.TaskMode,
+			TimeZone:    r.Mysql.TimeZone,
+		}, append(waitSyncTables, partSyncTables...))
+		if err != nil {
+			return err
+		}
+	}
+
 	// 数据 CSV
 	// 优先存在断点的表
 	// partTableTask -> waitTableTasks
@@ -365,14 +388,32 @@ func (r *CSV) CSV() error {
 func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) error {
 	startTime := time.Now()
 
+	// 获取字段数据转换规则
+	columnTransform, err := meta.NewColumnTransformRuleModel(r.MetaDB).GetSchemaColumnTransform(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.MetaDB).GetSchemaColumnNameRule(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
 	g := &errgroup.Group{}
 	g.SetLimit(r.Cfg.CSVConfig.TableThreads)
 
 	for _, tbl := range csvPartTables {
 		t := tbl
 		g.Go(func() error {
+			release, err := r.Cfg.AcquireThread(r.Ctx, common.ThreadBudgetCSVTable, r.Cfg.CSVConfig.TableThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			taskTime := time.Now()
-			err := meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
 				DBTypeS:     r.Cfg.DBTypeS,
 				DBTypeT:     r.Cfg.DBTypeT,
 				SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
@@ -418,6 +459,8 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 			if err != nil {
 				return nil
 			}
+			// 字段名映射规则，字段列表以目标端字段名为准，与查询别名保持一致
+			columnNameS = columnNameRule[common.StringUPPER(t)].TargetColumnNames(columnNameS)
 
 			g1 := &errgroup.Group{}
 			g1.SetLimit(r.Cfg.CSVConfig.SQLThreads)
@@ -425,7 +468,13 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 			for _, fullSyncMeta := range waitFullMetas {
 				m := fullSyncMeta
 				g1.Go(func() error {
-					err = public.IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Cfg, columnNameS, common.MigrateOracleCharsetStringConvertMapping[sourceDBCharset]))
+					chunkStartTime := time.Now()
+					err = public.IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Cfg, columnNameS, common.MigrateOracleCharsetStringConvertMapping[sourceDBCharset], columnNameRule[common.StringUPPER(t)].TargetColumnTransform(columnTransform[common.StringUPPER(t)])))
+					chunkStatus := metrics.StatusSuccess
+					if err != nil {
+						chunkStatus = metrics.StatusFailed
+					}
+					metrics.MigrateChunkDuration.WithLabelValues(m.TaskMode, m.SchemaNameS, m.TableNameS, chunkStatus).Observe(time.Since(chunkStartTime).Seconds())
 					if err != nil {
 						// record error, skip error
 						errf := meta.NewCommonModel(r.MetaDB).UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(r.Ctx, &meta.FullSyncMeta{
@@ -525,6 +574,7 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 				if err != nil {
 					return err
 				}
+				metrics.SetMigrateTableChunks(r.Cfg.TaskMode, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), int64(len(successChunkFullMeta)), 0)
 				zap.L().Info("csv single table oracle to mysql finished",
 					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 					zap.String("table", common.StringUPPER(t)),
@@ -547,6 +597,7 @@ func (r *CSV) csvPartSyncTable(csvPartTables []string, sourceDBCharset string) e
 			if err != nil {
 				return err
 			}
+			metrics.SetMigrateTableChunks(r.Cfg.TaskMode, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), int64(len(successChunkFullMeta)), failedChunkTotalErrs)
 			zap.L().Warn("update mysql [wait_sync_meta] meta",
 				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 				zap.String("table", common.StringUPPER(t)),
@@ -597,6 +648,12 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 	// 获取自定义库表迁移配置
 	tableMigrateRule := r.getCustomMigrateConfig()
 
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.MetaDB).GetSchemaColumnNameRule(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
 	partitionTables, err := r.Oracle.GetOracleSchemaPartitionTable(r.Cfg.SchemaConfig.SourceSchema)
 	if err != nil {
 		return err
@@ -616,6 +673,12 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 	for _, tbl := range csvWaitTables {
 		t := tbl
 		g.Go(func() error {
+			release, err := r.Cfg.AcquireThread(r.Ctx, common.ThreadBudgetCSVTask, r.Cfg.CSVConfig.TaskThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			startTime := time.Now()
 
 			// 库名、表名规则
@@ -641,7 +704,7 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 				sqlHint = r.Cfg.FullConfig.SQLHint
 			}
 
-			sourceColumnInfo, err := r.AdjustTableSelectColumn(t, oracleCollation)
+			sourceColumnInfo, err := r.AdjustTableSelectColumn(t, oracleCollation, columnNameRule[common.StringUPPER(t)])
 			if err != nil {
 				return err
 			}
@@ -660,17 +723,8 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 				return err
 			}
 
-			taskName := uuid.New().String()
-
-			if err = r.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
-				return err
-			}
-
-			if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows), r.Cfg.CSVConfig.CallTimeout); err != nil {
-				return err
-			}
-
-			chunkRes, err := r.Oracle.GetOracleTableChunksByRowID(taskName)
+			// 表 chunk 切分，rowid 基于 DBMS_PARALLEL_EXECUTE，key 基于主键/唯一键范围源端只读
+			chunkRes, err := r.Oracle.GetOracleTableChunks(r.Cfg.CSVConfig.ChunkMethod, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), r.Cfg.CSVConfig.Rows, r.Cfg.CSVConfig.CallTimeout)
 			if err != nil {
 				return err
 			}
@@ -776,10 +830,6 @@ func (r *CSV) initWaitSyncTableChunk(csvWaitTables []string, oracleCollation boo
 				return err
 			}
 
-			if err = r.Oracle.CloseOracleChunkTask(taskName); err != nil {
-				return err
-			}
-
 			endTime := time.Now()
 			zap.L().Info("init source single table wait_sync_meta and full_sync_meta finished",
 				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
@@ -828,7 +878,7 @@ func (r *CSV) getTableNameRule() (map[string]string, error) {
 	return tableNameRuleMap, nil
 }
 
-func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, error) {
+func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool, columnNameRule *common.TableColumnNameRule) (string, error) {
 	// Date/Timestamp 字段类型格式化
 	// Interval Year/Day 数据字符 TO_CHAR 格式化
 	columnsINFO, err := r.Oracle.GetOracleSchemaTableColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable, oracleCollation)
@@ -847,47 +897,63 @@ func (r *CSV) AdjustTableSelectColumn(sourceTable string, oracleCollation bool)
 		}
 		columnName = string(convertUtf8Raw)
 
+		// 字段名映射规则，不迁移字段跳过，重命名字段以目标端字段名作为查询别名
+		columnNameT, ok := columnNameRule.TargetColumnName(columnName)
+		if !ok {
+			continue
+		}
+		columnNameSelect := common.StringsBuilder(`"`, columnName, `"`)
+		if columnNameT != columnName {
+			columnNameSelect = common.StringsBuilder(`"`, columnName, `" AS "`, columnNameT, `"`)
+		}
+
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		case "DECIMAL", "DEC", "DOUBLE PRECISION", "FLOAT", "INTEGER", "INT", "REAL", "NUMERIC", "BINARY_FLOAT", "BINARY_DOUBLE", "SMALLINT":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		// 字符
 		case "BFILE", "CHARACTER", "LONG", "NCHAR VARYING", "ROWID", "UROWID", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "NCLOB", "CLOB":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		// XMLTYPE
 		case "XMLTYPE":
-			columnNames = append(columnNames, fmt.Sprintf(` XMLSERIALIZE(CONTENT "%s" AS CLOB) AS "%s"`, columnName, columnName))
+			columnNames = append(columnNames, fmt.Sprintf(` XMLSERIALIZE(CONTENT "%s" AS CLOB) AS "%s"`, columnName, columnNameT))
 		// 二进制
 		case "BLOB", "LONG RAW", "RAW":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		// 时间
 		case "DATE":
-			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-mm-dd hh24:mi:ss') AS "`, columnName, `"`))
+			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-mm-dd hh24:mi:ss') AS "`, columnNameT, `"`))
 		// 默认其他类型
 		default:
 			if strings.Contains(rowCol["DATA_TYPE"], "INTERVAL") {
-				columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `") AS "`, columnName, `"`))
+				columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `") AS "`, columnNameT, `"`))
 			} else if strings.Contains(rowCol["DATA_TYPE"], "TIMESTAMP") {
 				dataScale, err := strconv.Atoi(rowCol["DATA_SCALE"])
 				if err != nil {
 					return "", fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", rowCol["DATA_SCALE"], err)
 				}
+				// 带时区时间类型统一转换为目标端时区
+				columnExpr := common.OracleTimeZoneColumn(common.StringsBuilder(`"`, columnName, `"`), rowCol["DATA_TYPE"], r.Mysql.TimeZone)
 				if dataScale == 0 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-mm-dd hh24:mi:ss') AS "`, columnName, `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, columnExpr, `,'yyyy-mm-dd hh24:mi:ss') AS "`, columnNameT, `"`))
 				} else if dataScale < 0 && dataScale <= 6 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName,
-						`",'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, columnName, `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, columnExpr,
+						`,'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, columnNameT, `"`))
 				} else {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, columnName, `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, columnExpr, `,'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, columnNameT, `"`))
 				}
 			} else {
-				columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+				columnNames = append(columnNames, columnNameSelect)
 			}
 		}
 	}
 
+	if len(columnNames) == 0 {
+		return "", fmt.Errorf("oracle schema [%s] table [%s] column name rule exclude all columns, please check", r.Cfg.SchemaConfig.SourceSchema, sourceTable)
+	}
+
 	return strings.Join(columnNames, ","), nil
 }
 
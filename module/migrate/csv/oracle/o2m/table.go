@@ -24,6 +24,7 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"go.uber.org/zap"
 	"os"
 	"path/filepath"
@@ -33,33 +34,35 @@ import (
 )
 
 type Rows struct {
-	Ctx          context.Context
-	SyncMeta     meta.FullSyncMeta
-	Oracle       *oracle.Oracle
-	Cfg          *config.Config
-	DBCharsetS   string
-	DBCharsetT   string
-	ColumnNameS  []string
-	ReadChannel  chan [][]string
-	WriteChannel chan string
+	Ctx             context.Context
+	SyncMeta        meta.FullSyncMeta
+	Oracle          *oracle.Oracle
+	Cfg             *config.Config
+	DBCharsetS      string
+	DBCharsetT      string
+	ColumnNameS     []string
+	ColumnTransform map[string]*common.ColumnTransform
+	ReadChannel     chan [][]string
+	WriteChannel    chan string
 }
 
 func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
-	oracle *oracle.Oracle, cfg *config.Config, columnNameS []string, sourceDBCharset string) *Rows {
+	oracle *oracle.Oracle, cfg *config.Config, columnNameS []string, sourceDBCharset string, columnTransform map[string]*common.ColumnTransform) *Rows {
 
 	writeChannel := make(chan string, common.ChannelBufferSize)
 	readChannel := make(chan [][]string, common.ChannelBufferSize)
 
 	return &Rows{
-		Ctx:          ctx,
-		SyncMeta:     syncMeta,
-		Oracle:       oracle,
-		Cfg:          cfg,
-		DBCharsetS:   sourceDBCharset,
-		DBCharsetT:   common.StringUPPER(cfg.CSVConfig.Charset),
-		ColumnNameS:  columnNameS,
-		ReadChannel:  readChannel,
-		WriteChannel: writeChannel,
+		Ctx:             ctx,
+		SyncMeta:        syncMeta,
+		Oracle:          oracle,
+		Cfg:             cfg,
+		DBCharsetS:      sourceDBCharset,
+		DBCharsetT:      common.StringUPPER(cfg.CSVConfig.Charset),
+		ColumnNameS:     columnNameS,
+		ColumnTransform: columnTransform,
+		ReadChannel:     readChannel,
+		WriteChannel:    writeChannel,
 	}
 }
 
@@ -117,15 +120,41 @@ func (t *Rows) ReadData() error {
 
 func (t *Rows) ProcessData() error {
 
+	rowsR := metrics.MigrateRowsTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeRead)
+	bytesR := metrics.MigrateBytesTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeRead)
+
+	// 字段数据转换规则
+	transforms := make([]*common.ColumnTransform, len(t.ColumnNameS))
+	for i, column := range t.ColumnNameS {
+		if ct, ok := t.ColumnTransform[common.StringUPPER(column)]; ok {
+			transforms[i] = ct
+		}
+	}
 	for dataC := range t.ReadChannel {
 		for _, dSlice := range dataC {
 			if len(dSlice) != len(t.ColumnNameS) {
 				return fmt.Errorf("source schema table column counts vs data counts isn't match")
 			} else  {
+				for i, ct := range transforms {
+					if ct == nil {
+						continue
+					}
+					transVal, err := common.TransformCSVValue(ct, dSlice[i], t.Cfg.CSVConfig.NullValue, t.Cfg.CSVConfig.Delimiter, t.Cfg.CSVConfig.EscapeBackslash)
+					if err != nil {
+						// 通道关闭
+						close(t.WriteChannel)
+						return fmt.Errorf("source schema [%s] table [%s] column transform failed: %v", t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, err)
+					}
+					dSlice[i] = transVal
+				}
+				for _, d := range dSlice {
+					bytesR.Add(float64(len(d)))
+				}
 				// csv 文件行数据输入
 				t.WriteChannel <- common.StringsBuilder(exstrings.Join(dSlice, t.Cfg.CSVConfig.Separator), t.Cfg.CSVConfig.Terminator)
 			}
 		}
+		rowsR.Add(float64(len(dataC)))
 	}
 
 	// 通道关闭
@@ -161,10 +190,14 @@ func (t *Rows) ApplyData() error {
 		}
 	}
 
+	rowsW := metrics.MigrateRowsTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeWrite)
+	bytesW := metrics.MigrateBytesTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeWrite)
 	for dataC := range t.WriteChannel {
 		if _, err = writer.WriteString(dataC); err != nil {
 			return fmt.Errorf("failed to write data row to csv %w", err)
 		}
+		rowsW.Inc()
+		bytesW.Add(float64(len(dataC)))
 	}
 
 	endTime := time.Now()
@@ -0,0 +1,50 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"testing"
+)
+
+// 任务暂停或者取消中断增量应用，返回 context.Canceled，不做 park 元数据写入（MetaDB 为空，写入即 panic）
+func TestIncrApplyCanceled(t *testing.T) {
+	db, err := sql.Open("mysql", "root:@tcp(127.0.0.1:1)/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	task := &IncrTask{
+		Ctx:           ctx,
+		SourceSchema:  "MARVIN",
+		SourceTable:   "T1",
+		Operation:     common.MigrateOperationInsert,
+		OperationType: common.MigrateOperationInsert,
+		MySQLRedo:     []string{"REPLACE INTO MARVIN.T1 (ID) VALUES (1)"},
+		ErrorPolicy:   common.MigrateIncrErrorPolicyPark,
+		MySQL:         &mysql.MySQL{Ctx: ctx, MySQLDB: db},
+	}
+	if err = task.IncrApply(); !errors.Is(err, context.Canceled) {
+		t.Errorf("IncrApply() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -17,13 +17,14 @@ package o2m
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"github.com/google/uuid"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -39,6 +40,10 @@ type Migrate struct {
 	OracleMiner *oracle.Oracle
 	Mysql       *mysql.MySQL
 	MetaDB      *meta.Meta
+	// 增量同步消费追平当前 CURRENT 重做日志标志，按任务记录
+	// 当值 == 0 启用 filterOracleIncrRecord 大于或者等于逻辑
+	// 当值 == 1 启用 filterOracleIncrRecord 大于逻辑，避免已被消费得日志一直被重复消费
+	currentResetFlag int
 }
 
 func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
@@ -50,7 +55,7 @@ func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +138,34 @@ func (r *Migrate) Full() error {
 			return err
 		}
 
+		// 目标端表按表名以及表路由规则清理，merge 仅删除当前源端表数据
+		tableNameRule, err := r.GetTableNameRule()
+		if err != nil {
+			return err
+		}
+		tableRoute, err := meta.NewTableRouteRuleModel(r.MetaDB).GetSchemaTableRoute(r.Ctx, r.Cfg)
+		if err != nil {
+			return err
+		}
+
 		for _, tableName := range exporters {
+			// 延迟创建索引外键未完成，保留定义记录
+			deferSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+				TableNameS:  tableName,
+				TaskMode:    r.Cfg.TaskMode,
+			})
+			if err != nil {
+				return err
+			}
+			var deferIndexDDL, deferIndexStatus string
+			if len(deferSyncMetas) > 0 && deferSyncMetas[0].DeferIndexStatus != "" && deferSyncMetas[0].DeferIndexStatus != common.MigrateDeferIndexStatusBuilt {
+				deferIndexDDL = deferSyncMetas[0].DeferIndexDDL
+				deferIndexStatus = common.MigrateDeferIndexStatusDeferred
+			}
+
 			err = meta.NewWaitSyncMetaModel(r.MetaDB).DeleteWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
 				DBTypeS:     r.Cfg.DBTypeS,
 				DBTypeT:     r.Cfg.DBTypeT,
@@ -145,13 +177,20 @@ func (r *Migrate) Full() error {
 				return err
 			}
 			// 清理已有表数据
-			if err := r.Mysql.TruncateMySQLTable(r.Cfg.SchemaConfig.TargetSchema, tableName); err != nil {
-				return err
+			targetTableName := common.StringUPPER(tableName)
+			if val, ok := tableNameRule[common.StringUPPER(tableName)]; ok {
+				targetTableName = val
+			}
+			for _, c := range tableRoute[common.StringUPPER(tableName)].TargetTableClean(common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), targetTableName) {
+				if err := r.Mysql.WriteMySQLTable(c.SQL()); err != nil {
+					return fmt.Errorf("clean target table [%s] failed: %v", c.TargetTable(), err)
+				}
+				zap.L().Info("clean table",
+					zap.String("schema", c.SchemaNameT),
+					zap.String("table", c.TableNameT),
+					zap.String("action", c.Action),
+					zap.String("status", "success"))
 			}
-			zap.L().Info("truncate table",
-				zap.String("schema", r.Cfg.SchemaConfig.TargetSchema),
-				zap.String("table", tableName),
-				zap.String("status", "success"))
 
 			// 判断并记录待同步表列表
 			waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
@@ -166,14 +205,16 @@ func (r *Migrate) Full() error {
 			}
 			if len(waitSyncMetas) == 0 {
 				err = meta.NewWaitSyncMetaModel(r.MetaDB).CreateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
-					DBTypeS:        r.Cfg.DBTypeS,
-					DBTypeT:        r.Cfg.DBTypeT,
-					SchemaNameS:    common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-					TableNameS:     common.StringUPPER(tableName),
-					TaskMode:       r.Cfg.TaskMode,
-					TaskStatus:     common.TaskStatusWaiting,
-					GlobalScnS:     common.TaskTableDefaultSourceGlobalSCN,
-					ChunkTotalNums: common.TaskTableDefaultSplitChunkNums,
+					DBTypeS:          r.Cfg.DBTypeS,
+					DBTypeT:          r.Cfg.DBTypeT,
+					SchemaNameS:      common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+					TableNameS:       common.StringUPPER(tableName),
+					TaskMode:         r.Cfg.TaskMode,
+					TaskStatus:       common.TaskStatusWaiting,
+					GlobalScnS:       common.TaskTableDefaultSourceGlobalSCN,
+					ChunkTotalNums:   common.TaskTableDefaultSplitChunkNums,
+					DeferIndexDDL:    deferIndexDDL,
+					DeferIndexStatus: deferIndexStatus,
 				})
 				if err != nil {
 					return err
@@ -227,7 +268,7 @@ func (r *Migrate) Full() error {
 		return err
 	}
 	if errTotals > 0 {
-		return fmt.Errorf(`full schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, update meta table [wait_sync_meta] column [task_status] table status RUNNING (Need UPPER) and delete meta table [chunk_error_detail] current task all records; finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode)
+		return fmt.Errorf(`full schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [full_sync_meta] log record; secondly if need resume, run [-mode task -task-mode %s -action retry] to retry failed chunks, or run [-mode task -task-mode %s -action reset -table <table>] to reset table; finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode, strings.ToLower(r.Cfg.TaskMode), strings.ToLower(r.Cfg.TaskMode))
 	}
 
 	// 判断并记录待同步表列表
@@ -330,6 +371,29 @@ func (r *Migrate) Full() error {
 		return fmt.Errorf("checkpoint isn't consistent, can't be resume, please reruning [enable-checkpoint = fase]")
 	}
 
+	// 记录带时区时间类型数据转换时区，断点续传需与已记录时区保持一致
+	var panicTimeZoneTables []string
+	for _, t := range partSyncDetails {
+		if t.TimeZone != "" && !strings.EqualFold(t.TimeZone, r.Mysql.TimeZone) {
+			panicTimeZoneTables = append(panicTimeZoneTables, t.TableNameS)
+		}
+	}
+	if len(panicTimeZoneTables) > 0 {
+		return fmt.Errorf("table %v checkpoint time zone isn't equal to current time zone [%s], can't be resume, please keep config [mysql] time-zone consistent or reset table", panicTimeZoneTables, r.Mysql.TimeZone)
+	}
+	if len(waitSyncTables) > 0 || len(partSyncTables) > 0 {
+		err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMetaTimeZone(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TaskMode:    r.Cfg.TaskMode,
+			TimeZone:    r.Mysql.TimeZone,
+		}, append(waitSyncTables, partSyncTables...))
+		if err != nil {
+			return err
+		}
+	}
+
 	// 数据迁移
 	// 优先存在断点的表
 	// partSyncTables -> waitSyncTables
@@ -339,8 +403,18 @@ func (r *Migrate) Full() error {
 		return err
 	}
 
+	// 暂缓创建目标端索引外键
+	if r.Cfg.FullConfig.DeferIndex && (len(waitSyncTables) > 0 || len(partSyncTables) > 0) {
+		var deferTables []string
+		deferTables = append(deferTables, partSyncTables...)
+		deferTables = append(deferTables, waitSyncTables...)
+		if err = r.deferTableIndex(deferTables, tableNameRule); err != nil {
+			return err
+		}
+	}
+
 	if len(partSyncTables) > 0 {
-		err = r.FullPartSyncTable(partSyncTables, tableNameRule)
+		err = r.FullPartSyncTable(partSyncTables)
 		if err != nil {
 			return err
 		}
@@ -374,6 +448,11 @@ func (r *Migrate) Full() error {
 		return err
 	}
 
+	// 创建延迟索引外键，外键需任务全部表迁移成功
+	if err = r.buildDeferIndex(succTotals, len(failedTotals) == 0); err != nil {
+		return err
+	}
+
 	zap.L().Info("all full table data sync finished",
 		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 		zap.Int("table totals", len(exporters)),
@@ -384,7 +463,7 @@ func (r *Migrate) Full() error {
 	return nil
 }
 
-func (r *Migrate) FullPartSyncTable(fullPartTables []string, tableNameRule map[string]string) error {
+func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 	taskTime := time.Now()
 
 	zap.L().Info("source schema all table data loader starting",
@@ -392,14 +471,38 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string, tableNameRule map[s
 		zap.Int("table totals", len(fullPartTables)),
 		zap.String("startTime", taskTime.String()))
 
+	// 获取字段数据转换规则
+	columnTransform, err := meta.NewColumnTransformRuleModel(r.MetaDB).GetSchemaColumnTransform(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.MetaDB).GetSchemaColumnNameRule(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取表路由规则
+	tableRoute, err := meta.NewTableRouteRuleModel(r.MetaDB).GetSchemaTableRoute(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
 	g := &errgroup.Group{}
 	g.SetLimit(r.Cfg.FullConfig.TableThreads)
 
 	for _, table := range fullPartTables {
 		t := table
 		g.Go(func() error {
+			release, err := r.Cfg.AcquireThread(r.Ctx, common.ThreadBudgetFullTable, r.Cfg.FullConfig.TableThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			startTime := time.Now()
-			err := meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
 				DBTypeS:     r.Cfg.DBTypeS,
 				DBTypeT:     r.Cfg.DBTypeT,
 				SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
@@ -457,20 +560,28 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string, tableNameRule map[s
 			if err != nil {
 				return nil
 			}
-
-			var targetTableName string
-			if val, ok := tableNameRule[common.StringUPPER(t)]; ok {
-				targetTableName = val
-			} else {
-				targetTableName = common.StringUPPER(t)
+			// 字段名映射规则，字段列表以目标端字段名为准，与查询别名保持一致
+			columnNameS = columnNameRule[common.StringUPPER(t)].TargetColumnNames(columnNameS)
+			// 表路由 merge 规则目标端区分字段，与查询别名保持一致
+			if tableRoute[common.StringUPPER(t)].IsMerge() {
+				columnNameS = append(columnNameS, common.StringsBuilder("`", tableRoute[common.StringUPPER(t)].DiscriminatorColumn, "`"))
 			}
 
-			sqlStr00 := GenMySQLTablePrepareStmt(common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), targetTableName, columnNameS, r.Cfg.AppConfig.InsertBatchSize, true)
-			stmt, err := r.Mysql.MySQLDB.PrepareContext(r.Ctx, sqlStr00)
-			if err != nil {
-				return err
+			// 目标端表以 chunk 记录为准，表路由 hash/range 规则不同 chunk 写入不同分片表
+			stmts := make(map[string]*sql.Stmt)
+			for _, m := range waitFullMetas {
+				targetTable := common.StringsBuilder(m.SchemaNameT, ".", m.TableNameT)
+				if _, ok := stmts[targetTable]; ok {
+					continue
+				}
+				sqlStr00 := GenMySQLTablePrepareStmt(m.SchemaNameT, m.TableNameT, columnNameS, r.Cfg.AppConfig.InsertBatchSize, true)
+				stmt, err := r.Mysql.MySQLDB.PrepareContext(r.Ctx, sqlStr00)
+				if err != nil {
+					return err
+				}
+				defer stmt.Close()
+				stmts[targetTable] = stmt
 			}
-			defer stmt.Close()
 
 			g1 := &errgroup.Group{}
 			g1.SetLimit(r.Cfg.FullConfig.SQLThreads)
@@ -490,9 +601,15 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string, tableNameRule map[s
 					}); errf != nil {
 						return fmt.Errorf("update full_sync_meta table [%v] failed: %v", m.String(), errf)
 					}
-					err = public.IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Mysql, stmt,
+					chunkStartTime := time.Now()
+					err = public.IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Mysql, stmts[common.StringsBuilder(m.SchemaNameT, ".", m.TableNameT)],
 						common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
-						common.StringUPPER(r.Cfg.MySQLConfig.Charset), r.Cfg.FullConfig.ApplyThreads, r.Cfg.AppConfig.InsertBatchSize, r.Cfg.FullConfig.CallTimeout, true, columnNameS))
+						common.StringUPPER(r.Cfg.MySQLConfig.Charset), r.Cfg.FullConfig.ApplyThreads, r.Cfg.AppConfig.InsertBatchSize, r.Cfg.FullConfig.CallTimeout, true, columnNameS, columnNameRule[common.StringUPPER(t)].TargetColumnTransform(columnTransform[common.StringUPPER(t)])))
+					chunkStatus := metrics.StatusSuccess
+					if err != nil {
+						chunkStatus = metrics.StatusFailed
+					}
+					metrics.MigrateChunkDuration.WithLabelValues(m.TaskMode, m.SchemaNameS, m.TableNameS, chunkStatus).Observe(time.Since(chunkStartTime).Seconds())
 
 					if err != nil {
 						// record error, skip error
@@ -590,6 +707,27 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string, tableNameRule map[s
 				if err != nil {
 					return err
 				}
+				metrics.SetMigrateTableChunks(r.Cfg.TaskMode, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), int64(len(successChunkFullMeta)), 0)
+
+				// 表全部 chunk 成功，创建延迟索引
+				deferSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+					DBTypeS:     r.Cfg.DBTypeS,
+					DBTypeT:     r.Cfg.DBTypeT,
+					SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+					TableNameS:  common.StringUPPER(t),
+					TaskMode:    r.Cfg.TaskMode,
+				})
+				if err != nil {
+					return err
+				}
+				if len(deferSyncMetas) > 0 && deferSyncMetas[0].DeferIndexStatus == common.MigrateDeferIndexStatusDeferred {
+					if err = r.buildTableDeferIndex(deferSyncMetas[0], false); err != nil {
+						zap.L().Error("full table defer index build failed, retry when full task finished",
+							zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+							zap.String("table", common.StringUPPER(t)),
+							zap.Error(err))
+					}
+				}
 				zap.L().Info("full single table oracle to mysql finished",
 					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 					zap.String("table", common.StringUPPER(t)),
@@ -610,6 +748,7 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string, tableNameRule map[s
 				if err != nil {
 					return err
 				}
+				metrics.SetMigrateTableChunks(r.Cfg.TaskMode, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), int64(len(successChunkFullMeta)), failedChunkTotalErrs)
 				zap.L().Warn("update mysql [wait_sync_meta] meta",
 					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 					zap.String("table", common.StringUPPER(t)),
@@ -637,7 +776,7 @@ func (r *Migrate) FullWaitSyncTable(fullWaitTables []string, tableNameRule map[s
 	if err != nil {
 		return err
 	}
-	err = r.FullPartSyncTable(fullWaitTables, tableNameRule)
+	err = r.FullPartSyncTable(fullWaitTables)
 	if err != nil {
 		return err
 	}
@@ -654,6 +793,18 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 	// 获取自定义库表迁移配置
 	tableMigrateRule := r.GetCustomMigrateConfig()
 
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.MetaDB).GetSchemaColumnNameRule(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取表路由规则
+	tableRoute, err := meta.NewTableRouteRuleModel(r.MetaDB).GetSchemaTableRoute(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
 	// 全量同步前，获取 SCN 以及初始化元数据表
 	globalSCN, err := r.Oracle.GetOracleCurrentSnapshotSCN()
 	if err != nil {
@@ -678,6 +829,12 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 	for _, table := range fullWaitTables {
 		t := table
 		g.Go(func() error {
+			release, err := r.Cfg.AcquireThread(r.Ctx, common.ThreadBudgetFullTask, r.Cfg.FullConfig.TaskThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			startTime := time.Now()
 			// 库名、表名规则
 			var targetTableName string
@@ -702,10 +859,14 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 				sqlHint = r.Cfg.FullConfig.SQLHint
 			}
 
-			sourceColumnInfo, err := r.AdjustTableSelectColumn(t, oracleCollation)
+			sourceColumnInfo, err := r.AdjustTableSelectColumn(t, oracleCollation, columnNameRule[common.StringUPPER(t)])
 			if err != nil {
 				return err
 			}
+			// 表路由 merge 规则，源端以固定值查询目标端区分字段
+			if tableRoute[common.StringUPPER(t)].IsMerge() {
+				sourceColumnInfo = common.StringsBuilder(sourceColumnInfo, ",", tableRoute[common.StringUPPER(t)].DiscriminatorSelectS())
+			}
 
 			var (
 				isPartition string
@@ -721,62 +882,15 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 				return err
 			}
 
-			taskName := uuid.New().String()
-
-			if err = r.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
-				return err
-			}
-
-			if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows), r.Cfg.FullConfig.CallTimeout); err != nil {
-				return err
-			}
-
-			chunkRes, err := r.Oracle.GetOracleTableChunksByRowID(taskName)
+			// 表 chunk 切分，rowid 基于 DBMS_PARALLEL_EXECUTE，key 基于主键/唯一键范围源端只读
+			chunkRes, err := r.Oracle.GetOracleTableChunks(r.Cfg.FullConfig.ChunkMethod, common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema), common.StringUPPER(t), r.Cfg.CSVConfig.Rows, r.Cfg.FullConfig.CallTimeout)
 			if err != nil {
 				return err
 			}
 
-			// 判断数据是否存在
+			// 数据不存在，全表作为单个 chunk
 			if len(chunkRes) == 0 {
-				switch {
-				case enableSplit && !strings.EqualFold(wherePrefix, ""):
-					whereRange = common.StringsBuilder(`1 = 1 AND `, wherePrefix)
-				default:
-					whereRange = `1 = 1`
-				}
-				err = meta.NewCommonModel(r.MetaDB).CreateFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx, &meta.FullSyncMeta{
-					DBTypeS:        r.Cfg.DBTypeS,
-					DBTypeT:        r.Cfg.DBTypeT,
-					SchemaNameS:    common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-					TableNameS:     common.StringUPPER(t),
-					SchemaNameT:    common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-					TableNameT:     common.StringUPPER(targetTableName),
-					GlobalScnS:     globalSCN,
-					ConsistentRead: isConsistentRead,
-					SQLHint:        sqlHint,
-					ColumnDetailS:  sourceColumnInfo,
-					ChunkDetailS:   whereRange,
-					TaskMode:       r.Cfg.TaskMode,
-					TaskStatus:     common.TaskStatusWaiting,
-				}, &meta.WaitSyncMeta{
-					DBTypeS:          r.Cfg.DBTypeS,
-					DBTypeT:          r.Cfg.DBTypeT,
-					SchemaNameS:      common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-					TableNameS:       common.StringUPPER(t),
-					TaskMode:         r.Cfg.TaskMode,
-					TableNumRows:     uint64(tableRowsByStatistics),
-					GlobalScnS:       globalSCN,
-					ConsistentRead:   isConsistentRead,
-					ChunkTotalNums:   1,
-					ChunkSuccessNums: 0,
-					ChunkFailedNums:  0,
-					IsPartition:      isPartition,
-				})
-				if err != nil {
-					return err
-				}
-
-				return nil
+				chunkRes = append(chunkRes, map[string]string{"CMD": `1 = 1`})
 			}
 
 			var fullMetas []meta.FullSyncMeta
@@ -804,6 +918,9 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 				})
 			}
 
+			// 表路由规则
+			fullMetas = routeFullSyncMeta(fullMetas, tableRoute[common.StringUPPER(t)])
+
 			// 元数据库信息 batch 写入
 			err = meta.NewFullSyncMetaModel(r.MetaDB).BatchCreateFullSyncMeta(r.Ctx, fullMetas, r.Cfg.AppConfig.InsertBatchSize)
 			if err != nil {
@@ -821,7 +938,7 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 				"TableNumRows":     uint64(tableRowsByStatistics),
 				"GlobalScnS":       globalSCN,
 				"ConsistentRead":   isConsistentRead,
-				"ChunkTotalNums":   len(chunkRes),
+				"ChunkTotalNums":   len(fullMetas),
 				"ChunkSuccessNums": 0,
 				"ChunkFailedNums":  0,
 				"IsPartition":      isPartition,
@@ -830,10 +947,6 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 				return err
 			}
 
-			if err = r.Oracle.CloseOracleChunkTask(taskName); err != nil {
-				return err
-			}
-
 			endTime := time.Now()
 			zap.L().Info("init source single table wait_sync_meta and full_sync_meta finished",
 				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
@@ -853,6 +966,32 @@ func (r *Migrate) InitWaitSyncTableChunk(fullWaitTables []string, tableNameRule
 	return nil
 }
 
+// 表路由规则，merge 写入合并目标端表，hash/range 每个 chunk 按分片拆分写入分片表
+func routeFullSyncMeta(fullMetas []meta.FullSyncMeta, tableRoute *common.TableRoute) []meta.FullSyncMeta {
+	switch {
+	case tableRoute.IsMerge():
+		for i := range fullMetas {
+			fullMetas[i].SchemaNameT = tableRoute.SchemaNameT
+			fullMetas[i].TableNameT = tableRoute.TableNameT
+		}
+		return fullMetas
+	case tableRoute.IsSplit():
+		var routeMetas []meta.FullSyncMeta
+		for _, m := range fullMetas {
+			for _, shard := range tableRoute.Shards {
+				routeMeta := m
+				routeMeta.SchemaNameT = shard.SchemaNameT
+				routeMeta.TableNameT = shard.TableNameT
+				routeMeta.ChunkDetailS = common.StringsBuilder(m.ChunkDetailS, ` AND (`, tableRoute.ShardWhereS(shard), `)`)
+				routeMetas = append(routeMetas, routeMeta)
+			}
+		}
+		return routeMetas
+	default:
+		return fullMetas
+	}
+}
+
 func (r *Migrate) GetCustomMigrateConfig() map[string]config.MigrateConfig {
 	tableMigrateMap := make(map[string]config.MigrateConfig)
 	for _, t := range r.Cfg.SchemaConfig.MigrateConfig {
@@ -861,6 +1000,26 @@ func (r *Migrate) GetCustomMigrateConfig() map[string]config.MigrateConfig {
 	return tableMigrateMap
 }
 
+// 表级别 incr-config 优先，未配置则使用 all error-policy
+func (r *Migrate) GetIncrErrorPolicy(sourceTables []string) map[string]string {
+	tableIncrMap := make(map[string]string)
+	for _, t := range r.Cfg.SchemaConfig.IncrConfig {
+		if t.ErrorPolicy == "" {
+			continue
+		}
+		tableIncrMap[common.StringUPPER(t.SourceTable)] = t.ErrorPolicy
+	}
+	errorPolicyMap := make(map[string]string)
+	for _, t := range sourceTables {
+		if val, ok := tableIncrMap[common.StringUPPER(t)]; ok {
+			errorPolicyMap[common.StringUPPER(t)] = val
+		} else {
+			errorPolicyMap[common.StringUPPER(t)] = r.Cfg.AllConfig.ErrorPolicy
+		}
+	}
+	return errorPolicyMap
+}
+
 func (r *Migrate) GetTableNameRule() (map[string]string, error) {
 	// 获取表名自定义规则
 	tableNameRules, err := meta.NewTableNameRuleModel(r.MetaDB).DetailTableNameRule(r.Ctx, &meta.TableNameRule{
@@ -882,7 +1041,7 @@ func (r *Migrate) GetTableNameRule() (map[string]string, error) {
 	return tableNameRuleMap, nil
 }
 
-func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bool) (string, error) {
+func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bool, columnNameRule *common.TableColumnNameRule) (string, error) {
 	// Date/Timestamp 字段类型格式化
 	// Interval Year/Day 数据字符 TO_CHAR 格式化
 	columnsINFO, err := r.Oracle.GetOracleSchemaTableColumn(r.Cfg.SchemaConfig.SourceSchema, sourceTable, oracleCollation)
@@ -901,48 +1060,64 @@ func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bo
 		}
 		columnName = string(convertUtf8Raw)
 
+		// 字段名映射规则，不迁移字段跳过，重命名字段以目标端字段名作为查询别名
+		columnNameT, ok := columnNameRule.TargetColumnName(columnName)
+		if !ok {
+			continue
+		}
+		columnNameSelect := common.StringsBuilder(`"`, columnName, `"`)
+		if columnNameT != columnName {
+			columnNameSelect = common.StringsBuilder(`"`, columnName, `" AS "`, columnNameT, `"`)
+		}
+
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		case "DECIMAL", "DEC", "DOUBLE PRECISION", "FLOAT", "INTEGER", "INT", "REAL", "NUMERIC", "BINARY_FLOAT", "BINARY_DOUBLE", "SMALLINT":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		// 字符
 		case "BFILE", "CHARACTER", "LONG", "NCHAR VARYING", "ROWID", "UROWID", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "NCLOB", "CLOB":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		// XMLTYPE
 		case "XMLTYPE":
-			columnNames = append(columnNames, fmt.Sprintf(` XMLSERIALIZE(CONTENT "%s" AS CLOB) AS "%s"`, columnName, columnName))
+			columnNames = append(columnNames, fmt.Sprintf(` XMLSERIALIZE(CONTENT "%s" AS CLOB) AS "%s"`, columnName, columnNameT))
 		// 二进制
 		case "BLOB", "LONG RAW", "RAW":
-			columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+			columnNames = append(columnNames, columnNameSelect)
 		// 时间
 		case "DATE":
-			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-MM-dd HH24:mi:ss') AS "`, columnName, `"`))
+			columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-MM-dd HH24:mi:ss') AS "`, columnNameT, `"`))
 		// 默认其他类型
 		default:
 			if strings.Contains(rowCol["DATA_TYPE"], "INTERVAL") {
-				columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `") AS "`, columnName, `"`))
+				columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `") AS "`, columnNameT, `"`))
 			} else if strings.Contains(rowCol["DATA_TYPE"], "TIMESTAMP") {
 				dataScale, err := strconv.Atoi(rowCol["DATA_SCALE"])
 				if err != nil {
 					return "", fmt.Errorf("aujust oracle timestamp datatype scale [%s] strconv.Atoi failed: %v", rowCol["DATA_SCALE"], err)
 				}
+				// 带时区时间类型统一转换为目标端时区
+				columnExpr := common.OracleTimeZoneColumn(common.StringsBuilder(`"`, columnName, `"`), rowCol["DATA_TYPE"], r.Mysql.TimeZone)
 				if dataScale == 0 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-MM-dd HH24:mi:ss') AS "`, columnName, `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, columnExpr, `,'yyyy-MM-dd HH24:mi:ss') AS "`, columnNameT, `"`))
 				} else if dataScale < 0 && dataScale <= 6 {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName,
-						`",'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, columnName, `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, columnExpr,
+						`,'yyyy-mm-dd hh24:mi:ss.ff`, rowCol["DATA_SCALE"], `') AS "`, columnNameT, `"`))
 				} else {
-					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR("`, columnName, `",'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, columnName, `"`))
+					columnNames = append(columnNames, common.StringsBuilder(`TO_CHAR(`, columnExpr, `,'yyyy-mm-dd hh24:mi:ss.ff6') AS "`, columnNameT, `"`))
 				}
 
 			} else {
-				columnNames = append(columnNames, common.StringsBuilder(`"`, columnName, `"`))
+				columnNames = append(columnNames, columnNameSelect)
 			}
 		}
 
 	}
 
+	if len(columnNames) == 0 {
+		return "", fmt.Errorf("oracle schema [%s] table [%s] column name rule exclude all columns, please check", r.Cfg.SchemaConfig.SourceSchema, sourceTable)
+	}
+
 	return strings.Join(columnNames, ","), nil
 }
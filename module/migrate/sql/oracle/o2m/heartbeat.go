@@ -0,0 +1,141 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/metrics"
+	"go.uber.org/zap"
+	"time"
+)
+
+// 增量同步延迟监控
+// 1、开启心跳，源端心跳表定时写入当前毫秒时间戳，经 logminer 捕获应用至目标端心跳表，当前时间与目标端心跳时间差值即端到端同步延迟
+// 2、表级别延迟，当前 SCN 与元数据表 [incr_sync_meta] 表 checkpoint TableScnS 差值
+func (r *Migrate) startIncrMonitor() error {
+	if r.Cfg.AllConfig.EnableHeartbeat {
+		if err := r.initIncrHeartbeat(); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(r.Cfg.AllConfig.HeartbeatInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.Ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if r.Cfg.AllConfig.EnableHeartbeat {
+				if err := r.Oracle.UpdateOracleHeartbeat(r.Cfg.SchemaConfig.SourceSchema, r.Cfg.AllConfig.HeartbeatTable, time.Now().UnixMilli()); err != nil {
+					zap.L().Warn("increment heartbeat update failed", zap.Error(err))
+				}
+			}
+			if err := r.reportIncrLag(); err != nil {
+				zap.L().Warn("increment sync lag report failed", zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+// 初始化源端以及目标端心跳表，心跳表不存在增量元数据记录则以当前 SCN 写入
+func (r *Migrate) initIncrHeartbeat() error {
+	if err := r.Oracle.InitOracleHeartbeatTable(r.Cfg.SchemaConfig.SourceSchema, r.Cfg.AllConfig.HeartbeatTable); err != nil {
+		return err
+	}
+	if err := r.Mysql.InitMySQLHeartbeatTable(r.Cfg.SchemaConfig.TargetSchema, r.Cfg.AllConfig.HeartbeatTable); err != nil {
+		return err
+	}
+
+	counts, err := meta.NewIncrSyncMetaModel(r.MetaDB).CountsIncrSyncMetaBySchemaTable(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  r.Cfg.AllConfig.HeartbeatTable,
+	})
+	if err != nil {
+		return err
+	}
+	if counts > 0 {
+		return nil
+	}
+
+	currentSCN, err := r.Oracle.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return err
+	}
+	return meta.NewIncrSyncMetaModel(r.MetaDB).BatchCreateIncrSyncMeta(r.Ctx, []meta.IncrSyncMeta{{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		GlobalScnS:  currentSCN,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  r.Cfg.AllConfig.HeartbeatTable,
+		SchemaNameT: r.Cfg.SchemaConfig.TargetSchema,
+		TableNameT:  r.Cfg.AllConfig.HeartbeatTable,
+		TableScnS:   currentSCN,
+		IsPartition: "NO",
+	}}, r.Cfg.AppConfig.InsertBatchSize)
+}
+
+func (r *Migrate) reportIncrLag() error {
+	currentSCN, err := r.Oracle.GetOracleCurrentSnapshotSCN()
+	if err != nil {
+		return err
+	}
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(r.MetaDB).DetailIncrSyncMetaBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range incrSyncMetas {
+		var scnLag uint64
+		if currentSCN > m.TableScnS {
+			scnLag = currentSCN - m.TableScnS
+		}
+		metrics.IncrCheckpointSCN.WithLabelValues(m.SchemaNameS, m.TableNameS).Set(float64(m.TableScnS))
+		metrics.IncrSCNLag.WithLabelValues(m.SchemaNameS, m.TableNameS).Set(float64(scnLag))
+		zap.L().Info("increment table sync lag",
+			zap.String("schema", m.SchemaNameS),
+			zap.String("table", m.TableNameS),
+			zap.Uint64("current scn", currentSCN),
+			zap.Uint64("table scn", m.TableScnS),
+			zap.Uint64("scn lag", scnLag))
+	}
+
+	if r.Cfg.AllConfig.EnableHeartbeat {
+		heartbeatTS, err := r.Mysql.GetMySQLHeartbeat(r.Cfg.SchemaConfig.TargetSchema, r.Cfg.AllConfig.HeartbeatTable)
+		if err != nil {
+			return err
+		}
+		// 心跳尚未应用至目标端
+		if heartbeatTS == 0 {
+			return nil
+		}
+		metrics.IncrHeartbeatLag.WithLabelValues(r.Cfg.SchemaConfig.SourceSchema).Set(time.Since(time.UnixMilli(heartbeatTS)).Seconds())
+		zap.L().Info("increment heartbeat sync lag",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+			zap.String("heartbeat table", r.Cfg.AllConfig.HeartbeatTable),
+			zap.Time("heartbeat time", time.UnixMilli(heartbeatTS)),
+			zap.String("lag", time.Since(time.UnixMilli(heartbeatTS)).String()))
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
 	"go.uber.org/zap"
 	"strconv"
@@ -43,7 +44,7 @@ func NewIncr(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +60,11 @@ func NewIncr(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 }
 
 func (r *Migrate) Incr() error {
-	zap.L().Info("oracle to mysql increment sync table data start", zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+	var sourceSchemas []string
+	for _, s := range r.Cfg.SchemaConfigs() {
+		sourceSchemas = append(sourceSchemas, s.SourceSchema)
+	}
+	zap.L().Info("oracle to mysql increment sync table data start", zap.Strings("schemas", sourceSchemas))
 
 	// 判断上游 Oracle 数据库版本
 	// 需要 oracle 11g 及以上
@@ -91,11 +96,76 @@ func (r *Migrate) Incr() error {
 		return fmt.Errorf("mysql current config charset [%v] isn't support, support charset [%v]", r.Cfg.MySQLConfig.Charset, common.MigrateDataSupportCharset)
 	}
 
+	// Logminer TIMESTAMP WITH LOCAL TIME ZONE 以数据库时区不带时区字面值输出，增量同步不做时区转换
+	// 数据库时区与目标端时区不一致，增量同步 TIMESTAMP WITH LOCAL TIME ZONE 数据存在时区偏差
+	if r.Cfg.MySQLConfig.TimeZone != "" {
+		dbTimeZone, err := r.Oracle.GetOracleDBTimeZone()
+		if err != nil {
+			return err
+		}
+		if timeZone, err := common.AdjustTimeZoneOffset(dbTimeZone); err != nil || timeZone != r.Cfg.MySQLConfig.TimeZone {
+			zap.L().Warn("oracle dbtimezone and mysql config time-zone aren't equal, increment sync timestamp with local time zone column data won't be converted",
+				zap.String("oracle dbtimezone", dbTimeZone),
+				zap.String("mysql config time-zone", r.Cfg.MySQLConfig.TimeZone))
+		}
+	}
+
+	// 多 schema 任务，各 schema 分别初始化全量以及增量元数据，增量同步共享同一 logminer 会话
+	var schemaMigrates []*Migrate
+	for _, schemaCfg := range r.Cfg.SchemaConfigs() {
+		m, err := r.newSchemaMigrate(schemaCfg)
+		if err != nil {
+			return err
+		}
+		if err = m.initIncrSyncMeta(); err != nil {
+			return err
+		}
+		// 增量同步延迟监控
+		if err = m.startIncrMonitor(); err != nil {
+			return err
+		}
+		schemaMigrates = append(schemaMigrates, m)
+	}
+
+	// 增量数据同步
+	for range time.Tick(300 * time.Millisecond) {
+		if err = r.syncTableIncrRecord(schemaMigrates); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schema 级别迁移任务，多 schema 任务按 schema 初始化源端连接（CURRENT_SCHEMA），logminer、目标端以及元数据库连接共享
+func (r *Migrate) newSchemaMigrate(schemaCfg config.SchemaConfig) (*Migrate, error) {
+	if len(r.Cfg.SchemaRouteConfig) == 0 {
+		return r, nil
+	}
+	oracleDB, err := oracle.NewOracleDBEngine(r.Ctx, r.Cfg.OracleConfig, schemaCfg.SourceSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrate{
+		Ctx:         r.Ctx,
+		Cfg:         r.Cfg.WithSchemaConfig(schemaCfg),
+		Oracle:      oracleDB,
+		OracleMiner: r.OracleMiner,
+		Mysql:       r.Mysql,
+		MetaDB:      r.MetaDB,
+	}, nil
+}
+
+// 全量数据导出导入，初始化全量元数据表以及导入完成初始化增量元数据表
+func (r *Migrate) initIncrSyncMeta() error {
 	// 获取配置文件待同步表列表
 	exporters, err := public.FilterCFGTable(r.Cfg, r.Oracle)
 	if err != nil {
 		return err
 	}
+	// 心跳表不参与全量同步，由增量同步单独初始化
+	if r.Cfg.AllConfig.EnableHeartbeat {
+		exporters = common.FilterDifferenceStringItems(exporters, []string{r.Cfg.AllConfig.HeartbeatTable})
+	}
 
 	// 判断 [wait_sync_meta] 是否存在错误记录，是否可进行 ALL
 	errTotals, err := meta.NewWaitSyncMetaModel(r.MetaDB).CountsErrWaitSyncMetaBySchema(r.Ctx, &meta.WaitSyncMeta{
@@ -106,10 +176,9 @@ func (r *Migrate) Incr() error {
 		TaskStatus:  common.TaskStatusFailed,
 	})
 	if errTotals > 0 || err != nil {
-		return fmt.Errorf(`csv schema [%s] mode [%s] table task failed: %v, meta table [wait_sync_meta] exist failed error, please firstly check log and deal, secondly clear or update meta table [wait_sync_meta] column [task_status] table status WAITING (Need UPPER), thirdly clear meta table [full_sync_meta] error table record, fively clear target schema error table record, finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode, err)
+		return fmt.Errorf(`csv schema [%s] mode [%s] table task failed: %v, meta table [wait_sync_meta] exist failed error, please firstly check log and deal, secondly run [-mode task -task-mode %s -action retry] to retry failed chunks, or run [-mode task -task-mode %s -action reset -table <table>] to reset table and clear target table record, finally rerunning`, strings.ToUpper(r.Cfg.SchemaConfig.SourceSchema), r.Cfg.TaskMode, err, strings.ToLower(r.Cfg.TaskMode), strings.ToLower(r.Cfg.TaskMode))
 	}
 
-	// 全量数据导出导入，初始化全量元数据表以及导入完成初始化增量元数据表
 	var (
 		incrExistTableList, incrIsNotExistTableList []string
 	)
@@ -165,12 +234,6 @@ func (r *Migrate) Incr() error {
 			if len(panicTables) != 0 {
 				return fmt.Errorf("table list %s can't incremently sync, because table increment sync meta record is exist and full meta sync isn't finished", panicTables)
 			}
-			// 增量数据同步
-			for range time.Tick(300 * time.Millisecond) {
-				if err := r.syncTableIncrRecord(); err != nil {
-					return err
-				}
-			}
 			return nil
 		}
 
@@ -235,26 +298,85 @@ func (r *Migrate) Incr() error {
 			}
 		}
 
-		// 增量数据同步
-		for range time.Tick(300 * time.Millisecond) {
-			if err = r.syncTableIncrRecord(); err != nil {
-				return err
-			}
-		}
 		return nil
 	}
 	return fmt.Errorf("increment sync taskflow condition isn't match, can't sync")
 }
 
-func (r *Migrate) syncTableIncrRecord() error {
+// schema 级别增量同步规则
+type incrSchema struct {
+	migrate         *Migrate
+	tableNameRule   map[string]string
+	columnTransform map[string]map[string]*common.ColumnTransform
+	columnNameRule  map[string]*common.TableColumnNameRule
+	tableRoute      map[string]*common.TableRoute
+	globalSCN       uint64
+}
+
+func (r *Migrate) newIncrSchema() (*incrSchema, error) {
 	// 获取自定义库表名规则
 	tableNameRule, err := r.GetTableNameRule()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// 获取字段数据转换规则
+	columnTransform, err := meta.NewColumnTransformRuleModel(r.MetaDB).GetSchemaColumnTransform(r.Ctx, r.Cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取字段名映射规则
+	columnNameRule, err := meta.NewColumnNameRuleModel(r.MetaDB).GetSchemaColumnNameRule(r.Ctx, r.Cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取表路由规则
+	tableRoute, err := meta.NewTableRouteRuleModel(r.MetaDB).GetSchemaTableRoute(r.Ctx, r.Cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取增量表起始最小 SCN 号
+	globalSCN, err := meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinGlobalScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &incrSchema{
+		migrate:         r,
+		tableNameRule:   tableNameRule,
+		columnTransform: columnTransform,
+		columnNameRule:  columnNameRule,
+		tableRoute:      tableRoute,
+		globalSCN:       globalSCN,
+	}, nil
+}
+
+// 多 schema 共享同一 logminer 会话，每个日志文件只挖掘以及查询一次，按 schema 分别过滤、应用以及更新 checkpoint
+func (r *Migrate) syncTableIncrRecord(schemaMigrates []*Migrate) error {
+	var (
+		incrSchemas []*incrSchema
+		globalSCN   uint64
+	)
+	for i, m := range schemaMigrates {
+		s, err := m.newIncrSchema()
+		if err != nil {
+			return err
+		}
+		if i == 0 || s.globalSCN < globalSCN {
+			globalSCN = s.globalSCN
+		}
+		incrSchemas = append(incrSchemas, s)
 	}
 
 	// 获取增量所需得日志文件
-	logFiles, err := r.getTableIncrRecordLogfile()
+	logFiles, err := r.getTableIncrRecordLogfile(globalSCN)
 	if err != nil {
 		return err
 	}
@@ -281,37 +403,47 @@ func (r *Migrate) syncTableIncrRecord() error {
 			zap.Uint64("logminer start scn", logFileStartSCN),
 			zap.Uint64("logfile end scn", logFileEndSCN))
 
-		// 获取增量元数据表内所需同步表信息
-		incrSyncMetas, err := meta.NewIncrSyncMetaModel(r.MetaDB).DetailIncrSyncMetaBySchema(r.Ctx, &meta.IncrSyncMeta{
-			DBTypeS:     r.Cfg.DBTypeS,
-			DBTypeT:     r.Cfg.DBTypeT,
-			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
-		})
-		if err != nil {
-			return err
-		}
-		if len(incrSyncMetas) == 0 {
-			return fmt.Errorf("mysql increment mete table [incr_sync_meta] can't null")
-		}
-
+		// 获取增量元数据表内所需同步表信息，schema checkpoint 已超过当前日志文件则跳过
 		var (
-			transferTableMetaMap map[string]uint64
-			syncSourceTables     []string
+			logSchemas        []*incrLogSchema
+			filters           []public.LogminerFilter
+			minSourceTableSCN uint64
 		)
-		transferTableMetaMap = make(map[string]uint64)
-		for _, tbl := range incrSyncMetas {
-			transferTableMetaMap[strings.ToUpper(tbl.TableNameS)] = tbl.TableScnS
-			syncSourceTables = append(syncSourceTables, strings.ToUpper(tbl.TableNameS))
+		for _, s := range incrSchemas {
+			if logFileEndSCN < s.globalSCN {
+				continue
+			}
+			ls, err := s.newIncrLogSchema()
+			if err != nil {
+				return err
+			}
+			if len(logSchemas) == 0 || ls.minSourceTableSCN < minSourceTableSCN {
+				minSourceTableSCN = ls.minSourceTableSCN
+			}
+			logSchemas = append(logSchemas, ls)
+			filters = append(filters, public.LogminerFilter{
+				SourceSchema:  common.StringUPPER(s.migrate.Cfg.SchemaConfig.SourceSchema),
+				TargetSchema:  common.StringUPPER(s.migrate.Cfg.SchemaConfig.TargetSchema),
+				SourceTables:  ls.syncSourceTables,
+				TableNameRule: s.tableNameRule,
+			})
+		}
+		if len(logSchemas) == 0 {
+			continue
 		}
 
-		// 获取 logminer query 起始最小 SCN
-		minSourceTableSCN, err := meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinTableScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
-			DBTypeS:     r.Cfg.DBTypeS,
-			DBTypeT:     r.Cfg.DBTypeT,
-			SchemaNameS: r.Cfg.SchemaConfig.SourceSchema})
+		// 一致性校验快照申请，挖掘前获取当前 SCN 作为当前重做日志已挖掘范围上界
+		snapshotMetas, err := r.pendingIncrSnapshot(logSchemas)
 		if err != nil {
 			return err
 		}
+		var minerSCN uint64
+		if len(snapshotMetas) > 0 {
+			minerSCN, err = r.OracleMiner.GetOracleCurrentSnapshotSCN()
+			if err != nil {
+				return err
+			}
+		}
 
 		// logminer 运行
 		if err = r.OracleMiner.AddOracleLogminerlogFile(log["LOG_FILE"]); err != nil {
@@ -323,16 +455,17 @@ func (r *Migrate) syncTableIncrRecord() error {
 		}
 
 		// 捕获数据
+		minerStartTime := time.Now()
 		rowsResult, err := public.GetOracleIncrRecord(r.Ctx, r.OracleMiner,
-			common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
-			common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema),
-			common.StringArrayToCapitalChar(syncSourceTables),
-			tableNameRule,
+			filters,
 			strconv.FormatUint(minSourceTableSCN, 10),
 			r.Cfg.AllConfig.LogminerQueryTimeout)
 		if err != nil {
 			return err
 		}
+		for _, f := range filters {
+			metrics.IncrLogminerDuration.WithLabelValues(f.SourceSchema).Observe(time.Since(minerStartTime).Seconds())
+		}
 		zap.L().Info("increment table log extractor", zap.String("logfile", log["LOG_FILE"]),
 			zap.Uint64("logfile start scn", logFileStartSCN),
 			zap.Uint64("source table last scn", minSourceTableSCN),
@@ -355,167 +488,215 @@ func (r *Migrate) syncTableIncrRecord() error {
 			return err
 		}
 
-		// 按表级别筛选数据
-		var (
-			logminerContentMap map[string][]public.Logminer
-		)
-		if len(rowsResult) > 0 {
-			// 判断当前日志文件是否是重做日志文件
-			if common.IsContainString(redoLogList, log["LOG_FILE"]) {
-				// 判断是否是当前重做日志文件
-				// 如果当前日志文件是当前重做日志文件则 FilterOracleIncrRecord 只运行一次大于或等于对应表数据记录，也就是只重放一次已消费得SCN
-				if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
-					logminerContentMap, err = public.FilterOracleIncrRecord(
-						rowsResult,
-						syncSourceTables,
-						transferTableMetaMap,
-						r.Cfg.AllConfig.FilterThreads,
-						common.MigrateCurrentResetFlag,
-					)
-					if err != nil {
-						return err
-					}
-					zap.L().Warn("oracle current redo log reset flag", zap.Int("MigrateCurrentResetFlag", common.MigrateCurrentResetFlag))
-					common.MigrateCurrentResetFlag = 1
-				} else {
-					logminerContentMap, err = public.FilterOracleIncrRecord(
-						rowsResult,
-						syncSourceTables,
-						transferTableMetaMap,
-						r.Cfg.AllConfig.FilterThreads,
-						0,
-					)
-					if err != nil {
-						return err
-					}
-				}
+		// 按 schema 拆分数据
+		schemaRows := make(map[string][]public.Logminer)
+		for _, lc := range rowsResult {
+			schemaRows[common.StringUPPER(lc.SourceSchema)] = append(schemaRows[common.StringUPPER(lc.SourceSchema)], lc)
+		}
 
-				if len(logminerContentMap) > 0 {
-					// 数据应用
-					if err := applyOracleIncrRecord(r.MetaDB, r.Mysql, r.Cfg, logminerContentMap); err != nil {
-						return err
-					}
-					if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
-						// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
-						err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(r.Ctx,
-							r.Cfg.DBTypeS,
-							r.Cfg.DBTypeT,
-							r.Cfg.SchemaConfig.SourceSchema,
-							currentRedoLogMaxSCN,
-							logFileStartSCN,
-							logFileEndSCN)
-						if err != nil {
-							return err
-						}
-					} else {
-						// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
-						err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(r.Ctx,
-							r.Cfg.DBTypeS,
-							r.Cfg.DBTypeT,
-							r.Cfg.SchemaConfig.SourceSchema,
-							currentRedoLogMaxSCN,
-							logFileStartSCN,
-							logFileEndSCN,
-							syncSourceTables)
-						if err != nil {
-							return err
-						}
-					}
-
-					continue
-				}
-				zap.L().Warn("increment table log file logminer data that needn't to be consumed by current redo, transferdb will continue to capture")
-				continue
+		redoLog := &incrRedoLog{
+			logFile:              log["LOG_FILE"],
+			logFileStartSCN:      logFileStartSCN,
+			logFileEndSCN:        logFileEndSCN,
+			isRedo:               common.IsContainString(redoLogList, log["LOG_FILE"]),
+			isCurrentRedo:        logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName,
+			currentRedoLogMaxSCN: currentRedoLogMaxSCN,
+			currentResetFlag:     r.currentResetFlag,
+		}
+		for _, ls := range logSchemas {
+			if err = ls.syncLogRecord(schemaRows[common.StringUPPER(ls.migrate.Cfg.SchemaConfig.SourceSchema)], redoLog); err != nil {
+				return err
+			}
+		}
+		if err = r.respondIncrSnapshot(snapshotMetas, rowsResult, redoLog, minerSCN); err != nil {
+			return err
+		}
+		if len(rowsResult) > 0 && redoLog.isRedo && redoLog.isCurrentRedo {
+			zap.L().Warn("oracle current redo log reset flag", zap.Int("currentResetFlag", r.currentResetFlag))
+			r.currentResetFlag = 1
+		}
+	}
+	return nil
+}
+
+// 当前挖掘日志文件信息
+type incrRedoLog struct {
+	logFile              string
+	logFileStartSCN      uint64
+	logFileEndSCN        uint64
+	isRedo               bool
+	isCurrentRedo        bool
+	currentRedoLogMaxSCN uint64
+	currentResetFlag     int
+}
+
+// schema 级别当前日志文件同步表信息
+type incrLogSchema struct {
+	*incrSchema
+	transferTableMetaMap map[string]uint64
+	syncSourceTables     []string
+	incrErrorPolicy      map[string]string
+	minSourceTableSCN    uint64
+}
+
+func (s *incrSchema) newIncrLogSchema() (*incrLogSchema, error) {
+	r := s.migrate
+	incrSyncMetas, err := meta.NewIncrSyncMetaModel(r.MetaDB).DetailIncrSyncMetaBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(incrSyncMetas) == 0 {
+		return nil, fmt.Errorf("mysql increment mete table [incr_sync_meta] schema [%s] can't null", r.Cfg.SchemaConfig.SourceSchema)
+	}
+
+	var (
+		transferTableMetaMap map[string]uint64
+		syncSourceTables     []string
+	)
+	transferTableMetaMap = make(map[string]uint64)
+	for _, tbl := range incrSyncMetas {
+		transferTableMetaMap[strings.ToUpper(tbl.TableNameS)] = tbl.TableScnS
+		syncSourceTables = append(syncSourceTables, strings.ToUpper(tbl.TableNameS))
+	}
+
+	// 获取 logminer query 起始最小 SCN
+	minSourceTableSCN, err := meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinTableScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema})
+	if err != nil {
+		return nil, err
+	}
+
+	return &incrLogSchema{
+		incrSchema:           s,
+		transferTableMetaMap: transferTableMetaMap,
+		syncSourceTables:     syncSourceTables,
+		// 获取表级别增量数据应用错误处理策略
+		incrErrorPolicy:   r.GetIncrErrorPolicy(syncSourceTables),
+		minSourceTableSCN: minSourceTableSCN,
+	}, nil
+}
+
+// 按表级别筛选数据、应用以及更新 checkpoint
+func (s *incrLogSchema) syncLogRecord(rowsResult []public.Logminer, log *incrRedoLog) error {
+	r := s.migrate
+	var (
+		logminerContentMap map[string][]public.Logminer
+		err                error
+	)
+	if len(rowsResult) > 0 {
+		// 判断当前日志文件是否是重做日志文件
+		if log.isRedo {
+			// 判断是否是当前重做日志文件
+			// 如果当前日志文件是当前重做日志文件则 FilterOracleIncrRecord 只运行一次大于或等于对应表数据记录，也就是只重放一次已消费得SCN
+			resetFlag := 0
+			if log.isCurrentRedo {
+				resetFlag = log.currentResetFlag
 			}
 			logminerContentMap, err = public.FilterOracleIncrRecord(
 				rowsResult,
-				syncSourceTables,
-				transferTableMetaMap,
+				s.syncSourceTables,
+				s.transferTableMetaMap,
 				r.Cfg.AllConfig.FilterThreads,
-				0,
+				resetFlag,
 			)
 			if err != nil {
 				return err
 			}
+
 			if len(logminerContentMap) > 0 {
 				// 数据应用
-				if err := applyOracleIncrRecord(r.MetaDB, r.Mysql, r.Cfg, logminerContentMap); err != nil {
-					return err
-				}
-				// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
-				err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(r.Ctx,
-					r.Cfg.DBTypeS,
-					r.Cfg.DBTypeT,
-					r.Cfg.SchemaConfig.SourceSchema,
-					logFileEndSCN,
-					syncSourceTables)
-				if err != nil {
+				if err = applyOracleIncrRecord(r.MetaDB, r.Mysql, r.Cfg, logminerContentMap, s.incrErrorPolicy, s.columnTransform, s.columnNameRule, s.tableRoute); err != nil {
 					return err
 				}
-				continue
+				return s.updateRedoLogSCN(log)
 			}
-			zap.L().Warn("increment table log file logminer data that needn't to be consumed by logfile, transferdb will continue to capture")
-			continue
+			zap.L().Warn("increment table log file logminer data that needn't to be consumed by current redo, transferdb will continue to capture",
+				zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+			return nil
 		}
-
-		// 当前日志文件不存在数据记录
-		if common.IsContainString(redoLogList, log["LOG_FILE"]) {
-			if logFileStartSCN == currentRedoLogFirstChange && log["LOG_FILE"] == currentRedoLogFileName {
-				// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
-				err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(r.Ctx,
-					r.Cfg.DBTypeS,
-					r.Cfg.DBTypeT,
-					r.Cfg.SchemaConfig.SourceSchema,
-					currentRedoLogMaxSCN,
-					logFileStartSCN,
-					logFileEndSCN)
-				if err != nil {
-					return err
-				}
-			} else {
-				// 当前所有日志文件内容应用完毕，判断是否更新 GLOBAL_SCN 至日志文件结束 SCN
-				err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(r.Ctx,
-					r.Cfg.DBTypeS,
-					r.Cfg.DBTypeT,
-					r.Cfg.SchemaConfig.SourceSchema,
-					currentRedoLogMaxSCN,
-					logFileStartSCN,
-					logFileEndSCN,
-					syncSourceTables)
-				if err != nil {
-					return err
-				}
+		logminerContentMap, err = public.FilterOracleIncrRecord(
+			rowsResult,
+			s.syncSourceTables,
+			s.transferTableMetaMap,
+			r.Cfg.AllConfig.FilterThreads,
+			0,
+		)
+		if err != nil {
+			return err
+		}
+		if len(logminerContentMap) > 0 {
+			// 数据应用
+			if err = applyOracleIncrRecord(r.MetaDB, r.Mysql, r.Cfg, logminerContentMap, s.incrErrorPolicy, s.columnTransform, s.columnNameRule, s.tableRoute); err != nil {
+				return err
 			}
-		} else {
 			// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
-			err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(r.Ctx,
+			return meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(r.Ctx,
 				r.Cfg.DBTypeS,
 				r.Cfg.DBTypeT,
 				r.Cfg.SchemaConfig.SourceSchema,
-				logFileEndSCN,
-				syncSourceTables)
-			if err != nil {
-				return err
-			}
+				log.logFileEndSCN,
+				s.syncSourceTables)
+		}
+		zap.L().Warn("increment table log file logminer data that needn't to be consumed by logfile, transferdb will continue to capture",
+			zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
+		return nil
+	}
+
+	// 当前日志文件不存在数据记录
+	if log.isRedo {
+		if err = s.updateRedoLogSCN(log); err != nil {
+			return err
+		}
+	} else {
+		// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
+		err = meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByArchivedLog(r.Ctx,
+			r.Cfg.DBTypeS,
+			r.Cfg.DBTypeT,
+			r.Cfg.SchemaConfig.SourceSchema,
+			log.logFileEndSCN,
+			s.syncSourceTables)
+		if err != nil {
+			return err
 		}
-		zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture")
-		continue
 	}
+	zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema))
 	return nil
 }
 
-func (r *Migrate) getTableIncrRecordLogfile() ([]map[string]string, error) {
+func (s *incrLogSchema) updateRedoLogSCN(log *incrRedoLog) error {
+	r := s.migrate
+	if log.isCurrentRedo {
+		// 当前所有日志文件内容应用完毕，判断是否直接更新 GLOBAL_SCN 至当前重做日志文件起始 SCN
+		return meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByCurrentRedo(r.Ctx,
+			r.Cfg.DBTypeS,
+			r.Cfg.DBTypeT,
+			r.Cfg.SchemaConfig.SourceSchema,
+			log.currentRedoLogMaxSCN,
+			log.logFileStartSCN,
+			log.logFileEndSCN)
+	}
+	// 当前所有日志文件内容应用完毕，直接更新 GLOBAL_SCN 至日志文件结束 SCN
+	return meta.NewCommonModel(r.MetaDB).UpdateIncrSyncMetaSCNByNonCurrentRedo(r.Ctx,
+		r.Cfg.DBTypeS,
+		r.Cfg.DBTypeT,
+		r.Cfg.SchemaConfig.SourceSchema,
+		log.currentRedoLogMaxSCN,
+		log.logFileStartSCN,
+		log.logFileEndSCN,
+		s.syncSourceTables)
+}
+
+// 获取增量所需日志文件，globalSCN 为所有 schema 增量表起始最小 SCN 号
+func (r *Migrate) getTableIncrRecordLogfile(globalSCN uint64) ([]map[string]string, error) {
 	var logFiles []map[string]string
 
-	// 获取增量表起始最小 SCN 号
-	globalSCN, err := meta.NewIncrSyncMetaModel(r.MetaDB).GetIncrSyncMetaMinGlobalScnSBySchema(r.Ctx, &meta.IncrSyncMeta{
-		DBTypeS:     r.Cfg.DBTypeS,
-		DBTypeT:     r.Cfg.DBTypeT,
-		SchemaNameS: r.Cfg.SchemaConfig.SourceSchema,
-	})
-	if err != nil {
-		return logFiles, err
-	}
 	strGlobalSCN := strconv.FormatUint(globalSCN, 10)
 
 	// 判断数据是在 archived log Or redo log
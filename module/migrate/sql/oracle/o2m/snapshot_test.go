@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// MySQL 目标端暂停增量应用等待 compare 释放快照期间，任务取消立即退出
+func TestRespondIncrSnapshotCanceled(t *testing.T) {
+	metaDB, err := meta.NewSQLiteMetaDBEngine(filepath.Join(t.TempDir(), "meta.db"), "", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := meta.IncrSnapshotMeta{
+		DBTypeS:        common.DatabaseTypeOracle,
+		DBTypeT:        common.DatabaseTypeMySQL,
+		SchemaNameS:    "MARVIN",
+		TableNameS:     "T1",
+		SnapshotStatus: common.CompareSnapshotRequested,
+		PauseApply:     "Y",
+		PauseTimeout:   600,
+	}
+	if err = meta.NewIncrSnapshotMetaModel(metaDB).CreateIncrSnapshotMeta(context.Background(), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Migrate{
+		Ctx:    ctx,
+		Cfg:    &config.Config{DBTypeS: common.DatabaseTypeOracle, DBTypeT: common.DatabaseTypeMySQL},
+		MetaDB: metaDB,
+	}
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	startTime := time.Now()
+	err = r.respondIncrSnapshot([]meta.IncrSnapshotMeta{snapshot}, nil, &incrRedoLog{logFileEndSCN: 1001}, 900)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("respondIncrSnapshot() error = %v, want context canceled", err)
+	}
+	if cost := time.Since(startTime); cost > 5*time.Second {
+		t.Errorf("respondIncrSnapshot() returned after %s, want return on cancel", cost)
+	}
+
+	s, err := meta.NewIncrSnapshotMetaModel(metaDB).GetIncrSnapshotMeta(context.Background(), &snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SnapshotStatus != common.CompareSnapshotReady || s.ScnS != 1000 {
+		t.Errorf("snapshot status = %s scn = %d, want %s 1000", s.SnapshotStatus, s.ScnS, common.CompareSnapshotReady)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
 	"go.uber.org/zap"
 	"math"
@@ -146,7 +147,7 @@ func GenMySQLPrepareBindVarStmt(columns, bindVarBatch int) string {
 
 // Oracle SQL 转换
 // ORACLE 数据库同步需要开附加日志且表需要捕获字段列日志，Logminer 内容 UPDATE/DELETE/INSERT 语句会带所有字段信息
-func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, sourceTable string, metaDB *meta.Meta, mysql *mysql.MySQL, logminers []public.Logminer, taskQueue chan IncrTask) error {
+func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, sourceTable, errorPolicy string, metaDB *meta.Meta, mysql *mysql.MySQL, logminers []public.Logminer, columnTransform map[string]*common.ColumnTransform, columnNameRule *common.TableColumnNameRule, tableRoute *common.TableRoute, taskQueue chan *IncrTask) error {
 
 	startTime := time.Now()
 	zap.L().Info("oracle table increment log apply start",
@@ -155,15 +156,44 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 		zap.Time("start time", startTime))
 
 	for _, rows := range logminers {
+		// 任务暂停或者取消，停止转换
+		if err := mysql.Ctx.Err(); err != nil {
+			return err
+		}
+		metrics.IncrEventsTotal.WithLabelValues(rows.SourceSchema, rows.SourceTable, rows.Operation, metrics.StageCaptured).Inc()
+
 		// 如果 sqlRedo 存在记录则继续处理，不存在记录则报错
 		if rows.SQLRedo == "" {
-			return fmt.Errorf("does not meet expectations [oracle sql redo is be null], please check")
+			if err := parkOracleIncrRecord(dbTypeS, dbTypeT, taskMode, errorPolicy, metaDB, mysql, rows,
+				fmt.Errorf("does not meet expectations [oracle sql redo is be null], please check")); err != nil {
+				return err
+			}
+			continue
 		}
 
 		if rows.Operation == common.MigrateOperationDDL {
 			zap.L().Info("translator oracle payload", zap.String("ORACLE DDL", rows.SQLRedo))
 		}
 
+		// 带时区时间类型 TO_TIMESTAMP_TZ 转换为目标端时区时间字符串
+		sqlRedo, err := common.ReplaceOracleTimestampTZ(rows.SQLRedo, mysql.TimeZone)
+		if err != nil {
+			if err = parkOracleIncrRecord(dbTypeS, dbTypeT, taskMode, errorPolicy, metaDB, mysql, rows, err); err != nil {
+				return err
+			}
+			continue
+		}
+		sqlUndo, err := common.ReplaceOracleTimestampTZ(rows.SQLUndo, mysql.TimeZone)
+		if err != nil {
+			if err = parkOracleIncrRecord(dbTypeS, dbTypeT, taskMode, errorPolicy, metaDB, mysql, rows, err); err != nil {
+				return err
+			}
+			continue
+		}
+		// 暂存事件记录源端原始 redo/undo SQL，便于人工处理
+		originRows := rows
+		rows.SQLRedo, rows.SQLUndo = sqlRedo, sqlUndo
+
 		// 移除引号
 		rows.SQLRedo = common.ReplaceQuotesString(rows.SQLRedo)
 		// 移除分号
@@ -182,13 +212,16 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 		// 比如：UPDATE MARVIN.MARVIN1 SET ID = 2 , NAME = 'marvin' WHERE ID = 2 AND NAME = 'pty'
 		// 比如: drop table marvin.marvin7
 		// 比如: truncate table marvin.marvin7
-		mysqlRedo, operationType, err := translateOracleToMySQLSQL(rows.SQLRedo, rows.SQLUndo, common.StringUPPER(rows.TargetSchema), common.StringUPPER(rows.TargetTable))
+		mysqlRedo, operationType, err := translateOracleToMySQLSQL(rows.SQLRedo, rows.SQLUndo, common.StringUPPER(rows.TargetSchema), common.StringUPPER(rows.TargetTable), columnTransform, columnNameRule, tableRoute)
 		if err != nil {
-			return err
+			if err = parkOracleIncrRecord(dbTypeS, dbTypeT, taskMode, errorPolicy, metaDB, mysql, originRows, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// 注册任务到 Job 队列
-		lp := IncrTask{
+		lp := &IncrTask{
 			Ctx:            mysql.Ctx,
 			DBTypeS:        dbTypeS,
 			DBTypeT:        dbTypeT,
@@ -204,7 +237,8 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 			OracleRedo:     rows.SQLRedo,
 			MySQLRedo:      mysqlRedo,
 			Operation:      rows.Operation,
-			OperationType:  operationType}
+			OperationType:  operationType,
+			ErrorPolicy:    errorPolicy}
 
 		// 避免太多日志输出
 		// zlog.zap.L().Info("translator oracle payload", zap.String("payload", lp.Marshal()))
@@ -220,16 +254,60 @@ func translateAndAddOracleIncrRecord(dbTypeS, dbTypeT, taskMode, sourceSchema, s
 		zap.Time("end time", endTime),
 		zap.String("cost time", time.Since(startTime).String()))
 
-	// 任务结束，关闭通道
-	close(taskQueue)
-
 	return nil
 }
 
+// 转换失败事件按 error-policy 处理
+// 转换失败事件无目标端 SQL，park 记录源端原始 redo/undo SQL 并标记不可重放，replay 报告后需人工处理再 discard，表 checkpoint 随后续事件以及日志文件推进
+func parkOracleIncrRecord(dbTypeS, dbTypeT, taskMode, errorPolicy string, metaDB *meta.Meta, mysql *mysql.MySQL, rows public.Logminer, translateErr error) error {
+	if err := mysql.Ctx.Err(); err != nil {
+		return err
+	}
+	switch errorPolicy {
+	case common.MigrateIncrErrorPolicySkip:
+		zap.L().Warn("skip oracle increment record translate failed",
+			zap.String("oracle schema", rows.SourceSchema),
+			zap.String("oracle table", rows.SourceTable),
+			zap.Uint64("scn", rows.SCN),
+			zap.String("sql redo", rows.SQLRedo),
+			zap.Error(translateErr))
+		metrics.IncrEventsTotal.WithLabelValues(rows.SourceSchema, rows.SourceTable, rows.Operation, metrics.StageSkipped).Inc()
+		return nil
+	case common.MigrateIncrErrorPolicyPark:
+		zap.L().Warn("park oracle increment record translate failed",
+			zap.String("oracle schema", rows.SourceSchema),
+			zap.String("oracle table", rows.SourceTable),
+			zap.Uint64("scn", rows.SCN),
+			zap.String("sql redo", rows.SQLRedo),
+			zap.Error(translateErr))
+		metrics.IncrEventsTotal.WithLabelValues(rows.SourceSchema, rows.SourceTable, rows.Operation, metrics.StageParked).Inc()
+		return meta.NewIncrParkDetailModel(metaDB).CreateIncrParkDetail(mysql.Ctx, &meta.IncrParkDetail{
+			DBTypeS:     dbTypeS,
+			DBTypeT:     dbTypeT,
+			SchemaNameS: rows.SourceSchema,
+			TableNameS:  rows.SourceTable,
+			SchemaNameT: rows.TargetSchema,
+			TableNameT:  rows.TargetTable,
+			TaskMode:    taskMode,
+			ScnS:        rows.SCN,
+			Operation:   rows.Operation,
+			SQLRedoS:    rows.SQLRedo,
+			SQLUndoS:    rows.SQLUndo,
+			Replayable:  "NO",
+			ErrorDetail: translateErr.Error(),
+		})
+	default:
+		return translateErr
+	}
+}
+
 // Oracle SQL 转换
 // 1、INSERT INTO / REPLACE INTO
 // 2、UPDATE / DELETE、REPLACE INTO
-func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targetTable string) ([]string, string, error) {
+// 3、字段数据转换规则
+// 4、字段名映射规则，字段数据转换规则以源端字段名为准，需先于字段名映射
+// 5、表路由规则，merge 写入合并目标端表并以区分字段限定范围，hash/range 按路由字段值写入分片表
+func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targetTable string, columnTransform map[string]*common.ColumnTransform, columnNameRule *common.TableColumnNameRule, tableRoute *common.TableRoute) ([]string, string, error) {
 	var (
 		sqls          []string
 		operationType string
@@ -238,6 +316,12 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 	if err != nil {
 		return []string{}, operationType, fmt.Errorf("parse error: %v\n", err.Error())
 	}
+	if err = public.TransformStmt(astNode, columnTransform); err != nil {
+		return []string{}, operationType, fmt.Errorf("transform error: %v", err)
+	}
+	if err = public.RenameStmt(astNode, columnNameRule); err != nil {
+		return []string{}, operationType, fmt.Errorf("rename error: %v", err)
+	}
 
 	stmt := public.ExtractStmt(astNode)
 
@@ -245,6 +329,21 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 	stmt.Schema = targetSchema
 	stmt.Table = targetTable
 
+	// 表路由规则
+	if tableRoute.IsMerge() {
+		stmt.Schema = tableRoute.SchemaNameT
+		stmt.Table = tableRoute.TableNameT
+		if stmt.WhereExpr == "" {
+			stmt.WhereExpr = common.StringsBuilder(`WHERE `, tableRoute.DiscriminatorWhereT())
+		} else {
+			stmt.WhereExpr = common.StringsBuilder(`WHERE (`, strings.TrimPrefix(stmt.WhereExpr, `WHERE `), `) AND `, tableRoute.DiscriminatorWhereT())
+		}
+	}
+	routeTables, err := routeIncrTable(stmt.Schema, stmt.Table, columnNameRule, tableRoute)
+	if err != nil {
+		return []string{}, operationType, err
+	}
+
 	switch {
 	case stmt.Operation == common.MigrateOperationUpdate:
 		operationType = common.MigrateOperationUpdate
@@ -252,6 +351,12 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 		if err != nil {
 			return []string{}, operationType, fmt.Errorf("parse error: %v\n", err.Error())
 		}
+		if err = public.TransformStmt(astUndoNode, columnTransform); err != nil {
+			return []string{}, operationType, fmt.Errorf("transform error: %v", err)
+		}
+		if err = public.RenameStmt(astUndoNode, columnNameRule); err != nil {
+			return []string{}, operationType, fmt.Errorf("rename error: %v", err)
+		}
 		undoStmt := public.ExtractStmt(astUndoNode)
 
 		stmt.Data = undoStmt.Before
@@ -259,76 +364,133 @@ func translateOracleToMySQLSQL(oracleSQLRedo, oracleSQLUndo, targetSchema, targe
 			stmt.Columns = append(stmt.Columns, strings.ToUpper(column))
 		}
 
-		var deleteSQL string
-
-		if stmt.WhereExpr == "" {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table)
-		} else {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table, ` `, stmt.WhereExpr)
+		// 修改前数据所在表删除，修改后数据所在表写入
+		deleteTables, err := routeTables(stmt.Before)
+		if err != nil {
+			return []string{}, operationType, err
+		}
+		replaceTables, err := routeTables(stmt.Data)
+		if err != nil {
+			return []string{}, operationType, err
 		}
 
-		var (
-			values []string
-		)
-		for _, col := range stmt.Columns {
-			values = append(values, stmt.Data[col].(string))
+		for _, table := range deleteTables {
+			if stmt.WhereExpr == "" {
+				sqls = append(sqls, common.StringsBuilder(`DELETE FROM `, table))
+			} else {
+				sqls = append(sqls, common.StringsBuilder(`DELETE FROM `, table, ` `, stmt.WhereExpr))
+			}
+		}
+		for _, table := range replaceTables {
+			sqls = append(sqls, genMySQLReplaceSQL(table, stmt.Columns, stmt.Data, tableRoute))
 		}
-		insertSQL := common.StringsBuilder(`REPLACE INTO `, stmt.Schema, ".", stmt.Table,
-			"(",
-			strings.Join(stmt.Columns, ","),
-			")",
-			` VALUES `,
-			"(",
-			strings.Join(values, ","),
-			")")
-
-		sqls = append(sqls, deleteSQL)
-		sqls = append(sqls, insertSQL)
 
 	case stmt.Operation == common.MigrateOperationInsert:
 		operationType = common.MigrateOperationInsert
 
-		var values []string
-
-		for _, col := range stmt.Columns {
-			values = append(values, stmt.Data[col].(string))
+		replaceTables, err := routeTables(stmt.Data)
+		if err != nil {
+			return []string{}, operationType, err
+		}
+		for _, table := range replaceTables {
+			sqls = append(sqls, genMySQLReplaceSQL(table, stmt.Columns, stmt.Data, tableRoute))
 		}
-		replaceSQL := common.StringsBuilder(`REPLACE INTO `, stmt.Schema, ".", stmt.Table,
-			"(",
-			strings.Join(stmt.Columns, ","),
-			")",
-			` VALUES `,
-			"(",
-			strings.Join(values, ","),
-			")")
-
-		sqls = append(sqls, replaceSQL)
 
 	case stmt.Operation == common.MigrateOperationDelete:
 		operationType = common.MigrateOperationDelete
 
-		var deleteSQL string
-
-		if stmt.WhereExpr == "" {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table)
-		} else {
-			deleteSQL = common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table, ` `, stmt.WhereExpr)
+		deleteTables, err := routeTables(stmt.Before)
+		if err != nil {
+			return []string{}, operationType, err
+		}
+		for _, table := range deleteTables {
+			if stmt.WhereExpr == "" {
+				sqls = append(sqls, common.StringsBuilder(`DELETE FROM `, table))
+			} else {
+				sqls = append(sqls, common.StringsBuilder(`DELETE FROM `, table, ` `, stmt.WhereExpr))
+			}
 		}
-
-		sqls = append(sqls, deleteSQL)
 
 	case stmt.Operation == common.MigrateOperationTruncate:
 		operationType = common.MigrateOperationTruncateTable
 
-		truncateSQL := common.StringsBuilder(`TRUNCATE TABLE `, stmt.Schema, ".", stmt.Table)
-		sqls = append(sqls, truncateSQL)
+		// 表路由 merge 规则只清理当前源端表数据
+		if tableRoute.IsMerge() {
+			sqls = append(sqls, common.StringsBuilder(`DELETE FROM `, stmt.Schema, ".", stmt.Table, ` `, stmt.WhereExpr))
+			break
+		}
+		truncateTables, err := routeTables(nil)
+		if err != nil {
+			return []string{}, operationType, err
+		}
+		for _, table := range truncateTables {
+			sqls = append(sqls, common.StringsBuilder(`TRUNCATE TABLE `, table))
+		}
 
 	case stmt.Operation == common.MigrateOperationDrop:
 		operationType = common.MigrateOperationDropTable
 
+		if tableRoute.IsMerge() || tableRoute.IsSplit() {
+			return []string{}, operationType, fmt.Errorf("route type [%s] table isn't support drop table, please manual process", tableRoute.RouteType)
+		}
 		dropSQL := common.StringsBuilder(`DROP TABLE `, stmt.Schema, ".", stmt.Table)
 
 		sqls = append(sqls, dropSQL)
 	}
 	return sqls, operationType, nil
 }
+
+// 表路由规则目标端表
+// 非 hash/range 规则返回当前目标端表
+// hash/range 规则按路由字段值返回所属分片表，字段值不存在返回全部分片表，当前任务未配置对应分片则不写入
+func routeIncrTable(targetSchema, targetTable string, columnNameRule *common.TableColumnNameRule, tableRoute *common.TableRoute) (func(data map[string]interface{}) ([]string, error), error) {
+	if !tableRoute.IsSplit() {
+		return func(data map[string]interface{}) ([]string, error) {
+			return []string{common.StringsBuilder(targetSchema, ".", targetTable)}, nil
+		}, nil
+	}
+	columnNameT, ok := columnNameRule.TargetColumnName(tableRoute.RouteColumn)
+	if !ok {
+		return nil, fmt.Errorf("route column [%s] is excluded by column name rule", tableRoute.RouteColumn)
+	}
+	routeColumn := common.StringsBuilder("`", strings.ToUpper(columnNameT), "`")
+
+	return func(data map[string]interface{}) ([]string, error) {
+		var tables []string
+		val, exist := data[routeColumn]
+		if !exist {
+			for _, s := range tableRoute.Shards {
+				tables = append(tables, common.StringsBuilder(s.SchemaNameT, ".", s.TableNameT))
+			}
+			return tables, nil
+		}
+		shard, ok, err := tableRoute.ShardByValue(val.(string))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			tables = append(tables, common.StringsBuilder(shard.SchemaNameT, ".", shard.TableNameT))
+		}
+		return tables, nil
+	}, nil
+}
+
+// 表路由 merge 规则追加区分字段
+func genMySQLReplaceSQL(table string, columns []string, data map[string]interface{}, tableRoute *common.TableRoute) string {
+	var values []string
+	for _, col := range columns {
+		values = append(values, data[col].(string))
+	}
+	if tableRoute.IsMerge() {
+		columns = append(append([]string{}, columns...), common.StringsBuilder("`", tableRoute.DiscriminatorColumn, "`"))
+		values = append(values, tableRoute.DiscriminatorValueT())
+	}
+	return common.StringsBuilder(`REPLACE INTO `, table,
+		"(",
+		strings.Join(columns, ","),
+		")",
+		` VALUES `,
+		"(",
+		strings.Join(values, ","),
+		")")
+}
@@ -19,15 +19,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/metrics"
 	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
-	"sync"
+	"time"
 )
 
 type IncrTask struct {
@@ -45,17 +47,14 @@ type IncrTask struct {
 	OracleRedo     string          `json:"oracle_redo"` // Oracle SQL
 	MySQLRedo      []string        `json:"mysql_redo"`  // MySQL 待执行 SQL
 	OperationType  string          `json:"operation_type"`
+	ErrorPolicy    string          `json:"error_policy"`
 	MySQL          *mysql.MySQL    `json:"-"`
 	MetaDB         *meta.Meta      `json:"-"`
 }
 
-type IncrResult struct {
-	Task IncrTask
-	Err  error
-}
-
 // 应用当前日志文件中所有记录
-func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.Config, logminerMap map[string][]public.Logminer) error {
+func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.Config, logminerMap map[string][]public.Logminer, errorPolicy map[string]string,
+	columnTransform map[string]map[string]*common.ColumnTransform, columnNameRule map[string]*common.TableColumnNameRule, tableRoute map[string]*common.TableRoute) error {
 	g := &errgroup.Group{}
 	g.SetLimit(cfg.AllConfig.ApplyThreads)
 
@@ -65,41 +64,46 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 		g.Go(func() error {
 			if len(rowsResult) > 0 {
 				var (
-					done        = make(chan bool)
-					taskQueue   = make(chan IncrTask, cfg.AllConfig.WorkerQueue)
-					resultQueue = make(chan IncrResult, cfg.AllConfig.WorkerQueue)
+					done         = make(chan error)
+					taskQueue    = make(chan *IncrTask, cfg.AllConfig.WorkerQueue)
+					resultQueue  = make(chan public.IncrResult, cfg.AllConfig.WorkerQueue)
+					translateErr error
 				)
 				// 获取增量执行结果
-				go getIncrResult(done, resultQueue)
+				go public.GetIncrResult(done, resultQueue)
 
 				// 转换捕获内容以及数据应用
-				go func(mysql *mysql.MySQL, sourceSchema, sourceTable string, rowsResult []public.Logminer, taskQueue chan IncrTask) {
+				go func(mysql *mysql.MySQL, sourceSchema, sourceTable string, rowsResult []public.Logminer, taskQueue chan *IncrTask) {
+					// 任务结束，关闭通道
+					defer close(taskQueue)
 					defer func() {
 						if err := recover(); err != nil {
-							zap.L().Fatal("translatorAndApplyOracleIncrementRecord",
-								zap.String("oracle schema", cfg.SchemaConfig.SourceSchema),
-								zap.String("oracle table", sourceTable),
-								zap.Error(fmt.Errorf("%v", err)))
+							translateErr = fmt.Errorf("translatorAndApplyOracleIncrementRecord panic: %v", err)
 						}
 					}()
-					if err := translateAndAddOracleIncrRecord(
+					translateErr = translateAndAddOracleIncrRecord(
 						cfg.DBTypeS,
 						cfg.DBTypeT,
 						cfg.TaskMode,
 						sourceSchema,
 						sourceTable,
+						errorPolicy[common.StringUPPER(sourceTable)],
 						metaDB,
 						mysql,
-						rowsResult, taskQueue); err != nil {
-						return
-					}
+						rowsResult, columnTransform[common.StringUPPER(sourceTable)], columnNameRule[common.StringUPPER(sourceTable)], tableRoute[common.StringUPPER(sourceTable)], taskQueue)
 				}(mysqlDB, cfg.SchemaConfig.SourceSchema, sourceTable, rowsResult, taskQueue)
 
 				// 必须在任务分配和获取结果后创建工作池
-				go createWorkerPool(cfg.AllConfig.WorkerThreads, taskQueue, resultQueue)
+				go public.CreateIncrWorkerPool(cfg.AllConfig.WorkerThreads, taskQueue, resultQueue)
 				// 等待执行完成
-				<-done
+				applyErr := <-done
 
+				if translateErr != nil {
+					return fmt.Errorf("oracle schema [%s] table [%s] increment record translate failed: %w", cfg.SchemaConfig.SourceSchema, sourceTable, translateErr)
+				}
+				if applyErr != nil {
+					return fmt.Errorf("oracle schema [%s] table [%s] increment record apply failed: %w", cfg.SchemaConfig.SourceSchema, sourceTable, applyErr)
+				}
 				return nil
 			}
 			zap.L().Warn("increment table log file logminer null data, transferdb will continue to capture",
@@ -110,7 +114,13 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 		})
 	}
 	if err := g.Wait(); err != nil {
-		return fmt.Errorf("logminerContentMap concurrency meet error: %v", err)
+		// 任务暂停或者取消，正常退出
+		if errors.Is(err, context.Canceled) {
+			zap.L().Warn("increment table record apply canceled",
+				zap.String("oracle schema", cfg.SchemaConfig.SourceSchema))
+			return err
+		}
+		return fmt.Errorf("logminerContentMap concurrency meet error: %w", err)
 	}
 
 	return nil
@@ -120,28 +130,31 @@ func applyOracleIncrRecord(metaDB *meta.Meta, mysqlDB *mysql.MySQL, cfg *config.
 func (p *IncrTask) IncrApply() error {
 	// 数据写入并更新元数据表
 	//zap.L().Info("increment applier sql", zap.String("sql", sql))
-	if p.OperationType == common.MigrateOperationUpdate {
-		// update 语句拆分 delete/replace 放一个事务内
-		txn, err := p.MySQL.MySQLDB.BeginTx(p.Ctx, &sql.TxOptions{})
-		if err != nil {
-			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql redo [%v] transaction start falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-		}
-		for _, s := range p.MySQLRedo {
-			if _, err = txn.ExecContext(p.Ctx, s); err != nil {
-				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction doing falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-			}
-		}
-		if err = txn.Commit(); err != nil {
-			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction commit falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+	startTime := time.Now()
+	if err := p.applyMySQLRedo(); err != nil {
+		// 任务暂停或者取消中断执行，不属于应用失败，不做 skip/park 处理
+		if p.Ctx.Err() != nil {
+			return p.Ctx.Err()
 		}
-	} else {
-		for _, s := range p.MySQLRedo {
-			_, err := p.MySQL.MySQLDB.ExecContext(p.Ctx, s)
-			if err != nil {
-				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] exec falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
-			}
+		switch p.ErrorPolicy {
+		case common.MigrateIncrErrorPolicySkip:
+			zap.L().Warn("skip increment table record failed",
+				zap.String("task", p.String()),
+				zap.Error(err))
+			metrics.IncrEventsTotal.WithLabelValues(p.SourceSchema, p.SourceTable, p.Operation, metrics.StageSkipped).Inc()
+			return p.updateIncrSyncMeta()
+		case common.MigrateIncrErrorPolicyPark:
+			zap.L().Warn("park increment table record failed",
+				zap.String("task", p.String()),
+				zap.Error(err))
+			metrics.IncrEventsTotal.WithLabelValues(p.SourceSchema, p.SourceTable, p.Operation, metrics.StageParked).Inc()
+			return p.parkIncrRecord(err)
+		default:
+			return err
 		}
 	}
+	metrics.IncrApplyDuration.WithLabelValues(p.SourceSchema, p.SourceTable).Observe(time.Since(startTime).Seconds())
+	metrics.IncrEventsTotal.WithLabelValues(p.SourceSchema, p.SourceTable, p.Operation, metrics.StageApplied).Inc()
 	// 数据写入完毕，更新元数据 checkpoint 表
 	// 如果同步中断，数据同步使用会以 global_scn_s 为准，也就是会进行重复消费
 	if p.Operation == common.MigrateOperationDropTable {
@@ -164,70 +177,100 @@ func (p *IncrTask) IncrApply() error {
 			return err
 		}
 	} else {
-		err := meta.NewIncrSyncMetaModel(p.MetaDB).UpdateIncrSyncMeta(p.Ctx, &meta.IncrSyncMeta{
-			DBTypeS:     p.DBTypeS,
-			DBTypeT:     p.DBTypeT,
-			SchemaNameS: p.SourceSchema,
-			TableNameS:  p.SourceTable,
-			GlobalScnS:  p.GlobalSCN,
-			TableScnS:   p.SourceTableSCN,
-		})
+		return p.updateIncrSyncMeta()
+	}
+	return nil
+}
+
+func (p *IncrTask) applyMySQLRedo() error {
+	if p.OperationType == common.MigrateOperationUpdate {
+		// update 语句拆分 delete/replace 放一个事务内
+		txn, err := p.MySQL.MySQLDB.BeginTx(p.Ctx, &sql.TxOptions{})
 		if err != nil {
-			zap.L().Error("update table increment scn record failed",
-				zap.String("task", p.String()),
-				zap.Error(err))
-			return err
+			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql redo [%v] transaction start falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+		}
+		for _, s := range p.MySQLRedo {
+			if _, err = txn.ExecContext(p.Ctx, s); err != nil {
+				_ = txn.Rollback()
+				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction doing falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+			}
+		}
+		if err = txn.Commit(); err != nil {
+			return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] transaction commit falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+		}
+	} else {
+		for _, s := range p.MySQLRedo {
+			_, err := p.MySQL.MySQLDB.ExecContext(p.Ctx, s)
+			if err != nil {
+				return fmt.Errorf("single increment table [%s] data oracle redo [%v] insert mysql [%v] exec falied: %v", p.SourceTable, p.OracleRedo, p.MySQLRedo, err)
+			}
 		}
 	}
 	return nil
 }
 
-// 序列化
-func (p *IncrTask) String() string {
-	b, err := json.Marshal(&p)
+func (p *IncrTask) updateIncrSyncMeta() error {
+	err := meta.NewIncrSyncMetaModel(p.MetaDB).UpdateIncrSyncMeta(p.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     p.DBTypeS,
+		DBTypeT:     p.DBTypeT,
+		SchemaNameS: p.SourceSchema,
+		TableNameS:  p.SourceTable,
+		GlobalScnS:  p.GlobalSCN,
+		TableScnS:   p.SourceTableSCN,
+	})
 	if err != nil {
-		zap.L().Error("marshal task to string",
-			zap.String("string", string(b)),
+		zap.L().Error("update table increment scn record failed",
+			zap.String("task", p.String()),
 			zap.Error(err))
+		return err
 	}
-	return string(b)
+	return nil
 }
 
-func createWorkerPool(numOfWorkers int, jobQueue chan IncrTask, resultQueue chan IncrResult) {
-	var wg sync.WaitGroup
-	for i := 0; i < numOfWorkers; i++ {
-		wg.Add(1)
-		go worker(&wg, jobQueue, resultQueue)
+// 失败事件写入 [incr_park_detail] 并同事务推进表 checkpoint
+func (p *IncrTask) parkIncrRecord(applyErr error) error {
+	mysqlRedo, err := json.Marshal(p.MySQLRedo)
+	if err != nil {
+		return fmt.Errorf("json marshal increment table [%s] mysql redo [%v] failed: %v", p.SourceTable, p.MySQLRedo, err)
 	}
-	wg.Wait()
-	close(resultQueue)
-}
-
-func getIncrResult(done chan bool, resultQueue chan IncrResult) {
-	for result := range resultQueue {
-		if result.Err != nil {
-			zap.L().Fatal("task increment table record",
-				zap.String("payload", result.Task.String()),
-				zap.Error(result.Err))
-		}
+	err = meta.NewCommonModel(p.MetaDB).CreateIncrParkDetailAndUpdateIncrSyncMeta(p.Ctx, &meta.IncrParkDetail{
+		DBTypeS:       p.DBTypeS,
+		DBTypeT:       p.DBTypeT,
+		SchemaNameS:   p.SourceSchema,
+		TableNameS:    p.SourceTable,
+		SchemaNameT:   p.TargetSchema,
+		TableNameT:    p.TargetTable,
+		TaskMode:      p.TaskMode,
+		ScnS:          p.SourceTableSCN,
+		Operation:     p.Operation,
+		OperationType: p.OperationType,
+		SQLRedoS:      p.OracleRedo,
+		SQLRedoT:      string(mysqlRedo),
+		ErrorDetail:   applyErr.Error(),
+	}, &meta.IncrSyncMeta{
+		DBTypeS:     p.DBTypeS,
+		DBTypeT:     p.DBTypeT,
+		SchemaNameS: p.SourceSchema,
+		TableNameS:  p.SourceTable,
+		GlobalScnS:  p.GlobalSCN,
+		TableScnS:   p.SourceTableSCN,
+	})
+	if err != nil {
+		zap.L().Error("park table increment record failed",
+			zap.String("task", p.String()),
+			zap.Error(err))
+		return err
 	}
-	done <- true
+	return nil
 }
 
-func worker(wg *sync.WaitGroup, jobQueue chan IncrTask, resultQueue chan IncrResult) {
-	defer wg.Done()
-	for job := range jobQueue {
-		if err := job.IncrApply(); err != nil {
-			result := IncrResult{
-				Task: job,
-				Err:  err,
-			}
-			resultQueue <- result
-		}
-		result := IncrResult{
-			Task: job,
-			Err:  nil,
-		}
-		resultQueue <- result
+// 序列化
+func (p *IncrTask) String() string {
+	b, err := json.Marshal(&p)
+	if err != nil {
+		zap.L().Error("marshal task to string",
+			zap.String("string", string(b)),
+			zap.Error(err))
 	}
+	return string(b)
 }
@@ -0,0 +1,54 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"testing"
+)
+
+// update 事件拆分 delete/replace 事务应用，任务取消中断事务不按 skip 推进 checkpoint（MetaDB 为空，写入即 panic）
+func TestIncrApplyUpdateCanceled(t *testing.T) {
+	db, err := sql.Open("mysql", "root:@tcp(127.0.0.1:1)/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	task := IncrTask{
+		Ctx:           ctx,
+		SourceSchema:  "MARVIN",
+		SourceTable:   "T1",
+		Operation:     common.MigrateOperationUpdate,
+		OperationType: common.MigrateOperationUpdate,
+		MySQLRedo: []string{
+			"DELETE FROM MARVIN.T1 WHERE ID = 1",
+			"REPLACE INTO MARVIN.T1 (ID) VALUES (2)",
+		},
+		ErrorPolicy: common.MigrateIncrErrorPolicySkip,
+		MySQL:       &mysql.MySQL{Ctx: ctx, MySQLDB: db},
+	}
+	err = task.IncrApply()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("IncrApply() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	reverse "github.com/wentaojin/transferdb/module/reverse/oracle/public"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// Benchmark 采样压测，复用全量迁移读写链路 (ReadData/ProcessData/ApplyData) 按数据块随机采样读取源端表约 sampleRows 行，
+// 写入目标端按字段类型映射规则创建的临时表，完成后删除临时表，返回写入行数、字节数以及耗时
+func (r *Migrate) Benchmark(sourceTable, benchSchema string, numRows int64, sampleRows int, oracleCollation bool) (int64, int64, time.Duration, error) {
+	schemaNameS := common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema)
+	tableNameS := common.StringUPPER(sourceTable)
+	tableNameT := public.BenchmarkTableName(tableNameS)
+
+	// 目标端字段类型以字段类型映射规则为准
+	tableDatatype, err := (&reverse.Change{
+		Ctx:              r.Ctx,
+		DBTypeS:          r.Cfg.DBTypeS,
+		DBTypeT:          r.Cfg.DBTypeT,
+		SourceSchemaName: schemaNameS,
+		TargetSchemaName: benchSchema,
+		SourceTables:     []string{tableNameS},
+		Threads:          1,
+		SourceDBCharset:  common.StringUPPER(r.Cfg.OracleConfig.Charset),
+		TargetDBCharset:  common.StringUPPER(r.Cfg.MySQLConfig.Charset),
+		OracleCollation:  oracleCollation,
+		Oracle:           r.Oracle,
+		MetaDB:           r.MetaDB,
+	}).ChangeTableColumnDatatype()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	columnDetailS, err := r.AdjustTableSelectColumn(tableNameS, oracleCollation, nil)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	columnNameS, err := r.Oracle.GetOracleTableRowsColumn(
+		common.StringsBuilder(`SELECT *`, ` FROM `, schemaNameS, `.`, tableNameS, ` WHERE ROWNUM = 1`),
+		common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
+		common.StringUPPER(r.Cfg.MySQLConfig.Charset))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var columnDefs []string
+	for _, c := range columnNameS {
+		datatype, ok := tableDatatype[tableNameS][strings.Trim(c, "`")]
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("source schema [%s] table [%s] column [%s] datatype mapping isn't exist", schemaNameS, tableNameS, c)
+		}
+		columnDefs = append(columnDefs, common.StringsBuilder(c, " ", datatype))
+	}
+
+	if err = r.Mysql.DropMySQLTable(benchSchema, tableNameT); err != nil {
+		return 0, 0, 0, err
+	}
+	if err = r.Mysql.WriteMySQLTable(common.StringsBuilder(`CREATE TABLE `, benchSchema, `.`, tableNameT, ` (`,
+		strings.Join(columnDefs, ","), `) DEFAULT CHARSET = `, r.Cfg.MySQLConfig.Charset)); err != nil {
+		return 0, 0, 0, fmt.Errorf("target schema [%s] benchmark table [%s] create failed: %v", benchSchema, tableNameT, err)
+	}
+	defer func() {
+		if errD := r.Mysql.DropMySQLTable(benchSchema, tableNameT); errD != nil {
+			zap.L().Warn("target schema benchmark table drop failed",
+				zap.String("schema", benchSchema),
+				zap.String("table", tableNameT),
+				zap.Error(errD))
+		}
+	}()
+
+	stmt, err := r.Mysql.MySQLDB.PrepareContext(r.Ctx, GenMySQLTablePrepareStmt(benchSchema, tableNameT, columnNameS, r.Cfg.AppConfig.InsertBatchSize, false))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer stmt.Close()
+
+	syncMeta := meta.FullSyncMeta{
+		DBTypeS:        r.Cfg.DBTypeS,
+		DBTypeT:        r.Cfg.DBTypeT,
+		SchemaNameS:    schemaNameS,
+		TableNameS:     tableNameS,
+		SchemaNameT:    benchSchema,
+		TableNameT:     tableNameT,
+		ConsistentRead: "NO",
+		SQLHint:        r.Cfg.FullConfig.SQLHint,
+		ColumnDetailS:  columnDetailS,
+		ChunkDetailS:   public.BenchmarkSampleWhere(schemaNameS, tableNameS, numRows, sampleRows),
+		TaskMode:       common.TaskModeAssess,
+	}
+
+	rows := NewRows(r.Ctx, syncMeta, r.Oracle, r.Mysql, stmt,
+		common.MigrateOracleCharsetStringConvertMapping[common.StringUPPER(r.Cfg.OracleConfig.Charset)],
+		common.StringUPPER(r.Cfg.MySQLConfig.Charset), r.Cfg.FullConfig.ApplyThreads, r.Cfg.AppConfig.InsertBatchSize, r.Cfg.FullConfig.CallTimeout, false, columnNameS, nil)
+
+	startTime := time.Now()
+	if err = public.IMigrate(rows); err != nil {
+		return 0, 0, 0, fmt.Errorf("source schema [%s] table [%s] benchmark failed: %v", schemaNameS, tableNameS, err)
+	}
+	cost := time.Now().Sub(startTime)
+
+	zap.L().Info("source schema table benchmark finished",
+		zap.String("schema", schemaNameS),
+		zap.String("table", tableNameS),
+		zap.String("benchmark table", common.StringsBuilder(benchSchema, ".", tableNameT)),
+		zap.String("sample", syncMeta.ChunkDetailS),
+		zap.Int64("rows", rows.AppliedRows),
+		zap.Int64("bytes", rows.AppliedBytes),
+		zap.String("cost", cost.String()))
+	return rows.AppliedRows, rows.AppliedBytes, cost, nil
+}
@@ -0,0 +1,273 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// 全量同步前暂缓创建目标端二级索引、唯一索引以及外键（full defer-index = true），目标端表仅保留主键
+// 索引外键定义记录于元数据表 [wait_sync_meta]，已暂缓表以记录定义为准，先删除全部表外键再删除索引，避免外键依赖索引删除失败
+func (r *Migrate) deferTableIndex(syncTables []string, tableNameRule map[string]string) error {
+	startTime := time.Now()
+
+	// 获取表路由规则
+	tableRoute, err := meta.NewTableRouteRuleModel(r.MetaDB).GetSchemaTableRoute(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
+	var foreignKeys, indexes []common.MigrateDeferIndex
+	for _, t := range syncTables {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.SchemaConfig.SourceSchema),
+			TableNameS:  common.StringUPPER(t),
+			TaskMode:    r.Cfg.TaskMode,
+		})
+		if err != nil {
+			return err
+		}
+		if len(waitSyncMetas) == 0 {
+			continue
+		}
+
+		var deferIndexes []common.MigrateDeferIndex
+		if waitSyncMetas[0].DeferIndexStatus == "" || waitSyncMetas[0].DeferIndexStatus == common.MigrateDeferIndexStatusBuilt {
+			for _, targetTable := range r.deferTargetTables(t, tableNameRule, tableRoute[common.StringUPPER(t)]) {
+				targetIndexes, err := r.Mysql.GetMySQLTableDeferIndex(targetTable[0], targetTable[1])
+				if err != nil {
+					return err
+				}
+				deferIndexes = append(deferIndexes, targetIndexes...)
+			}
+			if len(deferIndexes) == 0 {
+				continue
+			}
+		} else {
+			if err = json.Unmarshal([]byte(waitSyncMetas[0].DeferIndexDDL), &deferIndexes); err != nil {
+				return fmt.Errorf("table [%s] meta table [wait_sync_meta] defer_index_ddl unmarshal failed: %v", t, err)
+			}
+		}
+
+		deferIndexDDL, err := json.Marshal(deferIndexes)
+		if err != nil {
+			return err
+		}
+		if err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &waitSyncMetas[0], map[string]interface{}{
+			"DeferIndexDDL":    string(deferIndexDDL),
+			"DeferIndexStatus": common.MigrateDeferIndexStatusDeferred,
+		}); err != nil {
+			return err
+		}
+
+		// 仅删除目标端当前存在的索引外键，支持重复运行
+		existIndexes, err := r.existTableDeferIndex(deferIndexes)
+		if err != nil {
+			return err
+		}
+		for _, d := range deferIndexes {
+			if _, ok := existIndexes[common.StringsBuilder(d.SchemaNameT, ".", d.TableNameT, ".", d.IndexType, ".", d.IndexName)]; !ok {
+				continue
+			}
+			if d.IndexType == common.MigrateDeferIndexTypeForeignKey {
+				foreignKeys = append(foreignKeys, d)
+			} else {
+				indexes = append(indexes, d)
+			}
+		}
+	}
+
+	// 非任务表外键依赖的唯一索引无法删除，忽略保留，创建时已存在跳过
+	for _, d := range append(foreignKeys, indexes...) {
+		if err := r.Mysql.DropMySQLTableDeferIndex(d); err != nil {
+			zap.L().Warn("full table defer index drop failed, skip defer",
+				zap.String("schema", d.SchemaNameT),
+				zap.String("table", d.TableNameT),
+				zap.String("index", d.IndexName),
+				zap.String("index type", d.IndexType),
+				zap.Error(err))
+		}
+	}
+
+	zap.L().Info("full table defer index finished",
+		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+		zap.Int("foreign key totals", len(foreignKeys)),
+		zap.Int("index totals", len(indexes)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// 创建表延迟索引外键，foreignKey 为 false 仅创建索引（表全部 chunk 成功后），true 同时创建外键（任务全部表迁移成功后）
+// 目标端已存在的索引外键跳过，创建失败记录状态 FAILED，重新运行 full 任务重试
+func (r *Migrate) buildTableDeferIndex(waitSyncMeta meta.WaitSyncMeta, foreignKey bool) error {
+	startTime := time.Now()
+
+	var deferIndexes []common.MigrateDeferIndex
+	if err := json.Unmarshal([]byte(waitSyncMeta.DeferIndexDDL), &deferIndexes); err != nil {
+		return fmt.Errorf("table [%s] meta table [wait_sync_meta] defer_index_ddl unmarshal failed: %v", waitSyncMeta.TableNameS, err)
+	}
+
+	buildStatus := common.MigrateDeferIndexStatusIndexed
+	if foreignKey {
+		buildStatus = common.MigrateDeferIndexStatusBuilt
+	}
+	buildErr := func() error {
+		existIndexes, err := r.existTableDeferIndex(deferIndexes)
+		if err != nil {
+			return err
+		}
+		for _, indexType := range []string{common.MigrateDeferIndexTypeIndex, common.MigrateDeferIndexTypeForeignKey} {
+			if indexType == common.MigrateDeferIndexTypeForeignKey && !foreignKey {
+				continue
+			}
+			for _, d := range deferIndexes {
+				if d.IndexType != indexType {
+					continue
+				}
+				if _, ok := existIndexes[common.StringsBuilder(d.SchemaNameT, ".", d.TableNameT, ".", d.IndexType, ".", d.IndexName)]; ok {
+					continue
+				}
+				if err = r.Mysql.AddMySQLTableDeferIndex(d); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}()
+	if buildErr != nil {
+		buildStatus = common.MigrateDeferIndexStatusFailed
+	}
+
+	if err := meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &waitSyncMeta, map[string]interface{}{
+		"DeferIndexStatus": buildStatus,
+	}); err != nil {
+		return err
+	}
+	if buildErr != nil {
+		return fmt.Errorf("table [%s] defer index build failed: %v", waitSyncMeta.TableNameS, buildErr)
+	}
+
+	zap.L().Info("full table defer index build finished",
+		zap.String("schema", waitSyncMeta.SchemaNameS),
+		zap.String("table", waitSyncMeta.TableNameS),
+		zap.Bool("foreign key", foreignKey),
+		zap.String("status", buildStatus),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// 全量任务结束创建未完成的延迟索引外键，存在迁移失败表时外键保持暂缓
+func (r *Migrate) buildDeferIndex(successMetas []meta.WaitSyncMeta, foreignKey bool) error {
+	var failedTables []string
+	for _, w := range successMetas {
+		if w.DeferIndexStatus == "" || w.DeferIndexStatus == common.MigrateDeferIndexStatusBuilt {
+			continue
+		}
+		if w.DeferIndexStatus == common.MigrateDeferIndexStatusIndexed && !foreignKey {
+			continue
+		}
+		if err := r.buildTableDeferIndex(w, foreignKey); err != nil {
+			zap.L().Error("full table defer index build failed",
+				zap.String("schema", w.SchemaNameS),
+				zap.String("table", w.TableNameS),
+				zap.Error(err))
+			failedTables = append(failedTables, w.TableNameS)
+		}
+	}
+	if len(failedTables) > 0 {
+		return fmt.Errorf("full table %v defer index build failed, please check meta table [wait_sync_meta] defer_index_ddl and log, then rerunning", failedTables)
+	}
+	return nil
+}
+
+// 获取延迟索引外键对应目标端表当前存在的索引外键
+func (r *Migrate) existTableDeferIndex(deferIndexes []common.MigrateDeferIndex) (map[string]struct{}, error) {
+	existIndexes := make(map[string]struct{})
+	targetTables := make(map[string]struct{})
+	for _, d := range deferIndexes {
+		targetTable := common.StringsBuilder(d.SchemaNameT, ".", d.TableNameT)
+		if _, ok := targetTables[targetTable]; ok {
+			continue
+		}
+		targetTables[targetTable] = struct{}{}
+		targetIndexes, err := r.Mysql.GetMySQLTableDeferIndex(d.SchemaNameT, d.TableNameT)
+		if err != nil {
+			return existIndexes, err
+		}
+		for _, e := range targetIndexes {
+			existIndexes[common.StringsBuilder(e.SchemaNameT, ".", e.TableNameT, ".", e.IndexType, ".", e.IndexName)] = struct{}{}
+		}
+	}
+	return existIndexes, nil
+}
+
+// 全量同步目标端表，与 full_sync_meta 目标端表保持一致
+func (r *Migrate) deferTargetTables(sourceTable string, tableNameRule map[string]string, tableRoute *common.TableRoute) [][]string {
+	switch {
+	case tableRoute.IsMerge():
+		return [][]string{{tableRoute.SchemaNameT, tableRoute.TableNameT}}
+	case tableRoute.IsSplit():
+		var targetTables [][]string
+		for _, shard := range tableRoute.Shards {
+			targetTables = append(targetTables, []string{shard.SchemaNameT, shard.TableNameT})
+		}
+		return targetTables
+	default:
+		targetTableName := common.StringUPPER(sourceTable)
+		if val, ok := tableNameRule[common.StringUPPER(sourceTable)]; ok {
+			targetTableName = val
+		}
+		return [][]string{{common.StringUPPER(r.Cfg.SchemaConfig.TargetSchema), targetTableName}}
+	}
+}
+
+// 全量任务期间开启 TiDB 快速 DDL 索引回填（full tidb-fast-reorg = true），返回恢复原值函数
+// 获取或者开启失败（例如 TiDB 版本低于 v6.3.0、无 SUPER 权限）日志告警，以常规模式创建索引
+func (r *Migrate) enableTiDBDDLFastReorg() func() {
+	if !r.Cfg.FullConfig.TiDBFastReorg {
+		return func() {}
+	}
+	originValue, err := r.Mysql.GetTiDBDDLFastReorg()
+	if err != nil {
+		zap.L().Warn("tidb ddl fast reorg enable failed, create index by normal mode", zap.Error(err))
+		return func() {}
+	}
+	if strings.EqualFold(originValue, "ON") || originValue == "1" {
+		return func() {}
+	}
+	if err = r.Mysql.SetTiDBDDLFastReorg("ON"); err != nil {
+		zap.L().Warn("tidb ddl fast reorg enable failed, create index by normal mode", zap.Error(err))
+		return func() {}
+	}
+	zap.L().Info("tidb ddl fast reorg enabled", zap.String("origin value", originValue))
+	return func() {
+		if err := r.Mysql.SetTiDBDDLFastReorg(originValue); err != nil {
+			zap.L().Warn("tidb ddl fast reorg restore failed, please manual restore",
+				zap.String("origin value", originValue),
+				zap.Error(err))
+			return
+		}
+		zap.L().Info("tidb ddl fast reorg restored", zap.String("origin value", originValue))
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2t
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"go.uber.org/zap"
+	"time"
+)
+
+// 获取待响应的一致性校验快照申请（compare consistent = true）
+func (r *Migrate) pendingIncrSnapshot(logSchemas []*incrLogSchema) ([]meta.IncrSnapshotMeta, error) {
+	var snapshotMetas []meta.IncrSnapshotMeta
+	for _, ls := range logSchemas {
+		m := ls.migrate
+		metas, err := meta.NewIncrSnapshotMetaModel(m.MetaDB).DetailIncrSnapshotMetaByStatus(m.Ctx, &meta.IncrSnapshotMeta{
+			DBTypeS:        m.Cfg.DBTypeS,
+			DBTypeT:        m.Cfg.DBTypeT,
+			SchemaNameS:    m.Cfg.SchemaConfig.SourceSchema,
+			SnapshotStatus: common.CompareSnapshotRequested,
+		})
+		if err != nil {
+			return snapshotMetas, err
+		}
+		snapshotMetas = append(snapshotMetas, metas...)
+	}
+	return snapshotMetas, nil
+}
+
+// 日志文件批次边界响应快照申请，此时批次内数据已全部应用
+// 表一致 SCN 取日志文件已挖掘范围上界与表已挖掘事件最大 SCN 较大值，目标端已包含源端该 SCN 及之前全部变更且不包含之后变更
+// 当前重做日志已挖掘范围上界为挖掘前获取的当前 SCN，其余日志文件为日志文件结束 SCN - 1
+func (r *Migrate) respondIncrSnapshot(snapshotMetas []meta.IncrSnapshotMeta, rowsResult []public.Logminer, log *incrRedoLog, minerSCN uint64) error {
+	if len(snapshotMetas) == 0 {
+		return nil
+	}
+	coverSCN := log.logFileEndSCN - 1
+	if log.isRedo && log.isCurrentRedo {
+		coverSCN = minerSCN
+	}
+	tableSCN := make(map[string]uint64)
+	for _, lc := range rowsResult {
+		key := common.StringsBuilder(common.StringUPPER(lc.SourceSchema), ".", common.StringUPPER(lc.SourceTable))
+		if lc.SCN > tableSCN[key] {
+			tableSCN[key] = lc.SCN
+		}
+	}
+
+	// TiDB 目标端记录当前 TSO 用于快照读，晚于批次内全部已应用事务，增量应用无需暂停
+	snapshotT, err := r.Mysql.GetTiDBCurrentTSO()
+	if err != nil {
+		return err
+	}
+
+	var pauseMetas []meta.IncrSnapshotMeta
+	for _, sm := range snapshotMetas {
+		scn := coverSCN
+		if s := tableSCN[common.StringsBuilder(common.StringUPPER(sm.SchemaNameS), ".", common.StringUPPER(sm.TableNameS))]; s > scn {
+			scn = s
+		}
+		ok, err := meta.NewIncrSnapshotMetaModel(r.MetaDB).UpdateIncrSnapshotMetaByStatus(r.Ctx, &sm, common.CompareSnapshotRequested, map[string]interface{}{
+			"SnapshotStatus": common.CompareSnapshotReady,
+			"ScnS":           scn,
+			"SnapshotT":      snapshotT,
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		zap.L().Info("increment table compare snapshot ready",
+			zap.String("schema", sm.SchemaNameS),
+			zap.String("table", sm.TableNameS),
+			zap.Uint64("snapshot scn", scn),
+			zap.String("snapshot tso", snapshotT),
+			zap.String("pause apply", sm.PauseApply))
+		if sm.PauseApply == "Y" {
+			pauseMetas = append(pauseMetas, sm)
+		}
+	}
+
+	// 申请暂停增量应用，直至 compare 表当前 chunk 批次校验完成释放或者超时，任务取消立即退出
+	startTime := time.Now()
+	for len(pauseMetas) > 0 {
+		select {
+		case <-r.Ctx.Done():
+			return r.Ctx.Err()
+		case <-time.After(time.Second):
+		}
+		var waitMetas []meta.IncrSnapshotMeta
+		for _, sm := range pauseMetas {
+			s, err := meta.NewIncrSnapshotMetaModel(r.MetaDB).GetIncrSnapshotMeta(r.Ctx, &sm)
+			if err != nil {
+				return err
+			}
+			if s.SnapshotStatus != common.CompareSnapshotReady {
+				continue
+			}
+			if time.Since(startTime) < time.Duration(sm.PauseTimeout)*time.Second {
+				waitMetas = append(waitMetas, sm)
+				continue
+			}
+			if _, err = meta.NewIncrSnapshotMetaModel(r.MetaDB).UpdateIncrSnapshotMetaByStatus(r.Ctx, &sm, common.CompareSnapshotReady, map[string]interface{}{
+				"SnapshotStatus": common.CompareSnapshotExpired,
+			}); err != nil {
+				return err
+			}
+			zap.L().Warn("increment table compare snapshot pause timeout, increment apply resume",
+				zap.String("schema", sm.SchemaNameS),
+				zap.String("table", sm.TableNameS),
+				zap.Int("pause timeout", sm.PauseTimeout))
+		}
+		pauseMetas = waitMetas
+	}
+	return nil
+}
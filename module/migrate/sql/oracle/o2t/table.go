@@ -23,10 +23,12 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/metrics"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -43,13 +45,17 @@ type Rows struct {
 	BatchSize       int
 	SafeMode        bool
 	ColumnNameS     []string
+	ColumnTransform map[string]*common.ColumnTransform
 	ReadChannel     chan []map[string]interface{}
 	WriteChannel    chan []interface{}
+	// 目标端写入行数以及字节数，ApplyData 完成后有效
+	AppliedRows  int64
+	AppliedBytes int64
 }
 
 func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
 	oracle *oracle.Oracle, mysql *mysql.MySQL, stmt *sql.Stmt, sourceDBCharset string, targetDBCharset string, applyThreads, batchSize, callTimeout int, safeMode bool,
-	columnNameS []string) *Rows {
+	columnNameS []string, columnTransform map[string]*common.ColumnTransform) *Rows {
 
 	readChannel := make(chan []map[string]interface{}, common.ChannelBufferSize)
 	writeChannel := make(chan []interface{}, common.ChannelBufferSize)
@@ -67,6 +73,7 @@ func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
 		BatchSize:       batchSize,
 		CallTimeout:     callTimeout,
 		ColumnNameS:     columnNameS,
+		ColumnTransform: columnTransform,
 		ReadChannel:     readChannel,
 		WriteChannel:    writeChannel,
 	}
@@ -133,6 +140,14 @@ func (t *Rows) ReadData() error {
 }
 
 func (t *Rows) ProcessData() error {
+	// 字段数据转换规则，字段名带反引号
+	transforms := make([]*common.ColumnTransform, len(t.ColumnNameS))
+	for i, column := range t.ColumnNameS {
+		if ct, ok := t.ColumnTransform[common.StringUPPER(strings.Trim(column, "`"))]; ok {
+			transforms[i] = ct
+		}
+	}
+
 	for dataC := range t.ReadChannel {
 		var batchRows []any
 
@@ -141,8 +156,17 @@ func (t *Rows) ProcessData() error {
 			var (
 				rowsTMP []any
 			)
-			for _, column := range t.ColumnNameS {
+			for i, column := range t.ColumnNameS {
 				if val, ok := dMap[column]; ok {
+					if transforms[i] != nil {
+						transVal, err := common.TransformMySQLEscapedValue(transforms[i], fmt.Sprintf("%v", val))
+						if err != nil {
+							// 通道关闭
+							close(t.WriteChannel)
+							return fmt.Errorf("source schema [%s] table [%s] column transform failed: %v", t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, err)
+						}
+						val = transVal
+					}
 					rowsTMP = append(rowsTMP, val)
 				}
 			}
@@ -156,6 +180,9 @@ func (t *Rows) ProcessData() error {
 			}
 		}
 
+		metrics.MigrateRowsTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeRead).Add(float64(len(dataC)))
+		metrics.MigrateBytesTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeRead).Add(float64(metrics.ValueBytes(batchRows...)))
+
 		// 数据输入
 		t.WriteChannel <- batchRows
 	}
@@ -196,6 +223,11 @@ func (t *Rows) ApplyData() error {
 					return fmt.Errorf("target sql execute failed: %v", err)
 				}
 			}
+			rows, bytes := len(vals)/len(t.ColumnNameS), metrics.ValueBytes(vals...)
+			atomic.AddInt64(&t.AppliedRows, int64(rows))
+			atomic.AddInt64(&t.AppliedBytes, int64(bytes))
+			metrics.MigrateRowsTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeWrite).Add(float64(rows))
+			metrics.MigrateBytesTotal.WithLabelValues(t.SyncMeta.TaskMode, t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, metrics.TypeWrite).Add(float64(bytes))
 			return nil
 		})
 	}
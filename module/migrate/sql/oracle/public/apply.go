@@ -0,0 +1,86 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+)
+
+// IncrApplier 增量事件应用任务，目标端应用逻辑由 o2m/o2t 各自实现
+type IncrApplier interface {
+	IncrApply() error
+	String() string
+}
+
+type IncrResult struct {
+	Task IncrApplier
+	Err  error
+}
+
+func CreateIncrWorkerPool[T IncrApplier](numOfWorkers int, jobQueue chan T, resultQueue chan IncrResult) {
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+	for i := 0; i < numOfWorkers; i++ {
+		wg.Add(1)
+		go incrWorker(&wg, &failed, jobQueue, resultQueue)
+	}
+	wg.Wait()
+	close(resultQueue)
+}
+
+// GetIncrResult 返回首个应用错误，任务暂停或者取消返回 context.Canceled
+func GetIncrResult(done chan error, resultQueue chan IncrResult) {
+	var applyErr error
+	for result := range resultQueue {
+		if result.Err != nil && applyErr == nil {
+			applyErr = result.Err
+			if !errors.Is(result.Err, context.Canceled) {
+				zap.L().Error("task increment table record",
+					zap.String("payload", result.Task.String()),
+					zap.Error(result.Err))
+			}
+		}
+	}
+	done <- applyErr
+}
+
+// 表存在应用失败事件，后续事件不再应用，避免表 checkpoint 越过失败事件
+func incrWorker[T IncrApplier](wg *sync.WaitGroup, failed *atomic.Bool, jobQueue chan T, resultQueue chan IncrResult) {
+	defer wg.Done()
+	for job := range jobQueue {
+		if failed.Load() {
+			continue
+		}
+		if err := job.IncrApply(); err != nil {
+			failed.Store(true)
+			resultQueue <- IncrResult{
+				Task: job,
+				Err:  err,
+			}
+			continue
+		}
+		resultQueue <- IncrResult{
+			Task: job,
+			Err:  nil,
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+type fakeIncrTask struct {
+	id      int
+	err     error
+	applied *atomic.Int32
+}
+
+func (f *fakeIncrTask) IncrApply() error {
+	f.applied.Add(1)
+	return f.err
+}
+
+func (f *fakeIncrTask) String() string {
+	return fmt.Sprintf("fake task %d", f.id)
+}
+
+func runIncrWorkerPool(numOfWorkers int, errs []error) (int32, error) {
+	var (
+		applied     atomic.Int32
+		done        = make(chan error)
+		taskQueue   = make(chan *fakeIncrTask, len(errs))
+		resultQueue = make(chan IncrResult, len(errs))
+	)
+	go GetIncrResult(done, resultQueue)
+	for i, err := range errs {
+		taskQueue <- &fakeIncrTask{id: i, err: err, applied: &applied}
+	}
+	close(taskQueue)
+	go CreateIncrWorkerPool(numOfWorkers, taskQueue, resultQueue)
+	err := <-done
+	return applied.Load(), err
+}
+
+func TestIncrWorkerPool(t *testing.T) {
+	applyErr := errors.New("duplicate entry")
+	tests := []struct {
+		name         string
+		numOfWorkers int
+		errs         []error
+		wantErr      error
+		wantApplied  int32
+	}{
+		{name: "applied", numOfWorkers: 2, errs: []error{nil, nil, nil}, wantErr: nil, wantApplied: 3},
+		{name: "canceled", numOfWorkers: 2, errs: []error{context.Canceled, context.Canceled, context.Canceled}, wantErr: context.Canceled},
+		// 单线程首个事件失败，后续事件不再应用
+		{name: "stop after failure", numOfWorkers: 1, errs: []error{applyErr, nil, nil}, wantErr: applyErr, wantApplied: 1},
+		{name: "first error", numOfWorkers: 1, errs: []error{nil, applyErr, context.Canceled}, wantErr: applyErr, wantApplied: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied, err := runIncrWorkerPool(tt.numOfWorkers, tt.errs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetIncrResult() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantApplied > 0 && applied != tt.wantApplied {
+				t.Errorf("IncrApply() called %d times, want %d", applied, tt.wantApplied)
+			}
+		})
+	}
+}
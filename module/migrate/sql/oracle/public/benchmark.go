@@ -0,0 +1,54 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"hash/crc32"
+	"strconv"
+)
+
+// 采样压测目标端临时表名前缀
+const benchmarkTablePrefix = "_TRANSFERDB_BENCH_"
+
+// BenchmarkTableName 目标端临时表名，超出 MySQL 表名长度 64 以源表名 crc32 后缀区分，避免截断后同名
+func BenchmarkTableName(tableNameS string) string {
+	tableNameT := common.StringsBuilder(benchmarkTablePrefix, tableNameS)
+	if len(tableNameT) <= 64 {
+		return tableNameT
+	}
+	return fmt.Sprintf("%s_%08X", tableNameT[:64-9], crc32.ChecksumIEEE([]byte(tableNameS)))
+}
+
+// BenchmarkSampleWhere 采样条件，按统计信息行数计算 SAMPLE BLOCK 采样百分比 (放大 1.5 倍弥补统计信息偏差以及数据块采样波动)，
+// 采样数据块分布于全表而非集中于表头部，ROWNUM 限制采样行数上限，表行数不超过采样行数直接读取全表
+func BenchmarkSampleWhere(schemaNameS, tableNameS string, numRows int64, sampleRows int) string {
+	limit := common.StringsBuilder(`ROWNUM <= `, strconv.Itoa(sampleRows))
+	if numRows <= 0 {
+		return limit
+	}
+	percent := float64(sampleRows) * 1.5 / float64(numRows) * 100
+	if percent >= 100 {
+		return limit
+	}
+	// Oracle SAMPLE 百分比取值范围 [0.000001, 100)
+	if percent < 0.000001 {
+		percent = 0.000001
+	}
+	return common.StringsBuilder(`ROWID IN (SELECT ROWID FROM `, schemaNameS, `.`, tableNameS,
+		` SAMPLE BLOCK (`, strconv.FormatFloat(percent, 'f', 6, 64), `)) AND `, limit)
+}
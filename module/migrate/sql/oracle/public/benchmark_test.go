@@ -0,0 +1,70 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBenchmarkTableName(t *testing.T) {
+	long01 := strings.Repeat("T", 60) + "_01"
+	long02 := strings.Repeat("T", 60) + "_02"
+	tests := []struct {
+		name       string
+		tableNameS string
+		want       string
+	}{
+		{name: "short", tableNameS: "ORDERS", want: "_TRANSFERDB_BENCH_ORDERS"},
+		{name: "max length", tableNameS: strings.Repeat("T", 46), want: "_TRANSFERDB_BENCH_" + strings.Repeat("T", 46)},
+		{name: "long", tableNameS: long01, want: "_TRANSFERDB_BENCH_" + strings.Repeat("T", 37) + "_E286346E"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BenchmarkTableName(tt.tableNameS)
+			if got != tt.want {
+				t.Errorf("BenchmarkTableName() = %v, want %v", got, tt.want)
+			}
+			if len(got) > 64 {
+				t.Errorf("BenchmarkTableName() length = %d, want <= 64", len(got))
+			}
+		})
+	}
+	if BenchmarkTableName(long01) == BenchmarkTableName(long02) {
+		t.Errorf("BenchmarkTableName() [%s] and [%s] conflict", long01, long02)
+	}
+}
+
+func TestBenchmarkSampleWhere(t *testing.T) {
+	tests := []struct {
+		name       string
+		numRows    int64
+		sampleRows int
+		want       string
+	}{
+		{name: "without statistics", numRows: 0, sampleRows: 1000, want: "ROWNUM <= 1000"},
+		{name: "small table", numRows: 1200, sampleRows: 1000, want: "ROWNUM <= 1000"},
+		{name: "sample", numRows: 1000000, sampleRows: 10000, want: "ROWID IN (SELECT ROWID FROM S.T SAMPLE BLOCK (1.500000)) AND ROWNUM <= 10000"},
+		{name: "minimum percent", numRows: 1 << 62, sampleRows: 1, want: "ROWID IN (SELECT ROWID FROM S.T SAMPLE BLOCK (0.000001)) AND ROWNUM <= 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BenchmarkSampleWhere("S", "T", tt.numRows, tt.sampleRows); got != tt.want {
+				t.Errorf("BenchmarkSampleWhere() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
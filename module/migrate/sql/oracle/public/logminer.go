@@ -42,13 +42,28 @@ type Logminer struct {
 	Operation    string
 }
 
+// logminer schema 级别捕获条件，多 schema 共享同一 logminer 会话以及查询
+type LogminerFilter struct {
+	SourceSchema  string
+	TargetSchema  string
+	SourceTables  []string
+	TableNameRule map[string]string
+}
+
 // 捕获增量数据
-func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, sourceSchema, targetSchema string, sourceTable string, tableNameRule map[string]string, lastCheckpoint string, queryTimeout int) ([]Logminer, error) {
+func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, filters []LogminerFilter, lastCheckpoint string, queryTimeout int) ([]Logminer, error) {
 	var lcs []Logminer
 
 	c, cancel := context.WithTimeout(ctx, time.Duration(queryTimeout)*time.Second)
 	defer cancel()
 
+	var schemaConds []string
+	filterMap := make(map[string]LogminerFilter)
+	for _, f := range filters {
+		schemaConds = append(schemaConds, common.StringsBuilder(`(UPPER(SEG_OWNER) = '`, common.StringUPPER(f.SourceSchema), `' AND UPPER(TABLE_NAME) IN (`, common.StringArrayToCapitalChar(f.SourceTables), `))`))
+		filterMap[common.StringUPPER(f.SourceSchema)] = f
+	}
+
 	querySQL := common.StringsBuilder(`SELECT SCN,
        SEG_OWNER AS SOURCE_SCHEMA,
        TABLE_NAME AS SOURCE_TABLE,
@@ -57,8 +72,7 @@ func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, sourceSchem
        OPERATION
   FROM V$LOGMNR_CONTENTS
  WHERE 1 = 1
-   AND UPPER(SEG_OWNER) = '`, common.StringUPPER(sourceSchema), `'
-   AND UPPER(TABLE_NAME) IN (`, sourceTable, `)
+   AND (`, strings.Join(schemaConds, "\n    OR "), `)
    AND OPERATION IN ('INSERT', 'DELETE', 'UPDATE', 'DDL')
    AND SCN >= `, lastCheckpoint, ` ORDER BY SCN`)
 
@@ -77,8 +91,13 @@ func GetOracleIncrRecord(ctx context.Context, oracle *oracle.Oracle, sourceSchem
 		}
 
 		// 目标库名以及表名
-		lc.TargetSchema = targetSchema
-		lc.TargetTable = tableNameRule[common.StringUPPER(lc.SourceTable)]
+		f := filterMap[common.StringUPPER(lc.SourceSchema)]
+		lc.TargetSchema = f.TargetSchema
+		if val, ok := f.TableNameRule[common.StringUPPER(lc.SourceTable)]; ok {
+			lc.TargetTable = val
+		} else {
+			lc.TargetTable = common.StringUPPER(lc.SourceTable)
+		}
 		lcs = append(lcs, lc)
 	}
 	endTime := time.Now()
@@ -0,0 +1,239 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"go.uber.org/zap"
+	"os"
+	"time"
+)
+
+// 增量同步暂存事件处理（error-policy = park）
+// list 查看暂存事件
+// replay 下游修复后按 SCN 顺序重放暂存事件，重放成功删除记录，同表遇错停止该表后续重放
+// 暂存后同表后续事件已应用，重放可能以旧值覆盖相同键新值，需停止增量同步任务并 -confirm 确认，未确认只输出待重放事件（dry-run）
+// discard 丢弃暂存事件
+type Park struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	Mysql  *mysql.MySQL
+	MetaDB *meta.Meta
+}
+
+func NewPark(ctx context.Context, cfg *config.Config) (*Park, error) {
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &Park{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		Mysql:  mysqlDB,
+		MetaDB: metaDB,
+	}, nil
+}
+
+func (p *Park) Park() error {
+	startTime := time.Now()
+	zap.L().Info("increment park record action start",
+		zap.String("schema", p.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", p.Cfg.TableName),
+		zap.String("action", p.Cfg.Action))
+
+	parkDetails, err := meta.NewIncrParkDetailModel(p.MetaDB).DetailIncrParkDetail(p.Ctx, &meta.IncrParkDetail{
+		DBTypeS:     p.Cfg.DBTypeS,
+		DBTypeT:     p.Cfg.DBTypeT,
+		SchemaNameS: p.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  p.Cfg.TableName,
+		TaskMode:    common.TaskModeAll,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch p.Cfg.Action {
+	case common.TaskActionList, "":
+		p.list(parkDetails)
+	case common.TaskActionReplay:
+		p.list(parkDetails)
+		if !p.Cfg.Confirm {
+			zap.L().Warn("increment park record replay dry run, park records not replayed",
+				zap.String("schema", p.Cfg.SchemaConfig.SourceSchema),
+				zap.String("table", p.Cfg.TableName),
+				zap.Int("park records", len(parkDetails)),
+				zap.String("tips", "events of the same table after the park record have been applied, replay may overwrite newer values of the same key, please stop the increment task, review the park records, then rerun with flag -confirm to replay"))
+			return nil
+		}
+		if err = p.checkIncrStopped(); err != nil {
+			return err
+		}
+		if err = p.replay(parkDetails); err != nil {
+			return err
+		}
+	case common.TaskActionDiscard:
+		err = meta.NewIncrParkDetailModel(p.MetaDB).DeleteIncrParkDetailBySchemaTable(p.Ctx, &meta.IncrParkDetail{
+			DBTypeS:     p.Cfg.DBTypeS,
+			DBTypeT:     p.Cfg.DBTypeT,
+			SchemaNameS: p.Cfg.SchemaConfig.SourceSchema,
+			TableNameS:  p.Cfg.TableName,
+			TaskMode:    common.TaskModeAll,
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("flag [action] value [%s] isn't support for mode [%s], support action [list replay discard]", p.Cfg.Action, p.Cfg.TaskMode)
+	}
+
+	zap.L().Info("increment park record action finished",
+		zap.String("schema", p.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", p.Cfg.TableName),
+		zap.String("action", p.Cfg.Action),
+		zap.Int("park records", len(parkDetails)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (p *Park) list(parkDetails []meta.IncrParkDetail) {
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.SetOutputMirror(os.Stdout)
+	sw.AppendHeader(table.Row{"ID", "SOURCE TABLE", "TARGET TABLE", "SCN", "OPERATION", "REPLAYABLE", "ERROR DETAIL"})
+	for _, d := range parkDetails {
+		replayable := "YES"
+		if !isParkReplayable(d) {
+			replayable = "NO"
+		}
+		sw.AppendRow(table.Row{
+			d.ID,
+			common.StringsBuilder(d.SchemaNameS, ".", d.TableNameS),
+			common.StringsBuilder(d.SchemaNameT, ".", d.TableNameT),
+			d.ScnS,
+			d.Operation,
+			replayable,
+			d.ErrorDetail,
+		})
+	}
+	sw.Render()
+}
+
+// 增量同步任务运行中重放与增量应用并发写同表，拒绝重放
+func (p *Park) checkIncrStopped() error {
+	updatedAt, err := meta.NewIncrSyncMetaModel(p.MetaDB).GetIncrSyncMetaMaxUpdatedAtBySchema(p.Ctx, &meta.IncrSyncMeta{
+		DBTypeS:     p.Cfg.DBTypeS,
+		DBTypeT:     p.Cfg.DBTypeT,
+		SchemaNameS: p.Cfg.SchemaConfig.SourceSchema,
+	})
+	if err != nil {
+		return err
+	}
+	if !updatedAt.IsZero() && time.Since(updatedAt) < common.MigrateIncrParkReplayIdleSeconds*time.Second {
+		return fmt.Errorf("schema [%s] increment sync meta updated at [%s] within [%ds], increment task seems running, please stop the increment task before park replay",
+			p.Cfg.SchemaConfig.SourceSchema, updatedAt.Format("2006-01-02 15:04:05"), common.MigrateIncrParkReplayIdleSeconds)
+	}
+	return nil
+}
+
+// 转换失败事件无目标端 SQL，不可重放
+func isParkReplayable(d meta.IncrParkDetail) bool {
+	return d.Replayable != "NO" && d.SQLRedoT != ""
+}
+
+func (p *Park) replay(parkDetails []meta.IncrParkDetail) error {
+	var (
+		failedTables  []string
+		unreplayables []uint
+		replayCounts  int
+	)
+	for _, d := range parkDetails {
+		if common.IsContainString(failedTables, d.TableNameS) {
+			continue
+		}
+		// 转换失败事件按源端 redo/undo SQL 人工处理后 discard，同表后续事件不再重放
+		if !isParkReplayable(d) {
+			zap.L().Warn("increment park record isn't replayable, please manual deal with the source sql and discard",
+				zap.Uint("id", d.ID),
+				zap.String("table", d.TableNameS),
+				zap.Uint64("scn", d.ScnS),
+				zap.String("sql redo", d.SQLRedoS),
+				zap.String("sql undo", d.SQLUndoS),
+				zap.String("error", d.ErrorDetail))
+			unreplayables = append(unreplayables, d.ID)
+			failedTables = append(failedTables, d.TableNameS)
+			continue
+		}
+		sqls, err := d.GetSQLRedoT()
+		if err != nil {
+			return err
+		}
+		if err = p.exec(sqls); err != nil {
+			zap.L().Error("increment park record replay failed",
+				zap.String("park", d.String()),
+				zap.Error(err))
+			if errf := meta.NewIncrParkDetailModel(p.MetaDB).UpdateIncrParkDetail(p.Ctx, d.ID, map[string]interface{}{
+				"ErrorDetail": err.Error(),
+			}); errf != nil {
+				return errf
+			}
+			failedTables = append(failedTables, d.TableNameS)
+			continue
+		}
+		if err = meta.NewIncrParkDetailModel(p.MetaDB).DeleteIncrParkDetail(p.Ctx, d.ID); err != nil {
+			return err
+		}
+		replayCounts++
+	}
+
+	zap.L().Info("increment park record replay finished",
+		zap.String("schema", p.Cfg.SchemaConfig.SourceSchema),
+		zap.Int("replay counts", replayCounts),
+		zap.Int("park totals", len(parkDetails)),
+		zap.Strings("failed tables", failedTables),
+		zap.Uints("unreplayable records", unreplayables))
+	if len(unreplayables) > 0 {
+		return fmt.Errorf("increment park record %v isn't replayable, table %v replay stopped, please manual deal with meta table [incr_park_detail] column [sql_redo_s sql_undo_s] then discard", unreplayables, failedTables)
+	}
+	if len(failedTables) > 0 {
+		return fmt.Errorf("increment park record replay table %v failed, please see meta table [incr_park_detail] and log", failedTables)
+	}
+	return nil
+}
+
+func (p *Park) exec(sqls []string) error {
+	txn, err := p.Mysql.MySQLDB.BeginTx(p.Ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	for _, s := range sqls {
+		if _, err = txn.ExecContext(p.Ctx, s); err != nil {
+			_ = txn.Rollback()
+			return fmt.Errorf("exec sql [%s] failed: %v", s, err)
+		}
+	}
+	return txn.Commit()
+}
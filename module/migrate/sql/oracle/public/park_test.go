@@ -0,0 +1,137 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package public
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 转换失败暂存事件不可重放，replay 报告该事件并停止同表后续事件重放，不执行目标端 SQL
+func TestParkReplayUnreplayable(t *testing.T) {
+	ctx := context.Background()
+	metaDB, err := meta.NewSQLiteMetaDBEngine(filepath.Join(t.TempDir(), "meta.db"), "", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	model := meta.NewIncrParkDetailModel(metaDB)
+	for _, d := range []meta.IncrParkDetail{
+		{ScnS: 100, Operation: "INSERT", SQLRedoS: `insert into "MARVIN"."T1"("ID") values (1)`, Replayable: "NO", ErrorDetail: "translate failed"},
+		{ScnS: 101, Operation: "UPDATE", SQLRedoS: `update "MARVIN"."T1" set "ID" = 2`, SQLRedoT: "[\"UPDATE `MARVIN`.`T1` SET `ID` = 2\"]"},
+	} {
+		d.DBTypeS, d.DBTypeT = common.DatabaseTypeOracle, common.DatabaseTypeMySQL
+		d.SchemaNameS, d.TableNameS, d.SchemaNameT, d.TableNameT = "MARVIN", "T1", "MARVIN", "T1"
+		d.TaskMode = common.TaskModeAll
+		if err = model.CreateIncrParkDetail(ctx, &d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &Park{
+		Ctx: ctx,
+		Cfg: &config.Config{
+			DBTypeS:      common.DatabaseTypeOracle,
+			DBTypeT:      common.DatabaseTypeMySQL,
+			SchemaConfig: config.SchemaConfig{SourceSchema: "MARVIN"},
+		},
+		MetaDB: metaDB,
+	}
+	parkDetails, err := model.DetailIncrParkDetail(ctx, &meta.IncrParkDetail{
+		DBTypeS:     common.DatabaseTypeOracle,
+		DBTypeT:     common.DatabaseTypeMySQL,
+		SchemaNameS: "MARVIN",
+		TaskMode:    common.TaskModeAll,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isParkReplayable(parkDetails[0]) || !isParkReplayable(parkDetails[1]) {
+		t.Fatalf("isParkReplayable() = %v %v, want false true", isParkReplayable(parkDetails[0]), isParkReplayable(parkDetails[1]))
+	}
+
+	err = p.replay(parkDetails)
+	if err == nil || !strings.Contains(err.Error(), "[1] isn't replayable") {
+		t.Fatalf("replay() error = %v, want park record [1] isn't replayable", err)
+	}
+	remains, err := model.DetailIncrParkDetail(ctx, &meta.IncrParkDetail{
+		DBTypeS:     common.DatabaseTypeOracle,
+		DBTypeT:     common.DatabaseTypeMySQL,
+		SchemaNameS: "MARVIN",
+		TaskMode:    common.TaskModeAll,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remains) != 2 {
+		t.Errorf("replay() remain park records = %d, want 2", len(remains))
+	}
+}
+
+func TestParkCheckIncrStopped(t *testing.T) {
+	ctx := context.Background()
+	metaDB, err := meta.NewSQLiteMetaDBEngine(filepath.Join(t.TempDir(), "meta.db"), "", 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = metaDB.MigrateTables(); err != nil {
+		t.Fatal(err)
+	}
+	p := &Park{
+		Ctx: ctx,
+		Cfg: &config.Config{
+			DBTypeS:      common.DatabaseTypeOracle,
+			DBTypeT:      common.DatabaseTypeMySQL,
+			SchemaConfig: config.SchemaConfig{SourceSchema: "MARVIN"},
+		},
+		MetaDB: metaDB,
+	}
+
+	// 无增量同步元数据
+	if err = p.checkIncrStopped(); err != nil {
+		t.Fatalf("checkIncrStopped() without increment meta error = %v", err)
+	}
+
+	// 增量同步元数据刚更新，视为增量同步任务运行中
+	if err = meta.NewIncrSyncMetaModel(metaDB).BatchCreateIncrSyncMeta(ctx, []meta.IncrSyncMeta{{
+		DBTypeS:     common.DatabaseTypeOracle,
+		DBTypeT:     common.DatabaseTypeMySQL,
+		SchemaNameS: "MARVIN",
+		TableNameS:  "T1",
+		SchemaNameT: "MARVIN",
+		TableNameT:  "T1",
+		GlobalScnS:  100,
+		TableScnS:   100,
+	}}, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.checkIncrStopped(); err == nil || !strings.Contains(err.Error(), "increment task seems running") {
+		t.Fatalf("checkIncrStopped() error = %v, want increment task running", err)
+	}
+
+	// 其他 schema 不受影响
+	p.Cfg.SchemaConfig.SourceSchema = "OTHER"
+	if err = p.checkIncrStopped(); err != nil {
+		t.Errorf("checkIncrStopped() other schema error = %v", err)
+	}
+}
@@ -17,6 +17,7 @@ package public
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/wentaojin/transferdb/common"
@@ -28,7 +29,9 @@ import (
 	"github.com/pingcap/tidb/parser"
 
 	"github.com/pingcap/tidb/parser/ast"
-	_ "github.com/pingcap/tidb/types/parser_driver"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/opcode"
+	driver "github.com/pingcap/tidb/types/parser_driver"
 )
 
 func ParseSQL(sql string) (*ast.StmtNode, error) {
@@ -187,3 +190,223 @@ func beforeData(where ast.ExprNode, before map[string]interface{}) {
 		}
 	}
 }
+
+// 字段数据转换，INSERT 字段值、UPDATE SET 字段值以及 WHERE 等值条件统一转换，保证与全量转换后目标端数据匹配
+func TransformStmt(rootNode *ast.StmtNode, transforms map[string]*common.ColumnTransform) error {
+	if len(transforms) == 0 {
+		return nil
+	}
+	var err error
+	switch node := (*rootNode).(type) {
+	case *ast.InsertStmt:
+		for i, col := range node.Columns {
+			ct, ok := transforms[strings.ToUpper(col.Name.O)]
+			if !ok {
+				continue
+			}
+			for _, lists := range node.Lists {
+				if lists[i], err = transformValueExpr(ct, lists[i]); err != nil {
+					return err
+				}
+			}
+		}
+	case *ast.UpdateStmt:
+		for _, assign := range node.List {
+			ct, ok := transforms[strings.ToUpper(assign.Column.Name.O)]
+			if !ok {
+				continue
+			}
+			if assign.Expr, err = transformValueExpr(ct, assign.Expr); err != nil {
+				return err
+			}
+		}
+		if node.Where != nil {
+			if node.Where, err = transformWhereExpr(node.Where, transforms); err != nil {
+				return err
+			}
+		}
+	case *ast.DeleteStmt:
+		if node.Where != nil {
+			if node.Where, err = transformWhereExpr(node.Where, transforms); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func transformWhereExpr(expr ast.ExprNode, transforms map[string]*common.ColumnTransform) (ast.ExprNode, error) {
+	var err error
+	switch node := expr.(type) {
+	case *ast.ParenthesesExpr:
+		if node.Expr, err = transformWhereExpr(node.Expr, transforms); err != nil {
+			return expr, err
+		}
+	case *ast.BinaryOperationExpr:
+		switch node.Op {
+		case opcode.LogicAnd:
+			if node.L, err = transformWhereExpr(node.L, transforms); err != nil {
+				return expr, err
+			}
+			if node.R, err = transformWhereExpr(node.R, transforms); err != nil {
+				return expr, err
+			}
+		case opcode.EQ:
+			col, ok := node.L.(*ast.ColumnNameExpr)
+			if !ok {
+				return expr, nil
+			}
+			ct, ok := transforms[strings.ToUpper(col.Name.Name.O)]
+			if !ok {
+				return expr, nil
+			}
+			if node.R, err = transformValueExpr(ct, node.R); err != nil {
+				return expr, err
+			}
+			// 转换后 NULL 值等值条件改写为 IS NULL
+			if val, ok := node.R.(*driver.ValueExpr); ok && val.Datum.IsNull() {
+				return &ast.IsNullExpr{Expr: node.L}, nil
+			}
+		}
+	}
+	return expr, nil
+}
+
+func transformValueExpr(ct *common.ColumnTransform, expr ast.ExprNode) (ast.ExprNode, error) {
+	var (
+		value  string
+		isNull bool
+		err    error
+	)
+	if val, ok := expr.(*driver.ValueExpr); ok {
+		isNull = val.Datum.IsNull()
+		if !isNull {
+			if value, err = val.Datum.ToString(); err != nil {
+				return expr, fmt.Errorf("column [%s] value transform failed: %v", ct.ColumnName, err)
+			}
+		}
+	} else if ct.TransformType != common.TransformTypeConstant && ct.TransformType != common.TransformTypeNullify {
+		// 非字面值（比如 TO_DATE、HEXTORAW 函数）仅支持固定值以及置空转换
+		return expr, fmt.Errorf("column [%s] value isn't literal, transform type [%s] isn't support", ct.ColumnName, ct.TransformType)
+	}
+	res, isNull, err := ct.Transform(value, isNull)
+	if err != nil {
+		return expr, err
+	}
+	if isNull {
+		return ast.NewValueExpr(nil, "", ""), nil
+	}
+	return ast.NewValueExpr(res, "", ""), nil
+}
+
+// 字段名映射，INSERT 字段、UPDATE SET 字段以及 WHERE 条件字段统一重命名，不迁移字段移除
+func RenameStmt(rootNode *ast.StmtNode, columnNameRule *common.TableColumnNameRule) error {
+	if columnNameRule == nil {
+		return nil
+	}
+	var err error
+	switch node := (*rootNode).(type) {
+	case *ast.InsertStmt:
+		var (
+			columns []*ast.ColumnName
+			lists   = make([][]ast.ExprNode, len(node.Lists))
+		)
+		for i, col := range node.Columns {
+			if _, ok := columnNameRule.TargetColumnName(col.Name.O); !ok {
+				continue
+			}
+			columns = append(columns, col)
+			for j := range node.Lists {
+				lists[j] = append(lists[j], node.Lists[j][i])
+			}
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("column name rule exclude all insert columns")
+		}
+		node.Columns, node.Lists = columns, lists
+	case *ast.UpdateStmt:
+		var assigns []*ast.Assignment
+		for _, assign := range node.List {
+			if _, ok := columnNameRule.TargetColumnName(assign.Column.Name.O); ok {
+				assigns = append(assigns, assign)
+			}
+		}
+		node.List = assigns
+		if node.Where != nil {
+			if node.Where, err = renameWhereExpr(node.Where, columnNameRule); err != nil {
+				return err
+			}
+		}
+	case *ast.DeleteStmt:
+		if node.Where != nil {
+			if node.Where, err = renameWhereExpr(node.Where, columnNameRule); err != nil {
+				return err
+			}
+		}
+	}
+	(*rootNode).Accept(&columnRenameVisitor{columnNameRule: columnNameRule})
+	return nil
+}
+
+// WHERE 条件移除不迁移字段条件，全部条件被移除则报错，避免目标端全表删除或者更新
+func renameWhereExpr(expr ast.ExprNode, columnNameRule *common.TableColumnNameRule) (ast.ExprNode, error) {
+	where := dropWhereExpr(expr, columnNameRule)
+	if where == nil {
+		return expr, fmt.Errorf("column name rule exclude all where condition columns")
+	}
+	return where, nil
+}
+
+func dropWhereExpr(expr ast.ExprNode, columnNameRule *common.TableColumnNameRule) ast.ExprNode {
+	switch node := expr.(type) {
+	case *ast.ParenthesesExpr:
+		inner := dropWhereExpr(node.Expr, columnNameRule)
+		if inner == nil {
+			return nil
+		}
+		node.Expr = inner
+	case *ast.BinaryOperationExpr:
+		switch node.Op {
+		case opcode.LogicAnd:
+			l := dropWhereExpr(node.L, columnNameRule)
+			r := dropWhereExpr(node.R, columnNameRule)
+			switch {
+			case l == nil:
+				return r
+			case r == nil:
+				return l
+			}
+			node.L, node.R = l, r
+		default:
+			if col, ok := node.L.(*ast.ColumnNameExpr); ok {
+				if _, ok = columnNameRule.TargetColumnName(col.Name.Name.O); !ok {
+					return nil
+				}
+			}
+		}
+	case *ast.IsNullExpr:
+		if col, ok := node.Expr.(*ast.ColumnNameExpr); ok {
+			if _, ok = columnNameRule.TargetColumnName(col.Name.Name.O); !ok {
+				return nil
+			}
+		}
+	}
+	return expr
+}
+
+type columnRenameVisitor struct {
+	columnNameRule *common.TableColumnNameRule
+}
+
+func (v *columnRenameVisitor) Enter(in ast.Node) (ast.Node, bool) {
+	if node, ok := in.(*ast.ColumnName); ok {
+		if target, ok := v.columnNameRule.TargetColumnName(node.Name.O); ok && target != node.Name.O {
+			node.Name = model.NewCIStr(target)
+		}
+	}
+	return in, false
+}
+
+func (v *columnRenameVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
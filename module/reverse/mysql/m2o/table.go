@@ -320,6 +320,12 @@ func GenReverseTableTask(r *Reverse, lowerCaseFieldName string, tableNameRule ma
 		for _, t := range exporters {
 			ts := t
 			g2.Go(func() error {
+				release, err := r.cfg.AcquireThread(r.ctx, common.ThreadBudgetReverse, r.cfg.ReverseConfig.ReverseThreads)
+				if err != nil {
+					return err
+				}
+				defer release()
+
 				var targetTableName string
 				if val, ok := tableNameRule[common.StringUPPER(ts)]; ok {
 					targetTableName = val
@@ -49,7 +49,7 @@ func NewReverse(ctx context.Context, cfg *config.Config) (*Reverse, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -189,6 +189,12 @@ func (r *Reverse) Reverse() error {
 	for _, table := range tables {
 		t := table
 		g.Go(func() error {
+			release, err := r.cfg.AcquireThread(r.ctx, common.ThreadBudgetReverse, r.cfg.ReverseConfig.ReverseThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			rule, err := IReader(t)
 			if err != nil {
 				if err = meta.NewErrorLogDetailModel(r.metaDB).CreateErrorLog(r.ctx, &meta.ErrorLogDetail{
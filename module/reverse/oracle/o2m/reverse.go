@@ -49,7 +49,7 @@ func NewReverse(ctx context.Context, cfg *config.Config) (*Reverse, error) {
 	if err != nil {
 		return nil, err
 	}
-	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
 	if err != nil {
 		return nil, err
 	}
@@ -165,8 +165,20 @@ func (r *Reverse) Reverse() error {
 		zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
 		zap.String("cost", time.Now().Sub(ruleTime).String()))
 
+	// 获取字段名映射规则
+	tableColumnNameRuleMap, err := meta.NewColumnNameRuleModel(r.MetaDB).GetSchemaColumnNameRule(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
+	// 获取表路由规则
+	tableRouteMap, err := meta.NewTableRouteRuleModel(r.MetaDB).GetSchemaTableRoute(r.Ctx, r.Cfg)
+	if err != nil {
+		return err
+	}
+
 	// 获取 reverse 表任务列表
-	tables, err := GenReverseTableTask(r, tableNameRuleMap, tableColumnRuleMap, tableDefaultRuleSourceMap, tableDefaultRuleMap, oracleDBVersion, oracleDBCharset, r.Cfg.MySQLConfig.Charset, oracleCollation, r.Cfg.ReverseConfig.LowerCaseFieldName, exporterTables, nlsSort, nlsComp)
+	tables, err := GenReverseTableTask(r, tableNameRuleMap, tableColumnRuleMap, tableDefaultRuleSourceMap, tableDefaultRuleMap, tableColumnNameRuleMap, tableRouteMap, oracleDBVersion, oracleDBCharset, r.Cfg.MySQLConfig.Charset, oracleCollation, r.Cfg.ReverseConfig.LowerCaseFieldName, exporterTables, nlsSort, nlsComp)
 	if err != nil {
 		return err
 	}
@@ -208,6 +220,12 @@ func (r *Reverse) Reverse() error {
 	for _, table := range tables {
 		t := table
 		g.Go(func() error {
+			release, err := r.Cfg.AcquireThread(r.Ctx, common.ThreadBudgetReverse, r.Cfg.ReverseConfig.ReverseThreads)
+			if err != nil {
+				return err
+			}
+			defer release()
+
 			rule, err := IReader(t)
 			if err != nil {
 				if err = meta.NewErrorLogDetailModel(r.MetaDB).CreateErrorLog(r.Ctx, &meta.ErrorLogDetail{
@@ -761,6 +761,11 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 			columnType      string
 		)
 		columnName := rowCol["COLUMN_NAME"]
+		// 字段名映射规则，不迁移字段跳过
+		columnNameT, ok := r.TableColumnNameRule.TargetColumnName(columnName)
+		if !ok {
+			continue
+		}
 
 		if r.OracleCollation {
 			// 字段排序规则检查
@@ -869,6 +874,8 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 			}
 		}
 
+		columnName = columnNameT
+
 		// 字段名大小写
 		if strings.EqualFold(r.LowerCaseFieldName, common.MigrateTableStructFieldNameLowerCase) {
 			columnName = strings.ToLower(columnName)
@@ -938,6 +945,16 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 		}
 	}
 
+	// 字段名映射规则目标端新增字段
+	for _, c := range r.TableColumnNameRule.AddColumns() {
+		tableColumns = append(tableColumns, c.TargetColumnMeta())
+	}
+
+	// 表路由 merge 规则目标端区分字段
+	if r.TableRoute.IsMerge() {
+		tableColumns = append(tableColumns, r.TableRoute.DiscriminatorColumnMeta())
+	}
+
 	return tableColumns, nil
 }
 
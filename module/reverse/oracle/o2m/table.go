@@ -25,6 +25,7 @@ import (
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
 	"github.com/wentaojin/transferdb/module/reverse"
+	"github.com/wentaojin/transferdb/module/reverse/oracle/public"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"strings"
@@ -48,9 +49,11 @@ type Table struct {
 	SourceTableType       string          `json:"source_table_type"`
 	LowerCaseFieldName    string          `json:"lower_case_field_name"`
 
-	TableColumnDatatypeRule         map[string]string `json:"table_column_datatype_rule"`
-	TableColumnDefaultValRule       map[string]string `json:"table_column_default_val_rule"`
-	TableColumnDefaultValSourceRule map[string]bool   `json:"table_column_default_val_source_rule"` // 判断表字段 defaultVal 来源于 database or custom
+	TableColumnDatatypeRule         map[string]string           `json:"table_column_datatype_rule"`
+	TableColumnDefaultValRule       map[string]string           `json:"table_column_default_val_rule"`
+	TableColumnDefaultValSourceRule map[string]bool             `json:"table_column_default_val_source_rule"` // 判断表字段 defaultVal 来源于 database or custom
+	TableColumnNameRule             *common.TableColumnNameRule `json:"-"`
+	TableRoute                      *common.TableRoute          `json:"-"`
 
 	Overwrite bool           `json:"overwrite"`
 	Oracle    *oracle.Oracle `json:"-"`
@@ -58,7 +61,7 @@ type Table struct {
 	MetaDB    *meta.Meta     `json:"-"`
 }
 
-func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColumnRule map[string]map[string]string, tableDefaultSourceRule map[string]map[string]bool, tableDefaultRule map[string]map[string]string, oracleDBVersion, oracleDBCharset, targetDBCharset string, oracleCollation bool, lowerCaseFieldName string, exporters []string, nlsSort, nlsComp string) ([]*Table, error) {
+func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColumnRule map[string]map[string]string, tableDefaultSourceRule map[string]map[string]bool, tableDefaultRule map[string]map[string]string, tableColumnNameRule map[string]*common.TableColumnNameRule, tableRoute map[string]*common.TableRoute, oracleDBVersion, oracleDBCharset, targetDBCharset string, oracleCollation bool, lowerCaseFieldName string, exporters []string, nlsSort, nlsComp string) ([]*Table, error) {
 	var tables []*Table
 
 	beginTime := time.Now()
@@ -137,12 +140,37 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 	g1 := &errgroup.Group{}
 	tableChan := make(chan *Table, common.ChannelBufferSize)
 
+	// 表路由 merge 规则，多张源端表合并至同一张目标端表，只以首张源端表生成目标端表结构
+	var routeExporters []string
+	mergeTables := make(map[string]string)
+	for _, t := range exporters {
+		if route, ok := tableRoute[common.StringUPPER(t)]; ok && route.IsMerge() {
+			mergeTable := common.StringsBuilder(route.SchemaNameT, ".", route.TableNameT)
+			if val, exist := mergeTables[mergeTable]; exist {
+				zap.L().Warn("route merge table structure has been generated, skip",
+					zap.String("schema", r.Cfg.SchemaConfig.SourceSchema),
+					zap.String("table", t),
+					zap.String("merge table", mergeTable),
+					zap.String("generate by table", val))
+				continue
+			}
+			mergeTables[mergeTable] = t
+		}
+		routeExporters = append(routeExporters, t)
+	}
+
 	g1.Go(func() error {
 		g2 := &errgroup.Group{}
 		g2.SetLimit(r.Cfg.ReverseConfig.ReverseThreads)
-		for _, exporter := range exporters {
+		for _, exporter := range routeExporters {
 			t := exporter
 			g2.Go(func() error {
+				release, err := r.Cfg.AcquireThread(r.Ctx, common.ThreadBudgetReverse, r.Cfg.ReverseConfig.ReverseThreads)
+				if err != nil {
+					return err
+				}
+				defer release()
+
 				// 库名、表名规则
 				var targetTableName string
 				if val, ok := tableNameRule[common.StringUPPER(t)]; ok {
@@ -167,6 +195,8 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 					TableColumnDatatypeRule:         tableColumnRule[common.StringUPPER(t)],
 					TableColumnDefaultValRule:       tableDefaultRule[common.StringUPPER(t)],
 					TableColumnDefaultValSourceRule: tableDefaultSourceRule[common.StringUPPER(t)],
+					TableColumnNameRule:             tableColumnNameRule[common.StringUPPER(t)],
+					TableRoute:                      tableRoute[common.StringUPPER(t)],
 					Overwrite:                       r.Cfg.MySQLConfig.Overwrite,
 					Oracle:                          r.Oracle,
 					MySQL:                           r.Mysql,
@@ -177,6 +207,20 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 					tbl.SourceSchemaCollation = schemaCollation
 					tbl.SourceTableCollation = tblCollation[common.StringUPPER(t)]
 				}
+				// 表路由规则，merge 以合并目标端表为准，hash/range 每个分片生成一张目标端表
+				switch {
+				case tbl.TableRoute.IsMerge():
+					tbl.TargetSchemaName = tbl.TableRoute.SchemaNameT
+					tbl.TargetTableName = tbl.TableRoute.TableNameT
+				case tbl.TableRoute.IsSplit():
+					for _, s := range tbl.TableRoute.Shards {
+						shardTbl := *tbl
+						shardTbl.TargetSchemaName = s.SchemaNameT
+						shardTbl.TargetTableName = s.TableNameT
+						tableChan <- &shardTbl
+					}
+					return nil
+				}
 				tableChan <- tbl
 				return nil
 			})
@@ -598,6 +642,22 @@ func (t *Table) GetTableInfo() (interface{}, error) {
 		return nil, err
 	}
 
+	// 字段名映射规则，约束、索引字段重命名，引用不迁移字段的约束、索引移除
+	primaryKey = public.ChangeTableColumnNameList(primaryKey, t.TableColumnNameRule)
+	uniqueKey = public.ChangeTableColumnNameList(uniqueKey, t.TableColumnNameRule)
+	foreignKey = public.ChangeTableColumnNameList(foreignKey, t.TableColumnNameRule)
+	uniqueIndex = public.ChangeTableColumnNameList(uniqueIndex, t.TableColumnNameRule)
+	normalIndex = public.ChangeTableColumnNameList(normalIndex, t.TableColumnNameRule)
+	checkKey, err = public.ChangeTableCheckColumnName(checkKey, t.TableColumnNameRule, columnMeta)
+	if err != nil {
+		return nil, err
+	}
+
+	// 表路由 merge 规则，主键、唯一约束以及唯一索引追加区分字段
+	primaryKey = public.ChangeTableRouteColumnList(primaryKey, t.TableRoute)
+	uniqueKey = public.ChangeTableRouteColumnList(uniqueKey, t.TableRoute)
+	uniqueIndex = public.ChangeTableRouteColumnList(uniqueIndex, t.TableRoute)
+
 	return &Info{
 		SourceTableDDL:    ddl,
 		PrimaryKeyINFO:    primaryKey,
@@ -23,6 +23,8 @@ import (
 	"github.com/wentaojin/transferdb/database/oracle"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 )
@@ -380,3 +382,97 @@ func (r *Change) ChangeTableColumnDefaultValue() (map[string]map[string]bool, ma
 		zap.String("cost", time.Now().Sub(startTime).String()))
 	return tableDefaultValSource, tableDefaultValMap, nil
 }
+
+// 字段名映射规则，主键、唯一键、外键以及索引字段列表 COLUMN_LIST 重命名，引用不迁移字段的约束、索引移除
+func ChangeTableColumnNameList(keyINFO []map[string]string, columnNameRule *common.TableColumnNameRule) []map[string]string {
+	if columnNameRule == nil {
+		return keyINFO
+	}
+	var changeINFO []map[string]string
+	for _, rowKey := range keyINFO {
+		columns := strings.Split(rowKey["COLUMN_LIST"], ",")
+		isDrop := false
+		for i, c := range columns {
+			columnNameT, ok := columnNameRule.TargetColumnName(c)
+			if !ok {
+				isDrop = true
+				break
+			}
+			columns[i] = columnNameT
+		}
+		if isDrop {
+			zap.L().Warn("column name rule exclude column, skip constraint or index",
+				zap.String("constraint or index", rowKey["CONSTRAINT_NAME"]+rowKey["INDEX_NAME"]),
+				zap.String("column list", rowKey["COLUMN_LIST"]))
+			continue
+		}
+		newKey := make(map[string]string, len(rowKey))
+		for k, v := range rowKey {
+			newKey[k] = v
+		}
+		newKey["COLUMN_LIST"] = strings.Join(columns, ",")
+		changeINFO = append(changeINFO, newKey)
+	}
+	return changeINFO
+}
+
+// 表路由 merge 规则，多张源端表合并后主键、唯一约束以及唯一索引追加区分字段，避免不同源端表数据冲突
+func ChangeTableRouteColumnList(keyINFO []map[string]string, tableRoute *common.TableRoute) []map[string]string {
+	if !tableRoute.IsMerge() {
+		return keyINFO
+	}
+	var changeINFO []map[string]string
+	for _, rowKey := range keyINFO {
+		newKey := make(map[string]string, len(rowKey))
+		for k, v := range rowKey {
+			newKey[k] = v
+		}
+		newKey["COLUMN_LIST"] = common.StringsBuilder(rowKey["COLUMN_LIST"], ",", tableRoute.DiscriminatorColumn)
+		changeINFO = append(changeINFO, newKey)
+	}
+	return changeINFO
+}
+
+// 字段名映射规则，检查约束条件字段重命名，引用不迁移字段的检查约束移除
+func ChangeTableCheckColumnName(checkINFO []map[string]string, columnNameRule *common.TableColumnNameRule, columnINFO []map[string]string) ([]map[string]string, error) {
+	if columnNameRule == nil {
+		return checkINFO, nil
+	}
+	var changeINFO []map[string]string
+	for _, rowCK := range checkINFO {
+		searchCond := rowCK["SEARCH_CONDITION"]
+		isDrop := false
+		for _, rowCol := range columnINFO {
+			columnName := rowCol["COLUMN_NAME"]
+			columnNameT, ok := columnNameRule.TargetColumnName(columnName)
+			if ok && columnNameT == columnName {
+				continue
+			}
+			columnRex, err := regexp.Compile(fmt.Sprintf(`(?i)"?\b%s\b"?`, regexp.QuoteMeta(columnName)))
+			if err != nil {
+				return checkINFO, fmt.Errorf("check constraint column [%s] regexp compile failed: %v", columnName, err)
+			}
+			if !columnRex.MatchString(searchCond) {
+				continue
+			}
+			if !ok {
+				isDrop = true
+				break
+			}
+			searchCond = columnRex.ReplaceAllString(searchCond, columnNameT)
+		}
+		if isDrop {
+			zap.L().Warn("column name rule exclude column, skip check constraint",
+				zap.String("constraint", rowCK["CONSTRAINT_NAME"]),
+				zap.String("search condition", rowCK["SEARCH_CONDITION"]))
+			continue
+		}
+		newCK := make(map[string]string, len(rowCK))
+		for k, v := range rowCK {
+			newCK[k] = v
+		}
+		newCK["SEARCH_CONDITION"] = searchCond
+		changeINFO = append(changeINFO, newCK)
+	}
+	return changeINFO, nil
+}
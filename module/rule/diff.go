@@ -0,0 +1,172 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+)
+
+// 规则变更动作
+const (
+	ruleActionCreate = "CREATE"
+	ruleActionUpdate = "UPDATE"
+	ruleActionDelete = "DELETE"
+)
+
+type ruleChange struct {
+	Rule   string
+	Action string
+	Object string
+	Before string
+	After  string
+}
+
+// 按规则唯一键比较元数据表记录 currents 与规则文件记录 expects，规则文件为准
+// key 与元数据表唯一索引一致，value 为非唯一键规则内容，withID 以元数据表已存在记录 ID 生成待更新记录
+func diffRule[T any](rule string, currents, expects []T,
+	key func(T) string, value func(T) string, comment func(T) string, withID func(expect, current T) T) (creates, updates, deletes []T, changes []ruleChange) {
+	currentMap := make(map[string]T)
+	for _, c := range currents {
+		currentMap[key(c)] = c
+	}
+	expectMap := make(map[string]struct{})
+	for _, e := range expects {
+		k := key(e)
+		expectMap[k] = struct{}{}
+		c, ok := currentMap[k]
+		if !ok {
+			creates = append(creates, e)
+			changes = append(changes, ruleChange{Rule: rule, Action: ruleActionCreate, Object: k, After: value(e)})
+			continue
+		}
+		if value(c) != value(e) || comment(c) != comment(e) {
+			updates = append(updates, withID(e, c))
+			changes = append(changes, ruleChange{Rule: rule, Action: ruleActionUpdate, Object: k, Before: value(c), After: value(e)})
+		}
+	}
+	for _, c := range currents {
+		k := key(c)
+		if _, ok := expectMap[k]; !ok {
+			deletes = append(deletes, c)
+			changes = append(changes, ruleChange{Rule: rule, Action: ruleActionDelete, Object: k, Before: value(c)})
+		}
+	}
+	return creates, updates, deletes, changes
+}
+
+func diffRuleMeta(currents, expects *meta.RuleMeta) (*meta.RuleMetaSync, []ruleChange) {
+	var (
+		syncS   meta.RuleMetaSync
+		changes []ruleChange
+		change  []ruleChange
+	)
+	syncS.Creates.SchemaDatatypeRules, syncS.Updates.SchemaDatatypeRules, syncS.Deletes.SchemaDatatypeRules, change = diffRule(
+		"schema-datatype-rule", currents.SchemaDatatypeRules, expects.SchemaDatatypeRules,
+		func(r meta.SchemaDatatypeRule) string {
+			return common.StringsBuilder(r.SchemaNameS, " ", r.ColumnTypeS)
+		},
+		func(r meta.SchemaDatatypeRule) string { return r.ColumnTypeT },
+		func(r meta.SchemaDatatypeRule) string { return ruleComment(r.BaseModel) },
+		func(e, c meta.SchemaDatatypeRule) meta.SchemaDatatypeRule {
+			e.ID = c.ID
+			return e
+		})
+	changes = append(changes, change...)
+
+	syncS.Creates.TableDatatypeRules, syncS.Updates.TableDatatypeRules, syncS.Deletes.TableDatatypeRules, change = diffRule(
+		"table-datatype-rule", currents.TableDatatypeRules, expects.TableDatatypeRules,
+		func(r meta.TableDatatypeRule) string {
+			return common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS, " ", r.ColumnTypeS)
+		},
+		func(r meta.TableDatatypeRule) string { return r.ColumnTypeT },
+		func(r meta.TableDatatypeRule) string { return ruleComment(r.BaseModel) },
+		func(e, c meta.TableDatatypeRule) meta.TableDatatypeRule {
+			e.ID = c.ID
+			return e
+		})
+	changes = append(changes, change...)
+
+	syncS.Creates.ColumnDatatypeRules, syncS.Updates.ColumnDatatypeRules, syncS.Deletes.ColumnDatatypeRules, change = diffRule(
+		"column-datatype-rule", currents.ColumnDatatypeRules, expects.ColumnDatatypeRules,
+		func(r meta.ColumnDatatypeRule) string {
+			return common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS, ".", r.ColumnNameS)
+		},
+		func(r meta.ColumnDatatypeRule) string {
+			return common.StringsBuilder(r.ColumnTypeS, " -> ", r.ColumnTypeT)
+		},
+		func(r meta.ColumnDatatypeRule) string { return ruleComment(r.BaseModel) },
+		func(e, c meta.ColumnDatatypeRule) meta.ColumnDatatypeRule {
+			e.ID = c.ID
+			return e
+		})
+	changes = append(changes, change...)
+
+	syncS.Creates.TableNameRules, syncS.Updates.TableNameRules, syncS.Deletes.TableNameRules, change = diffRule(
+		"table-name-rule", currents.TableNameRules, expects.TableNameRules,
+		func(r meta.TableNameRule) string {
+			return common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS)
+		},
+		func(r meta.TableNameRule) string { return common.StringsBuilder(r.SchemaNameT, ".", r.TableNameT) },
+		func(r meta.TableNameRule) string { return ruleComment(r.BaseModel) },
+		func(e, c meta.TableNameRule) meta.TableNameRule {
+			e.ID = c.ID
+			return e
+		})
+	changes = append(changes, change...)
+
+	syncS.Creates.ColumnDefaultvals, syncS.Updates.ColumnDefaultvals, syncS.Deletes.ColumnDefaultvals, change = diffRule(
+		"column-defaultval", currents.ColumnDefaultvals, expects.ColumnDefaultvals,
+		func(r meta.BuildinColumnDefaultval) string {
+			return common.StringsBuilder(r.SchemaNameS, ".", r.TableNameS, ".", r.ColumnNameS)
+		},
+		func(r meta.BuildinColumnDefaultval) string {
+			return common.StringsBuilder(r.DefaultValueS, " -> ", r.DefaultValueT)
+		},
+		func(r meta.BuildinColumnDefaultval) string { return ruleComment(r.BaseModel) },
+		func(e, c meta.BuildinColumnDefaultval) meta.BuildinColumnDefaultval {
+			e.ID = c.ID
+			return e
+		})
+	changes = append(changes, change...)
+
+	syncS.Creates.GlobalDefaultvals, syncS.Updates.GlobalDefaultvals, syncS.Deletes.GlobalDefaultvals, change = diffRule(
+		"global-defaultval", currents.GlobalDefaultvals, expects.GlobalDefaultvals,
+		func(r meta.BuildinGlobalDefaultval) string { return r.DefaultValueS },
+		func(r meta.BuildinGlobalDefaultval) string { return r.DefaultValueT },
+		func(r meta.BuildinGlobalDefaultval) string { return ruleComment(r.BaseModel) },
+		func(e, c meta.BuildinGlobalDefaultval) meta.BuildinGlobalDefaultval {
+			e.ID = c.ID
+			return e
+		})
+	changes = append(changes, change...)
+
+	return &syncS, changes
+}
+
+func printRuleChanges(changes []ruleChange) {
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.SetOutputMirror(os.Stdout)
+	sw.AppendHeader(table.Row{"RULE", "ACTION", "OBJECT", "BEFORE", "AFTER"})
+	for _, c := range changes {
+		sw.AppendRow(table.Row{c.Rule, c.Action, c.Object, c.Before, c.After})
+	}
+	sw.Render()
+}
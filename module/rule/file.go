@@ -0,0 +1,301 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"gopkg.in/yaml.v3"
+)
+
+// 规则文件，文件格式按扩展名区分 yaml/toml，对应元数据表
+// schema-datatype-rule -> schema_datatype_rule
+// table-datatype-rule -> table_datatype_rule
+// column-datatype-rule -> column_datatype_rule
+// table-name-rule -> table_name_rule
+// column-defaultval -> buildin_column_defaultval
+// global-defaultval -> buildin_global_defaultval
+type File struct {
+	SchemaDatatypeRules []SchemaDatatypeRule `toml:"schema-datatype-rule,omitempty" yaml:"schema-datatype-rule,omitempty"`
+	TableDatatypeRules  []TableDatatypeRule  `toml:"table-datatype-rule,omitempty" yaml:"table-datatype-rule,omitempty"`
+	ColumnDatatypeRules []ColumnDatatypeRule `toml:"column-datatype-rule,omitempty" yaml:"column-datatype-rule,omitempty"`
+	TableNameRules      []TableNameRule      `toml:"table-name-rule,omitempty" yaml:"table-name-rule,omitempty"`
+	ColumnDefaultvals   []ColumnDefaultval   `toml:"column-defaultval,omitempty" yaml:"column-defaultval,omitempty"`
+	GlobalDefaultvals   []GlobalDefaultval   `toml:"global-defaultval,omitempty" yaml:"global-defaultval,omitempty"`
+}
+
+type SchemaDatatypeRule struct {
+	SourceSchema string `toml:"source-schema" yaml:"source-schema"`
+	SourceType   string `toml:"source-type" yaml:"source-type"`
+	TargetType   string `toml:"target-type" yaml:"target-type"`
+	Comment      string `toml:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+type TableDatatypeRule struct {
+	SourceSchema string `toml:"source-schema" yaml:"source-schema"`
+	SourceTable  string `toml:"source-table" yaml:"source-table"`
+	SourceType   string `toml:"source-type" yaml:"source-type"`
+	TargetType   string `toml:"target-type" yaml:"target-type"`
+	Comment      string `toml:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+type ColumnDatatypeRule struct {
+	SourceSchema string `toml:"source-schema" yaml:"source-schema"`
+	SourceTable  string `toml:"source-table" yaml:"source-table"`
+	SourceColumn string `toml:"source-column" yaml:"source-column"`
+	SourceType   string `toml:"source-type" yaml:"source-type"`
+	TargetType   string `toml:"target-type" yaml:"target-type"`
+	Comment      string `toml:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+type TableNameRule struct {
+	SourceSchema string `toml:"source-schema" yaml:"source-schema"`
+	SourceTable  string `toml:"source-table" yaml:"source-table"`
+	TargetSchema string `toml:"target-schema,omitempty" yaml:"target-schema,omitempty"`
+	TargetTable  string `toml:"target-table" yaml:"target-table"`
+	Comment      string `toml:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+type ColumnDefaultval struct {
+	SourceSchema  string `toml:"source-schema" yaml:"source-schema"`
+	SourceTable   string `toml:"source-table" yaml:"source-table"`
+	SourceColumn  string `toml:"source-column" yaml:"source-column"`
+	SourceDefault string `toml:"source-default" yaml:"source-default"`
+	TargetDefault string `toml:"target-default" yaml:"target-default"`
+	Comment       string `toml:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+type GlobalDefaultval struct {
+	SourceDefault string `toml:"source-default" yaml:"source-default"`
+	TargetDefault string `toml:"target-default" yaml:"target-default"`
+	Comment       string `toml:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+func isYAMLFile(file string) (bool, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return true, nil
+	case ".toml":
+		return false, nil
+	default:
+		return false, fmt.Errorf("rule file [%s] extension isn't support, support extension [.yaml .yml .toml]", file)
+	}
+}
+
+func ReadFile(file string) (*File, error) {
+	isYAML, err := isYAMLFile(file)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read rule file [%s] failed: %v", file, err)
+	}
+	f := &File{}
+	if isYAML {
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed decode yaml rule file [%s]: %v", file, err)
+		}
+		return f, nil
+	}
+	md, err := toml.Decode(string(content), f)
+	if err != nil {
+		return nil, fmt.Errorf("failed decode toml rule file [%s]: %v", file, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("toml rule file [%s] contains unknown keys %v", file, undecoded)
+	}
+	return f, nil
+}
+
+func WriteFile(file string, f *File) error {
+	isYAML, err := isYAMLFile(file)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if isYAML {
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err = encoder.Encode(f); err != nil {
+			return fmt.Errorf("failed encode yaml rule file [%s]: %v", file, err)
+		}
+		if err = encoder.Close(); err != nil {
+			return fmt.Errorf("failed encode yaml rule file [%s]: %v", file, err)
+		}
+	} else {
+		if err = toml.NewEncoder(&buf).Encode(f); err != nil {
+			return fmt.Errorf("failed encode toml rule file [%s]: %v", file, err)
+		}
+	}
+	if err = os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write rule file [%s] failed: %v", file, err)
+	}
+	return nil
+}
+
+// 规则文件转换元数据表记录，schema、表、字段名以及源端类型统一大写，与元数据表查询规则一致
+// 表名规则 target-schema 未配置，以 schema 任务配置目标端 schema 为准
+func (f *File) ToRuleMeta(dbTypeS, dbTypeT string, targetSchemas map[string]string) *meta.RuleMeta {
+	var rules meta.RuleMeta
+	for _, r := range f.SchemaDatatypeRules {
+		rules.SchemaDatatypeRules = append(rules.SchemaDatatypeRules, meta.SchemaDatatypeRule{
+			DBTypeS:     dbTypeS,
+			DBTypeT:     dbTypeT,
+			SchemaNameS: common.StringUPPER(r.SourceSchema),
+			ColumnTypeS: common.StringUPPER(r.SourceType),
+			ColumnTypeT: strings.TrimSpace(r.TargetType),
+			BaseModel:   &meta.BaseModel{Comment: r.Comment},
+		})
+	}
+	for _, r := range f.TableDatatypeRules {
+		rules.TableDatatypeRules = append(rules.TableDatatypeRules, meta.TableDatatypeRule{
+			DBTypeS:     dbTypeS,
+			DBTypeT:     dbTypeT,
+			SchemaNameS: common.StringUPPER(r.SourceSchema),
+			TableNameS:  common.StringUPPER(r.SourceTable),
+			ColumnTypeS: common.StringUPPER(r.SourceType),
+			ColumnTypeT: strings.TrimSpace(r.TargetType),
+			BaseModel:   &meta.BaseModel{Comment: r.Comment},
+		})
+	}
+	for _, r := range f.ColumnDatatypeRules {
+		rules.ColumnDatatypeRules = append(rules.ColumnDatatypeRules, meta.ColumnDatatypeRule{
+			DBTypeS:     dbTypeS,
+			DBTypeT:     dbTypeT,
+			SchemaNameS: common.StringUPPER(r.SourceSchema),
+			TableNameS:  common.StringUPPER(r.SourceTable),
+			ColumnNameS: common.StringUPPER(r.SourceColumn),
+			ColumnTypeS: common.StringUPPER(r.SourceType),
+			ColumnTypeT: strings.TrimSpace(r.TargetType),
+			BaseModel:   &meta.BaseModel{Comment: r.Comment},
+		})
+	}
+	for _, r := range f.TableNameRules {
+		schemaNameT := common.StringUPPER(r.TargetSchema)
+		if schemaNameT == "" {
+			schemaNameT = targetSchemas[common.StringUPPER(r.SourceSchema)]
+		}
+		rules.TableNameRules = append(rules.TableNameRules, meta.TableNameRule{
+			DBTypeS:     dbTypeS,
+			DBTypeT:     dbTypeT,
+			SchemaNameS: common.StringUPPER(r.SourceSchema),
+			TableNameS:  common.StringUPPER(r.SourceTable),
+			SchemaNameT: schemaNameT,
+			TableNameT:  strings.TrimSpace(r.TargetTable),
+			BaseModel:   &meta.BaseModel{Comment: r.Comment},
+		})
+	}
+	for _, r := range f.ColumnDefaultvals {
+		rules.ColumnDefaultvals = append(rules.ColumnDefaultvals, meta.BuildinColumnDefaultval{
+			DBTypeS:       dbTypeS,
+			DBTypeT:       dbTypeT,
+			SchemaNameS:   common.StringUPPER(r.SourceSchema),
+			TableNameS:    common.StringUPPER(r.SourceTable),
+			ColumnNameS:   common.StringUPPER(r.SourceColumn),
+			DefaultValueS: r.SourceDefault,
+			DefaultValueT: r.TargetDefault,
+			BaseModel:     &meta.BaseModel{Comment: r.Comment},
+		})
+	}
+	for _, r := range f.GlobalDefaultvals {
+		rules.GlobalDefaultvals = append(rules.GlobalDefaultvals, meta.BuildinGlobalDefaultval{
+			DBTypeS:       dbTypeS,
+			DBTypeT:       dbTypeT,
+			DefaultValueS: r.SourceDefault,
+			DefaultValueT: r.TargetDefault,
+			BaseModel:     &meta.BaseModel{Comment: r.Comment},
+		})
+	}
+	return &rules
+}
+
+// 元数据表记录转换规则文件
+func NewFile(rules *meta.RuleMeta) *File {
+	f := &File{}
+	for _, r := range rules.SchemaDatatypeRules {
+		f.SchemaDatatypeRules = append(f.SchemaDatatypeRules, SchemaDatatypeRule{
+			SourceSchema: r.SchemaNameS,
+			SourceType:   r.ColumnTypeS,
+			TargetType:   r.ColumnTypeT,
+			Comment:      ruleComment(r.BaseModel),
+		})
+	}
+	for _, r := range rules.TableDatatypeRules {
+		f.TableDatatypeRules = append(f.TableDatatypeRules, TableDatatypeRule{
+			SourceSchema: r.SchemaNameS,
+			SourceTable:  r.TableNameS,
+			SourceType:   r.ColumnTypeS,
+			TargetType:   r.ColumnTypeT,
+			Comment:      ruleComment(r.BaseModel),
+		})
+	}
+	for _, r := range rules.ColumnDatatypeRules {
+		f.ColumnDatatypeRules = append(f.ColumnDatatypeRules, ColumnDatatypeRule{
+			SourceSchema: r.SchemaNameS,
+			SourceTable:  r.TableNameS,
+			SourceColumn: r.ColumnNameS,
+			SourceType:   r.ColumnTypeS,
+			TargetType:   r.ColumnTypeT,
+			Comment:      ruleComment(r.BaseModel),
+		})
+	}
+	for _, r := range rules.TableNameRules {
+		f.TableNameRules = append(f.TableNameRules, TableNameRule{
+			SourceSchema: r.SchemaNameS,
+			SourceTable:  r.TableNameS,
+			TargetSchema: r.SchemaNameT,
+			TargetTable:  r.TableNameT,
+			Comment:      ruleComment(r.BaseModel),
+		})
+	}
+	for _, r := range rules.ColumnDefaultvals {
+		f.ColumnDefaultvals = append(f.ColumnDefaultvals, ColumnDefaultval{
+			SourceSchema:  r.SchemaNameS,
+			SourceTable:   r.TableNameS,
+			SourceColumn:  r.ColumnNameS,
+			SourceDefault: r.DefaultValueS,
+			TargetDefault: r.DefaultValueT,
+			Comment:       ruleComment(r.BaseModel),
+		})
+	}
+	for _, r := range rules.GlobalDefaultvals {
+		f.GlobalDefaultvals = append(f.GlobalDefaultvals, GlobalDefaultval{
+			SourceDefault: r.DefaultValueS,
+			TargetDefault: r.DefaultValueT,
+			Comment:       ruleComment(r.BaseModel),
+		})
+	}
+	return f
+}
+
+func ruleComment(b *meta.BaseModel) string {
+	if b == nil {
+		return ""
+	}
+	return b.Comment
+}
@@ -0,0 +1,307 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"go.uber.org/zap"
+
+	_ "github.com/pingcap/tidb/types/parser_driver"
+)
+
+// 规则元数据运维（-mode rule），替代手工 SQL 写入自定义规则元数据表
+// export 导出 schema 任务配置范围内规则至规则文件
+// diff 校验规则文件并输出与元数据表差异，不写入元数据表
+// import 校验规则文件并输出差异后，同一事务同步至元数据表，规则文件未包含的规则记录将被删除
+type Rule struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	MetaDB *meta.Meta
+}
+
+func NewRule(ctx context.Context, cfg *config.Config) (*Rule, error) {
+	if !common.IsContainString([]string{common.TaskActionExport, common.TaskActionDiff, common.TaskActionImport}, cfg.Action) {
+		return nil, fmt.Errorf("flag [action] value [%s] isn't support for mode [%s], support action [export diff import]", cfg.Action, cfg.TaskMode)
+	}
+	if cfg.RuleFile == "" {
+		return nil, fmt.Errorf("flag [rule-file] can not null for mode [%s]", cfg.TaskMode)
+	}
+	for _, s := range cfg.SchemaConfigs() {
+		if s.SourceSchema == "" {
+			return nil, fmt.Errorf("schema config source-schema can not null for mode [%s]", cfg.TaskMode)
+		}
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		MetaDB: metaDB,
+	}, nil
+}
+
+func (r *Rule) Rule() error {
+	startTime := time.Now()
+	zap.L().Info("rule meta action start",
+		zap.Strings("schemas", r.sourceSchemas()),
+		zap.String("rule file", r.Cfg.RuleFile),
+		zap.String("action", r.Cfg.Action))
+
+	currents, err := meta.NewCommonModel(r.MetaDB).DetailRuleMeta(r.Ctx, r.Cfg.DBTypeS, r.Cfg.DBTypeT, r.sourceSchemas())
+	if err != nil {
+		return err
+	}
+
+	switch r.Cfg.Action {
+	case common.TaskActionExport:
+		if err = WriteFile(r.Cfg.RuleFile, NewFile(currents)); err != nil {
+			return err
+		}
+		zap.L().Info("export rules to rule file success",
+			zap.String("rule file", r.Cfg.RuleFile))
+	case common.TaskActionDiff, common.TaskActionImport:
+		if err = r.sync(currents); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("rule meta action finished",
+		zap.Strings("schemas", r.sourceSchemas()),
+		zap.String("rule file", r.Cfg.RuleFile),
+		zap.String("action", r.Cfg.Action),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (r *Rule) sync(currents *meta.RuleMeta) error {
+	f, err := ReadFile(r.Cfg.RuleFile)
+	if err != nil {
+		return err
+	}
+	if err = r.validate(f); err != nil {
+		return err
+	}
+
+	targetSchemas := make(map[string]string)
+	for _, s := range r.Cfg.SchemaConfigs() {
+		if s.TargetSchema != "" {
+			targetSchemas[s.SourceSchema] = s.TargetSchema
+		} else {
+			targetSchemas[s.SourceSchema] = s.SourceSchema
+		}
+	}
+	syncS, changes := diffRuleMeta(currents, f.ToRuleMeta(r.Cfg.DBTypeS, r.Cfg.DBTypeT, targetSchemas))
+	if len(changes) == 0 {
+		zap.L().Info("rule file is consistent with meta tables, nothing to do",
+			zap.String("rule file", r.Cfg.RuleFile),
+			zap.String("action", r.Cfg.Action))
+		return nil
+	}
+	printRuleChanges(changes)
+
+	if r.Cfg.Action == common.TaskActionDiff {
+		zap.L().Warn("there are rule changes, please run [-mode rule -action import] to apply",
+			zap.String("rule file", r.Cfg.RuleFile),
+			zap.Int("changes", len(changes)))
+		return nil
+	}
+	if err = meta.NewCommonModel(r.MetaDB).SyncRuleMeta(r.Ctx, syncS); err != nil {
+		return err
+	}
+	zap.L().Info("import rule changes from rule file success",
+		zap.String("rule file", r.Cfg.RuleFile),
+		zap.Int("changes", len(changes)))
+	return nil
+}
+
+// 规则文件校验，错误统一输出
+// 1、source-schema 必须为 schema 任务配置范围内 schema
+// 2、source-table、source-column 必须为源端存在的表以及字段
+// 3、target-type 必须为目标端可解析的字段类型
+// 4、规则唯一键不允许重复
+func (r *Rule) validate(f *File) error {
+	var (
+		errMsgs []string
+		keys    = make(map[string]struct{})
+	)
+	addErr := func(rule string, idx int, format string, a ...interface{}) {
+		errMsgs = append(errMsgs, fmt.Sprintf("%s #%d: %s", rule, idx+1, fmt.Sprintf(format, a...)))
+	}
+	isDuplicate := func(rule string, key ...string) bool {
+		k := common.StringsBuilder(rule, " ", strings.Join(key, "."))
+		if _, ok := keys[k]; ok {
+			return true
+		}
+		keys[k] = struct{}{}
+		return false
+	}
+
+	tableColumns, err := r.sourceTableColumns(f)
+	if err != nil {
+		return err
+	}
+	checkObject := func(rule string, idx int, schemaName, tableName, columnName string) bool {
+		columns, ok := tableColumns[common.StringUPPER(schemaName)]
+		if !ok {
+			addErr(rule, idx, "source-schema [%s] isn't in schema config", schemaName)
+			return false
+		}
+		if tableName == "" && columnName == "" {
+			return true
+		}
+		if _, ok = columns[common.StringUPPER(tableName)]; !ok {
+			addErr(rule, idx, "source-table [%s.%s] isn't exist in source database", schemaName, tableName)
+			return false
+		}
+		if columnName == "" {
+			return true
+		}
+		if !columns[common.StringUPPER(tableName)][common.StringUPPER(columnName)] {
+			addErr(rule, idx, "source-column [%s.%s.%s] isn't exist in source database", schemaName, tableName, columnName)
+			return false
+		}
+		return true
+	}
+
+	p := parser.New()
+	checkType := func(rule string, idx int, sourceType, targetType string) {
+		if strings.TrimSpace(sourceType) == "" {
+			addErr(rule, idx, "source-type can not null")
+		}
+		if err := validTargetType(p, targetType); err != nil {
+			addErr(rule, idx, "%v", err)
+		}
+	}
+
+	for i, c := range f.SchemaDatatypeRules {
+		checkObject("schema-datatype-rule", i, c.SourceSchema, "", "")
+		checkType("schema-datatype-rule", i, c.SourceType, c.TargetType)
+		if isDuplicate("schema-datatype-rule", common.StringUPPER(c.SourceSchema), common.StringUPPER(c.SourceType)) {
+			addErr("schema-datatype-rule", i, "source-schema [%s] source-type [%s] is duplicate", c.SourceSchema, c.SourceType)
+		}
+	}
+	for i, c := range f.TableDatatypeRules {
+		checkObject("table-datatype-rule", i, c.SourceSchema, c.SourceTable, "")
+		checkType("table-datatype-rule", i, c.SourceType, c.TargetType)
+		if isDuplicate("table-datatype-rule", common.StringUPPER(c.SourceSchema), common.StringUPPER(c.SourceTable), common.StringUPPER(c.SourceType)) {
+			addErr("table-datatype-rule", i, "source-table [%s.%s] source-type [%s] is duplicate", c.SourceSchema, c.SourceTable, c.SourceType)
+		}
+	}
+	for i, c := range f.ColumnDatatypeRules {
+		checkObject("column-datatype-rule", i, c.SourceSchema, c.SourceTable, c.SourceColumn)
+		checkType("column-datatype-rule", i, c.SourceType, c.TargetType)
+		if isDuplicate("column-datatype-rule", common.StringUPPER(c.SourceSchema), common.StringUPPER(c.SourceTable), common.StringUPPER(c.SourceColumn)) {
+			addErr("column-datatype-rule", i, "source-column [%s.%s.%s] is duplicate", c.SourceSchema, c.SourceTable, c.SourceColumn)
+		}
+	}
+	for i, c := range f.TableNameRules {
+		checkObject("table-name-rule", i, c.SourceSchema, c.SourceTable, "")
+		if strings.TrimSpace(c.TargetTable) == "" {
+			addErr("table-name-rule", i, "target-table can not null")
+		}
+		if isDuplicate("table-name-rule", common.StringUPPER(c.SourceSchema), common.StringUPPER(c.SourceTable)) {
+			addErr("table-name-rule", i, "source-table [%s.%s] is duplicate", c.SourceSchema, c.SourceTable)
+		}
+	}
+	for i, c := range f.ColumnDefaultvals {
+		checkObject("column-defaultval", i, c.SourceSchema, c.SourceTable, c.SourceColumn)
+		if isDuplicate("column-defaultval", common.StringUPPER(c.SourceSchema), common.StringUPPER(c.SourceTable), common.StringUPPER(c.SourceColumn)) {
+			addErr("column-defaultval", i, "source-column [%s.%s.%s] is duplicate", c.SourceSchema, c.SourceTable, c.SourceColumn)
+		}
+	}
+	for i, c := range f.GlobalDefaultvals {
+		if strings.TrimSpace(c.SourceDefault) == "" {
+			addErr("global-defaultval", i, "source-default can not null")
+		}
+		if isDuplicate("global-defaultval", c.SourceDefault) {
+			addErr("global-defaultval", i, "source-default [%s] is duplicate", c.SourceDefault)
+		}
+	}
+
+	if len(errMsgs) > 0 {
+		for _, e := range errMsgs {
+			zap.L().Error("rule file validate failed",
+				zap.String("rule file", r.Cfg.RuleFile),
+				zap.String("error", e))
+		}
+		return fmt.Errorf("rule file [%s] validate failed, there are [%d] errors", r.Cfg.RuleFile, len(errMsgs))
+	}
+	return nil
+}
+
+// 规则文件引用表、字段的源端 schema 数据字典，schema -> table -> column
+// 仅规则文件引用表、字段时连接源端数据库
+func (r *Rule) sourceTableColumns(f *File) (map[string]map[string]map[string]bool, error) {
+	tableColumns := make(map[string]map[string]map[string]bool)
+	for _, s := range r.sourceSchemas() {
+		tableColumns[s] = make(map[string]map[string]bool)
+	}
+	if len(f.TableDatatypeRules) == 0 && len(f.ColumnDatatypeRules) == 0 && len(f.TableNameRules) == 0 && len(f.ColumnDefaultvals) == 0 {
+		return tableColumns, nil
+	}
+
+	oracleDB, err := oracle.NewOracleDBEngine(r.Ctx, r.Cfg.OracleConfig, r.Cfg.SchemaConfigs()[0].SourceSchema)
+	if err != nil {
+		return tableColumns, err
+	}
+	for _, s := range r.sourceSchemas() {
+		columns, err := oracleDB.GetOracleSchemaTableColumnName(s)
+		if err != nil {
+			return tableColumns, err
+		}
+		tableColumns[s] = columns
+	}
+	return tableColumns, nil
+}
+
+func (r *Rule) sourceSchemas() []string {
+	var schemas []string
+	for _, s := range r.Cfg.SchemaConfigs() {
+		schemas = append(schemas, s.SourceSchema)
+	}
+	return schemas
+}
+
+// 目标端字段类型校验，以单字段建表语句解析
+func validTargetType(p *parser.Parser, targetType string) error {
+	if strings.TrimSpace(targetType) == "" {
+		return fmt.Errorf("target-type can not null")
+	}
+	stmts, _, err := p.Parse(fmt.Sprintf("CREATE TABLE t (c %s)", targetType), "", "")
+	if err != nil {
+		return fmt.Errorf("target-type [%s] is invalid: %v", targetType, err)
+	}
+	if len(stmts) != 1 {
+		return fmt.Errorf("target-type [%s] is invalid", targetType)
+	}
+	stmt, ok := stmts[0].(*ast.CreateTableStmt)
+	if !ok || len(stmt.Cols) != 1 || len(stmt.Constraints) > 0 || stmt.Partition != nil || len(stmt.Options) > 0 {
+		return fmt.Errorf("target-type [%s] is invalid, only support single column datatype", targetType)
+	}
+	return nil
+}
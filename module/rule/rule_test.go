@@ -0,0 +1,55 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rule
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/parser"
+)
+
+func TestValidTargetType(t *testing.T) {
+	p := parser.New()
+
+	for _, targetType := range []string{
+		"INT",
+		"DECIMAL(38,10)",
+		"VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+		"datetime(6)",
+		"ENUM('a','b')",
+		"BIGINT UNSIGNED NOT NULL DEFAULT 0",
+	} {
+		if err := validTargetType(p, targetType); err != nil {
+			t.Errorf("validTargetType(%q) error = %v", targetType, err)
+		}
+	}
+
+	// 非法类型以及借由类型拼接额外字段、约束、表选项或多语句均需拒绝
+	for _, targetType := range []string{
+		"",
+		"  ",
+		"VARCHAR2(10)",
+		"VARCHAR(",
+		"INT, d INT",
+		"INT, PRIMARY KEY (c)",
+		"INT) ENGINE=InnoDB; -- (",
+		"INT); DROP TABLE t; CREATE TABLE t2 (c INT",
+	} {
+		if err := validTargetType(p, targetType); err == nil {
+			t.Errorf("validTargetType(%q) should fail", targetType)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package task
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"time"
+)
+
+// chunk 任务进度，基于已成功 chunk 平均耗时估算剩余 chunk 执行时间
+type chunkProgress struct {
+	total      int64
+	success    int64
+	failed     int64
+	waiting    int64
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+func newChunkProgress(w meta.WaitSyncMeta, statusCounts []meta.TableStatusCounts) chunkProgress {
+	p := chunkProgress{}
+	if w.ChunkTotalNums > 0 {
+		p.total = w.ChunkTotalNums
+	}
+	// 表任务成功后 chunk 元数据记录已清理，以 wait_sync_meta 统计为准
+	if len(statusCounts) == 0 {
+		p.success = w.ChunkSuccessNums
+		p.failed = w.ChunkFailedNums
+		if w.TaskStatus == common.TaskStatusSuccess && w.BaseModel != nil {
+			p.startedAt = w.CreatedAt
+			p.finishedAt = w.UpdatedAt
+		}
+		return p
+	}
+	for _, c := range statusCounts {
+		switch c.TaskStatus {
+		case common.TaskStatusSuccess:
+			p.success += c.Counts
+			if c.FinishedAt.After(p.finishedAt) {
+				p.finishedAt = c.FinishedAt.Time
+			}
+		case common.TaskStatusFailed:
+			p.failed += c.Counts
+		default:
+			p.waiting += c.Counts
+		}
+		if p.startedAt.IsZero() || c.StartedAt.Before(p.startedAt) {
+			p.startedAt = c.StartedAt.Time
+		}
+	}
+	return p
+}
+
+func (p *chunkProgress) merge(o chunkProgress) {
+	p.total += o.total
+	p.success += o.success
+	p.failed += o.failed
+	p.waiting += o.waiting
+	if !o.startedAt.IsZero() && (p.startedAt.IsZero() || o.startedAt.Before(p.startedAt)) {
+		p.startedAt = o.startedAt
+	}
+	if o.finishedAt.After(p.finishedAt) {
+		p.finishedAt = o.finishedAt
+	}
+}
+
+func (p chunkProgress) elapsed() time.Duration {
+	if p.startedAt.IsZero() || p.finishedAt.Before(p.startedAt) {
+		return 0
+	}
+	return p.finishedAt.Sub(p.startedAt)
+}
+
+// 剩余时间 = 已耗时 / 成功 chunk 数 * 未执行 chunk 数，失败 chunk 需 retry 不计入
+func (p chunkProgress) eta() (time.Duration, bool) {
+	if p.total <= 0 || p.success <= 0 {
+		return 0, false
+	}
+	remaining := p.total - p.success - p.failed
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(float64(p.elapsed()) / float64(p.success) * float64(remaining)), true
+}
+
+func (p chunkProgress) totalString() string {
+	if p.total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", p.total)
+}
+
+func (p chunkProgress) progressString() string {
+	if p.total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(p.success)/float64(p.total)*100)
+}
+
+func (p chunkProgress) elapsedString() string {
+	if p.elapsed() == 0 {
+		return "-"
+	}
+	return p.elapsed().Round(time.Second).String()
+}
+
+func (p chunkProgress) etaString() string {
+	d, ok := p.eta()
+	if !ok {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// chunk 执行耗时，仅成功 chunk 有效
+func chunkCost(taskStatus string, b *meta.BaseModel) string {
+	if taskStatus != common.TaskStatusSuccess || b == nil {
+		return "-"
+	}
+	return b.UpdatedAt.Sub(b.CreatedAt).Round(time.Millisecond).String()
+}
@@ -0,0 +1,529 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"go.uber.org/zap"
+	"os"
+	"strings"
+	"time"
+)
+
+// 任务元数据运维（-mode task），替代手工修改元数据表
+// status 查看表、chunk 级别任务进度，基于 chunk 执行耗时估算剩余时间
+// retry 失败表失败 chunk 重试，重新运行断点续传
+// reset 重置指定表或者整个任务元数据，并清理目标端表数据，重新运行从头迁移
+// list 列出元数据库全部任务名以及任务进度
+// remove 删除指定任务名全部元数据记录
+type Task struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	MetaDB *meta.Meta
+}
+
+func NewTask(ctx context.Context, cfg *config.Config) (*Task, error) {
+	// list、remove 跨任务操作，元数据库不做任务隔离
+	if common.IsContainString([]string{common.TaskActionList, common.TaskActionRemove}, cfg.Action) {
+		metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, "", cfg.AppConfig.SlowlogThreshold)
+		if err != nil {
+			return nil, err
+		}
+		return &Task{
+			Ctx:    ctx,
+			Cfg:    cfg,
+			MetaDB: metaDB,
+		}, nil
+	}
+	if !common.IsContainString([]string{common.TaskModeFull, common.TaskModeCSV, common.TaskModeAll, common.TaskModeCompare}, cfg.ActionMode) {
+		return nil, fmt.Errorf("flag [task-mode] value [%s] isn't support, support task mode [full csv all compare]", cfg.ActionMode)
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &Task{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		MetaDB: metaDB,
+	}, nil
+}
+
+func (t *Task) Task() error {
+	switch t.Cfg.Action {
+	case common.TaskActionList:
+		return t.list()
+	case common.TaskActionRemove:
+		return t.remove()
+	}
+
+	startTime := time.Now()
+	zap.L().Info("task meta action start",
+		zap.String("task name", t.Cfg.AppConfig.TaskName),
+		zap.String("schema", t.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", t.Cfg.TableName),
+		zap.String("task mode", t.Cfg.ActionMode),
+		zap.String("action", t.Cfg.Action))
+
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(t.MetaDB).DetailWaitSyncMeta(t.Ctx, &meta.WaitSyncMeta{
+		DBTypeS:     t.Cfg.DBTypeS,
+		DBTypeT:     t.Cfg.DBTypeT,
+		SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  t.Cfg.TableName,
+		TaskMode:    t.Cfg.ActionMode,
+	})
+	if err != nil {
+		return err
+	}
+	if t.Cfg.TableName != "" && len(waitSyncMetas) == 0 {
+		return fmt.Errorf("schema [%s] table [%s] task mode [%s] isn't exist in meta table [wait_sync_meta]",
+			t.Cfg.SchemaConfig.SourceSchema, t.Cfg.TableName, t.Cfg.ActionMode)
+	}
+
+	switch t.Cfg.Action {
+	case common.TaskActionStatus, "":
+		if err = t.status(waitSyncMetas); err != nil {
+			return err
+		}
+	case common.TaskActionRetry:
+		if err = t.retry(waitSyncMetas); err != nil {
+			return err
+		}
+	case common.TaskActionReset:
+		if err = t.reset(waitSyncMetas); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("flag [action] value [%s] isn't support for mode [%s], support action [status retry reset list remove]", t.Cfg.Action, t.Cfg.TaskMode)
+	}
+
+	zap.L().Info("task meta action finished",
+		zap.String("schema", t.Cfg.SchemaConfig.SourceSchema),
+		zap.String("table", t.Cfg.TableName),
+		zap.String("task mode", t.Cfg.ActionMode),
+		zap.String("action", t.Cfg.Action),
+		zap.Int("table totals", len(waitSyncMetas)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (t *Task) status(waitSyncMetas []meta.WaitSyncMeta) error {
+	var (
+		statusCounts []meta.TableStatusCounts
+		err          error
+	)
+	if strings.EqualFold(t.Cfg.ActionMode, common.TaskModeCompare) {
+		statusCounts, err = meta.NewDataCompareMetaModel(t.MetaDB).CountsDataCompareMetaGroupByTableStatus(t.Ctx, &meta.DataCompareMeta{
+			DBTypeS:     t.Cfg.DBTypeS,
+			DBTypeT:     t.Cfg.DBTypeT,
+			SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+			TaskMode:    t.Cfg.ActionMode,
+		})
+	} else {
+		statusCounts, err = meta.NewFullSyncMetaModel(t.MetaDB).CountsFullSyncMetaGroupByTableStatus(t.Ctx, &meta.FullSyncMeta{
+			DBTypeS:     t.Cfg.DBTypeS,
+			DBTypeT:     t.Cfg.DBTypeT,
+			SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+			TaskMode:    t.Cfg.ActionMode,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	tableCounts := make(map[string][]meta.TableStatusCounts)
+	for _, c := range statusCounts {
+		tableCounts[c.TableNameS] = append(tableCounts[c.TableNameS], c)
+	}
+
+	var (
+		taskProgress                             chunkProgress
+		successTables, failedTables, splitTables int
+	)
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.SetOutputMirror(os.Stdout)
+	sw.AppendHeader(table.Row{"SOURCE TABLE", "STATUS", "CHUNK TOTAL", "SUCCESS", "FAILED", "WAITING", "PROGRESS", "ELAPSED", "ETA"})
+	for _, w := range waitSyncMetas {
+		p := newChunkProgress(w, tableCounts[w.TableNameS])
+		switch w.TaskStatus {
+		case common.TaskStatusSuccess:
+			successTables++
+		case common.TaskStatusFailed:
+			failedTables++
+		}
+		if p.total > 0 {
+			taskProgress.merge(p)
+		} else {
+			splitTables++
+		}
+		sw.AppendRow(table.Row{
+			common.StringsBuilder(w.SchemaNameS, ".", w.TableNameS),
+			w.TaskStatus,
+			p.totalString(),
+			p.success,
+			p.failed,
+			p.waiting,
+			p.progressString(),
+			p.elapsedString(),
+			p.etaString(),
+		})
+	}
+	sw.AppendFooter(table.Row{
+		"TASK " + t.Cfg.ActionMode,
+		fmt.Sprintf("%d/%d SUCCESS", successTables, len(waitSyncMetas)),
+		taskProgress.totalString(),
+		taskProgress.success,
+		taskProgress.failed,
+		taskProgress.waiting,
+		taskProgress.progressString(),
+		taskProgress.elapsedString(),
+		taskProgress.etaString(),
+	})
+	sw.Render()
+
+	if splitTables > 0 {
+		zap.L().Warn("there are tables not split chunk, not included in task progress and eta",
+			zap.String("task name", t.Cfg.AppConfig.TaskName),
+			zap.Int("tables", splitTables))
+	}
+	if failedTables > 0 {
+		zap.L().Warn("there are tables failed, please check log and chunk error, then run task action retry or reset",
+			zap.String("task name", t.Cfg.AppConfig.TaskName),
+			zap.Int("tables", failedTables),
+			zap.String("retry", fmt.Sprintf("-mode task -task-mode %s -action retry", strings.ToLower(t.Cfg.ActionMode))))
+	}
+
+	// 指定表输出 chunk 详情
+	if t.Cfg.TableName != "" {
+		return t.chunkStatus()
+	}
+	return nil
+}
+
+func (t *Task) list() error {
+	taskMetas, err := meta.NewCommonModel(t.MetaDB).ListTaskMeta(t.Ctx, common.TaskStatusSuccess, common.TaskStatusFailed)
+	if err != nil {
+		return err
+	}
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.SetOutputMirror(os.Stdout)
+	sw.AppendHeader(table.Row{"TASK NAME", "SOURCE", "TARGET", "SCHEMA", "TASK MODE", "TABLE TOTAL", "SUCCESS", "FAILED", "UPDATED AT"})
+	for _, m := range taskMetas {
+		// 仅存在错误日志等记录，无表任务
+		if m.TaskMode == "" {
+			sw.AppendRow(table.Row{m.TaskName, "-", "-", "-", "-", "-", "-", "-", "-"})
+			continue
+		}
+		sw.AppendRow(table.Row{
+			m.TaskName,
+			m.DBTypeS,
+			m.DBTypeT,
+			m.SchemaNameS,
+			m.TaskMode,
+			m.TableTotals,
+			m.SuccessTables,
+			m.FailedTables,
+			m.UpdatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	sw.Render()
+	return nil
+}
+
+func (t *Task) remove() error {
+	startTime := time.Now()
+	taskName := t.Cfg.AppConfig.TaskName
+	removes, err := meta.NewCommonModel(t.MetaDB).RemoveTaskMeta(t.Ctx, taskName)
+	if err != nil {
+		return err
+	}
+	var totals int64
+	for tableName, counts := range removes {
+		totals += counts
+		zap.L().Info("remove task meta records",
+			zap.String("task name", taskName),
+			zap.String("meta table", tableName),
+			zap.Int64("counts", counts))
+	}
+	zap.L().Info("remove task meta finished",
+		zap.String("task name", taskName),
+		zap.Int64("record totals", totals),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (t *Task) chunkStatus() error {
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.SetOutputMirror(os.Stdout)
+	sw.AppendHeader(table.Row{"CHUNK", "STATUS", "COST", "ERROR DETAIL"})
+
+	if strings.EqualFold(t.Cfg.ActionMode, common.TaskModeCompare) {
+		compareMetas, err := meta.NewDataCompareMetaModel(t.MetaDB).DetailDataCompareMeta(t.Ctx, &meta.DataCompareMeta{
+			DBTypeS:     t.Cfg.DBTypeS,
+			DBTypeT:     t.Cfg.DBTypeT,
+			SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+			TableNameS:  t.Cfg.TableName,
+			TaskMode:    t.Cfg.ActionMode,
+		})
+		if err != nil {
+			return err
+		}
+		for _, c := range compareMetas {
+			sw.AppendRow(table.Row{c.WhereRange, c.TaskStatus, chunkCost(c.TaskStatus, c.BaseModel), c.ErrorDetail})
+		}
+		sw.Render()
+		return nil
+	}
+
+	fullSyncMetas, err := meta.NewFullSyncMetaModel(t.MetaDB).DetailFullSyncMeta(t.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     t.Cfg.DBTypeS,
+		DBTypeT:     t.Cfg.DBTypeT,
+		SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  t.Cfg.TableName,
+		TaskMode:    t.Cfg.ActionMode,
+	})
+	if err != nil {
+		return err
+	}
+	chunkErrs, err := meta.NewChunkErrorDetailModel(t.MetaDB).DetailChunkErrorDetail(t.Ctx, &meta.ChunkErrorDetail{
+		DBTypeS:     t.Cfg.DBTypeS,
+		DBTypeT:     t.Cfg.DBTypeT,
+		SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+		TableNameS:  t.Cfg.TableName,
+		TaskMode:    t.Cfg.ActionMode,
+	})
+	if err != nil {
+		return err
+	}
+	chunkErrMap := make(map[string]string)
+	for _, c := range chunkErrs {
+		chunkErrMap[c.ChunkDetailS] = c.ErrorDetail
+	}
+	for _, f := range fullSyncMetas {
+		sw.AppendRow(table.Row{f.ChunkDetailS, f.TaskStatus, chunkCost(f.TaskStatus, f.BaseModel), chunkErrMap[f.ChunkDetailS]})
+	}
+	sw.Render()
+	return nil
+}
+
+func (t *Task) retry(waitSyncMetas []meta.WaitSyncMeta) error {
+	var (
+		retryTables, resetTables []string
+	)
+	for _, w := range waitSyncMetas {
+		if w.TaskStatus != common.TaskStatusFailed {
+			continue
+		}
+		// 未完成 chunk 切分或者 chunk 元数据不一致，无法断点续传，只能重置
+		var (
+			chunkCounts int64
+			err         error
+		)
+		if strings.EqualFold(t.Cfg.ActionMode, common.TaskModeCompare) {
+			chunkCounts, err = meta.NewDataCompareMetaModel(t.MetaDB).CountsDataCompareMetaByTaskTable(t.Ctx, &meta.DataCompareMeta{
+				DBTypeS:     w.DBTypeS,
+				DBTypeT:     w.DBTypeT,
+				SchemaNameS: w.SchemaNameS,
+				TableNameS:  w.TableNameS,
+				TaskMode:    w.TaskMode,
+			})
+		} else {
+			chunkCounts, err = meta.NewFullSyncMetaModel(t.MetaDB).CountsFullSyncMetaByTaskTable(t.Ctx, &meta.FullSyncMeta{
+				DBTypeS:     w.DBTypeS,
+				DBTypeT:     w.DBTypeT,
+				SchemaNameS: w.SchemaNameS,
+				TableNameS:  w.TableNameS,
+				TaskMode:    w.TaskMode,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if w.GlobalScnS == common.TaskTableDefaultSourceGlobalSCN || w.ChunkTotalNums <= 0 || chunkCounts != w.ChunkTotalNums {
+			resetTables = append(resetTables, w.TableNameS)
+			continue
+		}
+		if err = meta.NewCommonModel(t.MetaDB).RetryTableChunkMetaAndUpdateWaitSyncMeta(t.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     w.DBTypeS,
+			DBTypeT:     w.DBTypeT,
+			SchemaNameS: w.SchemaNameS,
+			TableNameS:  w.TableNameS,
+			TaskMode:    w.TaskMode,
+		}); err != nil {
+			return err
+		}
+		retryTables = append(retryTables, w.TableNameS)
+	}
+
+	zap.L().Info("task meta retry finished",
+		zap.String("schema", t.Cfg.SchemaConfig.SourceSchema),
+		zap.String("task mode", t.Cfg.ActionMode),
+		zap.Strings("retry tables", retryTables),
+		zap.Strings("unretryable tables", resetTables),
+		zap.String("suggest", fmt.Sprintf("please rerunning mode [%s] with [enable-checkpoint = true]", strings.ToLower(t.Cfg.ActionMode))))
+	if len(resetTables) > 0 {
+		return fmt.Errorf("table %v chunk meta isn't consistent, can't be retry, please run [-mode task -task-mode %s -action reset -table <table>]",
+			resetTables, strings.ToLower(t.Cfg.ActionMode))
+	}
+	return nil
+}
+
+func (t *Task) reset(waitSyncMetas []meta.WaitSyncMeta) error {
+	// all 模式增量同步已开始，单表重置会导致增量元数据与配置表列表不一致，只允许整个任务重置
+	if strings.EqualFold(t.Cfg.ActionMode, common.TaskModeAll) && t.Cfg.TableName != "" {
+		incrMetas, err := meta.NewIncrSyncMetaModel(t.MetaDB).DetailIncrSyncMetaBySchema(t.Ctx, &meta.IncrSyncMeta{
+			DBTypeS:     t.Cfg.DBTypeS,
+			DBTypeT:     t.Cfg.DBTypeT,
+			SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+		})
+		if err != nil {
+			return err
+		}
+		if len(incrMetas) > 0 {
+			return fmt.Errorf("schema [%s] increment sync meta record is exist, mode [all] table [%s] can't be reset alone, please reset the whole task without flag [table]",
+				t.Cfg.SchemaConfig.SourceSchema, t.Cfg.TableName)
+		}
+	}
+
+	// full/all 模式重置需清理目标端表数据，csv 模式重新运行覆盖 csv 文件，compare 模式无需清理
+	var (
+		mysqlDB      *mysql.MySQL
+		targetTables map[string][]common.TableClean
+		err          error
+	)
+	if strings.EqualFold(t.Cfg.ActionMode, common.TaskModeFull) || strings.EqualFold(t.Cfg.ActionMode, common.TaskModeAll) {
+		targetTables, err = t.resetTargetTables(waitSyncMetas)
+		if err != nil {
+			return err
+		}
+
+		sw := table.NewWriter()
+		sw.SetStyle(table.StyleLight)
+		sw.SetOutputMirror(os.Stdout)
+		sw.AppendHeader(table.Row{"SOURCE TABLE", "TARGET TABLE", "ACTION"})
+		for _, w := range waitSyncMetas {
+			for _, c := range targetTables[w.TableNameS] {
+				sw.AppendRow(table.Row{common.StringsBuilder(w.SchemaNameS, ".", w.TableNameS), c.TargetTable(), c.Action})
+			}
+		}
+		sw.Render()
+
+		// 清理目标端表数据需命令行确认，未确认只输出待清理表（dry-run）
+		if !t.Cfg.Confirm {
+			zap.L().Warn("task reset dry run, target tables not cleaned and meta not reset",
+				zap.String("schema", t.Cfg.SchemaConfig.SourceSchema),
+				zap.String("task mode", t.Cfg.ActionMode),
+				zap.Int("table totals", len(waitSyncMetas)),
+				zap.String("tips", "please review the target tables, then rerun with flag -confirm to reset"))
+			return nil
+		}
+
+		mysqlDB, err = mysql.NewMySQLDBEngine(t.Ctx, t.Cfg.MySQLConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, w := range waitSyncMetas {
+		if mysqlDB != nil {
+			for _, c := range targetTables[w.TableNameS] {
+				if _, err = mysqlDB.MySQLDB.ExecContext(t.Ctx, c.SQL()); err != nil {
+					return fmt.Errorf("clean target table [%s] failed: %v", c.TargetTable(), err)
+				}
+				zap.L().Info("clean table",
+					zap.String("table", c.TargetTable()),
+					zap.String("action", c.Action),
+					zap.String("status", "success"))
+			}
+		}
+		if err = meta.NewCommonModel(t.MetaDB).ResetTableChunkMetaAndWaitSyncMeta(t.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     w.DBTypeS,
+			DBTypeT:     w.DBTypeT,
+			SchemaNameS: w.SchemaNameS,
+			TableNameS:  w.TableNameS,
+			TaskMode:    w.TaskMode,
+		}); err != nil {
+			return err
+		}
+		zap.L().Info("reset table meta",
+			zap.String("schema", w.SchemaNameS),
+			zap.String("table", w.TableNameS),
+			zap.String("task mode", w.TaskMode),
+			zap.String("status", "success"))
+	}
+
+	// all 模式整个任务重置，清理 schema 全部增量元数据，包含心跳表以及非 [wait_sync_meta] 表记录
+	if strings.EqualFold(t.Cfg.ActionMode, common.TaskModeAll) && t.Cfg.TableName == "" {
+		if err = meta.NewCommonModel(t.MetaDB).ResetSchemaIncrMeta(t.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     t.Cfg.DBTypeS,
+			DBTypeT:     t.Cfg.DBTypeT,
+			SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+		}); err != nil {
+			return err
+		}
+		zap.L().Info("reset schema increment meta",
+			zap.String("schema", t.Cfg.SchemaConfig.SourceSchema),
+			zap.String("status", "success"))
+	}
+	return nil
+}
+
+// 表重置待清理目标端表，按表名以及表路由规则，merge 仅删除当前源端表数据
+func (t *Task) resetTargetTables(waitSyncMetas []meta.WaitSyncMeta) (map[string][]common.TableClean, error) {
+	tableNameRule, err := t.getTableNameRule()
+	if err != nil {
+		return nil, err
+	}
+	tableRoute, err := meta.NewTableRouteRuleModel(t.MetaDB).GetSchemaTableRoute(t.Ctx, t.Cfg)
+	if err != nil {
+		return nil, err
+	}
+	targetTables := make(map[string][]common.TableClean)
+	for _, w := range waitSyncMetas {
+		targetTable := common.StringUPPER(w.TableNameS)
+		if val, ok := tableNameRule[common.StringUPPER(w.TableNameS)]; ok {
+			targetTable = val
+		}
+		targetTables[w.TableNameS] = tableRoute[common.StringUPPER(w.TableNameS)].TargetTableClean(common.StringUPPER(t.Cfg.SchemaConfig.TargetSchema), targetTable)
+	}
+	return targetTables, nil
+}
+
+func (t *Task) getTableNameRule() (map[string]string, error) {
+	// 获取表名自定义规则
+	tableNameRules, err := meta.NewTableNameRuleModel(t.MetaDB).DetailTableNameRule(t.Ctx, &meta.TableNameRule{
+		DBTypeS:     t.Cfg.DBTypeS,
+		DBTypeT:     t.Cfg.DBTypeT,
+		SchemaNameS: t.Cfg.SchemaConfig.SourceSchema,
+		SchemaNameT: t.Cfg.SchemaConfig.TargetSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tableNameRuleMap := make(map[string]string)
+	for _, tr := range tableNameRules {
+		tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+	}
+	return tableNameRuleMap, nil
+}
@@ -17,11 +17,14 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/module/assess"
 	"github.com/wentaojin/transferdb/module/assess/oracle/o2m"
 	"github.com/wentaojin/transferdb/module/assess/oracle/o2t"
+	"github.com/wentaojin/transferdb/module/assess/oracle/public"
 
 	"strings"
 )
@@ -31,6 +34,10 @@ func IAssess(ctx context.Context, cfg *config.Config) error {
 		a   assess.Assesser
 		err error
 	)
+	if cfg.Action != "" {
+		return assessAction(ctx, cfg)
+	}
+
 	switch {
 	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
 		a, err = o2m.NewAssess(ctx, cfg)
@@ -50,3 +57,19 @@ func IAssess(ctx context.Context, cfg *config.Config) error {
 	}
 	return nil
 }
+
+// 评估结果列表以及多次评估对比，只读取元数据库
+func assessAction(ctx context.Context, cfg *config.Config) error {
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.TaskName, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+	switch cfg.Action {
+	case common.TaskActionList:
+		return public.AssessList(ctx, cfg, metaDB)
+	case common.TaskActionDiff:
+		return public.AssessDiff(ctx, cfg, metaDB)
+	default:
+		return fmt.Errorf("flag [action] value [%s] isn't support for mode [%s], support action [list diff]", cfg.Action, cfg.TaskMode)
+	}
+}
@@ -0,0 +1,492 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 服务模式，常驻进程通过 REST API 提交以及管理任务
+// POST /api/v1/tasks                  提交任务
+// GET  /api/v1/tasks                  任务列表
+// GET  /api/v1/tasks/{id}             任务详情
+// GET  /api/v1/tasks/{id}/tables      表级别任务状态
+// GET  /api/v1/tasks/{id}/errors      最近错误记录，follow=true 持续输出
+// POST /api/v1/tasks/{id}/pause       暂停任务
+// POST /api/v1/tasks/{id}/resume      恢复任务
+// POST /api/v1/tasks/{id}/cancel      取消任务
+type Daemon struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	mu     sync.RWMutex
+	tasks  map[string]*DaemonTask
+	runner func(ctx context.Context, cfg *config.Config) error
+}
+
+type DaemonTask struct {
+	ID        string     `json:"id"`
+	TaskMode  string     `json:"task_mode"`
+	DBTypeS   string     `json:"db_type_s"`
+	DBTypeT   string     `json:"db_type_t"`
+	SchemaS   string     `json:"schema_s"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	cfg       *config.Config
+	cancel    context.CancelFunc
+	action    string
+	metaDB    *meta.Meta
+}
+
+type DaemonTaskRequest struct {
+	Mode   string `json:"mode"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Config string `json:"config"`
+}
+
+type DaemonTableStatus struct {
+	TableNameS       string           `json:"table_name_s"`
+	TaskStatus       string           `json:"task_status"`
+	ChunkTotalNums   int64            `json:"chunk_total_nums"`
+	ChunkSuccessNums int64            `json:"chunk_success_nums"`
+	ChunkFailedNums  int64            `json:"chunk_failed_nums"`
+	ChunkStatus      map[string]int64 `json:"chunk_status"`
+}
+
+func IServer(ctx context.Context, cfg *config.Config) error {
+	return NewDaemon(ctx, cfg).Serve()
+}
+
+func NewDaemon(ctx context.Context, cfg *config.Config) *Daemon {
+	return &Daemon{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		tasks:  make(map[string]*DaemonTask),
+		runner: Run,
+	}
+}
+
+func (d *Daemon) Serve() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/tasks", d.handleTasks)
+	mux.HandleFunc("/api/v1/tasks/", d.handleTask)
+
+	zap.L().Info("transferdb server start", zap.String("addr", d.Cfg.AppConfig.ServerAddr))
+	if err := http.ListenAndServe(d.Cfg.AppConfig.ServerAddr, mux); err != nil {
+		return fmt.Errorf("transferdb server listen and serve failed: %v", err)
+	}
+	return nil
+}
+
+func (d *Daemon) handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		d.mu.RLock()
+		tasks := make([]*DaemonTask, 0, len(d.tasks))
+		for _, t := range d.tasks {
+			tasks = append(tasks, t)
+		}
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].StartTime.Before(tasks[j].StartTime)
+		})
+		writeJSON(w, http.StatusOK, tasks)
+		d.mu.RUnlock()
+	case http.MethodPost:
+		var req DaemonTaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("decode task request failed: %v", err))
+			return
+		}
+		t, err := d.submit(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		d.mu.RLock()
+		writeJSON(w, http.StatusCreated, t)
+		d.mu.RUnlock()
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method [%s] isn't support", r.Method))
+	}
+}
+
+func (d *Daemon) handleTask(w http.ResponseWriter, r *http.Request) {
+	paths := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/tasks/"), "/"), "/")
+	d.mu.RLock()
+	t, ok := d.tasks[paths[0]]
+	d.mu.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("task [%s] isn't exist", paths[0]))
+		return
+	}
+
+	var subPath string
+	if len(paths) > 1 {
+		subPath = common.StringUPPER(paths[1])
+	}
+
+	switch {
+	case r.Method == http.MethodGet && subPath == "":
+		d.mu.RLock()
+		writeJSON(w, http.StatusOK, t)
+		d.mu.RUnlock()
+	case r.Method == http.MethodGet && subPath == "TABLES":
+		tables, err := d.tableStatus(t)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, tables)
+	case r.Method == http.MethodGet && subPath == "ERRORS":
+		d.streamErrors(w, r, t)
+	case r.Method == http.MethodPost && (subPath == common.TaskActionPause || subPath == common.TaskActionResume || subPath == common.TaskActionCancel):
+		if err := d.operate(t, subPath); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		d.mu.RLock()
+		writeJSON(w, http.StatusOK, t)
+		d.mu.RUnlock()
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("method [%s] path [%s] isn't support", r.Method, r.URL.Path))
+	}
+}
+
+func (d *Daemon) submit(req DaemonTaskRequest) (*DaemonTask, error) {
+	mode := common.StringUPPER(req.Mode)
+	if mode == common.TaskModeServer || mode == "" {
+		return nil, fmt.Errorf("task mode [%s] isn't support in server mode", req.Mode)
+	}
+	if req.Source == "" {
+		req.Source = common.DatabaseTypeOracle
+	}
+	if req.Target == "" {
+		req.Target = common.DatabaseTypeMySQL
+	}
+	cfg, err := config.NewTaskConfig(req.Config, mode, req.Source, req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &DaemonTask{
+		ID:       uuid.New().String(),
+		TaskMode: cfg.TaskMode,
+		DBTypeS:  cfg.DBTypeS,
+		DBTypeT:  cfg.DBTypeT,
+		SchemaS:  cfg.SchemaConfig.SourceSchema,
+		cfg:      cfg,
+	}
+	if err = d.add(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (d *Daemon) add(t *DaemonTask) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.conflict(t); err != nil {
+		return err
+	}
+	d.tasks[t.ID] = t
+	d.start(t)
+	return nil
+}
+
+// all 模式增量同步共享 logminer 会话以及增量同步指标，park 重放需增量同步任务停止
+// 同一服务进程 all/park 任务串行运行，运行中拒绝提交或者恢复另一个 all/park 任务，调用方需持有锁
+func (d *Daemon) conflict(t *DaemonTask) error {
+	if t.TaskMode != common.TaskModeAll && t.TaskMode != common.TaskModePark {
+		return nil
+	}
+	for _, o := range d.tasks {
+		if o == t || o.Status != common.TaskStatusRunning {
+			continue
+		}
+		if o.TaskMode == common.TaskModeAll || o.TaskMode == common.TaskModePark {
+			return fmt.Errorf("task [%s] mode [%s] is running, mode [%s] task can't run concurrently, please wait or cancel it", o.ID, o.TaskMode, t.TaskMode)
+		}
+	}
+	return nil
+}
+
+// 任务运行，调用方需持有锁
+func (d *Daemon) start(t *DaemonTask) {
+	ctx, cancel := context.WithCancel(d.Ctx)
+	t.cancel = cancel
+	t.action = ""
+	t.Status = common.TaskStatusRunning
+	t.Error = ""
+	t.StartTime = time.Now()
+	t.EndTime = nil
+
+	zap.L().Info("transferdb server task start",
+		zap.String("task", t.ID),
+		zap.String("mode", t.TaskMode),
+		zap.String("schema", t.SchemaS))
+
+	go func() {
+		err := d.run(ctx, t.cfg)
+		cancel()
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		endTime := time.Now()
+		t.EndTime = &endTime
+		switch {
+		case t.action == common.TaskActionPause:
+			t.Status = common.TaskStatusPaused
+		case t.action == common.TaskActionCancel:
+			t.Status = common.TaskStatusCanceled
+		case err != nil:
+			t.Status = common.TaskStatusFailed
+			t.Error = err.Error()
+		default:
+			t.Status = common.TaskStatusSuccess
+		}
+		zap.L().Info("transferdb server task finished",
+			zap.String("task", t.ID),
+			zap.String("mode", t.TaskMode),
+			zap.String("status", t.Status),
+			zap.String("error", t.Error),
+			zap.String("cost", endTime.Sub(t.StartTime).String()))
+	}()
+}
+
+func (d *Daemon) run(ctx context.Context, cfg *config.Config) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panic: %v", r)
+		}
+	}()
+	return d.runner(ctx, cfg)
+}
+
+// 暂停即中断当前运行，恢复基于元数据表断点续传重新运行，取消后不可恢复
+func (d *Daemon) operate(t *DaemonTask, action string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch action {
+	case common.TaskActionPause, common.TaskActionCancel:
+		if t.Status != common.TaskStatusRunning {
+			if action == common.TaskActionCancel && t.Status == common.TaskStatusPaused {
+				t.Status = common.TaskStatusCanceled
+				return nil
+			}
+			return fmt.Errorf("task [%s] status [%s] can't %s", t.ID, t.Status, strings.ToLower(action))
+		}
+		t.action = action
+		t.cancel()
+	case common.TaskActionResume:
+		if t.Status != common.TaskStatusPaused && t.Status != common.TaskStatusFailed {
+			return fmt.Errorf("task [%s] status [%s] can't resume", t.ID, t.Status)
+		}
+		if err := d.conflict(t); err != nil {
+			return err
+		}
+		d.start(t)
+	}
+	return nil
+}
+
+func (d *Daemon) getMetaDB(t *DaemonTask) (*meta.Meta, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t.metaDB != nil {
+		return t.metaDB, nil
+	}
+	metaDB, err := meta.NewMetaDBEngine(d.Ctx, t.cfg.MetaConfig, t.cfg.AppConfig.TaskName, t.cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	t.metaDB = metaDB
+	return metaDB, nil
+}
+
+// full/csv/all 来源于 [wait_sync_meta] 以及 [full_sync_meta]，compare 来源于 [data_compare_meta]
+func (d *Daemon) tableStatus(t *DaemonTask) ([]*DaemonTableStatus, error) {
+	metaDB, err := d.getMetaDB(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*DaemonTableStatus
+	switch t.TaskMode {
+	case common.TaskModeFull, common.TaskModeCSV, common.TaskModeAll:
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(metaDB).DetailWaitSyncMeta(d.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     t.DBTypeS,
+			DBTypeT:     t.DBTypeT,
+			SchemaNameS: t.SchemaS,
+			TaskMode:    t.TaskMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		statusCounts, err := meta.NewFullSyncMetaModel(metaDB).CountsFullSyncMetaGroupByTableStatus(d.Ctx, &meta.FullSyncMeta{
+			DBTypeS:     t.DBTypeS,
+			DBTypeT:     t.DBTypeT,
+			SchemaNameS: t.SchemaS,
+			TaskMode:    t.TaskMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		chunkStatus := make(map[string]map[string]int64)
+		for _, c := range statusCounts {
+			if _, ok := chunkStatus[c.TableNameS]; !ok {
+				chunkStatus[c.TableNameS] = make(map[string]int64)
+			}
+			chunkStatus[c.TableNameS][c.TaskStatus] = c.Counts
+		}
+		for _, w := range waitSyncMetas {
+			tables = append(tables, &DaemonTableStatus{
+				TableNameS:       w.TableNameS,
+				TaskStatus:       w.TaskStatus,
+				ChunkTotalNums:   w.ChunkTotalNums,
+				ChunkSuccessNums: w.ChunkSuccessNums,
+				ChunkFailedNums:  w.ChunkFailedNums,
+				ChunkStatus:      chunkStatus[w.TableNameS],
+			})
+		}
+	case common.TaskModeCompare:
+		statusCounts, err := meta.NewDataCompareMetaModel(metaDB).CountsDataCompareMetaGroupByTableStatus(d.Ctx, &meta.DataCompareMeta{
+			DBTypeS:     t.DBTypeS,
+			DBTypeT:     t.DBTypeT,
+			SchemaNameS: t.SchemaS,
+			TaskMode:    t.TaskMode,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tableMap := make(map[string]*DaemonTableStatus)
+		for _, c := range statusCounts {
+			ts, ok := tableMap[c.TableNameS]
+			if !ok {
+				ts = &DaemonTableStatus{TableNameS: c.TableNameS, ChunkStatus: make(map[string]int64)}
+				tableMap[c.TableNameS] = ts
+				tables = append(tables, ts)
+			}
+			ts.ChunkStatus[c.TaskStatus] = c.Counts
+			ts.ChunkTotalNums += c.Counts
+			switch c.TaskStatus {
+			case common.TaskStatusSuccess:
+				ts.ChunkSuccessNums += c.Counts
+			case common.TaskStatusFailed:
+				ts.ChunkFailedNums += c.Counts
+			}
+		}
+		for _, ts := range tables {
+			switch {
+			case ts.ChunkFailedNums > 0:
+				ts.TaskStatus = common.TaskStatusFailed
+			case ts.ChunkSuccessNums == ts.ChunkTotalNums:
+				ts.TaskStatus = common.TaskStatusSuccess
+			default:
+				ts.TaskStatus = common.TaskStatusRunning
+			}
+		}
+	default:
+		return nil, fmt.Errorf("task mode [%s] isn't record table status, support mode [full csv all compare]", t.TaskMode)
+	}
+	return tables, nil
+}
+
+// 最近错误记录来源于 [error_log_detail]
+func (d *Daemon) streamErrors(w http.ResponseWriter, r *http.Request, t *DaemonTask) {
+	metaDB, err := d.getMetaDB(t)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("query param limit [%s] isn't valid", v))
+			return
+		}
+	}
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+
+	detailS := &meta.ErrorLogDetail{
+		DBTypeS:     t.DBTypeS,
+		DBTypeT:     t.DBTypeT,
+		SchemaNameS: t.SchemaS,
+		TaskMode:    t.TaskMode,
+	}
+	if !follow {
+		errLogs, err := meta.NewErrorLogDetailModel(metaDB).DetailErrorLogAfterID(r.Context(), detailS, 0, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, errLogs)
+		return
+	}
+
+	// 持续输出，每行一条 JSON 记录
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	var lastID uint
+	for {
+		errLogs, err := meta.NewErrorLogDetailModel(metaDB).DetailErrorLogAfterID(r.Context(), detailS, lastID, limit)
+		if err != nil {
+			zap.L().Warn("transferdb server stream task errors failed", zap.String("task", t.ID), zap.Error(err))
+			return
+		}
+		for _, e := range errLogs {
+			if err = encoder.Encode(e); err != nil {
+				return
+			}
+			lastID = e.ID
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		zap.L().Warn("transferdb server write response failed", zap.Error(err))
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
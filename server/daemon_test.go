@@ -0,0 +1,150 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"testing"
+	"time"
+)
+
+func waitTaskStatus(t *testing.T, d *Daemon, task *DaemonTask, status string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		d.mu.RLock()
+		got := task.Status
+		d.mu.RUnlock()
+		if got == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	t.Fatalf("task [%s] status = %s, want %s", task.ID, task.Status, status)
+}
+
+func TestDaemonPauseTask(t *testing.T) {
+	d := NewDaemon(context.Background(), &config.Config{})
+	// all 模式任务持续运行直至暂停取消，与增量应用相同返回 context.Canceled
+	d.runner = func(ctx context.Context, cfg *config.Config) error {
+		if cfg.TaskMode != common.TaskModeAll {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	submit := func(id, mode string) *DaemonTask {
+		task := &DaemonTask{ID: id, TaskMode: mode, cfg: &config.Config{TaskMode: mode}}
+		d.mu.Lock()
+		d.tasks[task.ID] = task
+		d.start(task)
+		d.mu.Unlock()
+		return task
+	}
+
+	allTask := submit("all", common.TaskModeAll)
+	fullTask := submit("full", common.TaskModeFull)
+	waitTaskStatus(t, d, fullTask, common.TaskStatusSuccess)
+
+	tests := []struct {
+		name   string
+		action string
+		want   string
+	}{
+		{name: "pause", action: common.TaskActionPause, want: common.TaskStatusPaused},
+		{name: "resume", action: common.TaskActionResume, want: common.TaskStatusRunning},
+		{name: "cancel", action: common.TaskActionCancel, want: common.TaskStatusCanceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.operate(allTask, tt.action); err != nil {
+				t.Fatalf("operate() error = %v", err)
+			}
+			waitTaskStatus(t, d, allTask, tt.want)
+			d.mu.RLock()
+			defer d.mu.RUnlock()
+			if allTask.Error != "" {
+				t.Errorf("operate() task error = %s, want empty", allTask.Error)
+			}
+		})
+	}
+
+	// 暂停取消任务后服务进程仍可运行其他任务
+	waitTaskStatus(t, d, submit("full-after", common.TaskModeFull), common.TaskStatusSuccess)
+}
+
+// all/park 任务共享增量同步全局状态，同一服务进程串行运行，其他模式任务不受影响
+func TestDaemonConcurrentIncrTask(t *testing.T) {
+	d := NewDaemon(context.Background(), &config.Config{})
+	d.runner = func(ctx context.Context, cfg *config.Config) error {
+		if cfg.TaskMode == common.TaskModeFull {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	newTask := func(id, mode string) *DaemonTask {
+		return &DaemonTask{ID: id, TaskMode: mode, cfg: &config.Config{TaskMode: mode}}
+	}
+
+	first := newTask("all-1", common.TaskModeAll)
+	if err := d.add(first); err != nil {
+		t.Fatalf("add() first all task error = %v", err)
+	}
+	waitTaskStatus(t, d, first, common.TaskStatusRunning)
+
+	for _, task := range []*DaemonTask{newTask("all-2", common.TaskModeAll), newTask("park", common.TaskModePark)} {
+		if err := d.add(task); err == nil {
+			t.Errorf("add() mode [%s] task while all task running error = nil, want conflict", task.TaskMode)
+		}
+	}
+	full := newTask("full", common.TaskModeFull)
+	if err := d.add(full); err != nil {
+		t.Fatalf("add() full task error = %v", err)
+	}
+	waitTaskStatus(t, d, full, common.TaskStatusSuccess)
+
+	// 暂停后可运行另一个 all 任务，此时恢复暂停任务被拒绝
+	if err := d.operate(first, common.TaskActionPause); err != nil {
+		t.Fatal(err)
+	}
+	waitTaskStatus(t, d, first, common.TaskStatusPaused)
+	second := newTask("all-3", common.TaskModeAll)
+	if err := d.add(second); err != nil {
+		t.Fatalf("add() all task after pause error = %v", err)
+	}
+	waitTaskStatus(t, d, second, common.TaskStatusRunning)
+	if err := d.operate(first, common.TaskActionResume); err == nil {
+		t.Error("operate() resume all task while another all task running error = nil, want conflict")
+	}
+	if err := d.operate(second, common.TaskActionCancel); err != nil {
+		t.Fatal(err)
+	}
+	waitTaskStatus(t, d, second, common.TaskStatusCanceled)
+	if err := d.operate(first, common.TaskActionResume); err != nil {
+		t.Fatalf("operate() resume all task error = %v", err)
+	}
+	waitTaskStatus(t, d, first, common.TaskStatusRunning)
+	d.mu.RLock()
+	if len(d.tasks) != 3 {
+		t.Errorf("daemon tasks = %d, want 3", len(d.tasks))
+	}
+	d.mu.RUnlock()
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"path/filepath"
+	"time"
+)
+
+// 元数据导出导入（-mode meta），[meta] 元数据库与内嵌 SQLite 元数据库文件之间全量复制
+// export [meta] 元数据库 -> -meta-file，import -meta-file -> [meta] 元数据库
+func IMeta(ctx context.Context, cfg *config.Config) error {
+	startTime := time.Now()
+	if cfg.MetaFile == "" {
+		return fmt.Errorf("flag [meta-file] can not null for mode [%s]", cfg.TaskMode)
+	}
+	if cfg.MetaConfig.DBType == common.MetaDBTypeSQLite && filepath.Clean(cfg.MetaConfig.DBFile) == filepath.Clean(cfg.MetaFile) {
+		return fmt.Errorf("flag [meta-file] [%s] can not be the same as meta config db-file", cfg.MetaFile)
+	}
+
+	// 导出导入全部任务元数据，不做任务隔离
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, "", cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+	fileDB, err := meta.NewSQLiteMetaDBEngine(cfg.MetaFile, "", cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return err
+	}
+
+	batchSize := cfg.AppConfig.InsertBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	switch cfg.Action {
+	case common.TaskActionExport:
+		err = metaDB.CopyTables(ctx, fileDB, batchSize)
+	case common.TaskActionImport:
+		err = fileDB.CopyTables(ctx, metaDB, batchSize)
+	default:
+		return fmt.Errorf("flag [action] value [%s] isn't support for mode [%s], support action [export import]", cfg.Action, cfg.TaskMode)
+	}
+	if err != nil {
+		return err
+	}
+	zap.L().Info("meta action finished",
+		zap.String("meta db-type", cfg.MetaConfig.DBType),
+		zap.String("meta file", cfg.MetaFile),
+		zap.String("action", cfg.Action),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/migrate"
+	"github.com/wentaojin/transferdb/module/migrate/sql/oracle/public"
+	"strings"
+)
+
+func IPark(ctx context.Context, cfg *config.Config) error {
+	var (
+		p   migrate.Parker
+		err error
+	)
+	switch {
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL),
+		strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeTiDB):
+		p, err = public.NewPark(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("mode [%s] isn't support source [%s] target [%s]", cfg.TaskMode, cfg.DBTypeS, cfg.DBTypeT)
+	}
+	err = p.Park()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -17,10 +17,13 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/module/prepare"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 	"strings"
 )
 
@@ -35,48 +38,131 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		}
 	case common.TaskModeAssess:
 		// 收集评估改造成本
-		err := IAssess(ctx, cfg)
+		err := runSchemas(ctx, cfg, IAssess)
 		if err != nil {
 			return err
 		}
 	case common.TaskModeReverse:
 		// 表结构转换 - reverse 阶段
-		err := IReverse(ctx, cfg)
+		err := runSchemas(ctx, cfg, IReverse)
 		if err != nil {
 			return err
 		}
 	case common.TaskModeCheck:
 		// 表结构校验 - 上下游
-		err := ICheck(ctx, cfg)
+		err := runSchemas(ctx, cfg, ICheck)
 		if err != nil {
 			return err
 		}
 	case common.TaskModeCompare:
 		// 数据校验 - 以上游为准
-		err := ICompare(ctx, cfg)
+		err := runSchemas(ctx, cfg, ICompare)
 		if err != nil {
 			return err
 		}
 	case common.TaskModeCSV:
 		// csv 全量数据导出
-		err := ICSVer(ctx, cfg)
+		err := runSchemas(ctx, cfg, ICSVer)
 		if err != nil {
 			return err
 		}
 	case common.TaskModeFull:
 		// 全量数据 ETL 非一致性（基于某个时间点，而是直接基于现有 SCN）抽取，离线环境提供与原库一致性
-		err := IMigrateFull(ctx, cfg)
+		err := runSchemas(ctx, cfg, IMigrateFull)
 		if err != nil {
 			return err
 		}
 	case common.TaskModeAll:
-		// 全量 + 增量数据同步阶段 - logminer
+		// 全量 + 增量数据同步阶段 - logminer，多 schema 共享同一 logminer 会话
 		err := IMigrateIncr(ctx, cfg)
 		if err != nil {
 			return err
 		}
+	case common.TaskModePark:
+		// 增量同步暂存事件处理 - list/replay/discard
+		err := runSchemas(ctx, cfg, IPark)
+		if err != nil {
+			return err
+		}
+	case common.TaskModeTask:
+		// 任务元数据运维 - status/retry/reset
+		err := runSchemas(ctx, cfg, ITask)
+		if err != nil {
+			return err
+		}
+	case common.TaskModeRule:
+		// 规则元数据运维 - export/diff/import，多 schema 规则同一规则文件
+		err := IRule(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case common.TaskModeMeta:
+		// 元数据导出导入 - export/import，[meta] 元数据库与内嵌 SQLite 元数据库文件之间复制
+		err := IMeta(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	case common.TaskModeServer:
+		// 服务模式 - REST API 提交以及管理任务
+		err := IServer(ctx, cfg)
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("flag [mode] can not null or value configure error")
 	}
 	return nil
 }
+
+// 多 schema 任务，按 [app] schema-threads 全局并发运行，各 schema 共享同一线程预算，表级别并发总数不超过线程配置
+// 单个 schema 失败不影响其他 schema 运行，全部完成后汇总失败 schema 错误，无失败时返回首个结果报告门禁错误
+func runSchemas(ctx context.Context, cfg *config.Config, run func(ctx context.Context, cfg *config.Config) error) error {
+	if len(cfg.SchemaRouteConfig) == 0 {
+		return run(ctx, cfg)
+	}
+
+	schemaCfgs := cfg.SchemaConfigs()
+	budgetCfg := *cfg
+	budgetCfg.ThreadBudget = config.NewThreadBudget()
+	cfg = &budgetCfg
+	schemaErrs := make([]error, len(schemaCfgs))
+
+	g := &errgroup.Group{}
+	g.SetLimit(cfg.AppConfig.SchemaThreads)
+	for i, schemaCfg := range schemaCfgs {
+		i, schemaCfg := i, schemaCfg
+		g.Go(func() error {
+			schemaErrs[i] = run(ctx, cfg.WithSchemaConfig(schemaCfg))
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var (
+		gateErr   error
+		failedErr []error
+	)
+	for i, err := range schemaErrs {
+		if err == nil {
+			continue
+		}
+		var reportErr *common.ReportGateError
+		if errors.As(err, &reportErr) {
+			zap.L().Warn("source schema task report gate failed",
+				zap.String("schema", schemaCfgs[i].SourceSchema),
+				zap.Error(err))
+			if gateErr == nil {
+				gateErr = err
+			}
+			continue
+		}
+		zap.L().Error("source schema task failed",
+			zap.String("schema", schemaCfgs[i].SourceSchema),
+			zap.Error(err))
+		failedErr = append(failedErr, fmt.Errorf("source schema [%s] task failed: %v", schemaCfgs[i].SourceSchema, err))
+	}
+	if len(failedErr) > 0 {
+		return errors.Join(failedErr...)
+	}
+	return gateErr
+}
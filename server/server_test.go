@@ -0,0 +1,175 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunSchemas(t *testing.T) {
+	gateErr := &common.ReportGateError{Report: &common.Report{}, FailOn: common.ReportFailOnMismatch}
+	tests := []struct {
+		name          string
+		schemaErrs    map[string]error
+		schemaThreads int
+		wantErr       []string
+		wantGate      bool
+	}{
+		{
+			name:          "all success",
+			schemaErrs:    map[string]error{},
+			schemaThreads: 2,
+		},
+		{
+			name:          "failed schemas collected",
+			schemaErrs:    map[string]error{"S1": errors.New("s1 failed"), "S3": errors.New("s3 failed")},
+			schemaThreads: 2,
+			wantErr:       []string{"source schema [S1] task failed: s1 failed", "source schema [S3] task failed: s3 failed"},
+		},
+		{
+			name:          "report gate",
+			schemaErrs:    map[string]error{"S2": gateErr},
+			schemaThreads: 1,
+			wantGate:      true,
+		},
+		{
+			name:          "failed schema prior to report gate",
+			schemaErrs:    map[string]error{"S2": gateErr, "S4": errors.New("s4 failed")},
+			schemaThreads: 4,
+			wantErr:       []string{"source schema [S4] task failed: s4 failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				AppConfig: config.AppConfig{SchemaThreads: tt.schemaThreads},
+				SchemaRouteConfig: []config.SchemaConfig{
+					{SourceSchema: "S1"}, {SourceSchema: "S2"}, {SourceSchema: "S3"}, {SourceSchema: "S4"},
+				},
+			}
+			var (
+				mu      sync.Mutex
+				runs    []string
+				running int32
+				maxRun  int32
+			)
+			err := runSchemas(context.Background(), cfg, func(ctx context.Context, cfg *config.Config) error {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					m := atomic.LoadInt32(&maxRun)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRun, m, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				runs = append(runs, cfg.SchemaConfig.SourceSchema)
+				mu.Unlock()
+				return tt.schemaErrs[cfg.SchemaConfig.SourceSchema]
+			})
+
+			if len(runs) != len(cfg.SchemaRouteConfig) {
+				t.Errorf("runSchemas() run schemas %v, want all %d schemas", runs, len(cfg.SchemaRouteConfig))
+			}
+			if maxRun > int32(tt.schemaThreads) {
+				t.Errorf("runSchemas() max concurrent schemas = %d, want <= %d", maxRun, tt.schemaThreads)
+			}
+
+			var reportErr *common.ReportGateError
+			if gotGate := errors.As(err, &reportErr); gotGate != tt.wantGate {
+				t.Errorf("runSchemas() error = %v, want report gate %v", err, tt.wantGate)
+			}
+			if len(tt.wantErr) == 0 {
+				if err != nil && !tt.wantGate {
+					t.Errorf("runSchemas() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("runSchemas() error = nil, want %v", tt.wantErr)
+			}
+			if got := strings.Split(err.Error(), "\n"); strings.Join(got, ";") != strings.Join(tt.wantErr, ";") {
+				t.Errorf("runSchemas() error = %v, want %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 多 schema 并发运行，各 schema 表级别线程共享同一线程预算
+func TestRunSchemasThreadBudget(t *testing.T) {
+	cfg := &config.Config{
+		AppConfig:  config.AppConfig{SchemaThreads: 4},
+		DiffConfig: config.DiffConfig{DiffThreads: 3},
+		SchemaRouteConfig: []config.SchemaConfig{
+			{SourceSchema: "S1"}, {SourceSchema: "S2"}, {SourceSchema: "S3"}, {SourceSchema: "S4"},
+		},
+	}
+	var (
+		running int32
+		maxRun  int32
+		workers int32
+	)
+	err := runSchemas(context.Background(), cfg, func(ctx context.Context, cfg *config.Config) error {
+		var wg sync.WaitGroup
+		errs := make(chan error, 5)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := cfg.AcquireThread(ctx, common.ThreadBudgetDiff, cfg.DiffConfig.DiffThreads)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer release()
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					m := atomic.LoadInt32(&maxRun)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRun, m, n) {
+						break
+					}
+				}
+				atomic.AddInt32(&workers, 1)
+				time.Sleep(10 * time.Millisecond)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		return <-errs
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workers != 20 {
+		t.Errorf("runSchemas() run workers = %d, want 20", workers)
+	}
+	if maxRun > int32(cfg.DiffConfig.DiffThreads) {
+		t.Errorf("runSchemas() max concurrent workers = %d, want <= %d", maxRun, cfg.DiffConfig.DiffThreads)
+	}
+	if cfg.ThreadBudget != nil {
+		t.Error("runSchemas() thread budget leaked into caller config")
+	}
+}